@@ -16,6 +16,7 @@ import (
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	profileRegister "github.com/kubeflow/kubeflow/components/access-management/pkg/apis/kubeflow/v1beta1"
@@ -46,8 +47,9 @@ type KfamV1Alpha1Client struct {
 	profileClient ProfileInterface
 	bindingClient BindingInterface
 	clusterAdmin  []string
-	userIdHeader  string
-	userIdPrefix  string
+	// userIdHeaders are tried in order, userIdPrefixes are aligned with them.
+	userIdHeaders  []string
+	userIdPrefixes []string
 }
 
 func NewKfamClient(userIdHeader string, userIdPrefix string, clusterAdmin string) (*KfamV1Alpha1Client, error) {
@@ -83,9 +85,9 @@ func NewKfamClient(userIdHeader string, userIdPrefix string, clusterAdmin string
 			kubeClient:        kubeClient,
 			roleBindingLister: roleBindingLister,
 		},
-		clusterAdmin: []string{clusterAdmin},
-		userIdHeader: userIdHeader,
-		userIdPrefix: userIdPrefix,
+		clusterAdmin:   []string{clusterAdmin},
+		userIdHeaders:  SplitUserIdList(userIdHeader),
+		userIdPrefixes: SplitUserIdList(userIdPrefix),
 	}, nil
 }
 
@@ -115,7 +117,7 @@ func (c *KfamV1Alpha1Client) CreateBinding(w http.ResponseWriter, r *http.Reques
 	// check permission before create binding
 	useremail := c.getUserEmail(r.Header)
 	if c.isOwnerOrAdmin(useremail, binding.ReferredNamespace) {
-		err := c.bindingClient.Create(&binding, c.userIdHeader, c.userIdPrefix)
+		err := c.bindingClient.Create(&binding, c.userIdHeaders, c.userIdPrefixes)
 		if err != nil {
 			IncRequestErrorCounter(err.Error(), useremail, action, r.URL.Path,
 				SEVERITY_MAJOR)
@@ -286,13 +288,20 @@ func writeResponse(w http.ResponseWriter, msg []byte) bool {
 	return true
 }
 
+// getUserEmail returns the user id from the first userid header present in the request.
 func (c *KfamV1Alpha1Client) getUserEmail(header http.Header) string {
-	return header.Get(c.userIdHeader)[len(c.userIdPrefix):]
+	for i, userIdHeader := range c.userIdHeaders {
+		if value := header.Get(userIdHeader); value != "" {
+			return NormalizeUserIdPrefixes(value, c.userIdPrefixes, i)
+		}
+	}
+	return ""
 }
 
 func (c *KfamV1Alpha1Client) isClusterAdmin(queryUser string) bool {
 	for _, val := range c.clusterAdmin {
-		if val == queryUser {
+		// User ids from the userid header are normalized to lowercase.
+		if strings.EqualFold(val, queryUser) {
 			return true
 		}
 	}
@@ -306,5 +315,5 @@ func (c *KfamV1Alpha1Client) isOwnerOrAdmin(queryUser string, profileName string
 	if err != nil {
 		return false
 	}
-	return isAdmin || (prof.Spec.Owner.Name == queryUser)
+	return isAdmin || strings.EqualFold(prof.Spec.Owner.Name, queryUser)
 }
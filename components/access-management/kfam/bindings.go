@@ -46,7 +46,7 @@ var roleBindingNameMap = map[string]string{
 }
 
 type BindingInterface interface {
-	Create(binding *Binding, userIdHeader string, userIdPrefix string) error
+	Create(binding *Binding, userIdHeaders []string, userIdPrefixes []string) error
 	Delete(binding *Binding) error
 	List(user string, namespaces []string, role string) (*BindingEntries, error)
 }
@@ -76,24 +76,25 @@ func getBindingName(binding *Binding) (string, error) {
 	return reg.ReplaceAllString(nameRaw, "-"), nil
 }
 
-func getAuthorizationPolicy(binding *Binding, userIdHeader string, userIdPrefix string) istioSecurity.AuthorizationPolicy {
-	return istioSecurity.AuthorizationPolicy{
-		Rules: []*istioSecurity.Rule{
-			{
-				When: []*istioSecurity.Condition{
-					{
-						Key: fmt.Sprintf("request.headers[%v]", userIdHeader),
-						Values: []string{
-							userIdPrefix + binding.User.Name,
-						},
+// getAuthorizationPolicy allows the bound user through any of the userid headers, one rule per header.
+func getAuthorizationPolicy(binding *Binding, userIdHeaders []string, userIdPrefixes []string) istioSecurity.AuthorizationPolicy {
+	policy := istioSecurity.AuthorizationPolicy{}
+	for i, userIdHeader := range userIdHeaders {
+		policy.Rules = append(policy.Rules, &istioSecurity.Rule{
+			When: []*istioSecurity.Condition{
+				{
+					Key: fmt.Sprintf("request.headers[%v]", userIdHeader),
+					Values: []string{
+						headerPrefix(userIdPrefixes, i) + binding.User.Name,
 					},
 				},
 			},
-		},
+		})
 	}
+	return policy
 }
 
-func (c *BindingClient) Create(binding *Binding, userIdHeader string, userIdPrefix string) error {
+func (c *BindingClient) Create(binding *Binding, userIdHeaders []string, userIdPrefixes []string) error {
 	// TODO: permission check before go ahead
 	bindingName, err := getBindingName(binding)
 	if err != nil {
@@ -125,7 +126,7 @@ func (c *BindingClient) Create(binding *Binding, userIdHeader string, userIdPref
 			Name:        bindingName,
 			Namespace:   binding.ReferredNamespace,
 		},
-		Spec: getAuthorizationPolicy(binding, userIdHeader, userIdPrefix),
+		Spec: getAuthorizationPolicy(binding, userIdHeaders, userIdPrefixes),
 	}
 
 	result := istioSecurityClient.AuthorizationPolicy{}
@@ -59,3 +59,22 @@ func TestGetBindingName(t *testing.T) {
 	}
 
 }
+
+func TestGetAuthorizationPolicyMultipleProviders(t *testing.T) {
+	policy := getAuthorizationPolicy(getBindingObject("user1@abcd.com"),
+		[]string{"x-goog-authenticated-user-email", "x-ms-client-principal-name"},
+		[]string{"accounts.google.com:", ""})
+	if len(policy.Rules) != 2 {
+		t.Fatalf("Expected one rule per header, got %v", len(policy.Rules))
+	}
+	expected := []struct{ key, value string }{
+		{"request.headers[x-goog-authenticated-user-email]", "accounts.google.com:user1@abcd.com"},
+		{"request.headers[x-ms-client-principal-name]", "user1@abcd.com"},
+	}
+	for i, rule := range policy.Rules {
+		condition := rule.When[0]
+		if condition.Key != expected[i].key || condition.Values[0] != expected[i].value {
+			t.Fatalf("Value different than expected: output: %v %v", condition.Key, condition.Values)
+		}
+	}
+}
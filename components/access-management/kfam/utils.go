@@ -13,3 +13,51 @@
 // limitations under the License.
 
 package kfam
+
+import "strings"
+
+// NormalizeUserId returns the user id of a userid header value: surrounding whitespace is trimmed, prefix is
+// stripped if present in any case, and the result is lowercased.
+func NormalizeUserId(header string, prefix string) string {
+	return NormalizeUserIdPrefixes(header, []string{prefix}, 0)
+}
+
+// NormalizeUserIdPrefixes normalizes a value of the userid header at index like NormalizeUserId, trying the
+// prefix aligned with the header first and then the other prefixes. At most one prefix is stripped, the
+// value is left as-is if none matches.
+func NormalizeUserIdPrefixes(header string, prefixes []string, index int) string {
+	userId := strings.TrimSpace(header)
+	candidates := prefixes
+	if index >= 0 && index < len(prefixes) {
+		candidates = append([]string{prefixes[index]}, prefixes...)
+	}
+	for _, prefix := range candidates {
+		if prefix != "" && strings.HasPrefix(strings.ToLower(userId), strings.ToLower(prefix)) {
+			userId = strings.TrimSpace(userId[len(prefix):])
+			break
+		}
+	}
+	return strings.ToLower(userId)
+}
+
+// SplitUserIdList splits a comma separated list of userid headers or prefixes. Empty entries are kept, an
+// empty prefix is valid for providers sending plain user ids.
+func SplitUserIdList(value string) []string {
+	list := strings.Split(value, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list
+}
+
+// headerPrefix returns the prefix aligned with the userid header at index. A single prefix applies to all
+// headers, headers without aligned prefix have none.
+func headerPrefix(prefixes []string, index int) string {
+	if len(prefixes) == 1 {
+		return prefixes[0]
+	}
+	if index < len(prefixes) {
+		return prefixes[index]
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package kfam
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNormalizeUserId(t *testing.T) {
+	var tests = []struct {
+		name   string
+		header string
+		prefix string
+		out    string
+	}{
+		{"prefix present", "accounts.google.com:user1@abcd.com", "accounts.google.com:", "user1@abcd.com"},
+		{"prefix absent", "user1@abcd.com", "accounts.google.com:", "user1@abcd.com"},
+		{"prefix case-variant", "Accounts.Google.com:user1@abcd.com", "accounts.google.com:", "user1@abcd.com"},
+		{"no prefix configured", "user1@abcd.com", "", "user1@abcd.com"},
+		{"whitespace", "  accounts.google.com: User1@abcd.com \t", "accounts.google.com:", "user1@abcd.com"},
+		{"uppercase", "USER1@ABCD.COM", "", "user1@abcd.com"},
+		{"only prefix", "accounts.google.com:", "accounts.google.com:", ""},
+		{"empty", "", "accounts.google.com:", ""},
+		{"shorter than prefix", "acc", "accounts.google.com:", "acc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := NormalizeUserId(tt.header, tt.prefix); s != tt.out {
+				t.Fatalf("Value different than expected: input: %q, output: %q, expected: %q", tt.header, s, tt.out)
+			}
+		})
+	}
+}
+
+func TestNormalizeUserIdPrefixes(t *testing.T) {
+	prefixes := SplitUserIdList("accounts.google.com:, https://login.microsoftonline.com/tenant#")
+	var tests = []struct {
+		name   string
+		header string
+		index  int
+		out    string
+	}{
+		{"google header", "accounts.google.com:user1@abcd.com", 0, "user1@abcd.com"},
+		{"microsoft header", "https://login.microsoftonline.com/tenant#User2@abcd.com", 1, "user2@abcd.com"},
+		{"prefix of other provider", "accounts.google.com:user1@abcd.com", 1, "user1@abcd.com"},
+		{"no matching prefix", "user3@abcd.com", 1, "user3@abcd.com"},
+		{"more headers than prefixes", "accounts.google.com:user1@abcd.com", 5, "user1@abcd.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := NormalizeUserIdPrefixes(tt.header, prefixes, tt.index); s != tt.out {
+				t.Fatalf("Value different than expected: input: %q, output: %q, expected: %q", tt.header, s, tt.out)
+			}
+		})
+	}
+}
+
+func TestGetUserEmailMultipleProviders(t *testing.T) {
+	c := &KfamV1Alpha1Client{
+		userIdHeaders:  SplitUserIdList("x-goog-authenticated-user-email,x-ms-client-principal-name"),
+		userIdPrefixes: SplitUserIdList("accounts.google.com:,"),
+	}
+	google := http.Header{}
+	google.Set("x-goog-authenticated-user-email", "accounts.google.com:user1@abcd.com")
+	microsoft := http.Header{}
+	microsoft.Set("x-ms-client-principal-name", "user2@abcd.com")
+
+	if s := c.getUserEmail(google); s != "user1@abcd.com" {
+		t.Fatalf("Value different than expected: output: %q", s)
+	}
+	if s := c.getUserEmail(microsoft); s != "user2@abcd.com" {
+		t.Fatalf("Value different than expected: output: %q", s)
+	}
+	if s := c.getUserEmail(http.Header{}); s != "" {
+		t.Fatalf("Value different than expected: output: %q", s)
+	}
+}
+
+func TestHeaderPrefix(t *testing.T) {
+	var tests = []struct {
+		name     string
+		prefixes []string
+		index    int
+		out      string
+	}{
+		{"aligned", []string{"a:", "b:"}, 1, "b:"},
+		{"single prefix for all headers", []string{"a:"}, 1, "a:"},
+		{"more headers than prefixes", []string{"a:", "b:"}, 2, ""},
+		{"more prefixes than headers", []string{"a:", "b:", "c:"}, 0, "a:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := headerPrefix(tt.prefixes, tt.index); s != tt.out {
+				t.Fatalf("Value different than expected: output: %q, expected: %q", s, tt.out)
+			}
+		})
+	}
+}
@@ -38,8 +38,8 @@ func main() {
 	var userIdHeader string
 	var userIdPrefix string
 	var clusterAdmin string
-	flag.StringVar(&userIdHeader, USERIDHEADER, "x-goog-authenticated-user-email", "Key of request header containing user id")
-	flag.StringVar(&userIdPrefix, USERIDPREFIX, "accounts.google.com:", "Request header user id common prefix")
+	flag.StringVar(&userIdHeader, USERIDHEADER, "x-goog-authenticated-user-email", "Key of request header containing user id, comma separated to accept several providers")
+	flag.StringVar(&userIdPrefix, USERIDPREFIX, "accounts.google.com:", "Request header user id common prefix, comma separated list aligned with the userid headers")
 	flag.StringVar(&clusterAdmin, CLUSTERADMIN, "", "cluster admin")
 	flag.Parse()
 
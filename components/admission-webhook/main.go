@@ -30,9 +30,11 @@ import (
 	"github.com/mattbaird/jsonpatch"
 	"k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1beta1 "k8s.io/api/scheduling/v1beta1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -108,6 +110,14 @@ func safeToApplyPodDefaultsOnPod(pod *corev1.Pod, podDefaults []*settingsapi.Pod
 		errs = append(errs, err)
 	}
 
+	if _, err := mergePriorityClassName(pod.Spec.PriorityClassName, podDefaults); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := mergeAffinity(pod.Spec.Affinity, podDefaults); err != nil {
+		errs = append(errs, err)
+	}
+
 	for _, ctr := range pod.Spec.Containers {
 		if err := safeToApplyPodDefaultsOnContainer(&ctr, podDefaults); err != nil {
 			errs = append(errs, err)
@@ -338,6 +348,95 @@ func mergeTolerations(tolerations []corev1.Toleration, podDefaults []*settingsap
 	return mergedTolerations, err
 }
 
+// mergePriorityClassName returns the priority class of the pod, or the one injected by given podDefaults
+// if the pod has none. It returns an error if podDefaults inject different priority classes.
+func mergePriorityClassName(priorityClassName string, podDefaults []*settingsapi.PodDefault) (string, error) {
+	defaultName := ""
+	var errs []error
+	for _, pd := range podDefaults {
+		if pd.Spec.PriorityClassName == "" {
+			continue
+		}
+		if defaultName == "" {
+			defaultName = pd.Spec.PriorityClassName
+			continue
+		}
+		if defaultName != pd.Spec.PriorityClassName {
+			errs = append(errs, fmt.Errorf("merging priorityClassName for %s has a conflict: %s does not match %s",
+				pd.GetName(), pd.Spec.PriorityClassName, defaultName))
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	if priorityClassName != "" {
+		return priorityClassName, nil
+	}
+	return defaultName, nil
+}
+
+// mergeAffinity merges the affinity of the pod with the podAntiAffinity terms injected by given podDefaults,
+// skipping the terms the pod already has. It returns an error if a podDefault injects another kind of affinity.
+func mergeAffinity(affinity *corev1.Affinity, podDefaults []*settingsapi.PodDefault) (*corev1.Affinity, error) {
+	var errs []error
+	merged := affinity.DeepCopy()
+	for _, pd := range podDefaults {
+		if pd.Spec.Affinity == nil {
+			continue
+		}
+		if pd.Spec.Affinity.NodeAffinity != nil || pd.Spec.Affinity.PodAffinity != nil {
+			errs = append(errs, fmt.Errorf("merging affinity for %s: only podAntiAffinity is supported", pd.GetName()))
+			continue
+		}
+		antiAffinity := pd.Spec.Affinity.PodAntiAffinity
+		if antiAffinity == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &corev1.Affinity{}
+		}
+		if merged.PodAntiAffinity == nil {
+			merged.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		for _, term := range antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			if !containsPodAffinityTerm(merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term) {
+				merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+					merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, *term.DeepCopy())
+			}
+		}
+		for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			if !containsWeightedPodAffinityTerm(merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, term) {
+				merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+					merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, *term.DeepCopy())
+			}
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return merged, nil
+}
+
+func containsPodAffinityTerm(terms []corev1.PodAffinityTerm, term corev1.PodAffinityTerm) bool {
+	for _, t := range terms {
+		if reflect.DeepEqual(t, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeightedPodAffinityTerm(terms []corev1.WeightedPodAffinityTerm, term corev1.WeightedPodAffinityTerm) bool {
+	for _, t := range terms {
+		if reflect.DeepEqual(t, term) {
+			return true
+		}
+	}
+	return false
+}
+
 // mergeMap copies the existing map and adds the keys in defaults. It returns
 // an error if it detects any conflict during the merge.
 func mergeMap(existing map[string]string, defaults []*map[string]string) (map[string]string, error) {
@@ -385,6 +484,18 @@ func applyPodDefaultsOnPod(pod *corev1.Pod, podDefaults []*settingsapi.PodDefaul
 	}
 	pod.Spec.Tolerations = tolerations
 
+	priorityClassName, err := mergePriorityClassName(pod.Spec.PriorityClassName, podDefaults)
+	if err != nil {
+		klog.Error(err)
+	}
+	pod.Spec.PriorityClassName = priorityClassName
+
+	affinity, err := mergeAffinity(pod.Spec.Affinity, podDefaults)
+	if err != nil {
+		klog.Error(err)
+	}
+	pod.Spec.Affinity = affinity
+
 	var (
 		defaultAnnotations = make([]*map[string]string, len(podDefaults))
 		defaultLabels      = make([]*map[string]string, len(podDefaults))
@@ -518,6 +629,17 @@ func mutatePods(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 
 	applyPodDefaultsOnPod(&pod, matchingPDs)
 
+	// The priority admission plugin resolved the priority before this webhook, resolve it again if a
+	// PodDefault injected the priority class.
+	if pod.Spec.PriorityClassName != podCopy.Spec.PriorityClassName {
+		priorityClass := &schedulingv1beta1.PriorityClass{}
+		if err := crdclient.Get(context.TODO(), types.NamespacedName{Name: pod.Spec.PriorityClassName}, priorityClass); err != nil {
+			klog.Errorf("error fetching priorityclass %s: %v", pod.Spec.PriorityClassName, err)
+			return toAdmissionResponse(err)
+		}
+		pod.Spec.Priority = &priorityClass.Value
+	}
+
 	klog.Infof("applied poddefaults: %s successfully on Pod: %+v ", strings.Join(defaultNames, ","), pod.GetName())
 
 	podCopyJSON, err := json.Marshal(podCopy)
@@ -176,6 +176,101 @@ func TestApplyPodDefaultsOnPod(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			"Add priorityClassName",
+			&corev1.Pod{},
+			[]*settingsapi.PodDefault{
+				{
+					Spec: settingsapi.PodDefaultSpec{
+						PriorityClassName: "low-priority",
+					},
+				},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"poddefault.admission.kubeflow.org/poddefault-": "",
+					},
+					Labels: map[string]string{},
+				},
+				Spec: corev1.PodSpec{
+					PriorityClassName: "low-priority",
+				},
+			},
+		}, {
+			"Keep priorityClassName of pod",
+			&corev1.Pod{
+				Spec: corev1.PodSpec{
+					PriorityClassName: "high-priority",
+				},
+			},
+			[]*settingsapi.PodDefault{
+				{
+					Spec: settingsapi.PodDefaultSpec{
+						PriorityClassName: "low-priority",
+					},
+				},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"poddefault.admission.kubeflow.org/poddefault-": "",
+					},
+					Labels: map[string]string{},
+				},
+				Spec: corev1.PodSpec{
+					PriorityClassName: "high-priority",
+				},
+			},
+		}, {
+			"Add podAntiAffinity",
+			&corev1.Pod{
+				Spec: corev1.PodSpec{
+					Affinity: &corev1.Affinity{
+						PodAntiAffinity: &corev1.PodAntiAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+								{TopologyKey: "topology.kubernetes.io/zone"},
+							},
+						},
+					},
+				},
+			},
+			[]*settingsapi.PodDefault{
+				{
+					Spec: settingsapi.PodDefaultSpec{
+						Affinity: &corev1.Affinity{
+							PodAntiAffinity: &corev1.PodAntiAffinity{
+								RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+									{TopologyKey: "topology.kubernetes.io/zone"},
+								},
+								PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+									{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+								},
+							},
+						},
+					},
+				},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"poddefault.admission.kubeflow.org/poddefault-": "",
+					},
+					Labels: map[string]string{},
+				},
+				Spec: corev1.PodSpec{
+					Affinity: &corev1.Affinity{
+						PodAntiAffinity: &corev1.PodAntiAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+								{TopologyKey: "topology.kubernetes.io/zone"},
+							},
+							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+								{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+							},
+						},
+					},
+				},
+			},
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -190,3 +285,22 @@ func TestApplyPodDefaultsOnPod(t *testing.T) {
 	}
 
 }
+
+func TestMergePriorityClassNameConflict(t *testing.T) {
+	podDefaults := []*settingsapi.PodDefault{
+		{Spec: settingsapi.PodDefaultSpec{PriorityClassName: "low-priority"}},
+		{Spec: settingsapi.PodDefaultSpec{PriorityClassName: "high-priority"}},
+	}
+	if _, err := mergePriorityClassName("", podDefaults); err == nil {
+		t.Fatal("Expected error but got none")
+	}
+}
+
+func TestMergeAffinityUnsupported(t *testing.T) {
+	podDefaults := []*settingsapi.PodDefault{
+		{Spec: settingsapi.PodDefaultSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}}},
+	}
+	if _, err := mergeAffinity(nil, podDefaults); err == nil {
+		t.Fatal("Expected error but got none")
+	}
+}
@@ -61,6 +61,15 @@ type PodDefaultSpec struct {
 	Labels map[string]string `json:"labels,omitempty"`
 
 	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// PriorityClassName defines the priority class to set on pods without one.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Affinity defines the scheduling constraints to add to the pod. Only podAntiAffinity is supported,
+	// its terms are appended to the ones of the pod.
+	// +optional
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
 }
 
 // PodDefaultStatus defines the observed state of PodDefault
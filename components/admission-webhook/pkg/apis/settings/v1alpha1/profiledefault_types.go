@@ -0,0 +1,64 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProfileDefaultSpec defines the defaults applied to new profiles by the profile-controller
+type ProfileDefaultSpec struct {
+	// ResourceQuotaSpec is set on new profiles without resourceQuotaSpec and quotaTemplate.
+	// +optional
+	ResourceQuotaSpec *v1.ResourceQuotaSpec `json:"resourceQuotaSpec,omitempty"`
+
+	// OwnerPrefixes are removed from the owner name of new profiles, e.g. the "accounts.google.com:" prefix
+	// of the user id header, so the owner is the plain email address.
+	// +optional
+	OwnerPrefixes []string `json:"ownerPrefixes,omitempty"`
+
+	// Labels are set on new profiles which do not have them.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ProfileDefault is the Schema for the profiledefaults API
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=profiledefaults,scope=Cluster
+type ProfileDefault struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ProfileDefaultSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ProfileDefaultList contains a list of ProfileDefault
+type ProfileDefaultList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProfileDefault `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProfileDefault{}, &ProfileDefaultList{})
+}
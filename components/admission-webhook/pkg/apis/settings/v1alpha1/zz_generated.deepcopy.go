@@ -116,6 +116,11 @@ func (in *PodDefaultSpec) DeepCopyInto(out *PodDefaultSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -144,3 +149,96 @@ func (in *PodDefaultStatus) DeepCopy() *PodDefaultStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfileDefault) DeepCopyInto(out *ProfileDefault) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfileDefault.
+func (in *ProfileDefault) DeepCopy() *ProfileDefault {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfileDefault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProfileDefault) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfileDefaultList) DeepCopyInto(out *ProfileDefaultList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProfileDefault, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfileDefaultList.
+func (in *ProfileDefaultList) DeepCopy() *ProfileDefaultList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfileDefaultList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProfileDefaultList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfileDefaultSpec) DeepCopyInto(out *ProfileDefaultSpec) {
+	*out = *in
+	if in.ResourceQuotaSpec != nil {
+		in, out := &in.ResourceQuotaSpec, &out.ResourceQuotaSpec
+		*out = new(v1.ResourceQuotaSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OwnerPrefixes != nil {
+		in, out := &in.OwnerPrefixes, &out.OwnerPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfileDefaultSpec.
+func (in *ProfileDefaultSpec) DeepCopy() *ProfileDefaultSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfileDefaultSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/kubeflow/kubeflow/components/admission-webhook/pkg/apis"
 	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1beta1 "k8s.io/api/scheduling/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 )
@@ -33,6 +34,7 @@ func init() {
 
 func addToScheme(scheme *runtime.Scheme) {
 	corev1.AddToScheme(scheme)
+	schedulingv1beta1.AddToScheme(scheme)
 	admissionregistrationv1beta1.AddToScheme(scheme)
 	apis.AddToScheme(scheme)
 }
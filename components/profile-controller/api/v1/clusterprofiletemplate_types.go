@@ -0,0 +1,62 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterProfileTemplateSpec defines the defaults of the profiles referencing the template
+type ClusterProfileTemplateSpec struct {
+	// Resourcequota applied to target namespace of profiles without resourceQuotaSpec or quotaTemplate
+	ResourceQuotaSpec *v1.ResourceQuotaSpec `json:"resourceQuotaSpec,omitempty"`
+
+	// LimitRange applied to target namespace of profiles without limitRangeSpec
+	LimitRangeSpec *v1.LimitRangeSpec `json:"limitRangeSpec,omitempty"`
+
+	// Labels of target namespace. Labels set by the controller take precedence
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Plugins added to profiles without a plugin of the same kind
+	Plugins []Plugin `json:"plugins,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusterprofiletemplates,scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterProfileTemplate is the Schema for the clusterprofiletemplates API, presets profiles reference with
+// spec.template
+type ClusterProfileTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterProfileTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterProfileTemplateList contains a list of ClusterProfileTemplate
+type ClusterProfileTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterProfileTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterProfileTemplate{}, &ClusterProfileTemplateList{})
+}
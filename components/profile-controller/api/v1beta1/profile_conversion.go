@@ -0,0 +1,58 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts the profile to v1.
+func (src *Profile) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*profilev1.Profile)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = profilev1.ProfileSpec{
+		Owner:             src.Spec.Owner,
+		ResourceQuotaSpec: src.Spec.ResourceQuotaSpec,
+	}
+	for _, p := range src.Spec.Plugins {
+		dst.Spec.Plugins = append(dst.Spec.Plugins, profilev1.Plugin(p))
+	}
+	dst.Status = profilev1.ProfileStatus{}
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, profilev1.ProfileCondition(c))
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1 profile. Fields v1beta1 lacks are dropped, as they were when the API server
+// converted the versions without webhook.
+func (dst *Profile) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*profilev1.Profile)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = ProfileSpec{
+		Owner:             src.Spec.Owner,
+		ResourceQuotaSpec: src.Spec.ResourceQuotaSpec,
+	}
+	for _, p := range src.Spec.Plugins {
+		dst.Spec.Plugins = append(dst.Spec.Plugins, Plugin(p))
+	}
+	dst.Status = ProfileStatus{}
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, ProfileCondition(c))
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// Kinds of the v1 plugins converted to ProfilePlugins, as applied by the controller.
+const (
+	workloadIdentityKind        = "WorkloadIdentity"
+	awsIamForServiceAccountKind = "AwsIamForServiceAccount"
+)
+
+// Annotation holding the v1 plugins without ProfilePlugins field, e.g. of unknown kinds, so they are kept when
+// the profile is updated as v2.
+const V1PLUGINSANNOTATION = "profile.kubeflow.org/v1-plugins"
+
+// Annotation holding the v1 spec.gcpServiceAccount, which has no v2 field, so it is kept when the profile is
+// updated as v2.
+const V1GCPSERVICEACCOUNTANNOTATION = "profile.kubeflow.org/v1-gcp-service-account"
+
+// ConvertTo converts the profile to v1. The plugins are followed by the ones kept in V1PLUGINSANNOTATION and
+// spec.gcpServiceAccount is restored from V1GCPSERVICEACCOUNTANNOTATION.
+func (src *Profile) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*profilev1.Profile)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	delete(dst.Annotations, V1PLUGINSANNOTATION)
+	delete(dst.Annotations, V1GCPSERVICEACCOUNTANNOTATION)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	dst.Spec = profilev1.ProfileSpec{
+		Owner:                src.Spec.Owner,
+		NamespaceAnnotations: src.Spec.NamespaceAnnotations,
+		DisableIstioSidecar:  src.Spec.DisableIstioSidecar,
+		Paused:               src.Spec.Paused,
+		Suspend:              src.Spec.Suspend,
+		TTL:                  src.Spec.TTL,
+		ExpiresAt:            src.Spec.ExpiresAt,
+		Template:             src.Spec.Template,
+		NamespaceName:        src.Spec.NamespaceName,
+		CostLabels:           (*profilev1.CostLabels)(src.Spec.CostLabels),
+		Workspace:            (*profilev1.Workspace)(src.Spec.Workspace),
+		DefaultStorageClass:  src.Spec.DefaultStorageClass,
+		LimitRangeSpec:       src.Spec.Quota.LimitRangeSpec,
+		QuotaTemplate:        src.Spec.Quota.Template,
+		GcpServiceAccount:    src.Annotations[V1GCPSERVICEACCOUNTANNOTATION],
+	}
+	for _, c := range src.Spec.Contributors {
+		dst.Spec.Contributors = append(dst.Spec.Contributors, profilev1.Contributor(c))
+	}
+	if src.Spec.Quota.ResourceQuotaSpec != nil {
+		dst.Spec.ResourceQuotaSpec = *src.Spec.Quota.ResourceQuotaSpec
+	}
+	if p := src.Spec.Plugins.WorkloadIdentity; p != nil {
+		plugin, err := newV1Plugin(workloadIdentityKind, p)
+		if err != nil {
+			return err
+		}
+		dst.Spec.Plugins = append(dst.Spec.Plugins, plugin)
+	}
+	if p := src.Spec.Plugins.AwsIamForServiceAccount; p != nil {
+		plugin, err := newV1Plugin(awsIamForServiceAccountKind, p)
+		if err != nil {
+			return err
+		}
+		dst.Spec.Plugins = append(dst.Spec.Plugins, plugin)
+	}
+	if kept, ok := src.Annotations[V1PLUGINSANNOTATION]; ok {
+		var plugins []profilev1.Plugin
+		if err := json.Unmarshal([]byte(kept), &plugins); err != nil {
+			return fmt.Errorf("invalid %v annotation: %v", V1PLUGINSANNOTATION, err)
+		}
+		dst.Spec.Plugins = append(dst.Spec.Plugins, plugins...)
+	}
+
+	dst.Status = profilev1.ProfileStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Namespace:          src.Status.Namespace,
+	}
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, profilev1.ProfileCondition(c))
+	}
+	for _, r := range src.Status.ManagedResources {
+		dst.Status.ManagedResources = append(dst.Status.ManagedResources, profilev1.ManagedResource(r))
+	}
+	for _, q := range src.Status.GPUQuotas {
+		dst.Status.GPUQuotas = append(dst.Status.GPUQuotas, profilev1.GPUQuotaStatus(q))
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1 profile. Plugins of unknown kind, repeated or with an invalid spec are kept in
+// V1PLUGINSANNOTATION and spec.gcpServiceAccount in V1GCPSERVICEACCOUNTANNOTATION, so converting back to v1
+// restores them.
+func (dst *Profile) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*profilev1.Profile)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	delete(dst.Annotations, V1PLUGINSANNOTATION)
+	delete(dst.Annotations, V1GCPSERVICEACCOUNTANNOTATION)
+
+	dst.Spec = ProfileSpec{
+		Owner:                src.Spec.Owner,
+		NamespaceAnnotations: src.Spec.NamespaceAnnotations,
+		DisableIstioSidecar:  src.Spec.DisableIstioSidecar,
+		Paused:               src.Spec.Paused,
+		Suspend:              src.Spec.Suspend,
+		TTL:                  src.Spec.TTL,
+		ExpiresAt:            src.Spec.ExpiresAt,
+		Template:             src.Spec.Template,
+		NamespaceName:        src.Spec.NamespaceName,
+		CostLabels:           (*CostLabels)(src.Spec.CostLabels),
+		Workspace:            (*Workspace)(src.Spec.Workspace),
+		DefaultStorageClass:  src.Spec.DefaultStorageClass,
+		Quota: ProfileQuota{
+			LimitRangeSpec: src.Spec.LimitRangeSpec,
+			Template:       src.Spec.QuotaTemplate,
+		},
+	}
+	for _, c := range src.Spec.Contributors {
+		dst.Spec.Contributors = append(dst.Spec.Contributors, Contributor(c))
+	}
+	if !isEmptyResourceQuotaSpec(src.Spec.ResourceQuotaSpec) {
+		quota := src.Spec.ResourceQuotaSpec
+		dst.Spec.Quota.ResourceQuotaSpec = &quota
+	}
+	var kept []profilev1.Plugin
+	for _, p := range src.Spec.Plugins {
+		switch {
+		case p.Kind == workloadIdentityKind && dst.Spec.Plugins.WorkloadIdentity == nil:
+			spec := &WorkloadIdentity{}
+			if unmarshalPluginSpec(p.Spec, spec) {
+				dst.Spec.Plugins.WorkloadIdentity = spec
+				continue
+			}
+		case p.Kind == awsIamForServiceAccountKind && dst.Spec.Plugins.AwsIamForServiceAccount == nil:
+			spec := &AwsIamForServiceAccount{}
+			if unmarshalPluginSpec(p.Spec, spec) {
+				dst.Spec.Plugins.AwsIamForServiceAccount = spec
+				continue
+			}
+		}
+		kept = append(kept, p)
+	}
+	if len(kept) > 0 {
+		data, err := json.Marshal(kept)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[V1PLUGINSANNOTATION] = string(data)
+	}
+	if src.Spec.GcpServiceAccount != "" {
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[V1GCPSERVICEACCOUNTANNOTATION] = src.Spec.GcpServiceAccount
+	}
+
+	dst.Status = ProfileStatus{ObservedGeneration: src.Status.ObservedGeneration, Namespace: src.Status.Namespace}
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, ProfileCondition(c))
+	}
+	for _, r := range src.Status.ManagedResources {
+		dst.Status.ManagedResources = append(dst.Status.ManagedResources, ManagedResource(r))
+	}
+	for _, q := range src.Status.GPUQuotas {
+		dst.Status.GPUQuotas = append(dst.Status.GPUQuotas, GPUQuotaStatus(q))
+	}
+	return nil
+}
+
+// newV1Plugin returns the v1 plugin of kind with spec marshaled as its raw spec.
+func newV1Plugin(kind string, spec interface{}) (profilev1.Plugin, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return profilev1.Plugin{}, err
+	}
+	plugin := profilev1.Plugin{Spec: &runtime.RawExtension{Raw: data}}
+	plugin.Kind = kind
+	return plugin, nil
+}
+
+// unmarshalPluginSpec unmarshals the raw spec of a v1 plugin into spec, telling if it holds a spec with all
+// fields set. Unknown fields keep the plugin from being converted, so they are not dropped.
+func unmarshalPluginSpec(raw *runtime.RawExtension, spec interface{}) bool {
+	if raw == nil {
+		return false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw.Raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(spec); err != nil {
+		return false
+	}
+	switch s := spec.(type) {
+	case *WorkloadIdentity:
+		return s.GcpServiceAccount != ""
+	case *AwsIamForServiceAccount:
+		return s.AwsIamRole != ""
+	}
+	return true
+}
+
+func isEmptyResourceQuotaSpec(spec v1.ResourceQuotaSpec) bool {
+	return len(spec.Hard) == 0 && len(spec.Scopes) == 0 && spec.ScopeSelector == nil
+}
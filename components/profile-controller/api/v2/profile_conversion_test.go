@@ -0,0 +1,140 @@
+package v2
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newV1Profile(plugins ...profilev1.Plugin) *profilev1.Profile {
+	workspaceSize := resource.MustParse("20Gi")
+	return &profilev1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeflow-user1", Annotations: map[string]string{"team": "ml"}},
+		Spec: profilev1.ProfileSpec{
+			Owner: rbacv1.Subject{Kind: "User", Name: "user1@abcd.com"},
+			Contributors: []profilev1.Contributor{
+				{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}, Role: "view"},
+			},
+			Plugins: plugins,
+			ResourceQuotaSpec: corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+			},
+			QuotaTemplate:        "small",
+			NamespaceAnnotations: map[string]string{"cost-center": "1234"},
+			TTL:                  &metav1.Duration{Duration: 720 * time.Hour},
+			Suspend:              true,
+			Template:             "medium",
+			NamespaceName:        "kf-user1",
+			CostLabels:           &profilev1.CostLabels{CostCenter: "cc-1234", WBSCode: "W.1234.01", Team: "ml"},
+			Workspace:            &profilev1.Workspace{Size: &workspaceSize, StorageClassName: "standard"},
+			DefaultStorageClass:  "team-ml-ssd",
+		},
+		Status: profilev1.ProfileStatus{
+			Conditions:         []profilev1.ProfileCondition{{Type: "Ready", Status: "True"}},
+			ObservedGeneration: 2,
+			Namespace:          "kf-user1",
+			ManagedResources:   []profilev1.ManagedResource{{Kind: "Namespace", Name: "kubeflow-user1"}},
+			GPUQuotas: []profilev1.GPUQuotaStatus{
+				{Resource: "nvidia.com/gpu", Hard: resource.MustParse("4"), Used: resource.MustParse("1")},
+			},
+		},
+	}
+}
+
+func newPlugin(kind string, spec string) profilev1.Plugin {
+	plugin := profilev1.Plugin{Spec: &runtime.RawExtension{Raw: []byte(spec)}}
+	plugin.Kind = kind
+	return plugin
+}
+
+func TestConvertFromV1(t *testing.T) {
+	src := newV1Profile(
+		newPlugin("WorkloadIdentity", `{"gcpServiceAccount":"user1-sa@my-project.iam.gserviceaccount.com"}`),
+		newPlugin("AwsIamForServiceAccount", `{"awsIamRole":"arn:aws:iam::123456789012:role/user1"}`),
+	)
+	dst := &Profile{}
+	require.NoError(t, dst.ConvertFrom(src))
+	assert.Equal(t, &WorkloadIdentity{GcpServiceAccount: "user1-sa@my-project.iam.gserviceaccount.com"},
+		dst.Spec.Plugins.WorkloadIdentity)
+	assert.Equal(t, &AwsIamForServiceAccount{AwsIamRole: "arn:aws:iam::123456789012:role/user1"},
+		dst.Spec.Plugins.AwsIamForServiceAccount)
+	assert.Equal(t, src.Spec.ResourceQuotaSpec, *dst.Spec.Quota.ResourceQuotaSpec)
+	assert.Equal(t, "small", dst.Spec.Quota.Template)
+	assert.Equal(t, []Contributor{{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}, Role: "view"}},
+		dst.Spec.Contributors)
+	assert.Equal(t, map[string]string{"team": "ml"}, dst.Annotations)
+	assert.Equal(t, int64(2), dst.Status.ObservedGeneration)
+
+	back := &profilev1.Profile{}
+	require.NoError(t, dst.ConvertTo(back))
+	assert.Equal(t, src, back)
+}
+
+func TestConvertFromV1GcpServiceAccount(t *testing.T) {
+	src := newV1Profile(newPlugin("WorkloadIdentity", `{"gcpServiceAccount":"old-sa@my-project.iam.gserviceaccount.com"}`))
+	src.Spec.GcpServiceAccount = "user1-sa@my-project.iam.gserviceaccount.com"
+	dst := &Profile{}
+	require.NoError(t, dst.ConvertFrom(src))
+	assert.Equal(t, "old-sa@my-project.iam.gserviceaccount.com", dst.Spec.Plugins.WorkloadIdentity.GcpServiceAccount)
+	assert.Equal(t, "user1-sa@my-project.iam.gserviceaccount.com", dst.Annotations[V1GCPSERVICEACCOUNTANNOTATION])
+
+	back := &profilev1.Profile{}
+	require.NoError(t, dst.ConvertTo(back))
+	assert.Equal(t, src, back)
+
+	// Without the plugin the field is kept as well.
+	src = newV1Profile()
+	src.Spec.GcpServiceAccount = "user1-sa@my-project.iam.gserviceaccount.com"
+	dst = &Profile{}
+	require.NoError(t, dst.ConvertFrom(src))
+	assert.Nil(t, dst.Spec.Plugins.WorkloadIdentity)
+	back = &profilev1.Profile{}
+	require.NoError(t, dst.ConvertTo(back))
+	assert.Equal(t, src, back)
+}
+
+func TestConvertFromV1KeepsUntypedPlugins(t *testing.T) {
+	custom := newPlugin("CustomPlugin", `{"foo":"bar"}`)
+	unknownField := newPlugin("AwsIamForServiceAccount", `{"awsIamRole":"arn:aws:iam::123456789012:role/user1","extra":1}`)
+	src := newV1Profile(custom, unknownField)
+	dst := &Profile{}
+	require.NoError(t, dst.ConvertFrom(src))
+	assert.Nil(t, dst.Spec.Plugins.AwsIamForServiceAccount)
+	var kept []profilev1.Plugin
+	require.NoError(t, json.Unmarshal([]byte(dst.Annotations[V1PLUGINSANNOTATION]), &kept))
+	assert.Len(t, kept, 2)
+
+	// Kept plugins follow the typed ones when the profile is updated as v2.
+	dst.Spec.Plugins.WorkloadIdentity = &WorkloadIdentity{GcpServiceAccount: "user1-sa@my-project.iam.gserviceaccount.com"}
+	back := &profilev1.Profile{}
+	require.NoError(t, dst.ConvertTo(back))
+	require.Len(t, back.Spec.Plugins, 3)
+	assert.Equal(t, "WorkloadIdentity", back.Spec.Plugins[0].Kind)
+	assert.Equal(t, custom, back.Spec.Plugins[1])
+	assert.Equal(t, unknownField, back.Spec.Plugins[2])
+	assert.Equal(t, map[string]string{"team": "ml"}, back.Annotations)
+
+	dst.Annotations[V1PLUGINSANNOTATION] = "not json"
+	assert.Error(t, dst.ConvertTo(&profilev1.Profile{}))
+}
+
+func TestConvertEmptyQuota(t *testing.T) {
+	src := &Profile{ObjectMeta: metav1.ObjectMeta{Name: "kubeflow-user1"}}
+	hub := &profilev1.Profile{}
+	require.NoError(t, src.ConvertTo(hub))
+	assert.Equal(t, corev1.ResourceQuotaSpec{}, hub.Spec.ResourceQuotaSpec)
+	assert.Nil(t, hub.Spec.Plugins)
+
+	back := &Profile{}
+	require.NoError(t, back.ConvertFrom(hub))
+	assert.Equal(t, src, back)
+}
@@ -0,0 +1,237 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadIdentity binds the default-editor ServiceAccount to a GCP service account with GKE workload identity
+type WorkloadIdentity struct {
+	// Email of the GCP service account, <name>@<project>.iam.gserviceaccount.com
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9-]{4,28}[a-z0-9]@[a-z][a-z0-9-]{4,28}[a-z0-9]\.iam\.gserviceaccount\.com$`
+	GcpServiceAccount string `json:"gcpServiceAccount"`
+}
+
+// AwsIamForServiceAccount binds the default-editor ServiceAccount to an AWS IAM role
+type AwsIamForServiceAccount struct {
+	// ARN of the IAM role
+	// +kubebuilder:validation:Pattern=`^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$`
+	AwsIamRole string `json:"awsIamRole"`
+}
+
+// ProfilePlugins configures the platform integrations of target namespace
+type ProfilePlugins struct {
+	// +optional
+	WorkloadIdentity *WorkloadIdentity `json:"workloadIdentity,omitempty"`
+
+	// +optional
+	AwsIamForServiceAccount *AwsIamForServiceAccount `json:"awsIamForServiceAccount,omitempty"`
+}
+
+// ProfileQuota configures the resource limits of target namespace
+type ProfileQuota struct {
+	// Resourcequota that will be applied to target namespace
+	// +optional
+	ResourceQuotaSpec *v1.ResourceQuotaSpec `json:"resourceQuotaSpec,omitempty"`
+
+	// LimitRange that will be applied to target namespace, e.g. default container limits
+	// +optional
+	LimitRangeSpec *v1.LimitRangeSpec `json:"limitRangeSpec,omitempty"`
+
+	// Name of the quota template applied to target namespace when ResourceQuotaSpec is empty
+	// +optional
+	Template string `json:"template,omitempty"`
+}
+
+type ProfileCondition struct {
+	Type    string `json:"type,omitempty"`
+	Status  string `json:"status,omitempty" description:"status of the condition, one of True, False, Unknown"`
+	Message string `json:"message,omitempty"`
+}
+
+// Contributor is a subject granted access to target namespace with a role
+type Contributor struct {
+	rbacv1.Subject `json:",inline"`
+
+	// Role of the contributor, edit or view, defaults to edit
+	// +kubebuilder:validation:Enum=edit;view
+	// +optional
+	Role string `json:"role,omitempty"`
+}
+
+// CostLabels are the chargeback labels of target namespace and its pods
+type CostLabels struct {
+	// Cost center charged for the resources of target namespace
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	// +optional
+	CostCenter string `json:"costCenter,omitempty"`
+
+	// Work breakdown structure code of the project charged
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	// +optional
+	WBSCode string `json:"wbsCode,omitempty"`
+
+	// Team charged
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	// +optional
+	Team string `json:"team,omitempty"`
+}
+
+// Workspace is the default volume provisioned in target namespace
+type Workspace struct {
+	// Requested size of the workspace volume, defaults to the controller default set with -workspace-size
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Storage class of the workspace volume, defaults to the controller default set with -workspace-storage-class,
+	// or the default storage class of the cluster
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// ProfileSpec defines the desired state of Profile
+type ProfileSpec struct {
+	// The profile owner
+	Owner rbacv1.Subject `json:"owner,omitempty"`
+
+	// Contributors are granted edit or view access to target namespace next to the owner
+	Contributors []Contributor `json:"contributors,omitempty"`
+
+	// Plugins configured for target namespace
+	Plugins ProfilePlugins `json:"plugins,omitempty"`
+
+	// Quota applied to target namespace
+	Quota ProfileQuota `json:"quota,omitempty"`
+
+	// Annotations of target namespace, e.g. the team and cost center for cost allocation. Annotations set by the
+	// controller take precedence
+	NamespaceAnnotations map[string]string `json:"namespaceAnnotations,omitempty"`
+
+	// Disable Istio sidecar injection for pods in target namespace
+	DisableIstioSidecar bool `json:"disableIstioSidecar,omitempty"`
+
+	// Pause reconciliation, the controller makes no changes to the resources of the profile while paused
+	Paused bool `json:"paused,omitempty"`
+
+	// Suspend the workloads of target namespace, keeping its volumes and configuration
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Lifetime of the profile from its creation, after which the profile expires. Ignored if ExpiresAt is set
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// Time the profile expires at, after which it is deleted or suspended by the controller
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Name of the ClusterProfileTemplate providing the quota, limit range, namespace labels and plugins the
+	// profile does not set
+	Template string `json:"template,omitempty"`
+
+	// Name of target namespace, overrides the namespace naming policy of the controller. Set at creation, the
+	// namespace of a profile is never renamed
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	NamespaceName string `json:"namespaceName,omitempty"`
+
+	// Chargeback labels set on target namespace and on every pod created in it
+	CostLabels *CostLabels `json:"costLabels,omitempty"`
+
+	// Workspace volume provisioned once in target namespace, overriding the controller defaults. Without it,
+	// profiles get a workspace if the controller sets -workspace-size
+	Workspace *Workspace `json:"workspace,omitempty"`
+
+	// Storage class set on the PersistentVolumeClaims created in target namespace without one
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	DefaultStorageClass string `json:"defaultStorageClass,omitempty"`
+}
+
+const (
+	ProfileSucceed = "Successful"
+	ProfileFailed  = "Failed"
+	ProfileUnknown = "Unknown"
+)
+
+// ProfileStatus defines the observed state of Profile
+type ProfileStatus struct {
+	Conditions []ProfileCondition `json:"conditions,omitempty"`
+	// Generation of the profile last reconciled completely
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Target namespace of the profile, recorded once it is created or adopted
+	Namespace string `json:"namespace,omitempty"`
+	// Resources the controller manages for the profile, updated every reconcile
+	ManagedResources []ManagedResource `json:"managedResources,omitempty"`
+	// GPU quotas of the target namespace and their usage, reported when GPU quotas are enabled
+	GPUQuotas []GPUQuotaStatus `json:"gpuQuotas,omitempty"`
+}
+
+// GPUQuotaStatus is the quota of a GPU resource in the target namespace
+type GPUQuotaStatus struct {
+	// Extended resource name of the GPU, e.g. nvidia.com/gpu
+	Resource string `json:"resource"`
+	// GPUs the pods of the namespace may request in total
+	Hard resource.Quantity `json:"hard"`
+	// GPUs requested by the pods of the namespace
+	Used resource.Quantity `json:"used"`
+}
+
+// ManagedResource references a resource managed by the controller for the profile
+type ManagedResource struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Empty for cluster scoped resources, e.g. the namespace of the profile
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:unservedversion
+// +kubebuilder:resource:path=profiles,scope=Cluster
+// +kubebuilder:printcolumn:name="Owner",type=string,JSONPath=`.spec.owner.name`
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.status.namespace`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].message`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Profile is the Schema for the profiles API. It is only served with the conversion webhook, see
+// config/crd/patches/webhook_in_profiles.yaml
+type Profile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProfileSpec   `json:"spec,omitempty"`
+	Status ProfileStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ProfileList contains a list of Profile
+type ProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Profile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Profile{}, &ProfileList{})
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Policies applied when the admission webhook cannot be reached or fails.
+const (
+	// ADMISSIONFAILCLOSED blocks the namespace creation until the webhook answers.
+	ADMISSIONFAILCLOSED = "closed"
+	// ADMISSIONFAILOPEN creates the namespace as if the webhook allowed it.
+	ADMISSIONFAILOPEN = "open"
+)
+
+// Condition type set while the namespace creation waits for an unavailable admission webhook.
+const AdmissionWebhookUnavailable = "AdmissionWebhookUnavailable"
+
+// AdmissionWebhook asks an external policy service whether the namespace of a profile may be created.
+type AdmissionWebhook struct {
+	// URL receives a POST of the AdmissionRequest of every profile before its namespace is created.
+	URL string
+	// FailPolicy is ADMISSIONFAILCLOSED or ADMISSIONFAILOPEN.
+	FailPolicy string
+	// Client sends the requests, its Timeout bounds the wait for an answer.
+	Client *http.Client
+}
+
+// AdmissionRequest is the body POSTed to the admission webhook.
+type AdmissionRequest struct {
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+}
+
+// AdmissionResponse is the optional body of the admission webhook answer.
+type AdmissionResponse struct {
+	// Allowed false denies the profile even with status 200.
+	Allowed *bool  `json:"allowed,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// AdmissionDeniedError is returned when the admission webhook denied the profile.
+type AdmissionDeniedError struct {
+	Reason string
+}
+
+func (e *AdmissionDeniedError) Error() string {
+	return fmt.Sprintf("namespace creation denied by admission webhook: %v", e.Reason)
+}
+
+// ValidateAdmissionFailPolicy checks policy is ADMISSIONFAILCLOSED or ADMISSIONFAILOPEN.
+func ValidateAdmissionFailPolicy(policy string) error {
+	if policy != ADMISSIONFAILCLOSED && policy != ADMISSIONFAILOPEN {
+		return fmt.Errorf("invalid admission fail policy %q, expected %v or %v", policy, ADMISSIONFAILCLOSED,
+			ADMISSIONFAILOPEN)
+	}
+	return nil
+}
+
+// check asks the webhook about the profile. Status 200 allows the profile unless the body sets allowed to false,
+// other statuses below 500 deny it and return an AdmissionDeniedError. Other errors mean the webhook is
+// unavailable.
+func (w *AdmissionWebhook) check(ctx context.Context, profileIns *profilev1.Profile) error {
+	body, err := json.Marshal(AdmissionRequest{Name: profileIns.Name, Owner: profileIns.Spec.Owner.Name})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("admission webhook returned %v", resp.Status)
+	}
+	answer := AdmissionResponse{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &answer); err != nil && resp.StatusCode == http.StatusOK {
+			return fmt.Errorf("invalid admission webhook response: %v", err)
+		}
+	}
+	if resp.StatusCode == http.StatusOK && (answer.Allowed == nil || *answer.Allowed) {
+		return nil
+	}
+	reason := answer.Reason
+	if reason == "" {
+		reason = strings.TrimSpace(resp.Status)
+	}
+	return &AdmissionDeniedError{Reason: reason}
+}
+
+// admitNamespace runs the AdmissionWebhook before the namespace of the profile is created and returns whether it
+// may be created. Denied profiles are failed, a webhook unavailable with ADMISSIONFAILCLOSED sets the
+// AdmissionWebhookUnavailable condition and requeues the profile.
+func (r *ProfileReconciler) admitNamespace(ctx context.Context, instance *profilev1.Profile) (ctrl.Result, bool,
+	error) {
+	if r.AdmissionWebhook == nil {
+		return reconcile.Result{}, true, nil
+	}
+	logger := r.Log.WithValues("profile", instance.Name)
+	err := r.AdmissionWebhook.check(ctx, instance)
+	var denied *AdmissionDeniedError
+	if goerrors.As(err, &denied) {
+		logger.Info("Profile denied by admission webhook", "reason", denied.Reason)
+		IncRequestCounter("reject profile denied by admission webhook")
+		result, err := r.appendErrorConditionAndReturn(ctx, instance, denied.Error())
+		return result, false, err
+	}
+	if err != nil {
+		IncRequestErrorCounter("admission webhook unavailable", SEVERITY_MAJOR)
+		if r.AdmissionWebhook.FailPolicy == ADMISSIONFAILOPEN {
+			logger.Error(err, "admission webhook unavailable, creating the namespace with fail policy open")
+			return reconcile.Result{}, true, nil
+		}
+		logger.Error(err, "admission webhook unavailable, waiting with fail policy closed")
+		r.setProfileCondition(instance, AdmissionWebhookUnavailable, "True",
+			fmt.Sprintf("namespace creation waits for the admission webhook: %v", err))
+		if err := r.Status().Update(ctx, instance); err != nil {
+			return reconcile.Result{}, false, err
+		}
+		return reconcile.Result{}, false, err
+	}
+	for _, condition := range instance.Status.Conditions {
+		if condition.Type == AdmissionWebhookUnavailable && condition.Status == "True" {
+			r.setProfileCondition(instance, AdmissionWebhookUnavailable, "False", "admission webhook allowed the profile")
+			if err := r.Status().Update(ctx, instance); err != nil {
+				return reconcile.Result{}, false, err
+			}
+		}
+	}
+	return reconcile.Result{}, true, nil
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestValidateAdmissionFailPolicy(t *testing.T) {
+	assert.NoError(t, ValidateAdmissionFailPolicy(ADMISSIONFAILCLOSED))
+	assert.NoError(t, ValidateAdmissionFailPolicy(ADMISSIONFAILOPEN))
+	assert.Error(t, ValidateAdmissionFailPolicy("ignore"))
+}
+
+func TestReconcileAdmissionWebhook(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		status     int
+		body       string
+		failPolicy string
+		// delay exceeds the client timeout.
+		delay   time.Duration
+		created bool
+		failed  string
+		waiting bool
+	}{
+		{name: "allow", status: http.StatusOK, created: true},
+		{name: "allow with body", status: http.StatusOK, body: `{"allowed": true}`, created: true},
+		{name: "deny in body", status: http.StatusOK, body: `{"allowed": false, "reason": "team over budget"}`,
+			failed: "namespace creation denied by admission webhook: team over budget"},
+		{name: "forbidden", status: http.StatusForbidden,
+			failed: "namespace creation denied by admission webhook: 403 Forbidden"},
+		{name: "timeout fail closed", status: http.StatusOK, delay: 300 * time.Millisecond, failPolicy: ADMISSIONFAILCLOSED,
+			waiting: true},
+		{name: "server error fail closed", status: http.StatusServiceUnavailable, failPolicy: ADMISSIONFAILCLOSED,
+			waiting: true},
+		{name: "timeout fail open", status: http.StatusOK, delay: 300 * time.Millisecond, failPolicy: ADMISSIONFAILOPEN,
+			created: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var received []AdmissionRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				admissionReq := AdmissionRequest{}
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&admissionReq))
+				received = append(received, admissionReq)
+				time.Sleep(tc.delay)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+			profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+			r := newFakeReconciler(profile)
+			r.AdmissionWebhook = &AdmissionWebhook{
+				URL:        server.URL,
+				FailPolicy: tc.failPolicy,
+				Client:     &http.Client{Timeout: 100 * time.Millisecond},
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+			_, err := r.Reconcile(request)
+			if tc.waiting {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NotEmpty(t, received)
+			assert.Equal(t, AdmissionRequest{Name: "kubeflow-user1", Owner: "user1@abcd.com"}, received[0])
+
+			err = r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, &corev1.Namespace{})
+			if tc.created {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err))
+			}
+			found := &profilev1.Profile{}
+			require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+			var failed, waiting []string
+			for _, condition := range found.Status.Conditions {
+				switch {
+				case condition.Type == profilev1.ProfileFailed:
+					failed = append(failed, condition.Message)
+				case condition.Type == AdmissionWebhookUnavailable && condition.Status == "True":
+					waiting = append(waiting, condition.Message)
+				}
+			}
+			if tc.failed != "" {
+				assert.Equal(t, []string{tc.failed}, failed)
+			} else {
+				assert.Empty(t, failed)
+			}
+			assert.Equal(t, tc.waiting, len(waiting) == 1)
+		})
+	}
+}
+
+func TestReconcileAdmissionWebhookOnlyBeforeCreation(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.AdmissionWebhook = &AdmissionWebhook{URL: server.URL, FailPolicy: ADMISSIONFAILCLOSED}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+	// Existing namespaces are not checked again.
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	istioSecurity "istio.io/api/security/v1beta1"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Name of the RoleBinding granting auditors read access to profile namespaces.
+const AUDITORBINDING = "auditor"
+
+// Name of the AuthorizationPolicy granting auditors read-only access to the services of profile namespaces.
+const AUDITORAUTHZPOLICY = "ns-auditor-access-istio"
+
+// Read-only HTTP methods, the ones auditors and viewer contributors may use on the services of profile namespaces.
+var auditorMethods = []string{"GET", "HEAD", "OPTIONS"}
+
+// AuditorAccess grants auditors read access to every profile namespace: a RoleBinding of the auditor users and
+// groups to ClusterRole, and an AuthorizationPolicy allowing the auditor users read-only requests in the mesh.
+type AuditorAccess struct {
+	Users       []string
+	Groups      []string
+	ClusterRole string
+}
+
+// ParseAuditorAccess parses the comma separated auditor users and groups bound to clusterRole, nil if there is
+// no auditor.
+func ParseAuditorAccess(users string, groups string, clusterRole string) (*AuditorAccess, error) {
+	access := &AuditorAccess{Users: splitList(users), Groups: splitList(groups), ClusterRole: clusterRole}
+	if len(access.Users) == 0 && len(access.Groups) == 0 {
+		return nil, nil
+	}
+	if clusterRole == "" {
+		return nil, fmt.Errorf("missing ClusterRole of the auditors")
+	}
+	return access, nil
+}
+
+// splitList splits a comma separated list, ignoring empty entries.
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// auditorRoleBinding returns the RoleBinding of the auditors in the profile namespace.
+func (a *AuditorAccess) auditorRoleBinding(profileIns *profilev1.Profile) *rbacv1.RoleBinding {
+	var subjects []rbacv1.Subject
+	for _, user := range a.Users {
+		subjects = append(subjects, rbacv1.Subject{APIGroup: "rbac.authorization.k8s.io", Kind: rbacv1.UserKind,
+			Name: user})
+	}
+	for _, group := range a.Groups {
+		subjects = append(subjects, rbacv1.Subject{APIGroup: "rbac.authorization.k8s.io", Kind: rbacv1.GroupKind,
+			Name: group})
+	}
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AUDITORBINDING,
+			Namespace: profileNamespace(profileIns),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     a.ClusterRole,
+		},
+		Subjects: subjects,
+	}
+}
+
+// auditorAuthorizationPolicy returns the AuthorizationPolicy allowing the auditor users read-only requests to the
+// workloads of the profile namespace, identified by the user id headers like the owner, one rule per header.
+func (r *ProfileReconciler) auditorAuthorizationPolicy(
+	profileIns *profilev1.Profile) *istioSecurityClient.AuthorizationPolicy {
+	var rules []*istioSecurity.Rule
+	for _, header := range r.userIdHeaders() {
+		var identities []string
+		for _, user := range r.AuditorAccess.Users {
+			identities = append(identities, header.Prefix+user)
+		}
+		rules = append(rules, &istioSecurity.Rule{
+			To: []*istioSecurity.Rule_To{
+				{
+					Operation: &istioSecurity.Operation{Methods: auditorMethods},
+				},
+			},
+			When: []*istioSecurity.Condition{
+				{
+					Key:    fmt.Sprintf("request.headers[%v]", header.Header),
+					Values: identities,
+				},
+			},
+		})
+	}
+	return &istioSecurityClient.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AUDITORAUTHZPOLICY,
+			Namespace: profileNamespace(profileIns),
+		},
+		Spec: istioSecurity.AuthorizationPolicy{
+			Action: istioSecurity.AuthorizationPolicy_ALLOW,
+			Rules:  rules,
+		},
+	}
+}
+
+// updateAuditorAccess creates or updates the auditor RoleBinding and AuthorizationPolicy of the profile namespace.
+// They are deleted if there is no AuditorAccess, the AuthorizationPolicy also if there is no auditor user.
+func (r *ProfileReconciler) updateAuditorAccess(ctx context.Context, profileIns *profilev1.Profile) error {
+	if r.AuditorAccess == nil {
+		if err := r.deleteOwnedRoleBinding(ctx, profileIns, AUDITORBINDING); err != nil {
+			return err
+		}
+		return r.deleteOwnedAuthorizationPolicy(ctx, profileIns, AUDITORAUTHZPOLICY)
+	}
+	if err := r.updateRoleBinding(ctx, profileIns, r.AuditorAccess.auditorRoleBinding(profileIns)); err != nil {
+		return err
+	}
+	if len(r.AuditorAccess.Users) == 0 {
+		return r.deleteOwnedAuthorizationPolicy(ctx, profileIns, AUDITORAUTHZPOLICY)
+	}
+	return r.applyAuthorizationPolicy(ctx, profileIns, r.auditorAuthorizationPolicy(profileIns))
+}
+
+// deleteOwnedAuthorizationPolicy deletes AuthorizationPolicy "name" in the profile namespace if it is controlled
+// by the profile.
+func (r *ProfileReconciler) deleteOwnedAuthorizationPolicy(ctx context.Context, profileIns *profilev1.Profile,
+	name string) error {
+	found := &istioSecurityClient.AuthorizationPolicy{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: profileNamespace(profileIns)}, found); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(found, profileIns) {
+		return nil
+	}
+	r.Log.Info("Deleting AuthorizationPolicy", "namespace", profileIns.Name, "name", name)
+	if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseAuditorAccess(t *testing.T) {
+	access, err := ParseAuditorAccess("auditor1@abcd.com, auditor2@abcd.com", "auditors", "kubeflow-view")
+	require.NoError(t, err)
+	assert.Equal(t, &AuditorAccess{
+		Users:       []string{"auditor1@abcd.com", "auditor2@abcd.com"},
+		Groups:      []string{"auditors"},
+		ClusterRole: "kubeflow-view",
+	}, access)
+
+	access, err = ParseAuditorAccess("", " ,", "kubeflow-view")
+	require.NoError(t, err)
+	assert.Nil(t, access)
+
+	_, err = ParseAuditorAccess("auditor1@abcd.com", "", "")
+	assert.Error(t, err)
+}
+
+func TestReconcileAuditorAccess(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	access, err := ParseAuditorAccess("auditor1@abcd.com", "auditors", "kubeflow-view")
+	require.NoError(t, err)
+	r.AuditorAccess = access
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	bindingKey := types.NamespacedName{Name: AUDITORBINDING, Namespace: profile.Name}
+	policyKey := types.NamespacedName{Name: AUDITORAUTHZPOLICY, Namespace: profile.Name}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	binding := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), bindingKey, binding))
+	assert.Equal(t, "kubeflow-view", binding.RoleRef.Name)
+	assert.Equal(t, []rbacv1.Subject{
+		{APIGroup: "rbac.authorization.k8s.io", Kind: rbacv1.UserKind, Name: "auditor1@abcd.com"},
+		{APIGroup: "rbac.authorization.k8s.io", Kind: rbacv1.GroupKind, Name: "auditors"},
+	}, binding.Subjects)
+	policy := &istioSecurityClient.AuthorizationPolicy{}
+	require.NoError(t, r.Get(context.TODO(), policyKey, policy))
+	require.Len(t, policy.Spec.Rules, 1)
+	rule := policy.Spec.Rules[0]
+	assert.Equal(t, []string{"GET", "HEAD", "OPTIONS"}, rule.To[0].Operation.Methods)
+	assert.Equal(t, "request.headers[x-goog-authenticated-user-email]", rule.When[0].Key)
+	assert.Equal(t, []string{"accounts.google.com:auditor1@abcd.com"}, rule.When[0].Values)
+
+	// Without auditor user there is no mesh access.
+	r.AuditorAccess = &AuditorAccess{Groups: []string{"auditors"}, ClusterRole: "kubeflow-view"}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), bindingKey, &rbacv1.RoleBinding{}))
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), policyKey, &istioSecurityClient.AuthorizationPolicy{})))
+
+	// Disabling auditor access removes the RoleBinding and AuthorizationPolicy.
+	r.AuditorAccess = access
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), policyKey, &istioSecurityClient.AuthorizationPolicy{}))
+	r.AuditorAccess = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), bindingKey, &rbacv1.RoleBinding{})))
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), policyKey, &istioSecurityClient.AuthorizationPolicy{})))
+}
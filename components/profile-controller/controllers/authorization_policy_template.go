@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	istioSecurity "istio.io/api/security/v1beta1"
+)
+
+// AuthorizationPolicyTemplateData is the data exposed to AuthorizationPolicy templates.
+type AuthorizationPolicyTemplateData struct {
+	// Namespace of the profile.
+	Namespace string
+	// Owner is the name of the profile owner subject.
+	Owner string
+	// OwnerKind is the kind of the profile owner subject, User or Group.
+	OwnerKind string
+	// UserIdHeader is the first request header containing the user id.
+	UserIdHeader string
+	// UserIdPrefix is the common prefix of user ids in UserIdHeader.
+	UserIdPrefix string
+	// UserIdHeaders are all the request headers containing the user id, with their prefix.
+	UserIdHeaders []UserIdHeader
+	// GroupClaim is the JWT claim listing the groups of the user.
+	GroupClaim string
+}
+
+// LoadAuthorizationPolicyTemplate reads the go template of an AuthorizationPolicy spec in yaml, usually
+// mounted from a ConfigMap, e.g.
+//
+//	action: ALLOW
+//	rules:
+//	{{- range .UserIdHeaders }}
+//	- when:
+//	  - key: request.headers[{{ .Header }}]
+//	    values: ["{{ .Prefix }}{{ $.Owner }}"]
+//	{{- end }}
+//	- from:
+//	  - source:
+//	      namespaces: ["{{ .Namespace }}", "monitoring"]
+func LoadAuthorizationPolicyTemplate(path string) (*template.Template, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+}
+
+// renderAuthorizationPolicy renders tmpl for the profile into an AuthorizationPolicy spec.
+func (r *ProfileReconciler) renderAuthorizationPolicy(tmpl *template.Template,
+	profileIns *profilev1.Profile) (istioSecurity.AuthorizationPolicy, error) {
+	policy := istioSecurity.AuthorizationPolicy{}
+	headers := r.userIdHeaders()
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, AuthorizationPolicyTemplateData{
+		Namespace:     profileNamespace(profileIns),
+		Owner:         profileIns.Spec.Owner.Name,
+		OwnerKind:     profileIns.Spec.Owner.Kind,
+		UserIdHeader:  headers[0].Header,
+		UserIdPrefix:  headers[0].Prefix,
+		UserIdHeaders: headers,
+		GroupClaim:    r.GroupClaim,
+	}); err != nil {
+		return policy, fmt.Errorf("error rendering AuthorizationPolicy template: %v", err)
+	}
+	if err := yaml.Unmarshal(buf.Bytes(), &policy); err != nil {
+		return policy, fmt.Errorf("invalid AuthorizationPolicy rendered from template: %v", err)
+	}
+	return policy, nil
+}
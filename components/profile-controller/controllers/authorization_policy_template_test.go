@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioSecurity "istio.io/api/security/v1beta1"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const testAuthorizationPolicyTemplate = `
+action: ALLOW
+rules:
+- when:
+  - key: request.headers[{{ .UserIdHeader }}]
+    values: ["{{ .UserIdPrefix }}{{ .Owner }}"]
+- from:
+  - source:
+      namespaces: ["{{ .Namespace }}", "monitoring"]
+- to:
+  - operation:
+      paths: ["/healthz"]
+`
+
+func writeAuthorizationPolicyTemplate(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "authorization-policy")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestRenderAuthorizationPolicyTemplate(t *testing.T) {
+	tmpl, err := LoadAuthorizationPolicyTemplate(writeAuthorizationPolicyTemplate(t, testAuthorizationPolicyTemplate))
+	require.NoError(t, err)
+	r := newFakeReconciler()
+	r.AuthorizationPolicyTemplate = tmpl
+
+	policy, err := r.getAuthorizationPolicy(newTestProfile("kubeflow-user1", "user1@abcd.com"))
+	require.NoError(t, err)
+	assert.Equal(t, istioSecurity.AuthorizationPolicy{
+		Action: istioSecurity.AuthorizationPolicy_ALLOW,
+		Rules: []*istioSecurity.Rule{
+			{
+				When: []*istioSecurity.Condition{{
+					Key:    "request.headers[x-goog-authenticated-user-email]",
+					Values: []string{"accounts.google.com:user1@abcd.com"},
+				}},
+			},
+			{
+				From: []*istioSecurity.Rule_From{{
+					Source: &istioSecurity.Source{Namespaces: []string{"kubeflow-user1", "monitoring"}},
+				}},
+			},
+			{
+				To: []*istioSecurity.Rule_To{{
+					Operation: &istioSecurity.Operation{Paths: []string{"/healthz"}},
+				}},
+			},
+		},
+	}, policy)
+}
+
+func TestRenderAuthorizationPolicyTemplateUserIdHeaders(t *testing.T) {
+	tmpl, err := LoadAuthorizationPolicyTemplate(writeAuthorizationPolicyTemplate(t, `
+action: ALLOW
+rules:
+{{- range .UserIdHeaders }}
+- when:
+  - key: request.headers[{{ .Header }}]
+    values: ["{{ .Prefix }}{{ $.Owner }}"]
+{{- end }}
+`))
+	require.NoError(t, err)
+	r := newFakeReconciler()
+	r.AuthorizationPolicyTemplate = tmpl
+	r.UserIdHeader = "x-goog-authenticated-user-email,x-ms-client-principal-name"
+	r.UserIdPrefix = "accounts.google.com:,"
+
+	policy, err := r.getAuthorizationPolicy(newTestProfile("kubeflow-user1", "user1@abcd.com"))
+	require.NoError(t, err)
+	require.Len(t, policy.Rules, 2)
+	assert.Equal(t, []*istioSecurity.Condition{{
+		Key:    "request.headers[x-ms-client-principal-name]",
+		Values: []string{"user1@abcd.com"},
+	}}, policy.Rules[1].When)
+}
+
+func TestRenderAuthorizationPolicyTemplateErrors(t *testing.T) {
+	r := newFakeReconciler()
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+
+	tmpl, err := LoadAuthorizationPolicyTemplate(writeAuthorizationPolicyTemplate(t, "action: {{ .Unknown }}"))
+	require.NoError(t, err)
+	r.AuthorizationPolicyTemplate = tmpl
+	_, err = r.getAuthorizationPolicy(profile)
+	assert.Error(t, err)
+
+	tmpl, err = LoadAuthorizationPolicyTemplate(writeAuthorizationPolicyTemplate(t, "action: NOT_AN_ACTION"))
+	require.NoError(t, err)
+	r.AuthorizationPolicyTemplate = tmpl
+	_, err = r.getAuthorizationPolicy(profile)
+	assert.Error(t, err)
+
+	_, err = LoadAuthorizationPolicyTemplate(writeAuthorizationPolicyTemplate(t, "action: {{ .Owner"))
+	assert.Error(t, err)
+}
+
+func TestReconcileAuthorizationPolicyTemplate(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getPolicy := func() *istioSecurityClient.AuthorizationPolicy {
+		policy := &istioSecurityClient.AuthorizationPolicy{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: AUTHZPOLICYISTIO, Namespace: profile.Name}, policy))
+		return policy
+	}
+
+	// Built-in policy without template.
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	builtin, err := r.getAuthorizationPolicy(profile)
+	require.NoError(t, err)
+	assert.Equal(t, builtin, getPolicy().Spec)
+
+	tmpl, err := LoadAuthorizationPolicyTemplate(writeAuthorizationPolicyTemplate(t, testAuthorizationPolicyTemplate))
+	require.NoError(t, err)
+	r.AuthorizationPolicyTemplate = tmpl
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	rendered, err := r.getAuthorizationPolicy(profile)
+	require.NoError(t, err)
+	assert.Equal(t, rendered, getPolicy().Spec)
+	assert.Len(t, getPolicy().Spec.Rules, 3)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Object count name of LoadBalancer services, enforced by the quota system as services.loadbalancers.
+const COUNTLOADBALANCERS = "count/services.loadbalancers"
+
+// quotaResourceName returns the resource name the quota system enforces for name.
+func quotaResourceName(name corev1.ResourceName) corev1.ResourceName {
+	if name == COUNTLOADBALANCERS {
+		return corev1.ResourceServicesLoadBalancers
+	}
+	return name
+}
+
+// ParseBaselineQuota parses the -baseline-quota value, comma separated "<resource>=<quantity>", e.g.
+// "services.loadbalancers=0,count/jobs.batch=20".
+func ParseBaselineQuota(value string) (corev1.ResourceList, error) {
+	baseline := corev1.ResourceList{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid baseline quota %q, expected <resource>=<quantity>", entry)
+		}
+		quantity, err := resource.ParseQuantity(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid baseline quota %q: %v", entry, err)
+		}
+		name := quotaResourceName(corev1.ResourceName(strings.TrimSpace(kv[0])))
+		if _, ok := baseline[name]; ok {
+			return nil, fmt.Errorf("duplicate baseline quota %v", name)
+		}
+		baseline[name] = quantity
+	}
+	return baseline, nil
+}
+
+// resourceQuotaSpec returns the ResourceQuotaSpec to apply to the profile namespace: the one of the profile or
+// its quota template, completed with the BaselineQuota resources and the GPU caps it does not set. ok is false if
+// no quota applies.
+func (r *ProfileReconciler) resourceQuotaSpec(profileIns *profilev1.Profile) (corev1.ResourceQuotaSpec, bool, error) {
+	spec, ok, err := r.QuotaTemplates.resourceQuotaSpec(profileIns)
+	if err != nil || (!ok && len(r.BaselineQuota) == 0 && !r.GPUQuota.capped()) {
+		return spec, ok, err
+	}
+	spec = *spec.DeepCopy()
+	hard := corev1.ResourceList{}
+	for name, quantity := range spec.Hard {
+		hard[quotaResourceName(name)] = quantity
+	}
+	if hard, err = r.GPUQuota.normalize(hard); err != nil {
+		return corev1.ResourceQuotaSpec{}, false, err
+	}
+	baseline, err := r.GPUQuota.normalize(r.BaselineQuota)
+	if err != nil {
+		return corev1.ResourceQuotaSpec{}, false, fmt.Errorf("invalid baseline quota: %v", err)
+	}
+	for name, quantity := range baseline {
+		if _, ok := hard[name]; !ok {
+			hard[name] = quantity
+		}
+	}
+	r.GPUQuota.addCaps(hard)
+	spec.Hard = hard
+	return spec, true, nil
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseBaselineQuota(t *testing.T) {
+	baseline, err := ParseBaselineQuota("count/services.loadbalancers=1, count/jobs.batch=20")
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ResourceList{
+		corev1.ResourceServicesLoadBalancers: resource.MustParse("1"),
+		"count/jobs.batch":                   resource.MustParse("20"),
+	}, baseline)
+
+	baseline, err = ParseBaselineQuota("")
+	require.NoError(t, err)
+	assert.Empty(t, baseline)
+
+	for _, value := range []string{
+		"services.loadbalancers",
+		"=1",
+		"services.loadbalancers=many",
+		"services.loadbalancers=1,count/services.loadbalancers=2",
+	} {
+		_, err := ParseBaselineQuota(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestReconcileLoadBalancerQuota(t *testing.T) {
+	// No quota in the profile, the baseline applies.
+	baselineOnly := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	// The profile allows more LoadBalancer services than the baseline, using the object count name.
+	override := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	override.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse("4"),
+		COUNTLOADBALANCERS: resource.MustParse("2"),
+	}
+	r := newFakeReconciler(baselineOnly, override)
+	r.BaselineQuota = corev1.ResourceList{corev1.ResourceServicesLoadBalancers: resource.MustParse("0")}
+
+	for _, test := range []struct {
+		name     string
+		expected corev1.ResourceList
+	}{
+		{baselineOnly.Name, corev1.ResourceList{corev1.ResourceServicesLoadBalancers: resource.MustParse("0")}},
+		{override.Name, corev1.ResourceList{
+			corev1.ResourceCPU:                   resource.MustParse("4"),
+			corev1.ResourceServicesLoadBalancers: resource.MustParse("2"),
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: test.name}})
+			require.NoError(t, err)
+			quota := &corev1.ResourceQuota{}
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: KFQUOTA, Namespace: test.name}, quota))
+			assert.Equal(t, test.expected, quota.Spec.Hard)
+		})
+	}
+	// The profile spec is not modified.
+	assert.Contains(t, override.Spec.ResourceQuotaSpec.Hard, corev1.ResourceName(COUNTLOADBALANCERS))
+}
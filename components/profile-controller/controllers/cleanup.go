@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Interval to check again on resources still being deleted during profile cleanup.
+const cleanupRequeue = 5 * time.Second
+
+// cleanupKinds are the kinds of resources which can be deleted in order on profile deletion.
+var cleanupKinds = map[string]schema.GroupVersionKind{
+	"AuthorizationPolicy": {Group: "security.istio.io", Version: "v1beta1", Kind: "AuthorizationPolicy"},
+	"PeerAuthentication":  {Group: "security.istio.io", Version: "v1beta1", Kind: "PeerAuthentication"},
+	"EnvoyFilter":         {Group: "networking.istio.io", Version: "v1alpha3", Kind: "EnvoyFilter"},
+	"PodDefault":          podDefaultGVK,
+	"RoleBinding":         {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+	"Role":                {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+	"ServiceAccount":      {Version: "v1", Kind: "ServiceAccount"},
+	"ResourceQuota":       {Version: "v1", Kind: "ResourceQuota"},
+	"LimitRange":          {Version: "v1", Kind: "LimitRange"},
+	"NetworkPolicy":       {Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+	"Namespace":           {Version: "v1", Kind: "Namespace"},
+}
+
+// ParseCleanupOrder parses a comma separated list of kinds deleted in order on profile deletion,
+// e.g. "PeerAuthentication,AuthorizationPolicy,Namespace".
+func ParseCleanupOrder(value string) ([]string, error) {
+	var order []string
+	seen := map[string]bool{}
+	for _, kind := range strings.Split(value, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind == "" {
+			continue
+		}
+		if _, ok := cleanupKinds[kind]; !ok {
+			return nil, fmt.Errorf("unsupported kind %q in cleanup order", kind)
+		}
+		if seen[kind] {
+			return nil, fmt.Errorf("duplicate kind %q in cleanup order", kind)
+		}
+		seen[kind] = true
+		order = append(order, kind)
+	}
+	return order, nil
+}
+
+// cleanupResources deletes the resources owned by the profile kind by kind in CleanupOrder, except for a retained
+// namespace. A kind is only deleted once all resources of the previous kinds are gone. Returns true while resources are still pending
+// deletion.
+func (r *ProfileReconciler) cleanupResources(ctx context.Context, profileIns *profilev1.Profile) (bool, error) {
+	for _, kind := range r.CleanupOrder {
+		if kind == "Namespace" && r.retainsNamespace() {
+			continue
+		}
+		remaining, err := r.deleteOwnedResources(ctx, profileIns, cleanupKinds[kind])
+		if err != nil {
+			return false, err
+		}
+		if remaining > 0 {
+			r.Log.Info("Waiting for resources to be deleted", "namespace", profileIns.Name, "kind", kind,
+				"remaining", remaining)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteOwnedResources deletes the resources of kind gvk controlled by the profile and returns how many of
+// them still exist, e.g. because of their own finalizers. Kinds not installed in the cluster are skipped.
+func (r *ProfileReconciler) deleteOwnedResources(ctx context.Context, profileIns *profilev1.Profile,
+	gvk schema.GroupVersionKind) (int, error) {
+	items, err := r.listOwnedResources(ctx, profileIns, gvk)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+	for i := range items {
+		if items[i].GetDeletionTimestamp() != nil {
+			continue
+		}
+		r.Log.Info("Deleting profile resource", "namespace", profileIns.Name, "kind", gvk.Kind,
+			"name", items[i].GetName())
+		if err := r.Delete(ctx, &items[i]); err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+	}
+	items, err = r.listOwnedResources(ctx, profileIns, gvk)
+	return len(items), err
+}
+
+// listOwnedResources lists the resources of kind gvk in the profile namespace controlled by the profile, or
+// the namespace itself.
+func (r *ProfileReconciler) listOwnedResources(ctx context.Context, profileIns *profilev1.Profile,
+	gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	var items []unstructured.Unstructured
+	if gvk.Kind == "Namespace" {
+		ns := unstructured.Unstructured{}
+		ns.SetGroupVersionKind(gvk)
+		if err := r.Get(ctx, types.NamespacedName{Name: profileNamespace(profileIns)}, &ns); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		items = append(items, ns)
+	} else {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := r.List(ctx, list, client.InNamespace(profileNamespace(profileIns))); err != nil {
+			if meta.IsNoMatchError(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		items = list.Items
+	}
+	var owned []unstructured.Unstructured
+	for _, item := range items {
+		if metav1.IsControlledBy(&item, profileIns) {
+			owned = append(owned, item)
+		}
+	}
+	return owned, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Profile annotation selecting the cleanup policy of the profile namespace, e.g. "delete-idle-pvcs".
+const CLEANUPPOLICY = "profile.kubeflow.org/cleanup-policy"
+
+// CleanupPolicy configures the namespace annotations the cleanup jobs select their namespaces by. Unlike profile
+// expiry, cleanup policies never delete the namespace.
+type CleanupPolicy struct {
+	// Policies are the policies profiles can select, any policy name if empty.
+	Policies []string
+	// Default is the policy of profiles without a CLEANUPPOLICY annotation, none if empty.
+	Default string
+	// Annotations are rendered with CleanupPolicyTemplateData for profiles with a policy, e.g.
+	// `cleanup.example.com/policy={{ .Policy }}`. They are removed from the namespace of other profiles.
+	Annotations AnnotationTemplates
+}
+
+// CleanupPolicyTemplateData is the data exposed to cleanup policy annotation templates.
+type CleanupPolicyTemplateData struct {
+	ProfileTemplateData
+	// Policy is the cleanup policy of the profile.
+	Policy string
+}
+
+// ParseCleanupPolicies parses the -cleanup-policies value: comma separated policy names.
+func ParseCleanupPolicies(value string) ([]string, error) {
+	var policies []string
+	for _, policy := range strings.Split(value, ",") {
+		policy = strings.TrimSpace(policy)
+		if policy == "" {
+			continue
+		}
+		if err := validateCleanupPolicy(policy); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// Validate checks that the default policy is one of the policies.
+func (c *CleanupPolicy) Validate() error {
+	if c.Default == "" {
+		return nil
+	}
+	if err := validateCleanupPolicy(c.Default); err != nil {
+		return err
+	}
+	if !c.known(c.Default) {
+		return fmt.Errorf("default cleanup policy %v is not one of %v", c.Default, strings.Join(c.Policies, ", "))
+	}
+	return nil
+}
+
+func validateCleanupPolicy(policy string) error {
+	if errs := validation.IsDNS1123Label(policy); len(errs) > 0 {
+		return fmt.Errorf("invalid cleanup policy %q: %v", policy, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// known tells if policy can be selected by profiles.
+func (c *CleanupPolicy) known(policy string) bool {
+	if len(c.Policies) == 0 {
+		return true
+	}
+	for _, p := range c.Policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// annotations returns the cleanup policy namespace annotations of the profile, with empty values if the profile
+// has no policy so that previous ones are removed.
+func (c *CleanupPolicy) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if c == nil || len(c.Annotations) == 0 {
+		return nil, nil
+	}
+	policy, ok := profileIns.Annotations[CLEANUPPOLICY]
+	if !ok {
+		policy = c.Default
+	}
+	if policy == "" {
+		if ok {
+			return nil, fmt.Errorf("invalid %v annotation: must not be empty", CLEANUPPOLICY)
+		}
+		annotations := make(map[string]string, len(c.Annotations))
+		for key := range c.Annotations {
+			annotations[key] = ""
+		}
+		return annotations, nil
+	}
+	if err := validateCleanupPolicy(policy); err != nil {
+		return nil, fmt.Errorf("invalid %v annotation: %v", CLEANUPPOLICY, err)
+	}
+	if !c.known(policy) {
+		return nil, fmt.Errorf("invalid %v annotation: unknown cleanup policy %v, expected one of %v", CLEANUPPOLICY,
+			policy, strings.Join(c.Policies, ", "))
+	}
+	return c.Annotations.render(CleanupPolicyTemplateData{
+		ProfileTemplateData: newProfileTemplateData(profileIns),
+		Policy:              policy,
+	})
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseCleanupPolicies(t *testing.T) {
+	policies, err := ParseCleanupPolicies("delete-idle-pvcs, keep,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delete-idle-pvcs", "keep"}, policies)
+
+	_, err = ParseCleanupPolicies("delete idle pvcs")
+	assert.Error(t, err)
+
+	c := &CleanupPolicy{Policies: policies, Default: "keep"}
+	assert.NoError(t, c.Validate())
+	c.Default = "delete-everything"
+	assert.Error(t, c.Validate())
+	c.Policies = nil
+	assert.NoError(t, c.Validate())
+}
+
+func TestCleanupPolicyAnnotations(t *testing.T) {
+	templates, err := ParseAnnotationTemplates("cleanup.example.com/policy={{ .Policy }}," +
+		"cleanup.example.com/notify={{ .Owner }}")
+	require.NoError(t, err)
+	c := &CleanupPolicy{Policies: []string{"delete-idle-pvcs", "keep"}, Annotations: templates}
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	for _, tc := range []struct {
+		name        string
+		defaultName string
+		annotations map[string]string
+		expected    map[string]string
+		err         bool
+	}{
+		{name: "policy", annotations: map[string]string{CLEANUPPOLICY: "delete-idle-pvcs"},
+			expected: map[string]string{
+				"cleanup.example.com/policy": "delete-idle-pvcs",
+				"cleanup.example.com/notify": "user1@abcd.com",
+			}},
+		{name: "default policy", defaultName: "keep", expected: map[string]string{
+			"cleanup.example.com/policy": "keep",
+			"cleanup.example.com/notify": "user1@abcd.com",
+		}},
+		{name: "policy over default", defaultName: "keep",
+			annotations: map[string]string{CLEANUPPOLICY: "delete-idle-pvcs"},
+			expected: map[string]string{
+				"cleanup.example.com/policy": "delete-idle-pvcs",
+				"cleanup.example.com/notify": "user1@abcd.com",
+			}},
+		{name: "no policy", expected: map[string]string{
+			"cleanup.example.com/policy": "",
+			"cleanup.example.com/notify": "",
+		}},
+		{name: "empty policy", annotations: map[string]string{CLEANUPPOLICY: ""}, err: true},
+		{name: "unknown policy", annotations: map[string]string{CLEANUPPOLICY: "delete-everything"}, err: true},
+		{name: "invalid policy", annotations: map[string]string{CLEANUPPOLICY: "Delete PVCs"}, err: true},
+	} {
+		c.Default = tc.defaultName
+		profile.Annotations = tc.annotations
+		annotations, err := c.annotations(profile)
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, annotations, tc.name)
+	}
+
+	var disabled *CleanupPolicy
+	annotations, err := disabled.annotations(profile)
+	require.NoError(t, err)
+	assert.Nil(t, annotations)
+}
+
+func TestReconcileCleanupPolicy(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Annotations = map[string]string{CLEANUPPOLICY: "delete-idle-pvcs"}
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("cleanup.example.com/policy={{ .Policy }}")
+	require.NoError(t, err)
+	r.CleanupPolicy = &CleanupPolicy{Annotations: templates}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getPolicy := func() (string, bool) {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		policy, ok := ns.Annotations["cleanup.example.com/policy"]
+		return policy, ok
+	}
+	updateProfile := func(update func(*profilev1.Profile)) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	policy, ok := getPolicy()
+	assert.True(t, ok)
+	assert.Equal(t, "delete-idle-pvcs", policy)
+
+	// Dropping the policy removes the namespace annotation.
+	updateProfile(func(p *profilev1.Profile) { delete(p.Annotations, CLEANUPPOLICY) })
+	_, ok = getPolicy()
+	assert.False(t, ok)
+}
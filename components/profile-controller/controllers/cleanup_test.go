@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deleteRecordingClient records deleted kinds and ignores deletion of the kinds in keep, as if they had
+// finalizers of their own.
+type deleteRecordingClient struct {
+	client.Client
+	deleted []string
+	keep    map[string]bool
+}
+
+func (c *deleteRecordingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	c.deleted = append(c.deleted, kind)
+	if c.keep[kind] {
+		return nil
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func TestParseCleanupOrder(t *testing.T) {
+	order, err := ParseCleanupOrder("PeerAuthentication, AuthorizationPolicy,Namespace")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PeerAuthentication", "AuthorizationPolicy", "Namespace"}, order)
+
+	order, err = ParseCleanupOrder("")
+	require.NoError(t, err)
+	assert.Empty(t, order)
+
+	_, err = ParseCleanupOrder("Deployment")
+	assert.Error(t, err)
+	_, err = ParseCleanupOrder("Namespace,Namespace")
+	assert.Error(t, err)
+}
+
+// newDeletedProfileReconciler reconciles a new profile, then marks it deleted.
+func newDeletedProfileReconciler(t *testing.T, cleanupOrder ...string) (*ProfileReconciler, *deleteRecordingClient) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, profile))
+	deletedAt := metav1.Now()
+	profile.DeletionTimestamp = &deletedAt
+	require.NoError(t, r.Update(context.TODO(), profile))
+
+	recorder := &deleteRecordingClient{Client: r.Client}
+	r.Client = recorder
+	r.CleanupOrder = cleanupOrder
+	return r, recorder
+}
+
+func TestCleanupOrder(t *testing.T) {
+	r, recorder := newDeletedProfileReconciler(t, "AuthorizationPolicy", "RoleBinding", "ServiceAccount", "Namespace")
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "kubeflow-user1"}}
+
+	result, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, result.IsZero())
+	// default-editor, default-viewer and namespaceAdmin RoleBindings, default-editor and default-viewer
+	// ServiceAccounts.
+	assert.Equal(t, []string{
+		"AuthorizationPolicy",
+		"RoleBinding", "RoleBinding", "RoleBinding",
+		"ServiceAccount", "ServiceAccount",
+		"Namespace",
+	}, recorder.deleted)
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), types.NamespacedName{Name: "kubeflow-user1"}, &corev1.Namespace{})))
+
+	profile := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	assert.NotContains(t, profile.Finalizers, PROFILEFINALIZER)
+}
+
+func TestCleanupOrderWaitsForDeletion(t *testing.T) {
+	r, recorder := newDeletedProfileReconciler(t, "ServiceAccount", "Namespace")
+	recorder.keep = map[string]bool{"ServiceAccount": true}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "kubeflow-user1"}}
+
+	result, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, cleanupRequeue, result.RequeueAfter)
+	// The namespace is not deleted before the service accounts are gone.
+	assert.NotContains(t, recorder.deleted, "Namespace")
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "kubeflow-user1"}, &corev1.Namespace{}))
+
+	recorder.keep = nil
+	result, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, result.IsZero())
+	assert.Contains(t, recorder.deleted, "Namespace")
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileConfiguredClusterRoles(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	contributor := profilev1.Contributor{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}, Role: EDIT}
+	profile.Spec.Contributors = []profilev1.Contributor{contributor}
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	roleRef := func(name string) string {
+		binding := &rbacv1.RoleBinding{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: profile.Name}, binding))
+		return binding.RoleRef.Name
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, kubeflowEdit, roleRef(DEFAULT_EDITOR))
+	assert.Equal(t, kubeflowView, roleRef(DEFAULT_VIEWER))
+
+	// Changing the ClusterRoles recreates the bindings, the roleRef is immutable.
+	r.EditorClusterRole = "aggregate-edit"
+	r.ViewerClusterRole = "aggregate-view"
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "aggregate-edit", roleRef(DEFAULT_EDITOR))
+	assert.Equal(t, "aggregate-view", roleRef(DEFAULT_VIEWER))
+	assert.Equal(t, "aggregate-edit", roleRef(getContributorBindingName(contributor)))
+	assert.Equal(t, kubeflowAdmin, roleRef("namespaceAdmin"))
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	istioSecurity "istio.io/api/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Namespace annotation holding a hash of the configuration applied by the controller, for downstream
+// tools to detect changes.
+const CONFIGHASH = "profile.kubeflow.org/config-hash"
+
+// appliedConfig is the desired state applied to a profile namespace.
+type appliedConfig struct {
+	Spec                     profilev1.ProfileSpec                     `json:"spec"`
+	ResourceQuota            corev1.ResourceQuotaSpec                  `json:"resourceQuota"`
+	NamespaceAnnotations     map[string]string                         `json:"namespaceAnnotations"`
+	DefaultEditorAnnotations map[string]string                         `json:"defaultEditorAnnotations"`
+	AuthorizationPolicy      *istioSecurity.AuthorizationPolicy        `json:"authorizationPolicy"`
+	PodDefaults              PodDefaults                               `json:"podDefaults"`
+	PodDefaultLabels         map[string]string                         `json:"podDefaultLabels,omitempty"`
+	ImagePullSecrets         []string                                  `json:"imagePullSecrets,omitempty"`
+	PodAntiAffinity          *corev1.PodAntiAffinity                   `json:"podAntiAffinity,omitempty"`
+	NetworkPolicies          map[string]networkingv1.NetworkPolicySpec `json:"networkPolicies,omitempty"`
+}
+
+// configHash returns a stable hash of the configuration applied to the profile namespace.
+func (r *ProfileReconciler) configHash(ctx context.Context, profileIns *profilev1.Profile,
+	quotaSpec corev1.ResourceQuotaSpec, nsAnnotations map[string]string) (string, error) {
+	editorAnnotations, err := r.serviceAccountAnnotations(profileIns, DEFAULT_EDITOR)
+	if err != nil {
+		return "", err
+	}
+	imagePullSecrets, err := r.DefaultImagePullSecrets.Render(profileIns)
+	if err != nil {
+		return "", err
+	}
+	policy, err := r.getAuthorizationPolicy(profileIns)
+	if err != nil {
+		return "", err
+	}
+	networkPolicies, err := r.NetworkPolicies.specs(profileIns)
+	if err != nil {
+		return "", err
+	}
+	podDefaults, err := r.configuredPodDefaults(ctx)
+	if err != nil {
+		return "", err
+	}
+	// encoding/json sorts map keys, so equal configurations marshal to equal bytes.
+	data, err := json.Marshal(appliedConfig{
+		Spec:                     profileIns.Spec,
+		ResourceQuota:            quotaSpec,
+		NamespaceAnnotations:     nsAnnotations,
+		DefaultEditorAnnotations: editorAnnotations,
+		AuthorizationPolicy:      &policy,
+		PodDefaults:              podDefaults,
+		PodDefaultLabels:         r.PodDefaultLabels,
+		ImagePullSecrets:         imagePullSecrets,
+		PodAntiAffinity:          r.PodAntiAffinity,
+		NetworkPolicies:          networkPolicies,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// updateConfigHash sets the CONFIGHASH annotation of the profile namespace if hash changed.
+func (r *ProfileReconciler) updateConfigHash(ctx context.Context, profileIns *profilev1.Profile, hash string) error {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: profileNamespace(profileIns)}, ns); err != nil {
+		return err
+	}
+	if ns.Annotations[CONFIGHASH] == hash {
+		return nil
+	}
+	r.Log.Info("Updating config hash", "namespace", profileIns.Name, "hash", hash)
+	return r.applyAnnotations(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns.Name}},
+		CONFIGHASHFIELDMANAGER, map[string]string{CONFIGHASH: hash}, nil)
+}
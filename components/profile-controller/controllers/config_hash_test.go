@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileConfigHash(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	ns := getNamespace()
+	hash := ns.Annotations[CONFIGHASH]
+	assert.NotEmpty(t, hash)
+
+	// Stable without config change, the namespace is not updated again.
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	unchanged := getNamespace()
+	assert.Equal(t, hash, unchanged.Annotations[CONFIGHASH])
+	assert.Equal(t, ns.ResourceVersion, unchanged.ResourceVersion)
+
+	// Changes with the profile spec.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	profile.Spec.ResourceQuotaSpec = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+	}
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	changed := getNamespace().Annotations[CONFIGHASH]
+	assert.NotEqual(t, hash, changed)
+
+	// Changes with the controller configuration.
+	templates, err := ParseAnnotationTemplates("catalog.example.com/owner={{ .Owner }}")
+	require.NoError(t, err)
+	r.CatalogAnnotations = templates
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.NotEqual(t, changed, getNamespace().Annotations[CONFIGHASH])
+}
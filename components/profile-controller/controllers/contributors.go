@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	istioSecurity "istio.io/api/security/v1beta1"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Label marking RoleBindings and AuthorizationPolicies created for spec.contributors, only those are pruned.
+const CONTRIBUTORLABEL = "profile.kubeflow.org/contributor"
+
+// Roles of contributors, also the role annotation of their RoleBindings as consumed by kfam.
+const (
+	EDIT = "edit"
+	VIEW = "view"
+)
+
+// sameSubject tells if a and b are the same subject.
+func sameSubject(a rbacv1.Subject, b rbacv1.Subject) bool {
+	return a.Kind == b.Kind && a.Name == b.Name && a.Namespace == b.Namespace
+}
+
+// getContributors returns the contributors of the profile without duplicates and without the owner, who
+// already has admin access. Contributors without a role get EDIT, a subject listed twice keeps its first role.
+func getContributors(profileIns *profilev1.Profile) []profilev1.Contributor {
+	var contributors []profilev1.Contributor
+	for _, contributor := range profileIns.Spec.Contributors {
+		if sameSubject(contributor.Subject, profileIns.Spec.Owner) {
+			continue
+		}
+		duplicate := false
+		for _, c := range contributors {
+			if sameSubject(contributor.Subject, c.Subject) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		if contributor.Role == "" {
+			contributor.Role = EDIT
+		}
+		contributors = append(contributors, contributor)
+	}
+	return contributors
+}
+
+// contributorClusterRole returns the ClusterRole bound to a contributor with its role.
+func (r *ProfileReconciler) contributorClusterRole(contributor profilev1.Contributor) string {
+	if contributor.Role == VIEW {
+		return r.viewerClusterRole()
+	}
+	return r.editorClusterRole()
+}
+
+// getContributorBindingName returns the RoleBinding and AuthorizationPolicy name of a contributor, distinct from
+// the RoleBindings kfam and membership create for the same subject. It does not depend on the configured
+// ClusterRoles, so remapping them keeps the names.
+func getContributorBindingName(contributor profilev1.Contributor) string {
+	clusterRole := kubeflowEdit
+	if contributor.Role == VIEW {
+		clusterRole = kubeflowView
+	}
+	return "contributor-" + getMemberBindingName(Member{Subject: contributor.Subject, ClusterRole: clusterRole})
+}
+
+// updateContributorRoleBindings grants every contributor access to target namespace with its role and deletes
+// the RoleBindings of contributors removed from the profile.
+func (r *ProfileReconciler) updateContributorRoleBindings(ctx context.Context, profileIns *profilev1.Profile,
+	contributors []profilev1.Contributor) error {
+	desired := map[string]bool{}
+	for _, contributor := range contributors {
+		roleBinding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{USER: contributor.Name, ROLE: contributor.Role},
+				Labels:      map[string]string{CONTRIBUTORLABEL: "true"},
+				Name:        getContributorBindingName(contributor),
+				Namespace:   profileNamespace(profileIns),
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: "rbac.authorization.k8s.io",
+				Kind:     "ClusterRole",
+				Name:     r.contributorClusterRole(contributor),
+			},
+			Subjects: []rbacv1.Subject{contributor.Subject},
+		}
+		if err := r.updateRoleBinding(ctx, profileIns, roleBinding); err != nil {
+			return err
+		}
+		desired[roleBinding.Name] = true
+	}
+
+	existing := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, existing, client.InNamespace(profileNamespace(profileIns)),
+		client.MatchingLabels{CONTRIBUTORLABEL: "true"}); err != nil {
+		return err
+	}
+	for i := range existing.Items {
+		if desired[existing.Items[i].Name] {
+			continue
+		}
+		r.Log.Info("Deleting RoleBinding of removed contributor", "namespace", profileIns.Name,
+			"name", existing.Items[i].Name)
+		if err := r.Delete(ctx, &existing.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// contributorAuthorizationPolicy returns the AuthorizationPolicy allowing a contributor requests to the workloads
+// of the profile namespace, nil if the contributor cannot be matched in the mesh. Viewers may only read.
+func (r *ProfileReconciler) contributorAuthorizationPolicy(profileIns *profilev1.Profile,
+	contributor profilev1.Contributor) *istioSecurityClient.AuthorizationPolicy {
+	var to []*istioSecurity.Rule_To
+	if contributor.Role == VIEW {
+		to = []*istioSecurity.Rule_To{
+			{
+				Operation: &istioSecurity.Operation{Methods: auditorMethods},
+			},
+		}
+	}
+	rules := r.subjectRules(contributor.Subject, to)
+	if len(rules) == 0 {
+		return nil
+	}
+	return &istioSecurityClient.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    map[string]string{CONTRIBUTORLABEL: "true"},
+			Name:      getContributorBindingName(contributor),
+			Namespace: profileNamespace(profileIns),
+		},
+		Spec: istioSecurity.AuthorizationPolicy{
+			Action: istioSecurity.AuthorizationPolicy_ALLOW,
+			Rules:  rules,
+		},
+	}
+}
+
+// updateContributorAuthorizationPolicies allows every contributor matched in the mesh requests to the workloads
+// of the profile namespace and deletes the AuthorizationPolicies of contributors removed from the profile.
+// Contributors that cannot be matched, see subjectConditions, only get their RoleBinding.
+func (r *ProfileReconciler) updateContributorAuthorizationPolicies(ctx context.Context, profileIns *profilev1.Profile,
+	contributors []profilev1.Contributor) error {
+	desired := map[string]bool{}
+	for _, contributor := range contributors {
+		policy := r.contributorAuthorizationPolicy(profileIns, contributor)
+		if policy == nil {
+			continue
+		}
+		if err := r.applyAuthorizationPolicy(ctx, profileIns, policy); err != nil {
+			return err
+		}
+		desired[policy.Name] = true
+	}
+
+	existing := &istioSecurityClient.AuthorizationPolicyList{}
+	if err := r.List(ctx, existing, client.InNamespace(profileNamespace(profileIns)),
+		client.MatchingLabels{CONTRIBUTORLABEL: "true"}); err != nil {
+		return err
+	}
+	for i := range existing.Items {
+		if desired[existing.Items[i].Name] {
+			continue
+		}
+		if err := r.deleteOwnedAuthorizationPolicy(ctx, profileIns, existing.Items[i].Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
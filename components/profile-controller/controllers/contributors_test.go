@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetContributors(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.Contributors = []profilev1.Contributor{
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}},
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user1@abcd.com"}},
+		{Subject: rbacv1.Subject{Kind: "Group", Name: "user2@abcd.com"}, Role: VIEW},
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}, Role: VIEW},
+	}
+	assert.Equal(t, []profilev1.Contributor{
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}, Role: EDIT},
+		{Subject: rbacv1.Subject{Kind: "Group", Name: "user2@abcd.com"}, Role: VIEW},
+	}, getContributors(profile))
+}
+
+func TestReconcileContributors(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.Contributors = []profilev1.Contributor{
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}},
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user3@abcd.com"}, Role: VIEW},
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user3@abcd.com"}},
+		{Subject: rbacv1.Subject{Kind: "Group", Name: "team@abcd.com"}},
+		{Subject: profile.Spec.Owner},
+	}
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	contributorBindings := func() map[string]string {
+		list := &rbacv1.RoleBindingList{}
+		require.NoError(t, r.List(context.TODO(), list, client.InNamespace(profile.Name),
+			client.MatchingLabels{CONTRIBUTORLABEL: "true"}))
+		roles := map[string]string{}
+		for _, rb := range list.Items {
+			require.Len(t, rb.Subjects, 1)
+			roles[rb.Subjects[0].Name] = rb.RoleRef.Name
+		}
+		return roles
+	}
+	// contributorPolicies returns the identity and methods allowed by every contributor AuthorizationPolicy.
+	contributorPolicies := func() map[string][]string {
+		list := &istioSecurityClient.AuthorizationPolicyList{}
+		require.NoError(t, r.List(context.TODO(), list, client.InNamespace(profile.Name),
+			client.MatchingLabels{CONTRIBUTORLABEL: "true"}))
+		policies := map[string][]string{}
+		for _, policy := range list.Items {
+			require.Len(t, policy.Spec.Rules, 1)
+			rule := policy.Spec.Rules[0]
+			require.Len(t, rule.When, 1)
+			assert.Equal(t, fmt.Sprintf("request.headers[%v]", r.UserIdHeader), rule.When[0].Key)
+			require.Len(t, rule.When[0].Values, 1)
+			var methods []string
+			for _, to := range rule.To {
+				methods = append(methods, to.Operation.Methods...)
+			}
+			policies[rule.When[0].Values[0]] = methods
+		}
+		return policies
+	}
+	updateContributors := func(contributors []profilev1.Contributor) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		found.Spec.Contributors = contributors
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"user2@abcd.com": kubeflowEdit,
+		"user3@abcd.com": kubeflowView,
+		"team@abcd.com":  kubeflowEdit,
+	}, contributorBindings())
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Namespace: profile.Name,
+		Name: getContributorBindingName(profile.Spec.Contributors[1])}, rb))
+	assert.Equal(t, VIEW, rb.Annotations[ROLE])
+	// Groups cannot be matched by the user id header, viewers may only read.
+	assert.Equal(t, map[string][]string{
+		r.UserIdPrefix + "user2@abcd.com": nil,
+		r.UserIdPrefix + "user3@abcd.com": auditorMethods,
+	}, contributorPolicies())
+	// The owner keeps the single admin binding.
+	owner := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "namespaceAdmin", Namespace: profile.Name}, owner))
+	assert.Equal(t, kubeflowAdmin, owner.RoleRef.Name)
+
+	// user3 is promoted to edit, user2 removed and user4 added.
+	updateContributors([]profilev1.Contributor{
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user3@abcd.com"}, Role: EDIT},
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user4@abcd.com"}, Role: VIEW},
+	})
+	assert.Equal(t, map[string]string{
+		"user3@abcd.com": kubeflowEdit,
+		"user4@abcd.com": kubeflowView,
+	}, contributorBindings())
+	assert.Equal(t, map[string][]string{
+		r.UserIdPrefix + "user3@abcd.com": nil,
+		r.UserIdPrefix + "user4@abcd.com": auditorMethods,
+	}, contributorPolicies())
+
+	updateContributors(nil)
+	assert.Empty(t, contributorBindings())
+	assert.Empty(t, contributorPolicies())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Labels spec.costLabels are propagated as, to target namespace and to its pods, for chargeback.
+const (
+	COSTCENTERLABEL = "cost.kubeflow.org/cost-center"
+	COSTWBSLABEL    = "cost.kubeflow.org/wbs-code"
+	COSTTEAMLABEL   = "cost.kubeflow.org/team"
+)
+
+// Name of the PodDefault setting the cost labels on every pod of the namespace.
+const COSTLABELSPODDEFAULT = "cost-labels"
+
+// costLabels returns the cost labels of the profile, by label key, without the ones not set.
+func costLabels(profileIns *profilev1.Profile) map[string]string {
+	c := profileIns.Spec.CostLabels
+	if c == nil {
+		return nil
+	}
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		COSTCENTERLABEL: c.CostCenter,
+		COSTWBSLABEL:    c.WBSCode,
+		COSTTEAMLABEL:   c.Team,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// validateCostLabels checks that the cost labels of the profile are valid label values.
+func validateCostLabels(profileIns *profilev1.Profile) error {
+	for key, value := range costLabels(profileIns) {
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid value %q of cost label %v: %v", value, key, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// getCostLabelsPodDefault returns the PodDefault labeling all pods of the namespace with the cost labels of the
+// profile, nil if the profile has none.
+func getCostLabelsPodDefault(profileIns *profilev1.Profile) *unstructured.Unstructured {
+	labels := costLabels(profileIns)
+	if len(labels) == 0 {
+		return nil
+	}
+	podLabels := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		podLabels[k] = v
+	}
+	return newPodDefault(profileNamespace(profileIns), COSTLABELSPODDEFAULT, map[string]interface{}{
+		"desc": "Cost allocation labels of the profile",
+		// Empty selector matches all pods.
+		"selector": map[string]interface{}{},
+		"labels":   podLabels,
+	})
+}
+
+// updateCostLabelsPodDefault reconciles the PodDefault for the cost labels of the profile.
+func (r *ProfileReconciler) updateCostLabelsPodDefault(ctx context.Context, profileIns *profilev1.Profile) error {
+	podDefault := getCostLabelsPodDefault(profileIns)
+	if podDefault == nil {
+		return r.deletePodDefault(ctx, profileIns, COSTLABELSPODDEFAULT)
+	}
+	return r.updatePodDefault(ctx, profileIns, podDefault)
+}
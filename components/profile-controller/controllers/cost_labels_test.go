@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestCostLabels(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	assert.Nil(t, costLabels(profile))
+	assert.Nil(t, getCostLabelsPodDefault(profile))
+
+	profile.Spec.CostLabels = &profilev1.CostLabels{CostCenter: "cc-1234", Team: "ml"}
+	assert.Equal(t, map[string]string{COSTCENTERLABEL: "cc-1234", COSTTEAMLABEL: "ml"}, costLabels(profile))
+	assert.NoError(t, validateCostLabels(profile))
+	profile.Spec.CostLabels.WBSCode = "W 1234"
+	assert.Error(t, validateCostLabels(profile))
+}
+
+func TestReconcileCostLabels(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.CostLabels = &profilev1.CostLabels{CostCenter: "cc-1234", WBSCode: "W.1234.01", Team: "ml"}
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getPodDefault := func() (*unstructured.Unstructured, error) {
+		pd := &unstructured.Unstructured{}
+		pd.SetGroupVersionKind(podDefaultGVK)
+		err := r.Get(context.TODO(), types.NamespacedName{Name: COSTLABELSPODDEFAULT, Namespace: profile.Name}, pd)
+		return pd, err
+	}
+	expected := map[string]string{COSTCENTERLABEL: "cc-1234", COSTWBSLABEL: "W.1234.01", COSTTEAMLABEL: "ml"}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	for k, v := range expected {
+		assert.Equal(t, v, ns.Labels[k], k)
+	}
+	pd, err := getPodDefault()
+	require.NoError(t, err)
+	selector, found, _ := unstructured.NestedMap(pd.Object, "spec", "selector")
+	require.True(t, found)
+	assert.Empty(t, selector)
+	labels, _, _ := unstructured.NestedStringMap(pd.Object, "spec", "labels")
+	assert.Equal(t, expected, labels)
+
+	// Dropping the cost labels removes them from the namespace and deletes the PodDefault.
+	updated := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, updated))
+	updated.Spec.CostLabels = nil
+	require.NoError(t, r.Update(context.TODO(), updated))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	for k := range expected {
+		assert.NotContains(t, ns.Labels, k)
+	}
+	_, err = getPodDefault()
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Profile annotation declaring the data classification level of the profile, propagated to its namespace.
+const DATACLASSIFICATION = "data-classification"
+
+// Condition type and event reason set while the data classification of the profile is missing or invalid.
+const InvalidDataClassification = "InvalidDataClassification"
+
+// DataClassification configures the allowed data classification levels of profiles.
+type DataClassification struct {
+	// Levels are the allowed values of the DATACLASSIFICATION annotation.
+	Levels []string
+	// Required rejects profiles without DATACLASSIFICATION annotation.
+	Required bool
+}
+
+// ParseDataClassificationLevels parses the -data-classification-levels value, comma separated levels, e.g.
+// "public,internal,confidential,restricted".
+func ParseDataClassificationLevels(value string) ([]string, error) {
+	var levels []string
+	seen := map[string]bool{}
+	for _, level := range strings.Split(value, ",") {
+		level = strings.TrimSpace(level)
+		if level == "" {
+			continue
+		}
+		if errs := validation.IsValidLabelValue(level); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid data classification level %q: %v", level, strings.Join(errs, ", "))
+		}
+		if seen[level] {
+			return nil, fmt.Errorf("duplicate data classification level %q", level)
+		}
+		seen[level] = true
+		levels = append(levels, level)
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("no data classification level")
+	}
+	return levels, nil
+}
+
+// validate checks the DATACLASSIFICATION annotation of the profile is one of the Levels, and is set if Required.
+func (d *DataClassification) validate(profileIns *profilev1.Profile) error {
+	if d == nil {
+		return nil
+	}
+	level, ok := profileIns.Annotations[DATACLASSIFICATION]
+	if !ok {
+		if d.Required {
+			return fmt.Errorf("profile has no %v annotation, expected one of %v", DATACLASSIFICATION,
+				strings.Join(d.Levels, ", "))
+		}
+		return nil
+	}
+	for _, allowed := range d.Levels {
+		if level == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %v annotation %q, expected one of %v", DATACLASSIFICATION, level,
+		strings.Join(d.Levels, ", "))
+}
+
+// annotations returns the namespace annotation of the data classification of the profile, if any.
+func (d *DataClassification) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if d == nil {
+		return nil, nil
+	}
+	if err := d.validate(profileIns); err != nil {
+		return nil, err
+	}
+	level, ok := profileIns.Annotations[DATACLASSIFICATION]
+	if !ok {
+		return nil, nil
+	}
+	return map[string]string{DATACLASSIFICATION: level}, nil
+}
+
+// updateDataClassification sets the InvalidDataClassification condition of the profile and emits a warning event
+// if its data classification is missing or invalid, or clears the condition once fixed. It returns whether the
+// profile is rejected, rejected profiles are not requeued, the annotation has to be fixed first.
+func (r *ProfileReconciler) updateDataClassification(ctx context.Context, profileIns *profilev1.Profile) (bool,
+	error) {
+	err := r.DataClassification.validate(profileIns)
+	status, message := "False", "data classification is valid"
+	if err != nil {
+		status, message = "True", err.Error()
+	}
+	for _, condition := range profileIns.Status.Conditions {
+		if condition.Type == InvalidDataClassification {
+			if condition.Status == status && condition.Message == message {
+				return err != nil, nil
+			}
+			return err != nil, r.writeDataClassification(ctx, profileIns, status, message)
+		}
+	}
+	if err == nil {
+		return false, nil
+	}
+	return true, r.writeDataClassification(ctx, profileIns, status, message)
+}
+
+func (r *ProfileReconciler) writeDataClassification(ctx context.Context, profileIns *profilev1.Profile,
+	status string, message string) error {
+	r.Log.Info("Updating data classification condition", "profile", profileIns.Name, "invalid", status,
+		"message", message)
+	if status == "True" && r.Recorder != nil {
+		r.Recorder.Event(profileIns, corev1.EventTypeWarning, InvalidDataClassification, message)
+	}
+	r.setProfileCondition(profileIns, InvalidDataClassification, status, message)
+	return r.Status().Update(ctx, profileIns)
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseDataClassificationLevels(t *testing.T) {
+	levels, err := ParseDataClassificationLevels("public, internal,confidential")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"public", "internal", "confidential"}, levels)
+
+	for _, value := range []string{"", "public,public", "top secret"} {
+		_, err := ParseDataClassificationLevels(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestReconcileDataClassification(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		required    bool
+		invalid     string
+	}{
+		{name: "present", annotations: map[string]string{DATACLASSIFICATION: "internal"}, required: true},
+		{name: "missing, not required"},
+		{name: "missing", required: true, invalid: "profile has no data-classification annotation"},
+		{name: "invalid", annotations: map[string]string{DATACLASSIFICATION: "secret"},
+			invalid: `invalid data-classification annotation "secret", expected one of public, internal`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+			profile.Annotations = tc.annotations
+			r := newFakeReconciler(profile)
+			countingClient := &namespaceCreateCountingClient{Client: r.Client}
+			r.Client = countingClient
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+			r.DataClassification = &DataClassification{Levels: []string{"public", "internal"}, Required: tc.required}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+			result, err := r.Reconcile(request)
+			require.NoError(t, err)
+			assert.True(t, result.IsZero())
+			found := &profilev1.Profile{}
+			require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+
+			if tc.invalid == "" {
+				ns := &corev1.Namespace{}
+				require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+				level, ok := tc.annotations[DATACLASSIFICATION]
+				actual, actualOk := ns.Annotations[DATACLASSIFICATION]
+				assert.Equal(t, ok, actualOk)
+				assert.Equal(t, level, actual)
+				for _, condition := range found.Status.Conditions {
+					assert.NotEqual(t, InvalidDataClassification, condition.Type)
+				}
+				return
+			}
+			assert.Equal(t, 0, countingClient.namespaceCreates)
+			require.Len(t, found.Status.Conditions, 1)
+			assert.Equal(t, InvalidDataClassification, found.Status.Conditions[0].Type)
+			assert.Equal(t, "True", found.Status.Conditions[0].Status)
+			assert.Contains(t, found.Status.Conditions[0].Message, tc.invalid)
+			require.Len(t, recorder.Events, 1)
+			assert.Contains(t, <-recorder.Events, "Warning InvalidDataClassification")
+
+			// Fixing the annotation creates the namespace and clears the condition.
+			found.Annotations = map[string]string{DATACLASSIFICATION: "public"}
+			require.NoError(t, r.Update(context.TODO(), found))
+			_, err = r.Reconcile(request)
+			require.NoError(t, err)
+			ns := &corev1.Namespace{}
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+			assert.Equal(t, "public", ns.Annotations[DATACLASSIFICATION])
+			found = &profilev1.Profile{}
+			require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+			assert.Equal(t, InvalidDataClassification, found.Status.Conditions[0].Type)
+			assert.Equal(t, "False", found.Status.Conditions[0].Status)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Path of the dry-run diff endpoint, served next to the metrics.
+const DRYRUNPATH = "/dry-run/diff"
+
+// dryRunReport holds the changes recorded for every profile by its last reconcile in dry-run mode.
+type dryRunReport struct {
+	mu      sync.Mutex
+	changes map[string]string
+}
+
+// DryRun switches the reconciler to dry-run mode: nothing is written to the cluster, the changes a reconcile would
+// make are logged and served by DryRunHandler instead. Events are not emitted and plugins are neither applied nor
+// revoked. Must be called before the reconciler is started.
+func (r *ProfileReconciler) DryRun() {
+	r.ObserveOnly(types.NamespacedName{})
+	r.dryRun = &dryRunReport{changes: map[string]string{}}
+	r.Recorder = nil
+}
+
+// recordDryRun keeps the changes of the last reconcile of profile, logged when they changed.
+func (r *ProfileReconciler) recordDryRun(profile string, changes []string) {
+	report := strings.Join(changes, "\n")
+	r.dryRun.mu.Lock()
+	defer r.dryRun.mu.Unlock()
+	if current, ok := r.dryRun.changes[profile]; ok == (len(changes) > 0) && current == report {
+		return
+	}
+	if len(changes) == 0 {
+		delete(r.dryRun.changes, profile)
+		r.Log.Info("Dry-run: no changes", "profile", profile)
+		return
+	}
+	r.dryRun.changes[profile] = report
+	r.Log.Info("Dry-run: changes not applied", "profile", profile, "changes", report)
+}
+
+// DryRunHandler serves the changes recorded in dry-run mode as text, for the profile of the profile query
+// parameter or for all profiles.
+func (r *ProfileReconciler) DryRunHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r.dryRun.mu.Lock()
+		defer r.dryRun.mu.Unlock()
+		profiles := make([]string, 0, len(r.dryRun.changes))
+		if profile := req.URL.Query().Get("profile"); profile != "" {
+			if _, ok := r.dryRun.changes[profile]; ok {
+				profiles = append(profiles, profile)
+			}
+		} else {
+			for profile := range r.dryRun.changes {
+				profiles = append(profiles, profile)
+			}
+			sort.Strings(profiles)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, profile := range profiles {
+			_, _ = w.Write([]byte("# profile " + profile + "\n" + r.dryRun.changes[profile] + "\n\n"))
+		}
+	})
+}
+
+// pluginKind returns the kind of plugin in the profile spec.
+func pluginKind(plugin Plugin) string {
+	switch plugin.(type) {
+	case *GcpWorkloadIdentity:
+		return KIND_WORKLOAD_IDENTITY
+	case *AwsIAMForServiceAccount:
+		return KIND_AWS_IAM_FOR_SERVICE_ACCOUNT
+	}
+	return ""
+}
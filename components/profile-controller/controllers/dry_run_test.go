@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileDryRun(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.Plugins = []profilev1.Plugin{
+		newPluginWithSpec(KIND_WORKLOAD_IDENTITY, `{"gcpServiceAccount":"user1-sa@my-project.iam.gserviceaccount.com"}`),
+	}
+	other := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	r := newFakeReconciler(profile, other)
+	r.DryRun()
+	assert.Nil(t, r.Recorder)
+	get := func(query string) string {
+		w := httptest.NewRecorder()
+		r.DryRunHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, DRYRUNPATH+query, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: other.Name}})
+	require.NoError(t, err)
+	diff := get("")
+	assert.Contains(t, diff, "# profile kubeflow-user1\ncreate Namespace kubeflow-user1\n")
+	assert.Contains(t, diff, "create ServiceAccount kubeflow-user1/default-editor\n")
+	assert.Contains(t, diff, "apply plugin "+KIND_WORKLOAD_IDENTITY)
+	assert.Contains(t, diff, "# profile kubeflow-user2\n")
+	assert.Less(t, len(get("?profile=kubeflow-user2")), len(diff))
+	assert.NotContains(t, get("?profile=kubeflow-user2"), "kubeflow-user1")
+	assert.Empty(t, get("?profile=kubeflow-user3"))
+
+	// Nothing was written, nor the configured ConfigMap of observe-only mode.
+	err = r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, &corev1.Namespace{})
+	assert.True(t, apierrors.IsNotFound(err))
+	configMaps := &corev1.ConfigMapList{}
+	require.NoError(t, r.List(context.TODO(), configMaps))
+	assert.Empty(t, configMaps.Items)
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+	assert.Empty(t, found.Finalizers)
+	assert.Empty(t, found.Status.Conditions)
+
+	w := httptest.NewRecorder()
+	r.DryRunHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, DRYRUNPATH, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestRecordDryRun(t *testing.T) {
+	r := newFakeReconciler()
+	r.DryRun()
+	r.recordDryRun("kubeflow-user1", []string{"create ServiceAccount kubeflow-user1/default-editor"})
+	assert.Equal(t, map[string]string{"kubeflow-user1": "create ServiceAccount kubeflow-user1/default-editor"},
+		r.dryRun.changes)
+	r.recordDryRun("kubeflow-user1", nil)
+	assert.Empty(t, r.dryRun.changes)
+}
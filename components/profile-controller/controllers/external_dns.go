@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Annotation of external-dns holding the comma separated hostnames to create DNS records for.
+const EXTERNALDNSHOSTNAME = "external-dns.alpha.kubernetes.io/hostname"
+
+// renderExternalDNSAnnotations renders the external-dns annotation templates for the profile, e.g.
+// `external-dns.alpha.kubernetes.io/hostname={{ .Name }}.kubeflow.example.com`. Rendered hostnames must be
+// valid DNS names, so a profile name cannot create arbitrary records.
+func renderExternalDNSAnnotations(templates AnnotationTemplates,
+	profileIns *profilev1.Profile) (map[string]string, error) {
+	annotations, err := templates.Render(profileIns)
+	if err != nil {
+		return nil, err
+	}
+	if value := annotations[EXTERNALDNSHOSTNAME]; value != "" {
+		for _, hostname := range strings.Split(value, ",") {
+			hostname = strings.TrimSpace(hostname)
+			if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(hostname, "*.")); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid external-dns hostname %q: %v", hostname, strings.Join(errs, ", "))
+			}
+		}
+	}
+	return annotations, nil
+}
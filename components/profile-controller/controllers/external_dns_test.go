@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestRenderExternalDNSAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	templates, err := ParseAnnotationTemplates(EXTERNALDNSHOSTNAME + "={{ .Name }}.kubeflow.example.com," +
+		"external-dns.alpha.kubernetes.io/ttl=60")
+	require.NoError(t, err)
+	annotations, err := renderExternalDNSAnnotations(templates, profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		EXTERNALDNSHOSTNAME:                    "kubeflow-user1.kubeflow.example.com",
+		"external-dns.alpha.kubernetes.io/ttl": "60",
+	}, annotations)
+}
+
+func TestRenderExternalDNSAnnotationsInvalidHostname(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Annotations = map[string]string{"host": "not a hostname"}
+	templates, err := ParseAnnotationTemplates(EXTERNALDNSHOSTNAME + "={{ .Annotations.host }}")
+	require.NoError(t, err)
+	_, err = renderExternalDNSAnnotations(templates, profile)
+	assert.Error(t, err)
+}
+
+func TestReconcileExternalDNSAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates(EXTERNALDNSHOSTNAME + "=*.{{ .Name }}.kubeflow.example.com")
+	require.NoError(t, err)
+	r.ExternalDNSAnnotations = templates
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "*.kubeflow-user1.kubeflow.example.com", ns.Annotations[EXTERNALDNSHOSTNAME])
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+	for _, condition := range found.Status.Conditions {
+		assert.NotEqual(t, profilev1.ProfileFailed, condition.Type)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// GatewayTLS configures the namespace annotations exposing the apps of profile namespaces with TLS termination
+// at a shared Istio gateway, under a host derived from the namespace name.
+type GatewayTLS struct {
+	// Domain is appended to the namespace name to build the host of the namespace, e.g. "apps.example.com".
+	Domain string
+	// Annotations are rendered with GatewayTLSTemplateData, e.g.
+	// `gateway.example.com/hosts={{ .Host }},gateway.example.com/tls-secret={{ .Name }}-tls`.
+	Annotations AnnotationTemplates
+}
+
+// GatewayTLSTemplateData is the data exposed to gateway TLS annotation templates.
+type GatewayTLSTemplateData struct {
+	ProfileTemplateData
+	// Host is the namespace name under the Domain, e.g. "kubeflow-user1.apps.example.com".
+	Host string
+}
+
+// ValidateGatewayDomain checks domain is a DNS name hosts can be built under.
+func ValidateGatewayDomain(domain string) error {
+	if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+		return fmt.Errorf("invalid gateway domain %q: %v", domain, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// host returns the host of the profile namespace. It must be a valid DNS name, so a long profile name cannot
+// produce an invalid host.
+func (g *GatewayTLS) host(profileIns *profilev1.Profile) (string, error) {
+	host := profileNamespace(profileIns) + "." + g.Domain
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return "", fmt.Errorf("invalid gateway host %q: %v", host, strings.Join(errs, ", "))
+	}
+	return host, nil
+}
+
+// annotations renders the gateway TLS namespace annotations of the profile.
+func (g *GatewayTLS) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if g == nil || len(g.Annotations) == 0 {
+		return nil, nil
+	}
+	host, err := g.host(profileIns)
+	if err != nil {
+		return nil, err
+	}
+	return g.Annotations.render(GatewayTLSTemplateData{
+		ProfileTemplateData: newProfileTemplateData(profileIns),
+		Host:                host,
+	})
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestValidateGatewayDomain(t *testing.T) {
+	assert.NoError(t, ValidateGatewayDomain("apps.example.com"))
+	for _, domain := range []string{"", "Apps.Example.com", "apps_example.com", "*.example.com"} {
+		assert.Error(t, ValidateGatewayDomain(domain), domain)
+	}
+}
+
+func TestGatewayTLSAnnotations(t *testing.T) {
+	templates, err := ParseAnnotationTemplates("gateway.example.com/hosts={{ .Host }}," +
+		"gateway.example.com/tls-secret={{ .Name }}-tls")
+	require.NoError(t, err)
+	g := &GatewayTLS{Domain: "apps.example.com", Annotations: templates}
+
+	annotations, err := g.annotations(newTestProfile("kubeflow-user1", "user1@abcd.com"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"gateway.example.com/hosts":      "kubeflow-user1.apps.example.com",
+		"gateway.example.com/tls-secret": "kubeflow-user1-tls",
+	}, annotations)
+
+	// Hosts longer than a DNS name are rejected.
+	_, err = g.annotations(newTestProfile(strings.Repeat("a", 63)+"-"+strings.Repeat("b", 190), "user1@abcd.com"))
+	assert.Error(t, err)
+
+	var disabled *GatewayTLS
+	annotations, err = disabled.annotations(newTestProfile("kubeflow-user1", "user1@abcd.com"))
+	require.NoError(t, err)
+	assert.Nil(t, annotations)
+}
+
+func TestReconcileGatewayTLS(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("gateway.example.com/hosts={{ .Host }}")
+	require.NoError(t, err)
+	r.GatewayTLS = &GatewayTLS{Domain: "apps.example.com", Annotations: templates}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns := getNamespace()
+	assert.Equal(t, "kubeflow-user1.apps.example.com", ns.Annotations["gateway.example.com/hosts"])
+
+	// Drift is corrected.
+	ns.Annotations["gateway.example.com/hosts"] = "other.apps.example.com"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "kubeflow-user1.apps.example.com", getNamespace().Annotations["gateway.example.com/hosts"])
+
+	// Disabling the gateway removes its annotations.
+	r.GatewayTLS = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.NotContains(t, getNamespace().Annotations, "gateway.example.com/hosts")
+}
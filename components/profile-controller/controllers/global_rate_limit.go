@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// globalReconcileLimiter returns the token bucket shared by the reconciles of all profiles, nil if
+// GlobalReconcileRPS is disabled. The bucket holds a single token, so reconciles are spread evenly even with
+// MaxConcurrentReconciles > 1.
+func (r *ProfileReconciler) globalReconcileLimiter() *rate.Limiter {
+	r.globalReconcileLimiterOnce.Do(func() {
+		if r.GlobalReconcileRPS > 0 {
+			r.globalReconcileRateLimiter = rate.NewLimiter(rate.Limit(r.GlobalReconcileRPS), 1)
+		}
+	})
+	return r.globalReconcileRateLimiter
+}
+
+// waitGlobalReconcileLimit blocks until the global reconcile rate allows another reconcile to proceed.
+func (r *ProfileReconciler) waitGlobalReconcileLimit(ctx context.Context) error {
+	limiter := r.globalReconcileLimiter()
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestGlobalReconcileRPS(t *testing.T) {
+	var objs []runtime.Object
+	for i := 0; i < 6; i++ {
+		objs = append(objs, newTestProfile(fmt.Sprintf("kubeflow-user%d", i), fmt.Sprintf("user%d@abcd.com", i)))
+	}
+	r := newFakeReconciler(objs...)
+	r.GlobalReconcileRPS = 20
+
+	// Concurrent reconciles of different profiles share the global rate.
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, len(objs))
+	for i := range objs {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+			errs <- err
+		}(fmt.Sprintf("kubeflow-user%d", i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	// The first reconcile proceeds immediately, the other 5 wait for a token each.
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(5*time.Second/20))
+}
+
+func TestGlobalReconcileRPSDisabled(t *testing.T) {
+	r := newFakeReconciler()
+	assert.Nil(t, r.globalReconcileLimiter())
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+)
+
+// Profile annotation overriding the GPU fair-share weight derived from the owner tier.
+const GPUFAIRSHAREWEIGHT = "profile.kubeflow.org/gpu-fair-share-weight"
+
+// GPUFairShare configures the namespace annotation the GPU scheduler reads fair-share weights from.
+type GPUFairShare struct {
+	// Annotation is the namespace annotation key holding the weight.
+	Annotation string
+	// TierLabel is the Profile label holding the owner tier.
+	TierLabel string
+	// TierWeights maps owner tiers to weights.
+	TierWeights map[string]string
+	// DefaultWeight applies to profiles of an unknown tier, no annotation is set if empty.
+	DefaultWeight string
+}
+
+// ParseGPUFairShareWeights parses a comma separated list of tier=weight pairs.
+func ParseGPUFairShareWeights(value string) (map[string]string, error) {
+	weights := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		tier := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || tier == "" {
+			return nil, fmt.Errorf("invalid GPU fair-share weight %q, expected tier=weight", entry)
+		}
+		weight := strings.TrimSpace(kv[1])
+		if err := validateGPUFairShareWeight(weight); err != nil {
+			return nil, fmt.Errorf("invalid GPU fair-share weight of tier %v: %v", tier, err)
+		}
+		weights[tier] = weight
+	}
+	return weights, nil
+}
+
+// validateGPUFairShareWeight checks weight is a positive number.
+func validateGPUFairShareWeight(weight string) error {
+	w, err := strconv.ParseFloat(weight, 64)
+	if err != nil || w <= 0 {
+		return fmt.Errorf("%q is not a positive number", weight)
+	}
+	return nil
+}
+
+// weight returns the GPU fair-share weight of the profile: the GPUFAIRSHAREWEIGHT annotation if set, else
+// the weight of the owner tier, else DefaultWeight.
+func (g *GPUFairShare) weight(profileIns *profilev1.Profile) (string, error) {
+	if weight, ok := profileIns.Annotations[GPUFAIRSHAREWEIGHT]; ok {
+		if err := validateGPUFairShareWeight(weight); err != nil {
+			return "", fmt.Errorf("invalid %v annotation: %v", GPUFAIRSHAREWEIGHT, err)
+		}
+		return weight, nil
+	}
+	if weight, ok := g.TierWeights[profileIns.Labels[g.TierLabel]]; ok {
+		return weight, nil
+	}
+	return g.DefaultWeight, nil
+}
+
+// annotations returns the namespace annotation of the GPU fair-share weight, with an empty value if the
+// profile has no weight so that a previous one is removed.
+func (g *GPUFairShare) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if g == nil || g.Annotation == "" {
+		return nil, nil
+	}
+	weight, err := g.weight(profileIns)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{g.Annotation: weight}, nil
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseGPUFairShareWeights(t *testing.T) {
+	weights, err := ParseGPUFairShareWeights("gold=4, silver=2,bronze=0.5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"gold": "4", "silver": "2", "bronze": "0.5"}, weights)
+
+	for _, value := range []string{"gold", "=4", "gold=zero", "gold=0", "gold=-1"} {
+		_, err := ParseGPUFairShareWeights(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestGPUFairShareWeight(t *testing.T) {
+	g := &GPUFairShare{
+		Annotation:    "scheduler.example.com/gpu-weight",
+		TierLabel:     "tier",
+		TierWeights:   map[string]string{"gold": "4"},
+		DefaultWeight: "1",
+	}
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	for _, tc := range []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		expected    string
+		err         bool
+	}{
+		{name: "tier", labels: map[string]string{"tier": "gold"}, expected: "4"},
+		{name: "unknown tier", labels: map[string]string{"tier": "silver"}, expected: "1"},
+		{name: "no tier", expected: "1"},
+		{name: "annotation overrides tier", labels: map[string]string{"tier": "gold"},
+			annotations: map[string]string{GPUFAIRSHAREWEIGHT: "8"}, expected: "8"},
+		{name: "invalid annotation", annotations: map[string]string{GPUFAIRSHAREWEIGHT: "many"}, err: true},
+	} {
+		profile.Labels = tc.labels
+		profile.Annotations = tc.annotations
+		weight, err := g.weight(profile)
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, weight, tc.name)
+	}
+}
+
+func TestReconcileGPUFairShare(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Labels = map[string]string{"tier": "gold"}
+	r := newFakeReconciler(profile)
+	r.GPUFairShare = &GPUFairShare{
+		Annotation:  "scheduler.example.com/gpu-weight",
+		TierLabel:   "tier",
+		TierWeights: map[string]string{"gold": "4", "silver": "2"},
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getWeight := func() (string, bool) {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		weight, ok := ns.Annotations["scheduler.example.com/gpu-weight"]
+		return weight, ok
+	}
+	updateProfile := func(update func(*profilev1.Profile)) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	weight, _ := getWeight()
+	assert.Equal(t, "4", weight)
+
+	// Drift is corrected.
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	ns.Annotations["scheduler.example.com/gpu-weight"] = "100"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	weight, _ = getWeight()
+	assert.Equal(t, "4", weight)
+
+	updateProfile(func(p *profilev1.Profile) { p.Annotations = map[string]string{GPUFAIRSHAREWEIGHT: "3"} })
+	weight, _ = getWeight()
+	assert.Equal(t, "3", weight)
+
+	// A profile without weight, there is no default one, loses the annotation.
+	updateProfile(func(p *profilev1.Profile) {
+		p.Annotations = nil
+		p.Labels = map[string]string{"tier": "bronze"}
+	})
+	_, ok := getWeight()
+	assert.False(t, ok)
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Extended resources handled as GPUs by default.
+const DEFAULTGPURESOURCES = "nvidia.com/gpu,amd.com/gpu"
+
+// GPUQuota configures the quota of GPUs, extended resources the quota system only enforces as
+// requests.<resource>.
+type GPUQuota struct {
+	// Resources are the extended resource names of GPUs, e.g. nvidia.com/gpu.
+	Resources []corev1.ResourceName
+	// Max caps the quota of each GPU resource per profile namespace, namespaces whose quota sets none are
+	// capped at Max. No cap if nil.
+	Max *resource.Quantity
+}
+
+// ParseGPUQuota parses the -gpu-resources and -max-gpu-quota values, GPU quotas are not handled if resources
+// is empty.
+func ParseGPUQuota(resources string, max string) (*GPUQuota, error) {
+	g := &GPUQuota{}
+	for _, name := range strings.Split(resources, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 || !strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid GPU resource %q, expected an extended resource name like nvidia.com/gpu",
+				name)
+		}
+		if strings.HasPrefix(name, corev1.DefaultResourceRequestsPrefix) || strings.HasPrefix(name, "limits.") {
+			return nil, fmt.Errorf("invalid GPU resource %q, expected the name without requests. or limits.", name)
+		}
+		for _, existing := range g.Resources {
+			if existing == corev1.ResourceName(name) {
+				return nil, fmt.Errorf("duplicate GPU resource %v", name)
+			}
+		}
+		g.Resources = append(g.Resources, corev1.ResourceName(name))
+	}
+	if len(g.Resources) == 0 {
+		if max != "" {
+			return nil, fmt.Errorf("a maximum GPU quota requires GPU resources")
+		}
+		return nil, nil
+	}
+	if max != "" {
+		quantity, err := resource.ParseQuantity(max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum GPU quota %q: %v", max, err)
+		}
+		if err := validateGPUQuantity(quantity); err != nil {
+			return nil, fmt.Errorf("invalid maximum GPU quota %q: %v", max, err)
+		}
+		g.Max = &quantity
+	}
+	return g, nil
+}
+
+// validateGPUQuantity returns an error if quantity is not a whole, non-negative number of GPUs.
+func validateGPUQuantity(quantity resource.Quantity) error {
+	if quantity.Sign() < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	if quantity.MilliValue()%1000 != 0 {
+		return fmt.Errorf("must be a whole number of GPUs")
+	}
+	return nil
+}
+
+// gpuResource returns the GPU resource quota name refers to and the prefix of name, false if name is not a GPU.
+func (g *GPUQuota) gpuResource(name corev1.ResourceName) (corev1.ResourceName, string, bool) {
+	for _, gpu := range g.Resources {
+		for _, prefix := range []string{"", corev1.DefaultResourceRequestsPrefix, "limits."} {
+			if string(name) == prefix+string(gpu) {
+				return gpu, prefix, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// quotaName returns the name the quota system enforces for the quota of gpu.
+func quotaName(gpu corev1.ResourceName) corev1.ResourceName {
+	return corev1.ResourceName(corev1.DefaultResourceRequestsPrefix + string(gpu))
+}
+
+// normalize returns hard with the quota of GPUs named requests.<resource>, so it is enforced. Limits of GPUs,
+// which quotas do not support, fractional or conflicting quotas and quotas above Max are rejected.
+func (g *GPUQuota) normalize(hard corev1.ResourceList) (corev1.ResourceList, error) {
+	if g == nil || len(hard) == 0 {
+		return hard, nil
+	}
+	names := make([]string, 0, len(hard))
+	for name := range hard {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	normalized := corev1.ResourceList{}
+	for _, name := range names {
+		quantity := hard[corev1.ResourceName(name)]
+		gpu, prefix, ok := g.gpuResource(corev1.ResourceName(name))
+		if !ok {
+			normalized[corev1.ResourceName(name)] = quantity
+			continue
+		}
+		if prefix == "limits." {
+			return nil, fmt.Errorf("quota %v is not supported for extended resources, use %v", name, quotaName(gpu))
+		}
+		if err := validateGPUQuantity(quantity); err != nil {
+			return nil, fmt.Errorf("invalid quota %v of %v: %v", quantity.String(), name, err)
+		}
+		if g.Max != nil && quantity.Cmp(*g.Max) > 0 {
+			return nil, fmt.Errorf("quota %v of %v exceeds the maximum of %v per profile", quantity.String(), name,
+				g.Max.String())
+		}
+		if existing, ok := normalized[quotaName(gpu)]; ok && existing.Cmp(quantity) != 0 {
+			return nil, fmt.Errorf("conflicting quotas of %v: %v and %v", gpu, existing.String(), quantity.String())
+		}
+		normalized[quotaName(gpu)] = quantity
+	}
+	return normalized, nil
+}
+
+// ValidateBaselineQuota returns an error if the GPU quotas of the -baseline-quota value are invalid.
+func (g *GPUQuota) ValidateBaselineQuota(baseline corev1.ResourceList) error {
+	_, err := g.normalize(baseline)
+	return err
+}
+
+// capped reports whether every profile namespace gets a quota of GPUs.
+func (g *GPUQuota) capped() bool {
+	return g != nil && g.Max != nil
+}
+
+// addCaps sets the quota of the GPU resources hard does not set to Max.
+func (g *GPUQuota) addCaps(hard corev1.ResourceList) {
+	if !g.capped() {
+		return
+	}
+	for _, gpu := range g.Resources {
+		if _, ok := hard[quotaName(gpu)]; !ok {
+			hard[quotaName(gpu)] = g.Max.DeepCopy()
+		}
+	}
+}
+
+// gpuQuotas returns the GPU quotas of the profile namespace from its KFQUOTA ResourceQuota, in the order of
+// GPUQuota.Resources.
+func (r *ProfileReconciler) gpuQuotas(ctx context.Context, profileIns *profilev1.Profile) ([]profilev1.GPUQuotaStatus,
+	error) {
+	quota := &corev1.ResourceQuota{}
+	err := r.Get(ctx, types.NamespacedName{Name: KFQUOTA, Namespace: profileNamespace(profileIns)}, quota)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var quotas []profilev1.GPUQuotaStatus
+	for _, gpu := range r.GPUQuota.Resources {
+		// Hard is read from the spec, the status is only set once the quota system has processed the quota.
+		hard, ok := quota.Spec.Hard[quotaName(gpu)]
+		if !ok {
+			continue
+		}
+		quotas = append(quotas, profilev1.GPUQuotaStatus{
+			Resource: string(gpu),
+			Hard:     hard,
+			Used:     quota.Status.Used[quotaName(gpu)],
+		})
+	}
+	return quotas, nil
+}
+
+// updateGPUQuotaStatus reports the GPU quotas of the profile namespace and their usage in the gpuQuotas status.
+func (r *ProfileReconciler) updateGPUQuotaStatus(ctx context.Context, profileIns *profilev1.Profile) error {
+	if r.GPUQuota == nil {
+		return nil
+	}
+	quotas, err := r.gpuQuotas(ctx, profileIns)
+	if err != nil {
+		return err
+	}
+	if gpuQuotasEqual(quotas, profileIns.Status.GPUQuotas) {
+		return nil
+	}
+	profileIns.Status.GPUQuotas = quotas
+	return r.Status().Update(ctx, profileIns)
+}
+
+// gpuQuotasEqual compares the quantities of the quotas by value, their serialized form may differ.
+func gpuQuotasEqual(a, b []profilev1.GPUQuotaStatus) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Resource != b[i].Resource || a[i].Hard.Cmp(b[i].Hard) != 0 || a[i].Used.Cmp(b[i].Used) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseGPUQuota(t *testing.T) {
+	g, err := ParseGPUQuota(DEFAULTGPURESOURCES, "8")
+	require.NoError(t, err)
+	assert.Equal(t, []corev1.ResourceName{"nvidia.com/gpu", "amd.com/gpu"}, g.Resources)
+	assert.Equal(t, resource.MustParse("8"), *g.Max)
+
+	g, err = ParseGPUQuota("", "")
+	require.NoError(t, err)
+	assert.Nil(t, g)
+
+	for _, tc := range [][2]string{{"gpu", ""}, {"requests.nvidia.com/gpu", ""}, {"nvidia.com/gpu,nvidia.com/gpu", ""},
+		{"nvidia.com/gpu", "1.5"}, {"nvidia.com/gpu", "-1"}, {"", "8"}} {
+		_, err = ParseGPUQuota(tc[0], tc[1])
+		assert.Error(t, err, tc)
+	}
+}
+
+func TestGPUResourceQuotaSpec(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler()
+	r.GPUQuota, _ = ParseGPUQuota(DEFAULTGPURESOURCES, "")
+	_, hasQuota, err := r.resourceQuotaSpec(profile)
+	require.NoError(t, err)
+	assert.False(t, hasQuota)
+
+	// GPU quotas are enforced as requests.
+	profile.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{
+		"nvidia.com/gpu":                   resource.MustParse("2"),
+		"requests.nvidia.com/gpu":          resource.MustParse("2"),
+		corev1.ResourceRequestsCPU:         resource.MustParse("8"),
+		"requests.example.com/accelerator": resource.MustParse("1"),
+	}
+	spec, hasQuota, err := r.resourceQuotaSpec(profile)
+	require.NoError(t, err)
+	assert.True(t, hasQuota)
+	assert.Equal(t, corev1.ResourceList{
+		"requests.nvidia.com/gpu":          resource.MustParse("2"),
+		corev1.ResourceRequestsCPU:         resource.MustParse("8"),
+		"requests.example.com/accelerator": resource.MustParse("1"),
+	}, spec.Hard)
+
+	// Profiles without a GPU quota are capped.
+	r.GPUQuota.Max = resource.NewQuantity(4, resource.DecimalSI)
+	spec, _, err = r.resourceQuotaSpec(profile)
+	require.NoError(t, err)
+	assert.Equal(t, resource.MustParse("2"), spec.Hard["requests.nvidia.com/gpu"])
+	amd := spec.Hard["requests.amd.com/gpu"]
+	assert.Equal(t, int64(4), amd.Value())
+
+	for _, hard := range []corev1.ResourceList{
+		{"limits.nvidia.com/gpu": resource.MustParse("2")},
+		{"nvidia.com/gpu": resource.MustParse("500m")},
+		{"nvidia.com/gpu": resource.MustParse("8")},
+		{"nvidia.com/gpu": resource.MustParse("1"), "requests.nvidia.com/gpu": resource.MustParse("2")},
+	} {
+		profile.Spec.ResourceQuotaSpec.Hard = hard
+		_, _, err = r.resourceQuotaSpec(profile)
+		assert.Error(t, err, hard)
+	}
+}
+
+func TestProfileValidatorGPUQuota(t *testing.T) {
+	v := newProfileValidator(t)
+	v.r.GPUQuota, _ = ParseGPUQuota(DEFAULTGPURESOURCES, "4")
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{"amd.com/gpu": resource.MustParse("4")}
+	resp := v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create, profile, nil))
+	assert.True(t, resp.Allowed)
+
+	profile.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{"amd.com/gpu": resource.MustParse("6")}
+	resp = v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create, profile, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Reason, "quota 6 of amd.com/gpu exceeds the maximum of 4 per profile")
+}
+
+func TestReconcileGPUQuotaStatus(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")}
+	r := newFakeReconciler(profile)
+	r.GPUQuota, _ = ParseGPUQuota("nvidia.com/gpu", "")
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	quota := &corev1.ResourceQuota{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: KFQUOTA, Namespace: profile.Name}, quota))
+	assert.Equal(t, corev1.ResourceList{"requests.nvidia.com/gpu": resource.MustParse("2")}, quota.Spec.Hard)
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	require.Len(t, found.Status.GPUQuotas, 1)
+	assert.Equal(t, "nvidia.com/gpu", found.Status.GPUQuotas[0].Resource)
+	assert.Equal(t, int64(2), found.Status.GPUQuotas[0].Hard.Value())
+	assert.True(t, found.Status.GPUQuotas[0].Used.IsZero())
+
+	// The usage computed by the quota system is reported.
+	quota.Status.Used = corev1.ResourceList{"requests.nvidia.com/gpu": resource.MustParse("1")}
+	require.NoError(t, r.Status().Update(context.TODO(), quota))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	found = &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	require.Len(t, found.Status.GPUQuotas, 1)
+	assert.Equal(t, int64(1), found.Status.GPUQuotas[0].Used.Value())
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Profile annotation naming the GPU reservation of the profile namespace.
+const GPURESERVATION = "profile.kubeflow.org/gpu-reservation"
+
+// GPUReservation configures the namespace annotations the GPU reservation system keys reservations by.
+type GPUReservation struct {
+	// Annotations are rendered with GPUReservationTemplateData for profiles with a GPURESERVATION annotation,
+	// e.g. `gpu.example.com/reservation={{ .Reservation }}`. They are removed from the namespace of other profiles.
+	Annotations AnnotationTemplates
+}
+
+// GPUReservationTemplateData is the data exposed to GPU reservation annotation templates.
+type GPUReservationTemplateData struct {
+	ProfileTemplateData
+	// Reservation is the GPURESERVATION annotation of the profile.
+	Reservation string
+}
+
+// annotations returns the GPU reservation namespace annotations of the profile, with empty values if the profile
+// has no reservation so that previous ones are removed.
+func (g *GPUReservation) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if g == nil || len(g.Annotations) == 0 {
+		return nil, nil
+	}
+	reservation, ok := profileIns.Annotations[GPURESERVATION]
+	if !ok {
+		annotations := make(map[string]string, len(g.Annotations))
+		for key := range g.Annotations {
+			annotations[key] = ""
+		}
+		return annotations, nil
+	}
+	if reservation == "" {
+		return nil, fmt.Errorf("invalid %v annotation: must not be empty", GPURESERVATION)
+	}
+	if errs := validation.IsValidLabelValue(reservation); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid %v annotation %q: %v", GPURESERVATION, reservation, strings.Join(errs, ", "))
+	}
+	return g.Annotations.render(GPUReservationTemplateData{
+		ProfileTemplateData: newProfileTemplateData(profileIns),
+		Reservation:         reservation,
+	})
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestGPUReservationAnnotations(t *testing.T) {
+	templates, err := ParseAnnotationTemplates("gpu.example.com/reservation={{ .Reservation }}," +
+		"gpu.example.com/reserved-by={{ .Owner }}")
+	require.NoError(t, err)
+	g := &GPUReservation{Annotations: templates}
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+		err         bool
+	}{
+		{name: "reservation", annotations: map[string]string{GPURESERVATION: "a100-team-ml"},
+			expected: map[string]string{
+				"gpu.example.com/reservation": "a100-team-ml",
+				"gpu.example.com/reserved-by": "user1@abcd.com",
+			}},
+		{name: "no reservation", expected: map[string]string{
+			"gpu.example.com/reservation": "",
+			"gpu.example.com/reserved-by": "",
+		}},
+		{name: "empty reservation", annotations: map[string]string{GPURESERVATION: ""}, err: true},
+		{name: "invalid reservation", annotations: map[string]string{GPURESERVATION: "a100 team"}, err: true},
+	} {
+		profile.Annotations = tc.annotations
+		annotations, err := g.annotations(profile)
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, annotations, tc.name)
+	}
+
+	var disabled *GPUReservation
+	annotations, err := disabled.annotations(profile)
+	require.NoError(t, err)
+	assert.Nil(t, annotations)
+}
+
+func TestReconcileGPUReservation(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Annotations = map[string]string{GPURESERVATION: "a100-team-ml"}
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("gpu.example.com/reservation={{ .Reservation }}")
+	require.NoError(t, err)
+	r.GPUReservation = &GPUReservation{Annotations: templates}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getReservation := func() (string, bool) {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		reservation, ok := ns.Annotations["gpu.example.com/reservation"]
+		return reservation, ok
+	}
+	updateProfile := func(update func(*profilev1.Profile)) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	reservation, ok := getReservation()
+	assert.True(t, ok)
+	assert.Equal(t, "a100-team-ml", reservation)
+
+	updateProfile(func(p *profilev1.Profile) { p.Annotations[GPURESERVATION] = "h100-team-ml" })
+	reservation, _ = getReservation()
+	assert.Equal(t, "h100-team-ml", reservation)
+
+	// Dropping the reservation removes the namespace annotation.
+	updateProfile(func(p *profilev1.Profile) { delete(p.Annotations, GPURESERVATION) })
+	_, ok = getReservation()
+	assert.False(t, ok)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ServiceAccount annotation listing the imagePullSecrets set by the controller, so secrets dropped from
+// -default-image-pull-secrets are removed while the ones added by users are kept.
+const MANAGEDIMAGEPULLSECRETS = "profile.kubeflow.org/managed-image-pull-secrets"
+
+// ImagePullSecretTemplates are the names of the imagePullSecrets of the default ServiceAccounts, as templates
+// rendered with ProfileTemplateData, e.g. `registry-{{ .Labels.team }}`.
+type ImagePullSecretTemplates []*template.Template
+
+// ParseImagePullSecrets parses the comma separated -default-image-pull-secrets value. Commas inside template
+// actions ({{ }}) do not split entries.
+func ParseImagePullSecrets(value string) (ImagePullSecretTemplates, error) {
+	var templates ImagePullSecretTemplates
+	for _, entry := range splitTemplateList(value) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tmpl, err := template.New(entry).Option("missingkey=zero").Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid imagePullSecret template %q: %v", entry, err)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+// Render returns the sorted imagePullSecret names of the profile. Templates rendering to an empty string are
+// skipped.
+func (t ImagePullSecretTemplates) Render(profileIns *profilev1.Profile) ([]string, error) {
+	data := newProfileTemplateData(profileIns)
+	seen := map[string]bool{}
+	var names []string
+	for _, tmpl := range t {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("error rendering imagePullSecret %v: %v", tmpl.Name(), err)
+		}
+		name := strings.TrimSpace(buf.String())
+		if name == "" || seen[name] {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid imagePullSecret %q rendered from %v: %v", name, tmpl.Name(),
+				strings.Join(errs, ", "))
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// updateManagedImagePullSecrets sets the desired imagePullSecrets on sa and removes the ones previously set by
+// the controller but no longer desired. imagePullSecrets added by users are kept. It returns whether sa changed.
+func updateManagedImagePullSecrets(sa *corev1.ServiceAccount, desired []string) bool {
+	isDesired := map[string]bool{}
+	for _, name := range desired {
+		isDesired[name] = true
+	}
+	wasManaged := map[string]bool{}
+	for _, name := range strings.Split(sa.Annotations[MANAGEDIMAGEPULLSECRETS], ",") {
+		wasManaged[name] = true
+	}
+	updated := false
+	present := map[string]bool{}
+	secrets := make([]corev1.LocalObjectReference, 0, len(sa.ImagePullSecrets)+len(desired))
+	for _, secret := range sa.ImagePullSecrets {
+		if wasManaged[secret.Name] && !isDesired[secret.Name] {
+			updated = true
+			continue
+		}
+		present[secret.Name] = true
+		secrets = append(secrets, secret)
+	}
+	for _, name := range desired {
+		if !present[name] {
+			secrets = append(secrets, corev1.LocalObjectReference{Name: name})
+			updated = true
+		}
+	}
+	if updated {
+		sa.ImagePullSecrets = secrets
+	}
+	marker := strings.Join(desired, ",")
+	if current, ok := sa.Annotations[MANAGEDIMAGEPULLSECRETS]; marker == "" {
+		if ok {
+			delete(sa.Annotations, MANAGEDIMAGEPULLSECRETS)
+			updated = true
+		}
+	} else if current != marker {
+		if sa.Annotations == nil {
+			sa.Annotations = map[string]string{}
+		}
+		sa.Annotations[MANAGEDIMAGEPULLSECRETS] = marker
+		updated = true
+	}
+	return updated
+}
+
+// updateServiceAccountImagePullSecrets sets the DefaultImagePullSecrets of the profile on ServiceAccount saName
+// of the profile namespace.
+func (r *ProfileReconciler) updateServiceAccountImagePullSecrets(ctx context.Context, profileIns *profilev1.Profile,
+	saName string) error {
+	desired, err := r.DefaultImagePullSecrets.Render(profileIns)
+	if err != nil {
+		return err
+	}
+	found := &corev1.ServiceAccount{}
+	if err := r.Get(ctx, types.NamespacedName{Name: saName, Namespace: profileNamespace(profileIns)}, found); err != nil {
+		return err
+	}
+	if !updateManagedImagePullSecrets(found, desired) {
+		return nil
+	}
+	r.Log.Info("Updating ServiceAccount imagePullSecrets", "namespace", profileIns.Name, "name", saName,
+		"imagePullSecrets", desired)
+	return r.Update(ctx, found)
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseImagePullSecrets(t *testing.T) {
+	templates, err := ParseImagePullSecrets(`registry-credentials, registry-{{ index .Labels "team" }},` +
+		`{{ .Labels.missing }}`)
+	require.NoError(t, err)
+	require.Len(t, templates, 3)
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Labels = map[string]string{"team": "ml"}
+	names, err := templates.Render(profile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry-credentials", "registry-ml"}, names)
+
+	_, err = ParseImagePullSecrets("registry-{{ .Labels.team ")
+	assert.Error(t, err)
+	templates, err = ParseImagePullSecrets("Registry_Credentials")
+	require.NoError(t, err)
+	_, err = templates.Render(profile)
+	assert.Error(t, err)
+}
+
+func TestReconcileImagePullSecrets(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	templates, err := ParseImagePullSecrets("registry-credentials,registry-{{ .Name }}")
+	require.NoError(t, err)
+	r.DefaultImagePullSecrets = templates
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getServiceAccount := func(name string) *corev1.ServiceAccount {
+		sa := &corev1.ServiceAccount{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: profile.Name}, sa))
+		return sa
+	}
+	secretNames := func(sa *corev1.ServiceAccount) []string {
+		var names []string
+		for _, secret := range sa.ImagePullSecrets {
+			names = append(names, secret.Name)
+		}
+		return names
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	editor := getServiceAccount(DEFAULT_EDITOR)
+	assert.Equal(t, []string{"registry-credentials", "registry-kubeflow-user1"}, secretNames(editor))
+	assert.Equal(t, []string{"registry-credentials", "registry-kubeflow-user1"},
+		secretNames(getServiceAccount(DEFAULT_VIEWER)))
+
+	// A removed secret is reasserted, a secret added by the user is kept.
+	editor.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry-credentials"}, {Name: "users-own"}}
+	require.NoError(t, r.Update(context.TODO(), editor))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry-credentials", "users-own", "registry-kubeflow-user1"},
+		secretNames(getServiceAccount(DEFAULT_EDITOR)))
+
+	// Secrets dropped from the configuration are removed, except the user's.
+	templates, err = ParseImagePullSecrets("registry-credentials")
+	require.NoError(t, err)
+	r.DefaultImagePullSecrets = templates
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	editor = getServiceAccount(DEFAULT_EDITOR)
+	assert.Equal(t, []string{"registry-credentials", "users-own"}, secretNames(editor))
+	assert.Equal(t, "registry-credentials", editor.Annotations[MANAGEDIMAGEPULLSECRETS])
+
+	r.DefaultImagePullSecrets = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	editor = getServiceAccount(DEFAULT_EDITOR)
+	assert.Equal(t, []string{"users-own"}, secretNames(editor))
+	assert.NotContains(t, editor.Annotations, MANAGEDIMAGEPULLSECRETS)
+}
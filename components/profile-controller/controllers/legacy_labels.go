@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"unicode"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Ownership label of resources created by older profile controllers, which set no controller reference and
+// named resources in kebab case, e.g. RoleBinding "namespace-admin" instead of "namespaceAdmin".
+const (
+	LEGACYMANAGEDBYLABEL = "app.kubernetes.io/managed-by"
+	LEGACYMANAGEDBYVALUE = "profile-controller"
+)
+
+// legacyName returns the name an older profile controller gave to resource "name".
+func legacyName(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		if unicode.IsUpper(c) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// isLegacyManaged tells if obj bears the legacy ownership label and is not controlled by anything.
+func isLegacyManaged(obj metav1.Object) bool {
+	return obj.GetLabels()[LEGACYMANAGEDBYLABEL] == LEGACYMANAGEDBYVALUE && metav1.GetControllerOf(obj) == nil
+}
+
+// dropLegacyLabel removes the legacy ownership label from obj, returns true if obj changed.
+func dropLegacyLabel(obj metav1.Object) bool {
+	labels := obj.GetLabels()
+	if labels[LEGACYMANAGEDBYLABEL] != LEGACYMANAGEDBYVALUE {
+		return false
+	}
+	delete(labels, LEGACYMANAGEDBYLABEL)
+	obj.SetLabels(labels)
+	return true
+}
+
+// deleteLegacyRoleBinding deletes the RoleBinding an older profile controller created for the same purpose as
+// roleBinding under its legacy name, after roleBinding was created. Only a legacy labeled RoleBinding with the
+// same role and subjects is deleted, so the migration never revokes access it does not replace.
+func (r *ProfileReconciler) deleteLegacyRoleBinding(ctx context.Context, profileIns *profilev1.Profile,
+	roleBinding *rbacv1.RoleBinding) error {
+	name := legacyName(roleBinding.Name)
+	if name == roleBinding.Name {
+		return nil
+	}
+	found := &rbacv1.RoleBinding{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: roleBinding.Namespace}, found); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !isLegacyManaged(found) || !reflect.DeepEqual(roleBinding.RoleRef, found.RoleRef) ||
+		!reflect.DeepEqual(roleBinding.Subjects, found.Subjects) {
+		return nil
+	}
+	r.Log.Info("Deleting legacy RoleBinding", "profile", profileIns.Name, "namespace", found.Namespace,
+		"name", found.Name, "replacement", roleBinding.Name)
+	if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
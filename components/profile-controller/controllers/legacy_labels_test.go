@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestLegacyName(t *testing.T) {
+	assert.Equal(t, "namespace-admin", legacyName("namespaceAdmin"))
+	assert.Equal(t, "default-editor", legacyName("default-editor"))
+}
+
+func TestReconcileAdoptLegacyLabels(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	legacyBinding := func(name string, role string, subject rbacv1.Subject) *rbacv1.RoleBinding {
+		return &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: profile.Name,
+				Labels:    map[string]string{LEGACYMANAGEDBYLABEL: LEGACYMANAGEDBYVALUE},
+			},
+			RoleRef:  rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: role},
+			Subjects: []rbacv1.Subject{subject},
+		}
+	}
+	r := newFakeReconciler(profile,
+		legacyBinding("namespace-admin", kubeflowAdmin, profile.Spec.Owner),
+		legacyBinding(DEFAULT_EDITOR, kubeflowEdit, rbacv1.Subject{
+			Kind: "ServiceAccount", Name: DEFAULT_EDITOR, Namespace: profile.Name}),
+	)
+	r.AdoptLegacyLabels = true
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	list := &rbacv1.RoleBindingList{}
+	require.NoError(t, r.List(context.TODO(), list, client.InNamespace(profile.Name)))
+	admins := 0
+	for _, rb := range list.Items {
+		if rb.RoleRef.Name == kubeflowAdmin {
+			admins++
+			assert.Equal(t, "namespaceAdmin", rb.Name)
+		}
+	}
+	assert.Equal(t, 1, admins, "legacy owner binding must be replaced, not duplicated")
+	err = r.Get(context.TODO(), types.NamespacedName{Name: "namespace-admin", Namespace: profile.Name},
+		&rbacv1.RoleBinding{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// A legacy binding with the current name is relabeled and adopted in place.
+	editor := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name},
+		editor))
+	assert.NotContains(t, editor.Labels, LEGACYMANAGEDBYLABEL)
+	assert.True(t, metav1.IsControlledBy(editor, profile))
+}
+
+func TestReconcileAdoptLegacyLabelsKeepsMismatch(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	// Legacy label and name match, but the subject is not the owner: not the same purpose.
+	other := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "namespace-admin",
+			Namespace: profile.Name,
+			Labels:    map[string]string{LEGACYMANAGEDBYLABEL: LEGACYMANAGEDBYVALUE},
+		},
+		RoleRef:  rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: kubeflowAdmin},
+		Subjects: []rbacv1.Subject{{Kind: "User", Name: "user2@abcd.com"}},
+	}
+	r := newFakeReconciler(profile, other)
+	r.AdoptLegacyLabels = true
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "namespace-admin", Namespace: profile.Name},
+		&rbacv1.RoleBinding{}))
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Name of the LimitRange applying the profile limitRangeSpec.
+const KFLIMITRANGE = "kf-limit-range"
+
+// ParsePVCStorageLimit parses the -pvc-storage-min and -pvc-storage-max values into the PersistentVolumeClaim
+// limit of profile LimitRanges, nil if both are empty.
+func ParsePVCStorageLimit(min string, max string) (*corev1.LimitRangeItem, error) {
+	if min == "" && max == "" {
+		return nil, nil
+	}
+	item := &corev1.LimitRangeItem{Type: corev1.LimitTypePersistentVolumeClaim}
+	for _, bound := range []struct {
+		name  string
+		value string
+		list  *corev1.ResourceList
+	}{{"minimum", min, &item.Min}, {"maximum", max, &item.Max}} {
+		if bound.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(bound.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v PVC storage %q: %v", bound.name, bound.value, err)
+		}
+		*bound.list = corev1.ResourceList{corev1.ResourceStorage: quantity}
+	}
+	if err := validatePVCLimit(*item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// validatePVCLimit checks the storage bounds of a PersistentVolumeClaim limit are positive and min <= max.
+func validatePVCLimit(item corev1.LimitRangeItem) error {
+	min, hasMin := item.Min[corev1.ResourceStorage]
+	max, hasMax := item.Max[corev1.ResourceStorage]
+	if hasMin && min.Sign() <= 0 {
+		return fmt.Errorf("minimum PVC storage %v must be positive", min.String())
+	}
+	if hasMax && max.Sign() <= 0 {
+		return fmt.Errorf("maximum PVC storage %v must be positive", max.String())
+	}
+	if hasMin && hasMax && min.Cmp(max) > 0 {
+		return fmt.Errorf("minimum PVC storage %v exceeds the maximum %v", min.String(), max.String())
+	}
+	return nil
+}
+
+// ParseContainerDefaults parses the -container-default-request and -container-default-limit values, comma
+// separated <resource>=<quantity>, into the Container limit of profile LimitRanges. nil if both are empty.
+func ParseContainerDefaults(requests string, limits string) (*corev1.LimitRangeItem, error) {
+	item := &corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}
+	for _, defaults := range []struct {
+		name  string
+		value string
+		list  *corev1.ResourceList
+	}{{"request", requests, &item.DefaultRequest}, {"limit", limits, &item.Default}} {
+		for _, entry := range strings.Split(defaults.value, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			kv := strings.SplitN(entry, "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return nil, fmt.Errorf("invalid container default %v %q, expected <resource>=<quantity>",
+					defaults.name, entry)
+			}
+			quantity, err := resource.ParseQuantity(strings.TrimSpace(kv[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid container default %v %q: %v", defaults.name, entry, err)
+			}
+			if *defaults.list == nil {
+				*defaults.list = corev1.ResourceList{}
+			}
+			name := corev1.ResourceName(strings.TrimSpace(kv[0]))
+			if _, ok := (*defaults.list)[name]; ok {
+				return nil, fmt.Errorf("duplicate container default %v %v", defaults.name, name)
+			}
+			(*defaults.list)[name] = quantity
+		}
+	}
+	if item.DefaultRequest == nil && item.Default == nil {
+		return nil, nil
+	}
+	if err := validateContainerDefaults(*item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// validateContainerDefaults checks the default requests of a Container limit are positive and do not exceed the
+// default limits, which the API server enforces on the LimitRange.
+func validateContainerDefaults(item corev1.LimitRangeItem) error {
+	for _, list := range []corev1.ResourceList{item.DefaultRequest, item.Default} {
+		for name, quantity := range list {
+			if quantity.Sign() <= 0 {
+				return fmt.Errorf("container default %v %v must be positive", name, quantity.String())
+			}
+		}
+	}
+	for name, request := range item.DefaultRequest {
+		if limit, ok := item.Default[name]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("container default request %v %v exceeds the default limit %v", name,
+				request.String(), limit.String())
+		}
+	}
+	return nil
+}
+
+// limitRangeSpec returns the LimitRangeSpec to apply to the profile namespace: the limitRangeSpec of the profile,
+// completed with the PVCStorageLimit if it has no PersistentVolumeClaim limit and with the ContainerDefaults if
+// it has no Container limit. nil if no limit applies.
+func (r *ProfileReconciler) limitRangeSpec(profileIns *profilev1.Profile) (*corev1.LimitRangeSpec, error) {
+	spec := profileIns.Spec.LimitRangeSpec
+	hasPVCLimit, hasContainerLimit := false, false
+	if spec != nil {
+		for _, item := range spec.Limits {
+			switch item.Type {
+			case corev1.LimitTypePersistentVolumeClaim:
+				if err := validatePVCLimit(item); err != nil {
+					return nil, fmt.Errorf("invalid limitRangeSpec: %v", err)
+				}
+				hasPVCLimit = true
+			case corev1.LimitTypeContainer:
+				if err := validateContainerDefaults(item); err != nil {
+					return nil, fmt.Errorf("invalid limitRangeSpec: %v", err)
+				}
+				hasContainerLimit = true
+			}
+		}
+	}
+	var defaults []corev1.LimitRangeItem
+	if !hasContainerLimit && r.ContainerDefaults != nil {
+		defaults = append(defaults, *r.ContainerDefaults.DeepCopy())
+	}
+	if !hasPVCLimit && r.PVCStorageLimit != nil {
+		defaults = append(defaults, *r.PVCStorageLimit.DeepCopy())
+	}
+	if len(defaults) == 0 {
+		return spec, nil
+	}
+	merged := &corev1.LimitRangeSpec{}
+	if spec != nil {
+		spec.DeepCopyInto(merged)
+	}
+	merged.Limits = append(merged.Limits, defaults...)
+	return merged, nil
+}
+
+// updateLimitRange creates or updates the LimitRange of spec in target namespace, or deletes it if spec is nil.
+func (r *ProfileReconciler) updateLimitRange(ctx context.Context, profileIns *profilev1.Profile,
+	spec *corev1.LimitRangeSpec) error {
+	logger := r.Log.WithValues("profile", profileIns.Name)
+	found := &corev1.LimitRange{}
+	err := r.Get(ctx, types.NamespacedName{Name: KFLIMITRANGE, Namespace: profileNamespace(profileIns)}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if spec == nil {
+		if !exists || !metav1.IsControlledBy(found, profileIns) {
+			return nil
+		}
+		logger.Info("Deleting LimitRange", "namespace", profileIns.Name, "name", KFLIMITRANGE)
+		if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      KFLIMITRANGE,
+			Namespace: profileNamespace(profileIns),
+		},
+		Spec: *spec,
+	}
+	if err := controllerutil.SetControllerReference(profileIns, limitRange, r.Scheme); err != nil {
+		return err
+	}
+	if !exists {
+		logger.Info("Creating LimitRange", "namespace", limitRange.Namespace, "name", limitRange.Name)
+		return r.Create(ctx, limitRange)
+	}
+	refUpdated, err := r.setMissingControllerReference(profileIns, found)
+	if err != nil {
+		return err
+	}
+	if refUpdated || !reflect.DeepEqual(limitRange.Spec, found.Spec) {
+		found.Spec = limitRange.Spec
+		logger.Info("Updating LimitRange", "namespace", limitRange.Namespace, "name", limitRange.Name)
+		return r.Update(ctx, found)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileLimitRange(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.LimitRangeSpec = &corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+		Type:    corev1.LimitTypeContainer,
+		Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		Max:     corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+	}}}
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: KFLIMITRANGE, Namespace: profile.Name}
+	getLimitRange := func() *corev1.LimitRange {
+		limitRange := &corev1.LimitRange{}
+		require.NoError(t, r.Get(context.TODO(), key, limitRange))
+		return limitRange
+	}
+	updateProfile := func(update func(*profilev1.Profile)) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	limitRange := getLimitRange()
+	require.Len(t, limitRange.Spec.Limits, 1)
+	assert.Equal(t, "1Gi", limitRange.Spec.Limits[0].Default.Memory().String())
+	owner := metav1.GetControllerOf(limitRange)
+	require.NotNil(t, owner)
+	assert.Equal(t, profile.Name, owner.Name)
+
+	// Drift is reasserted.
+	limitRange.Spec.Limits[0].Max = nil
+	require.NoError(t, r.Update(context.TODO(), limitRange))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "8Gi", getLimitRange().Spec.Limits[0].Max.Memory().String())
+
+	updateProfile(func(p *profilev1.Profile) {
+		p.Spec.LimitRangeSpec.Limits[0].Default[corev1.ResourceMemory] = resource.MustParse("2Gi")
+	})
+	assert.Equal(t, "2Gi", getLimitRange().Spec.Limits[0].Default.Memory().String())
+
+	// Clearing the field removes the LimitRange.
+	updateProfile(func(p *profilev1.Profile) { p.Spec.LimitRangeSpec = nil })
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &corev1.LimitRange{})))
+}
+
+func TestParsePVCStorageLimit(t *testing.T) {
+	limit, err := ParsePVCStorageLimit("1Gi", "100Gi")
+	require.NoError(t, err)
+	assert.Equal(t, corev1.LimitTypePersistentVolumeClaim, limit.Type)
+	assert.Equal(t, "1Gi", limit.Min.Storage().String())
+	assert.Equal(t, "100Gi", limit.Max.Storage().String())
+
+	limit, err = ParsePVCStorageLimit("", "10Gi")
+	require.NoError(t, err)
+	assert.Empty(t, limit.Min)
+
+	limit, err = ParsePVCStorageLimit("", "")
+	require.NoError(t, err)
+	assert.Nil(t, limit)
+
+	for _, bounds := range [][2]string{{"lots", ""}, {"", "-1Gi"}, {"0", ""}, {"10Gi", "1Gi"}} {
+		_, err := ParsePVCStorageLimit(bounds[0], bounds[1])
+		assert.Error(t, err, bounds)
+	}
+}
+
+func TestReconcilePVCLimitRange(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.PVCStorageLimit = &corev1.LimitRangeItem{
+		Type: corev1.LimitTypePersistentVolumeClaim,
+		Max:  corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("50Gi")},
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: KFLIMITRANGE, Namespace: profile.Name}
+	reconcileLimits := func(update func(*profilev1.Profile)) []corev1.LimitRangeItem {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+		limitRange := &corev1.LimitRange{}
+		require.NoError(t, r.Get(context.TODO(), key, limitRange))
+		return limitRange.Spec.Limits
+	}
+
+	// The baseline alone creates the LimitRange.
+	limits := reconcileLimits(func(*profilev1.Profile) {})
+	require.Len(t, limits, 1)
+	assert.Equal(t, corev1.LimitTypePersistentVolumeClaim, limits[0].Type)
+	assert.Equal(t, "50Gi", limits[0].Max.Storage().String())
+
+	// It completes a profile limitRangeSpec without PersistentVolumeClaim limit.
+	limits = reconcileLimits(func(p *profilev1.Profile) {
+		p.Spec.LimitRangeSpec = &corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			Max:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+		}}}
+	})
+	require.Len(t, limits, 2)
+	assert.Equal(t, corev1.LimitTypeContainer, limits[0].Type)
+	assert.Equal(t, "50Gi", limits[1].Max.Storage().String())
+
+	// The profile PersistentVolumeClaim limit takes precedence.
+	limits = reconcileLimits(func(p *profilev1.Profile) {
+		p.Spec.LimitRangeSpec.Limits = []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypePersistentVolumeClaim,
+			Min:  corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			Max:  corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("200Gi")},
+		}}
+	})
+	require.Len(t, limits, 1)
+	assert.Equal(t, "200Gi", limits[0].Max.Storage().String())
+
+	// An inverted profile limit is rejected and the LimitRange left unchanged.
+	limits = reconcileLimits(func(p *profilev1.Profile) {
+		p.Spec.LimitRangeSpec.Limits[0].Min[corev1.ResourceStorage] = resource.MustParse("500Gi")
+	})
+	assert.Equal(t, "1Gi", limits[0].Min.Storage().String())
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	require.NotEmpty(t, found.Status.Conditions)
+	assert.Equal(t, profilev1.ProfileFailed, found.Status.Conditions[len(found.Status.Conditions)-1].Type)
+}
+
+func TestParseContainerDefaults(t *testing.T) {
+	defaults, err := ParseContainerDefaults("cpu=100m, memory=256Mi", "cpu=1,memory=1Gi")
+	require.NoError(t, err)
+	assert.Equal(t, corev1.LimitTypeContainer, defaults.Type)
+	assert.Equal(t, "100m", defaults.DefaultRequest.Cpu().String())
+	assert.Equal(t, "256Mi", defaults.DefaultRequest.Memory().String())
+	assert.Equal(t, "1", defaults.Default.Cpu().String())
+	assert.Equal(t, "1Gi", defaults.Default.Memory().String())
+
+	defaults, err = ParseContainerDefaults("cpu=100m", "")
+	require.NoError(t, err)
+	assert.Nil(t, defaults.Default)
+
+	defaults, err = ParseContainerDefaults("", "")
+	require.NoError(t, err)
+	assert.Nil(t, defaults)
+
+	for _, values := range [][2]string{{"cpu", ""}, {"cpu=lots", ""}, {"", "memory=0"}, {"cpu=2", "cpu=1"},
+		{"cpu=1,cpu=2", ""}} {
+		_, err := ParseContainerDefaults(values[0], values[1])
+		assert.Error(t, err, values)
+	}
+}
+
+func TestReconcileContainerDefaultsLimitRange(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	defaults, err := ParseContainerDefaults("cpu=100m,memory=256Mi", "memory=1Gi")
+	require.NoError(t, err)
+	r.ContainerDefaults = defaults
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: KFLIMITRANGE, Namespace: profile.Name}
+	reconcileLimits := func(update func(*profilev1.Profile)) []corev1.LimitRangeItem {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+		limitRange := &corev1.LimitRange{}
+		require.NoError(t, r.Get(context.TODO(), key, limitRange))
+		return limitRange.Spec.Limits
+	}
+
+	// The defaults alone create the LimitRange.
+	limits := reconcileLimits(func(*profilev1.Profile) {})
+	require.Len(t, limits, 1)
+	assert.Equal(t, *defaults, limits[0])
+
+	// The profile Container limit takes precedence.
+	limits = reconcileLimits(func(p *profilev1.Profile) {
+		p.Spec.LimitRangeSpec = &corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type:    corev1.LimitTypeContainer,
+			Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+		}}}
+	})
+	require.Len(t, limits, 1)
+	assert.Equal(t, "4Gi", limits[0].Default.Memory().String())
+	assert.Empty(t, limits[0].DefaultRequest)
+
+	// A profile Container limit with a request over its limit is rejected and the LimitRange left unchanged.
+	limits = reconcileLimits(func(p *profilev1.Profile) {
+		p.Spec.LimitRangeSpec.Limits[0].DefaultRequest = corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+		}
+	})
+	require.Len(t, limits, 1)
+	assert.Empty(t, limits[0].DefaultRequest)
+}
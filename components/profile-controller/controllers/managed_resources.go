@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// managedResourceKinds are the kinds of resources listed in the managedResources status of profiles: the kinds
+// cleaned up on profile deletion and the quota summary ConfigMap.
+var managedResourceKinds = func() []schema.GroupVersionKind {
+	kinds := []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}}
+	for _, gvk := range cleanupKinds {
+		kinds = append(kinds, gvk)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Kind < kinds[j].Kind })
+	return kinds
+}()
+
+// managedResources returns the resources controlled by the profile, sorted by kind, namespace and name.
+func (r *ProfileReconciler) managedResources(ctx context.Context,
+	profileIns *profilev1.Profile) ([]profilev1.ManagedResource, error) {
+	var resources []profilev1.ManagedResource
+	for _, gvk := range managedResourceKinds {
+		items, err := r.listOwnedResources(ctx, profileIns, gvk)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			resources = append(resources, profilev1.ManagedResource{
+				Kind:      gvk.Kind,
+				Name:      items[i].GetName(),
+				Namespace: items[i].GetNamespace(),
+			})
+		}
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Kind != resources[j].Kind {
+			return resources[i].Kind < resources[j].Kind
+		}
+		if resources[i].Namespace != resources[j].Namespace {
+			return resources[i].Namespace < resources[j].Namespace
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	return resources, nil
+}
+
+// updateManagedResources records the resources controlled by the profile in its managedResources status, so
+// created and pruned resources are reflected once reconciled.
+func (r *ProfileReconciler) updateManagedResources(ctx context.Context, profileIns *profilev1.Profile) error {
+	resources, err := r.managedResources(ctx, profileIns)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(resources, profileIns.Status.ManagedResources) {
+		return nil
+	}
+	profileIns.Status.ManagedResources = resources
+	return r.Status().Update(ctx, profileIns)
+}
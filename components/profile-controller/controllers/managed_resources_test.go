@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileManagedResources(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	managedResources := func() []profilev1.ManagedResource {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		return found.Status.ManagedResources
+	}
+	resource := func(kind, name string) profilev1.ManagedResource {
+		return profilev1.ManagedResource{Kind: kind, Name: name, Namespace: profile.Name}
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	resources := managedResources()
+	assert.Contains(t, resources, profilev1.ManagedResource{Kind: "Namespace", Name: profile.Name})
+	assert.Contains(t, resources, resource("ServiceAccount", DEFAULT_EDITOR))
+	assert.Contains(t, resources, resource("ServiceAccount", DEFAULT_VIEWER))
+	assert.Contains(t, resources, resource("RoleBinding", "namespaceAdmin"))
+	assert.Contains(t, resources, resource("AuthorizationPolicy", AUTHZPOLICYISTIO))
+	assert.NotContains(t, resources, resource("Role", OWNERPODRESTART))
+	for i := 1; i < len(resources); i++ {
+		assert.LessOrEqual(t, resources[i-1].Kind, resources[i].Kind, "sorted by kind")
+	}
+
+	// Created resources are listed.
+	r.OwnerPodRestartAccess = true
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	resources = managedResources()
+	assert.Contains(t, resources, resource("Role", OWNERPODRESTART))
+	assert.Contains(t, resources, resource("RoleBinding", OWNERPODRESTART))
+
+	// Pruned resources are removed.
+	r.OwnerPodRestartAccess = false
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	resources = managedResources()
+	assert.NotContains(t, resources, resource("Role", OWNERPODRESTART))
+	assert.NotContains(t, resources, resource("RoleBinding", OWNERPODRESTART))
+	assert.Contains(t, resources, resource("ServiceAccount", DEFAULT_EDITOR))
+}
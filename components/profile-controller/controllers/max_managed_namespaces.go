@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Condition type set while the namespace is not created because the controller manages MaxManagedNamespaces.
+const MaxManagedNamespacesExceeded = "MaxManagedNamespacesExceeded"
+
+// countManagedNamespaces returns the number of namespaces controlled by a Profile, terminating namespaces
+// excluded since they no longer count against the cap.
+func (r *ProfileReconciler) countManagedNamespaces(ctx context.Context) (int, error) {
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces); err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range namespaces.Items {
+		owner := metav1.GetControllerOf(&namespaces.Items[i])
+		if owner == nil || owner.Kind != "Profile" || owner.APIVersion != profilev1.GroupVersion.String() {
+			continue
+		}
+		if !isNamespaceTerminating(&namespaces.Items[i]) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// checkMaxManagedNamespaces tells if the namespace of the profile can be created without exceeding
+// MaxManagedNamespaces. Otherwise it sets the MaxManagedNamespacesExceeded condition and requeues the profile with
+// the backoff of namespace quota retries.
+func (r *ProfileReconciler) checkMaxManagedNamespaces(ctx context.Context, instance *profilev1.Profile) (ctrl.Result,
+	bool, error) {
+	if r.MaxManagedNamespaces <= 0 {
+		return reconcile.Result{}, true, nil
+	}
+	count, err := r.countManagedNamespaces(ctx)
+	if err != nil {
+		return reconcile.Result{}, false, err
+	}
+	if count < r.MaxManagedNamespaces {
+		for _, condition := range instance.Status.Conditions {
+			if condition.Type == MaxManagedNamespacesExceeded && condition.Status == "True" {
+				r.setProfileCondition(instance, MaxManagedNamespacesExceeded, "False",
+					fmt.Sprintf("%d of %d managed namespaces", count, r.MaxManagedNamespaces))
+				if err := r.Status().Update(ctx, instance); err != nil {
+					return reconcile.Result{}, false, err
+				}
+			}
+		}
+		return reconcile.Result{}, true, nil
+	}
+	delay := r.namespaceQuotaBackoff().When(instance.Name)
+	r.Log.Info("Maximum number of managed namespaces reached, retrying", "profile", instance.Name,
+		"managed", count, "max", r.MaxManagedNamespaces, "retryAfter", delay.String())
+	IncRequestErrorCounter("max managed namespaces exceeded", SEVERITY_MINOR)
+	r.setProfileCondition(instance, MaxManagedNamespacesExceeded, "True",
+		fmt.Sprintf("namespace not created, the controller already manages %d of at most %d namespaces, "+
+			"retrying in %v", count, r.MaxManagedNamespaces, delay))
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{}, false, err
+	}
+	return reconcile.Result{RequeueAfter: delay}, false, nil
+}
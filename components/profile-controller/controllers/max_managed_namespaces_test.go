@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileMaxManagedNamespaces(t *testing.T) {
+	first := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	second := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	// Namespaces without a Profile controller do not count.
+	unmanaged := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	r := newFakeReconciler(first, second, unmanaged)
+	r.MaxManagedNamespaces = 1
+	r.NamespaceQuotaRetryBaseDelay = time.Second
+	getCondition := func(name string) *profilev1.ProfileCondition {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name}, found))
+		for i := range found.Status.Conditions {
+			if found.Status.Conditions[i].Type == MaxManagedNamespacesExceeded {
+				return &found.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	// Under the cap, the namespace is created.
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: first.Name}})
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: first.Name}, &corev1.Namespace{}))
+	assert.Nil(t, getCondition(first.Name))
+
+	// Over the cap, the namespace is not created and the profile retried.
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: second.Name}}
+	result, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, result.RequeueAfter)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), types.NamespacedName{Name: second.Name},
+		&corev1.Namespace{})))
+	condition := getCondition(second.Name)
+	require.NotNil(t, condition)
+	assert.Equal(t, "True", condition.Status)
+	assert.Contains(t, condition.Message, "1 of at most 1")
+
+	// Existing namespaces keep being reconciled at the cap.
+	result, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: first.Name}})
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	// The cap is raised, the namespace is created and the condition cleared.
+	r.MaxManagedNamespaces = 2
+	result, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: second.Name}, &corev1.Namespace{}))
+	condition = getCondition(second.Name)
+	require.NotNil(t, condition)
+	assert.Equal(t, "False", condition.Status)
+}
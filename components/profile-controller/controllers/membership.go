@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Label marking RoleBindings created from resolved members, only those are pruned.
+const MEMBERSHIPLABEL = "profile.kubeflow.org/membership"
+
+// Member is a subject granted access to a profile namespace.
+type Member struct {
+	Subject rbacv1.Subject
+	// ClusterRole bound to the subject, defaults to kubeflow-edit.
+	ClusterRole string
+}
+
+// MembershipResolver resolves the members of a profile namespace from an external source,
+// e.g. a team-membership service.
+type MembershipResolver interface {
+	ResolveMembers(ctx context.Context, profileIns *profilev1.Profile) ([]Member, error)
+}
+
+// NoopMembershipResolver resolves no members.
+type NoopMembershipResolver struct{}
+
+func (NoopMembershipResolver) ResolveMembers(ctx context.Context, profileIns *profilev1.Profile) ([]Member, error) {
+	return nil, nil
+}
+
+var bindingNameRegexp = regexp.MustCompile("[^a-z0-9]+")
+
+// getMemberBindingName returns the RoleBinding name of a member, following the naming of kfam bindings:
+// combination of subject kind, subject name, "clusterrole" and the role name.
+func getMemberBindingName(member Member) string {
+	return bindingNameRegexp.ReplaceAllString(strings.ToLower(strings.Join([]string{
+		member.Subject.Kind,
+		member.Subject.Name,
+		"clusterrole",
+		member.ClusterRole,
+	}, "-")), "-")
+}
+
+// resolveMembers resolves the members of the profile with MembershipResolver, defaulting their ClusterRole to
+// the editor ClusterRole.
+func (r *ProfileReconciler) resolveMembers(ctx context.Context, profileIns *profilev1.Profile) ([]Member, error) {
+	resolver := r.MembershipResolver
+	if resolver == nil {
+		resolver = NoopMembershipResolver{}
+	}
+	members, err := resolver.ResolveMembers(ctx, profileIns)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if members[i].ClusterRole == "" {
+			members[i].ClusterRole = r.editorClusterRole()
+		}
+	}
+	return members, nil
+}
+
+// rolePrivilege ranks the admin, editor and viewer ClusterRoles, other ClusterRoles rank 0 as they cannot be
+// compared.
+func (r *ProfileReconciler) rolePrivilege(clusterRole string) int {
+	switch clusterRole {
+	case kubeflowAdmin:
+		return 3
+	case r.editorClusterRole():
+		return 2
+	case r.viewerClusterRole():
+		return 1
+	}
+	return 0
+}
+
+// dedupeSubjects deduplicates the subjects across the owner, the contributors and the resolved members of the
+// profile, so every subject is bound once with its highest privilege. On a tie the owner binding is kept over a
+// contributor binding, which is kept over a member binding. Members bound to other ClusterRoles are kept.
+func (r *ProfileReconciler) dedupeSubjects(profileIns *profilev1.Profile,
+	members []Member) ([]profilev1.Contributor, []Member) {
+	type subjectKey struct{ kind, name, namespace string }
+	keyOf := func(subject rbacv1.Subject) subjectKey {
+		return subjectKey{subject.Kind, subject.Name, subject.Namespace}
+	}
+	highest := map[subjectKey]int{keyOf(profileIns.Spec.Owner): r.rolePrivilege(kubeflowAdmin)}
+	raise := func(subject rbacv1.Subject, privilege int) {
+		if privilege > highest[keyOf(subject)] {
+			highest[keyOf(subject)] = privilege
+		}
+	}
+	contributors := getContributors(profileIns)
+	for _, contributor := range contributors {
+		raise(contributor.Subject, r.rolePrivilege(r.contributorClusterRole(contributor)))
+	}
+	for _, member := range members {
+		raise(member.Subject, r.rolePrivilege(member.ClusterRole))
+	}
+
+	bound := map[subjectKey]bool{keyOf(profileIns.Spec.Owner): true}
+	var dedupedContributors []profilev1.Contributor
+	for _, contributor := range contributors {
+		if highest[keyOf(contributor.Subject)] == r.rolePrivilege(r.contributorClusterRole(contributor)) {
+			bound[keyOf(contributor.Subject)] = true
+			dedupedContributors = append(dedupedContributors, contributor)
+		}
+	}
+	var dedupedMembers []Member
+	names := map[string]bool{}
+	for _, member := range members {
+		key, privilege := keyOf(member.Subject), r.rolePrivilege(member.ClusterRole)
+		if privilege > 0 {
+			if bound[key] || privilege < highest[key] {
+				continue
+			}
+			bound[key] = true
+		} else if names[getMemberBindingName(member)] {
+			continue
+		}
+		names[getMemberBindingName(member)] = true
+		dedupedMembers = append(dedupedMembers, member)
+	}
+	return dedupedContributors, dedupedMembers
+}
+
+// updateMemberRoleBindings creates a RoleBinding for every resolved member of the profile and deletes
+// the ones of members no longer resolved.
+func (r *ProfileReconciler) updateMemberRoleBindings(ctx context.Context, profileIns *profilev1.Profile,
+	members []Member) error {
+	desired := map[string]bool{}
+	for _, member := range members {
+		roleBinding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{USER: member.Subject.Name, ROLE: member.ClusterRole},
+				Labels:      map[string]string{MEMBERSHIPLABEL: "true"},
+				Name:        getMemberBindingName(member),
+				Namespace:   profileNamespace(profileIns),
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: "rbac.authorization.k8s.io",
+				Kind:     "ClusterRole",
+				Name:     member.ClusterRole,
+			},
+			Subjects: []rbacv1.Subject{member.Subject},
+		}
+		if err := r.updateRoleBinding(ctx, profileIns, roleBinding); err != nil {
+			return err
+		}
+		desired[roleBinding.Name] = true
+	}
+
+	existing := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, existing, client.InNamespace(profileNamespace(profileIns)),
+		client.MatchingLabels{MEMBERSHIPLABEL: "true"}); err != nil {
+		return err
+	}
+	for i := range existing.Items {
+		if desired[existing.Items[i].Name] {
+			continue
+		}
+		r.Log.Info("Deleting RoleBinding of removed member", "namespace", profileIns.Name,
+			"name", existing.Items[i].Name)
+		if err := r.Delete(ctx, &existing.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
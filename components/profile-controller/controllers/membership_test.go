@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeMembershipResolver returns the members currently assigned to it.
+type fakeMembershipResolver struct {
+	members []Member
+}
+
+func (f *fakeMembershipResolver) ResolveMembers(ctx context.Context, profileIns *profilev1.Profile) ([]Member, error) {
+	return f.members, nil
+}
+
+func TestGetMemberBindingName(t *testing.T) {
+	name := getMemberBindingName(Member{
+		Subject:     rbacv1.Subject{Kind: "User", Name: "User2@abcd.com"},
+		ClusterRole: kubeflowEdit,
+	})
+	assert.Equal(t, "user-user2-abcd-com-clusterrole-kubeflow-edit", name)
+}
+
+func TestReconcileMemberRoleBindings(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	// A RoleBinding not created from membership must survive pruning.
+	manual := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: profile.Name},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: kubeflowView},
+	}
+	r := newFakeReconciler(profile, manual)
+	resolver := &fakeMembershipResolver{members: []Member{
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}},
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user3@abcd.com"}, ClusterRole: kubeflowView},
+	}}
+	r.MembershipResolver = resolver
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	memberBindings := func() map[string]string {
+		list := &rbacv1.RoleBindingList{}
+		require.NoError(t, r.List(context.TODO(), list, client.InNamespace(profile.Name),
+			client.MatchingLabels{MEMBERSHIPLABEL: "true"}))
+		roles := map[string]string{}
+		for _, rb := range list.Items {
+			require.Len(t, rb.Subjects, 1)
+			roles[rb.Subjects[0].Name] = rb.RoleRef.Name
+		}
+		return roles
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"user2@abcd.com": kubeflowEdit,
+		"user3@abcd.com": kubeflowView,
+	}, memberBindings())
+
+	// user3 leaves the team, user4 joins.
+	resolver.members = []Member{
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}},
+		{Subject: rbacv1.Subject{Kind: "User", Name: "user4@abcd.com"}},
+	}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"user2@abcd.com": kubeflowEdit,
+		"user4@abcd.com": kubeflowEdit,
+	}, memberBindings())
+
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "manual", Namespace: profile.Name}, manual))
+}
+
+func TestDedupeSubjects(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	owner := profile.Spec.Owner
+	user2 := rbacv1.Subject{Kind: "User", Name: "user2@abcd.com"}
+	user3 := rbacv1.Subject{Kind: "User", Name: "user3@abcd.com"}
+	team := rbacv1.Subject{Kind: "Group", Name: "team@abcd.com"}
+	admins := rbacv1.Subject{Kind: "Group", Name: "admins@abcd.com"}
+	viewer := rbacv1.Subject{Kind: "User", Name: "viewer@abcd.com"}
+	profile.Spec.Contributors = []profilev1.Contributor{
+		{Subject: owner}, {Subject: user2}, {Subject: user3}, {Subject: team}, {Subject: viewer, Role: VIEW},
+	}
+
+	contributors, members := newFakeReconciler().dedupeSubjects(profile, []Member{
+		// The owner already has admin access.
+		{Subject: owner, ClusterRole: kubeflowEdit},
+		// Edit access of a contributor is kept over the member binding.
+		{Subject: user2, ClusterRole: kubeflowEdit},
+		{Subject: team, ClusterRole: kubeflowView},
+		// Admin access of a member is kept over the contributor binding.
+		{Subject: user3, ClusterRole: kubeflowAdmin},
+		// The highest privilege of a group resolved twice is kept.
+		{Subject: admins, ClusterRole: kubeflowView},
+		{Subject: admins, ClusterRole: kubeflowAdmin},
+		// View access of a contributor is kept over the same member binding.
+		{Subject: viewer, ClusterRole: kubeflowView},
+		// Other ClusterRoles cannot be compared and are kept once.
+		{Subject: owner, ClusterRole: "pipeline-runner"},
+		{Subject: owner, ClusterRole: "pipeline-runner"},
+	})
+	assert.Equal(t, []profilev1.Contributor{
+		{Subject: user2, Role: EDIT},
+		{Subject: team, Role: EDIT},
+		{Subject: viewer, Role: VIEW},
+	}, contributors)
+	assert.Equal(t, []Member{
+		{Subject: user3, ClusterRole: kubeflowAdmin},
+		{Subject: admins, ClusterRole: kubeflowAdmin},
+		{Subject: owner, ClusterRole: "pipeline-runner"},
+	}, members)
+}
+
+func TestReconcileDedupesSubjects(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	team := rbacv1.Subject{Kind: "Group", Name: "team@abcd.com"}
+	profile.Spec.Contributors = []profilev1.Contributor{{Subject: profile.Spec.Owner}, {Subject: team}}
+	r := newFakeReconciler(profile)
+	r.MembershipResolver = &fakeMembershipResolver{members: []Member{
+		{Subject: profile.Spec.Owner},
+		{Subject: team, ClusterRole: kubeflowView},
+	}}
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	// Every subject is bound once: the owner as admin, the team as contributor.
+	list := &rbacv1.RoleBindingList{}
+	require.NoError(t, r.List(context.TODO(), list, client.InNamespace(profile.Name)))
+	bindings := map[string][]string{}
+	for _, rb := range list.Items {
+		for _, subject := range rb.Subjects {
+			bindings[subject.Kind+":"+subject.Name] = append(bindings[subject.Kind+":"+subject.Name], rb.RoleRef.Name)
+		}
+	}
+	assert.Equal(t, []string{kubeflowAdmin}, bindings["User:user1@abcd.com"])
+	assert.Equal(t, []string{kubeflowEdit}, bindings["Group:team@abcd.com"])
+}
@@ -11,6 +11,7 @@ import (
 const PROFILE = "profile_controller"
 const COMPONENT = "component"
 const KIND = "kind"
+const NAMESPACE = "namespace"
 
 // User that make the request
 const REQUSER = "user"
@@ -42,6 +43,21 @@ var (
 		Name: "service_heartbeat",
 		Help: "Heartbeat signal every 10 seconds indicating pods are alive.",
 	}, []string{COMPONENT, SEVERITY})
+
+	// Counter metrics of the PodDefaults configured with -pd
+	podDefaultsApplied = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "poddefaults_applied_total",
+		Help: "Number of PodDefaults applied to profile namespaces",
+	}, []string{NAMESPACE})
+	podDefaultParseErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "poddefaults_parse_errors_total",
+		Help: "Number of invalid -pd entries",
+	})
+	// Counter metrics of the managed resources set back after drifting from their profile
+	driftCorrections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "profile_drift_corrections_total",
+		Help: "Number of managed resources recreated or reverted after manual edits or deletions",
+	}, []string{KIND})
 )
 
 func init() {
@@ -49,6 +65,9 @@ func init() {
 	metrics.Registry.MustRegister(requestCounter)
 	metrics.Registry.MustRegister(requestErrorCounter)
 	metrics.Registry.MustRegister(serviceHeartbeat)
+	metrics.Registry.MustRegister(podDefaultsApplied)
+	metrics.Registry.MustRegister(podDefaultParseErrors)
+	metrics.Registry.MustRegister(driftCorrections)
 	// Count heartbeat
 	go func() {
 		labels := prometheus.Labels{COMPONENT: PROFILE, SEVERITY: SEVERITY_CRITICAL}
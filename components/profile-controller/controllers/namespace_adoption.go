@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Profile annotation letting the profile adopt the namespace of its name if it already exists, set to "true".
+const ADOPTNAMESPACE = "profile.kubeflow.org/adopt-namespace"
+
+// Event reason of a namespace adopted by its profile.
+const NamespaceAdopted = "NamespaceAdopted"
+
+// adoptsNamespace tells if the profile takes over its existing namespace ns instead of failing, with
+// AdoptNamespaces or the ADOPTNAMESPACE annotation. Namespaces controlled by anything but the profile, e.g. by
+// another profile, are never adopted.
+func (r *ProfileReconciler) adoptsNamespace(profileIns *profilev1.Profile, ns *corev1.Namespace) bool {
+	if !r.AdoptNamespaces && profileIns.Annotations[ADOPTNAMESPACE] != "true" {
+		return false
+	}
+	controller := metav1.GetControllerOf(ns)
+	return controller == nil || (profileIns.UID != "" && controller.UID == profileIns.UID)
+}
+
+// adoptNamespace makes the profile owner the owner of ns and the profile its controller, so it is handled as a
+// namespace the profile created. The previous owner annotation is replaced and istio-injection enabled, unless the
+// namespace sets it already.
+func (r *ProfileReconciler) adoptNamespace(profileIns *profilev1.Profile, ns *corev1.Namespace) error {
+	if err := controllerutil.SetControllerReference(profileIns, ns, r.Scheme); err != nil {
+		return err
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations["owner"] = profileIns.Spec.Owner.Name
+	if _, ok := ns.Labels[istioInjectionLabel]; !ok {
+		if ns.Labels == nil {
+			ns.Labels = map[string]string{}
+		}
+		ns.Labels[istioInjectionLabel] = "enabled"
+	}
+	return nil
+}
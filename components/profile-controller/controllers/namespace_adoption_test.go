@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func newLegacyNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Labels:      map[string]string{"team": "ml"},
+		Annotations: map[string]string{"owner": "team-lead@abcd.com"},
+	}}
+}
+
+func hasFailedCondition(t *testing.T, r *ProfileReconciler, name string) bool {
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name}, found))
+	for _, c := range found.Status.Conditions {
+		if c.Type == profilev1.ProfileFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReconcileAdoptNamespace(t *testing.T) {
+	profile := newTestProfile("team-ml", "user1@abcd.com")
+	profile.Annotations = map[string]string{ADOPTNAMESPACE: "true"}
+	r := newFakeReconciler(profile, newLegacyNamespace(profile.Name))
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.False(t, hasFailedCondition(t, r, profile.Name))
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["owner"])
+	assert.Equal(t, "ml", ns.Labels["team"], "labels of the namespace are kept")
+	assert.Equal(t, "enabled", ns.Labels[istioInjectionLabel])
+	controller := metav1.GetControllerOf(ns)
+	require.NotNil(t, controller)
+	assert.Equal(t, profile.Name, controller.Name)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "namespaceAdmin", Namespace: profile.Name},
+		&rbacv1.RoleBinding{}))
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: AUTHZPOLICYISTIO, Namespace: profile.Name},
+		&istioSecurityClient.AuthorizationPolicy{}))
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal NamespaceAdopted")
+
+	// Adopted once.
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Len(t, recorder.Events, 0)
+}
+
+func TestReconcileAdoptNamespaceDisabled(t *testing.T) {
+	profile := newTestProfile("team-ml", "user1@abcd.com")
+	r := newFakeReconciler(profile, newLegacyNamespace(profile.Name))
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, hasFailedCondition(t, r, profile.Name))
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "team-lead@abcd.com", ns.Annotations["owner"])
+	assert.Nil(t, metav1.GetControllerOf(ns))
+}
+
+func TestAdoptsNamespace(t *testing.T) {
+	profile := newTestProfile("team-ml", "user1@abcd.com")
+	profile.UID = "1234"
+	r := newFakeReconciler()
+	ns := newLegacyNamespace(profile.Name)
+	assert.False(t, r.adoptsNamespace(profile, ns))
+	r.AdoptNamespaces = true
+	assert.True(t, r.adoptsNamespace(profile, ns))
+
+	// Namespaces controlled by another profile are not adopted.
+	controller := true
+	ns.OwnerReferences = []metav1.OwnerReference{{APIVersion: "kubeflow.org/v1", Kind: "Profile", Name: "other",
+		UID: "5678", Controller: &controller}}
+	assert.False(t, r.adoptsNamespace(profile, ns))
+
+	problem, err := newFakeReconciler(newLegacyNamespace(profile.Name)).validateProfileNamespace(context.TODO(),
+		profile)
+	require.NoError(t, err)
+	assert.NotEmpty(t, problem)
+	profile.Annotations = map[string]string{ADOPTNAMESPACE: "true"}
+	problem, err = newFakeReconciler(newLegacyNamespace(profile.Name)).validateProfileNamespace(context.TODO(),
+		profile)
+	require.NoError(t, err)
+	assert.Empty(t, problem)
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// AnnotationTemplates maps namespace annotation keys to templates rendered against the owning Profile.
+// Templates are standard go templates evaluated with ProfileTemplateData, e.g.
+// `{{ .Labels.team }}-{{ .Labels.environment }}`.
+type AnnotationTemplates map[string]*template.Template
+
+// ProfileTemplateData is the data exposed to annotation templates.
+type ProfileTemplateData struct {
+	// Name of the profile.
+	Name string
+	// Namespace is the name of the profile namespace.
+	Namespace string
+	// Owner is the name of the profile owner subject.
+	Owner       string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+func newProfileTemplateData(profileIns *profilev1.Profile) ProfileTemplateData {
+	return ProfileTemplateData{
+		Name:        profileIns.Name,
+		Namespace:   profileNamespace(profileIns),
+		Owner:       profileIns.Spec.Owner.Name,
+		Labels:      profileIns.Labels,
+		Annotations: profileIns.Annotations,
+	}
+}
+
+// ParseAnnotationTemplates parses a comma separated list of key=template pairs.
+// Commas inside template actions ({{ }}) do not split entries.
+func ParseAnnotationTemplates(value string) (AnnotationTemplates, error) {
+	templates := AnnotationTemplates{}
+	for _, entry := range splitTemplateList(value) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid annotation template %q, expected key=template", entry)
+		}
+		tmpl, err := template.New(key).Option("missingkey=zero").Parse(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid annotation template for %v: %v", key, err)
+		}
+		templates[key] = tmpl
+	}
+	return templates, nil
+}
+
+// splitTemplateList splits value on commas which are not enclosed in template actions.
+func splitTemplateList(value string) []string {
+	var entries []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "{{"):
+			depth++
+			i++
+		case strings.HasPrefix(value[i:], "}}") && depth > 0:
+			depth--
+			i++
+		case value[i] == ',' && depth == 0:
+			entries = append(entries, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(entries, value[start:])
+}
+
+// Render evaluates all templates for the profile. Annotations rendering to an empty string are
+// returned with an empty value, meaning they should be removed from the namespace.
+func (t AnnotationTemplates) Render(profileIns *profilev1.Profile) (map[string]string, error) {
+	return t.render(newProfileTemplateData(profileIns))
+}
+
+// render evaluates all templates with data, like Render.
+func (t AnnotationTemplates) render(data interface{}) (map[string]string, error) {
+	rendered := make(map[string]string, len(t))
+	for key, tmpl := range t {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("error rendering annotation %v: %v", key, err)
+		}
+		rendered[key] = strings.TrimSpace(buf.String())
+	}
+	return rendered, nil
+}
+
+// namespaceAnnotations renders all annotation sources configured on the reconciler for the profile, next to the
+// spec.namespaceAnnotations of the profile and the annotations of metadata. The version annotation records the
+// controller version which last reconciled the namespace.
+func (r *ProfileReconciler) namespaceAnnotations(profileIns *profilev1.Profile,
+	metadata *NamespaceMetadata) (map[string]string, error) {
+	// Later sources win over earlier ones with the same key.
+	sources := []func(*profilev1.Profile) (map[string]string, error){
+		r.profileNamespaceAnnotations,
+		metadata.annotations,
+		r.LogRoutingAnnotations.Render,
+		r.CatalogAnnotations.Render,
+		func(profileIns *profilev1.Profile) (map[string]string, error) {
+			return renderExternalDNSAnnotations(r.ExternalDNSAnnotations, profileIns)
+		},
+		r.CertReminderAnnotations.Render,
+		r.DocumentationAnnotations.Render,
+		r.GatewayTLS.annotations,
+		r.GPUFairShare.annotations,
+		r.GPUReservation.annotations,
+		r.VPAInclusion.annotations,
+		r.TracingSampling.annotations,
+		r.DataClassification.annotations,
+		r.CleanupPolicy.annotations,
+		r.RegistryMirror.annotations,
+		r.VaultInjection.annotations,
+	}
+	annotations := map[string]string{}
+	for _, source := range sources {
+		rendered, err := source(profileIns)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range rendered {
+			annotations[k] = v
+		}
+	}
+	if r.VersionAnnotation != "" {
+		annotations[r.VersionAnnotation] = r.Version
+	}
+	return annotations, nil
+}
+
+// profileNamespaceAnnotations returns the spec.namespaceAnnotations of the profile. Keys holding the namespace
+// owner or state of the controller cannot be set by the profile.
+func (r *ProfileReconciler) profileNamespaceAnnotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	annotations := make(map[string]string, len(profileIns.Spec.NamespaceAnnotations))
+	for k, v := range profileIns.Spec.NamespaceAnnotations {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid namespace annotation %q: %v", k, strings.Join(errs, ", "))
+		}
+		switch k {
+		case "owner", MANAGEDANNOTATIONS, MANAGEDLABELS, CONFIGHASH, WORKSPACEPROVISIONED, ISTIOINJECTIONRESTORE,
+			r.VersionAnnotation:
+			return nil, fmt.Errorf("namespace annotation %v is set by the controller", k)
+		}
+		annotations[k] = v
+	}
+	return annotations, nil
+}
+
+// MANAGEDANNOTATIONS lists the namespace annotation keys set by the controller, so keys dropped from the
+// configured templates are pruned as well.
+const MANAGEDANNOTATIONS = "profile.kubeflow.org/managed-annotations"
+
+// updateManagedAnnotations sets the desired annotations on obj and removes the ones with empty value or
+// previously managed by the controller but no longer desired. Annotations not managed by the controller,
+// e.g. the ones of plugins, are left alone. Returns true if obj was changed.
+func updateManagedAnnotations(obj metav1.Object, desired map[string]string) bool {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	updated := false
+	var managed []string
+	for k, v := range desired {
+		current, ok := annotations[k]
+		if v == "" {
+			if ok {
+				delete(annotations, k)
+				updated = true
+			}
+			continue
+		}
+		managed = append(managed, k)
+		if !ok || current != v {
+			annotations[k] = v
+			updated = true
+		}
+	}
+	for _, k := range strings.Split(annotations[MANAGEDANNOTATIONS], ",") {
+		if _, ok := desired[k]; ok || k == "" {
+			continue
+		}
+		if _, ok := annotations[k]; ok {
+			delete(annotations, k)
+			updated = true
+		}
+	}
+	sort.Strings(managed)
+	marker := strings.Join(managed, ",")
+	if current, ok := annotations[MANAGEDANNOTATIONS]; marker == "" {
+		if ok {
+			delete(annotations, MANAGEDANNOTATIONS)
+			updated = true
+		}
+	} else if current != marker {
+		annotations[MANAGEDANNOTATIONS] = marker
+		updated = true
+	}
+	if updated {
+		obj.SetAnnotations(annotations)
+	}
+	return updated
+}
+
+// updateServiceAccountAnnotations sets the configured annotations of service account saName in the profile
+// namespace.
+func (r *ProfileReconciler) updateServiceAccountAnnotations(ctx context.Context, profileIns *profilev1.Profile,
+	saName string) error {
+	desired, err := r.serviceAccountAnnotations(profileIns, saName)
+	if err != nil {
+		return err
+	}
+	found := &corev1.ServiceAccount{}
+	if err := r.Get(ctx, types.NamespacedName{Name: saName, Namespace: profileNamespace(profileIns)}, found); err != nil {
+		return err
+	}
+	before := found.DeepCopy()
+	if !updateManagedAnnotations(found, desired) {
+		return nil
+	}
+	annotations := map[string]string{}
+	for k, v := range found.Annotations {
+		if _, ok := desired[k]; ok || k == MANAGEDANNOTATIONS {
+			annotations[k] = v
+		}
+	}
+	r.Log.Info("Updating ServiceAccount annotations", "namespace", profileIns.Name, "name", saName)
+	return r.applyAnnotations(ctx, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: found.Name,
+		Namespace: found.Namespace}}, SAANNOTATIONSFIELDMANAGER, annotations,
+		droppedKeys(before.Annotations, found.Annotations))
+}
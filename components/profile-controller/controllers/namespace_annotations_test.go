@@ -0,0 +1,375 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseAnnotationTemplates(t *testing.T) {
+	templates, err := ParseAnnotationTemplates(
+		"logging.example.com/index={{ .Labels.team }}-{{ .Labels.environment }}, logging.example.com/owner={{ .Owner }}")
+	require.NoError(t, err)
+	assert.Len(t, templates, 2)
+
+	templates, err = ParseAnnotationTemplates(`a={{ printf "%v,%v" .Name .Owner }}`)
+	require.NoError(t, err)
+	assert.Len(t, templates, 1)
+
+	templates, err = ParseAnnotationTemplates("")
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+
+	_, err = ParseAnnotationTemplates("missing-template")
+	assert.Error(t, err)
+	_, err = ParseAnnotationTemplates("a={{ .Name")
+	assert.Error(t, err)
+}
+
+func TestRenderLogRoutingAnnotations(t *testing.T) {
+	templates, err := ParseAnnotationTemplates(
+		"logging.example.com/index={{ .Labels.team }}-{{ .Labels.environment }},logging.example.com/team={{ .Labels.team }}")
+	require.NoError(t, err)
+
+	profile := &profilev1.Profile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "kubeflow-user1",
+			Labels: map[string]string{"team": "ml", "environment": "prod"},
+		},
+		Spec: profilev1.ProfileSpec{Owner: rbacv1.Subject{Kind: "User", Name: "user1@abcd.com"}},
+	}
+	rendered, err := templates.Render(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"logging.example.com/index": "ml-prod",
+		"logging.example.com/team":  "ml",
+	}, rendered)
+
+	// Missing labels render to empty values.
+	profile.Labels = map[string]string{"environment": "prod"}
+	rendered, err = templates.Render(profile)
+	require.NoError(t, err)
+	assert.Equal(t, "", rendered["logging.example.com/team"])
+}
+
+func TestUpdateManagedAnnotations(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kubeflow-user1",
+			Annotations: map[string]string{
+				"owner":                     "user1@abcd.com",
+				"logging.example.com/index": "old-index",
+				"logging.example.com/team":  "ml",
+				"logging.example.com/old":   "removed-from-flag",
+				MANAGEDANNOTATIONS:          "logging.example.com/index,logging.example.com/old,logging.example.com/team",
+			},
+		},
+	}
+
+	// Drift is corrected, cleared values and keys no longer configured are pruned.
+	updated := updateManagedAnnotations(ns, map[string]string{
+		"logging.example.com/index": "ml-prod",
+		"logging.example.com/team":  "",
+	})
+	assert.True(t, updated)
+	assert.Equal(t, map[string]string{
+		"owner":                     "user1@abcd.com",
+		"logging.example.com/index": "ml-prod",
+		MANAGEDANNOTATIONS:          "logging.example.com/index",
+	}, ns.Annotations)
+
+	// Already up to date.
+	updated = updateManagedAnnotations(ns, map[string]string{
+		"logging.example.com/index": "ml-prod",
+		"logging.example.com/team":  "",
+	})
+	assert.False(t, updated)
+
+	// Nothing configured anymore, the annotations set by the controller are removed.
+	assert.True(t, updateManagedAnnotations(ns, map[string]string{}))
+	assert.Equal(t, map[string]string{"owner": "user1@abcd.com"}, ns.Annotations)
+
+	// Namespace without annotations.
+	ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kubeflow-user2"}}
+	assert.True(t, updateManagedAnnotations(ns, map[string]string{"logging.example.com/index": "ml-prod"}))
+	assert.Equal(t, "ml-prod", ns.Annotations["logging.example.com/index"])
+}
+
+func TestReconcileLogRoutingAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Labels = map[string]string{"team": "ml", "environment": "prod"}
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: profile.Name,
+			Annotations: map[string]string{
+				"owner":                     "user1@abcd.com",
+				"logging.example.com/index": "edited-by-hand",
+				"logging.example.com/old":   "removed-from-flag",
+				MANAGEDANNOTATIONS:          "logging.example.com/index,logging.example.com/old",
+			},
+		},
+	}
+	r := newFakeReconciler(profile, ns)
+	templates, err := ParseAnnotationTemplates("logging.example.com/index={{ .Labels.team }}-{{ .Labels.environment }}")
+	require.NoError(t, err)
+	r.LogRoutingAnnotations = templates
+
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	found := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: ns.Name}, found))
+	assert.Equal(t, "ml-prod", found.Annotations["logging.example.com/index"])
+	assert.NotContains(t, found.Annotations, "logging.example.com/old")
+	assert.Equal(t, "user1@abcd.com", found.Annotations["owner"])
+}
+
+func TestReconcileCatalogAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Labels = map[string]string{"team": "ml"}
+	profile.Annotations = map[string]string{"purpose": "research"}
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("catalog.example.com/owner={{ .Owner }}," +
+		"catalog.example.com/team={{ .Labels.team }},catalog.example.com/purpose={{ .Annotations.purpose }}")
+	require.NoError(t, err)
+	r.CatalogAnnotations = templates
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns := getNamespace()
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["catalog.example.com/owner"])
+	assert.Equal(t, "ml", ns.Annotations["catalog.example.com/team"])
+	assert.Equal(t, "research", ns.Annotations["catalog.example.com/purpose"])
+
+	// Drift is corrected.
+	ns.Annotations["catalog.example.com/team"] = "edited-by-hand"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "ml", getNamespace().Annotations["catalog.example.com/team"])
+
+	// Clearing the purpose annotation of the profile prunes the catalog annotation.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	delete(profile.Annotations, "purpose")
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns = getNamespace()
+	assert.NotContains(t, ns.Annotations, "catalog.example.com/purpose")
+	assert.Equal(t, "ml", ns.Annotations["catalog.example.com/team"])
+}
+
+func TestReconcileCertReminderAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("certs.example.com/remind={{ .Owner }}," +
+		`certs.example.com/remind-before={{ or (index .Annotations "remind-before") "720h" }}`)
+	require.NoError(t, err)
+	r.CertReminderAnnotations = templates
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns := getNamespace()
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["certs.example.com/remind"])
+	assert.Equal(t, "720h", ns.Annotations["certs.example.com/remind-before"])
+
+	// Drift is corrected.
+	ns.Annotations["certs.example.com/remind"] = "nobody"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "user1@abcd.com", getNamespace().Annotations["certs.example.com/remind"])
+
+	// The profile sets its own reminder lead time.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	profile.Annotations = map[string]string{"remind-before": "168h"}
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "168h", getNamespace().Annotations["certs.example.com/remind-before"])
+}
+
+func TestReconcileDocumentationAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Labels = map[string]string{"slack-channel": "ml-team"}
+	profile.Annotations = map[string]string{"purpose": "research"}
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("docs.example.com/owner={{ .Owner }}," +
+		"docs.example.com/purpose={{ .Annotations.purpose }}," +
+		`docs.example.com/slack-channel={{ index .Labels "slack-channel" }}`)
+	require.NoError(t, err)
+	r.DocumentationAnnotations = templates
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns := getNamespace()
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["docs.example.com/owner"])
+	assert.Equal(t, "research", ns.Annotations["docs.example.com/purpose"])
+	assert.Equal(t, "ml-team", ns.Annotations["docs.example.com/slack-channel"])
+
+	// Drift is corrected.
+	ns.Annotations["docs.example.com/purpose"] = "edited-by-hand"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "research", getNamespace().Annotations["docs.example.com/purpose"])
+
+	// Annotations dropped from the mapping are pruned.
+	templates, err = ParseAnnotationTemplates("docs.example.com/owner={{ .Owner }}")
+	require.NoError(t, err)
+	r.DocumentationAnnotations = templates
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns = getNamespace()
+	assert.NotContains(t, ns.Annotations, "docs.example.com/purpose")
+	assert.NotContains(t, ns.Annotations, "docs.example.com/slack-channel")
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["docs.example.com/owner"])
+}
+
+func TestReconcileProfileNamespaceAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.NamespaceAnnotations = map[string]string{
+		"cost.example.com/team":        "ml",
+		"cost.example.com/cost-center": "cc-1",
+	}
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("cost.example.com/team=platform")
+	require.NoError(t, err)
+	r.CatalogAnnotations = templates
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+	updateProfile := func(annotations map[string]string) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		found.Spec.NamespaceAnnotations = annotations
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns := getNamespace()
+	assert.Equal(t, "cc-1", ns.Annotations["cost.example.com/cost-center"])
+	assert.Equal(t, "platform", ns.Annotations["cost.example.com/team"], "controller annotations take precedence")
+
+	// Drift is corrected.
+	ns.Annotations["cost.example.com/cost-center"] = "edited-by-hand"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "cc-1", getNamespace().Annotations["cost.example.com/cost-center"])
+
+	// Updated and removed annotations.
+	updateProfile(map[string]string{"cost.example.com/project": "kubeflow"})
+	ns = getNamespace()
+	assert.Equal(t, "kubeflow", ns.Annotations["cost.example.com/project"])
+	assert.NotContains(t, ns.Annotations, "cost.example.com/cost-center")
+
+	// The owner annotation cannot be overridden.
+	updateProfile(map[string]string{"owner": "user2@abcd.com"})
+	assert.Equal(t, "user1@abcd.com", getNamespace().Annotations["owner"])
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	require.NotEmpty(t, found.Status.Conditions)
+	assert.Equal(t, profilev1.ProfileFailed, found.Status.Conditions[len(found.Status.Conditions)-1].Type)
+}
+
+func TestReconcileDefaultEditorAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        DEFAULT_EDITOR,
+			Namespace:   profile.Name,
+			Annotations: map[string]string{GCP_ANNOTATION_KEY: "user1@project.iam.gserviceaccount.com"},
+		},
+	}
+	r := newFakeReconciler(profile, sa)
+	templates, err := ParseAnnotationTemplates("eventing.knative.dev/broker=default,eventing.example.com/namespace={{ .Name }}")
+	require.NoError(t, err)
+	r.DefaultEditorAnnotations = templates
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getServiceAccount := func() *corev1.ServiceAccount {
+		sa := &corev1.ServiceAccount{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name}, sa))
+		return sa
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	sa = getServiceAccount()
+	assert.Equal(t, "default", sa.Annotations["eventing.knative.dev/broker"])
+	assert.Equal(t, profile.Name, sa.Annotations["eventing.example.com/namespace"])
+	// The workload identity annotation coexists.
+	assert.Equal(t, "user1@project.iam.gserviceaccount.com", sa.Annotations[GCP_ANNOTATION_KEY])
+
+	// Annotations dropped from the configuration are removed, workload identity stays.
+	templates, err = ParseAnnotationTemplates("eventing.knative.dev/broker=default")
+	require.NoError(t, err)
+	r.DefaultEditorAnnotations = templates
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	sa = getServiceAccount()
+	assert.NotContains(t, sa.Annotations, "eventing.example.com/namespace")
+	assert.Equal(t, "default", sa.Annotations["eventing.knative.dev/broker"])
+	assert.Equal(t, "user1@project.iam.gserviceaccount.com", sa.Annotations[GCP_ANNOTATION_KEY])
+}
+
+func TestReconcileVersionAnnotation(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.Version = "v1.3.0"
+	r.VersionAnnotation = CONTROLLERVERSION
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getVersion := func() string {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns.Annotations[CONTROLLERVERSION]
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0", getVersion())
+
+	// An upgraded controller records its version on the next reconcile.
+	r.Version = "v1.4.0"
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.4.0", getVersion())
+
+	// Disabling the annotation removes it.
+	r.VersionAnnotation = ""
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Empty(t, getVersion())
+}
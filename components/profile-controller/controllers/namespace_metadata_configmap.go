@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Keys of the NamespaceMetadataConfigMap holding the label and annotation templates.
+const (
+	NAMESPACEMETADATALABELS      = "labels"
+	NAMESPACEMETADATAANNOTATIONS = "annotations"
+)
+
+// NamespaceMetadata are the label and annotation templates set on every profile namespace, rendered with
+// ProfileTemplateData.
+type NamespaceMetadata struct {
+	Labels      AnnotationTemplates
+	Annotations AnnotationTemplates
+}
+
+// namespaceMetadataCache holds the NamespaceMetadata parsed from the NamespaceMetadataConfigMap at
+// resourceVersion.
+type namespaceMetadataCache struct {
+	mu              sync.Mutex
+	resourceVersion string
+	metadata        *NamespaceMetadata
+}
+
+// parseNamespaceMetadataConfigMap parses the data of a namespace metadata ConfigMap. The NAMESPACEMETADATALABELS
+// and NAMESPACEMETADATAANNOTATIONS values hold key=template pairs, one per line or comma separated, e.g.
+// `cost-center={{ index .Labels "cost-center" }}`. Other keys are rejected so a typo does not go unnoticed.
+func parseNamespaceMetadataConfigMap(data map[string]string) (*NamespaceMetadata, error) {
+	metadata := &NamespaceMetadata{}
+	for key, value := range data {
+		templates, err := ParseAnnotationTemplates(strings.Join(strings.Split(value, "\n"), ","))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v: %v", key, err)
+		}
+		switch key {
+		case NAMESPACEMETADATALABELS:
+			for labelKey := range templates {
+				if err := validateNamespaceMetadataLabelKey(labelKey); err != nil {
+					return nil, err
+				}
+			}
+			metadata.Labels = templates
+		case NAMESPACEMETADATAANNOTATIONS:
+			for annotationKey := range templates {
+				if err := validateNamespaceMetadataAnnotationKey(annotationKey); err != nil {
+					return nil, err
+				}
+			}
+			metadata.Annotations = templates
+		default:
+			return nil, fmt.Errorf("unknown key %v, expected %v or %v", key, NAMESPACEMETADATALABELS,
+				NAMESPACEMETADATAANNOTATIONS)
+		}
+	}
+	return metadata, nil
+}
+
+// validateNamespaceMetadataLabelKey checks that key is a label key not set by the controller otherwise.
+func validateNamespaceMetadataLabelKey(key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace label %q: %v", key, strings.Join(errs, ", "))
+	}
+	if _, ok := kubeflowNamespaceLabels[key]; ok || key == istioInjectionLabel {
+		return fmt.Errorf("namespace label %v is set by the controller", key)
+	}
+	return nil
+}
+
+// validateNamespaceMetadataAnnotationKey checks that key is an annotation key not holding the state of the
+// controller.
+func validateNamespaceMetadataAnnotationKey(key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace annotation %q: %v", key, strings.Join(errs, ", "))
+	}
+	switch key {
+	case "owner", MANAGEDANNOTATIONS, MANAGEDLABELS, CONFIGHASH, WORKSPACEPROVISIONED:
+		return fmt.Errorf("namespace annotation %v is set by the controller", key)
+	}
+	return nil
+}
+
+// configuredNamespaceMetadata returns the NamespaceMetadata read from NamespaceMetadataConfigMap, nil if it is
+// not set. A ConfigMap is only parsed again once its resourceVersion changed.
+func (r *ProfileReconciler) configuredNamespaceMetadata(ctx context.Context) (*NamespaceMetadata, error) {
+	if r.NamespaceMetadataConfigMap.Name == "" {
+		return nil, nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, r.NamespaceMetadataConfigMap, configMap); err != nil {
+		return nil, fmt.Errorf("error reading namespace metadata ConfigMap %v: %v", r.NamespaceMetadataConfigMap, err)
+	}
+	r.namespaceMetadataCache.mu.Lock()
+	defer r.namespaceMetadataCache.mu.Unlock()
+	if r.namespaceMetadataCache.metadata != nil &&
+		r.namespaceMetadataCache.resourceVersion == configMap.ResourceVersion {
+		return r.namespaceMetadataCache.metadata, nil
+	}
+	metadata, err := parseNamespaceMetadataConfigMap(configMap.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace metadata ConfigMap %v: %v", r.NamespaceMetadataConfigMap, err)
+	}
+	r.Log.Info("Loaded namespace metadata", "configmap", r.NamespaceMetadataConfigMap,
+		"labels", len(metadata.Labels), "annotations", len(metadata.Annotations))
+	r.namespaceMetadataCache.resourceVersion = configMap.ResourceVersion
+	r.namespaceMetadataCache.metadata = metadata
+	return metadata, nil
+}
+
+// labels renders the label templates for the profile. Labels rendering empty are not set, so templates can
+// select the namespaces they apply to. Other rendered values must be valid label values.
+func (m *NamespaceMetadata) labels(profileIns *profilev1.Profile) (map[string]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	labels, err := m.Labels.Render(profileIns)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range labels {
+		if value == "" {
+			delete(labels, key)
+			continue
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value %q of namespace label %v: %v", value, key, strings.Join(errs, ", "))
+		}
+	}
+	return labels, nil
+}
+
+// annotations renders the annotation templates for the profile. Annotations rendering empty are removed.
+func (m *NamespaceMetadata) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return m.Annotations.Render(profileIns)
+}
+
+// namespaceLabels returns the labels managed on the namespace of the profile: the labels of metadata, VPA
+// labels and cost labels taking precedence over them.
+func (r *ProfileReconciler) namespaceLabels(profileIns *profilev1.Profile,
+	metadata *NamespaceMetadata) (map[string]string, error) {
+	labels, err := metadata.labels(profileIns)
+	if err != nil {
+		return nil, err
+	}
+	merged := map[string]string{}
+	for _, l := range []map[string]string{labels, r.VPAInclusion.labels(profileIns), costLabels(profileIns)} {
+		for k, v := range l {
+			merged[k] = v
+		}
+	}
+	return merged, nil
+}
+
+// namespaceMetadataConfigMapToProfiles maps the namespace metadata ConfigMap to all profiles managed by the
+// controller, so a changed ConfigMap is applied to every profile namespace.
+func (r *ProfileReconciler) namespaceMetadataConfigMapToProfiles() *handler.EnqueueRequestsFromMapFunc {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			key := types.NamespacedName{Namespace: obj.Meta.GetNamespace(), Name: obj.Meta.GetName()}
+			if key != r.NamespaceMetadataConfigMap {
+				return nil
+			}
+			profiles := &profilev1.ProfileList{}
+			if err := r.List(context.Background(), profiles); err != nil {
+				r.Log.Error(err, "error listing profiles for namespace metadata ConfigMap", "configmap", key)
+				IncRequestErrorCounter("error listing profiles for namespace metadata ConfigMap", SEVERITY_MINOR)
+				return nil
+			}
+			var requests []reconcile.Request
+			for _, profileIns := range profiles.Items {
+				if r.managesProfile(profileIns.Labels) {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: profileIns.Name}})
+				}
+			}
+			return requests
+		}),
+	}
+}
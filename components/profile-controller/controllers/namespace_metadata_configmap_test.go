@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestParseNamespaceMetadataConfigMap(t *testing.T) {
+	metadata, err := parseNamespaceMetadataConfigMap(map[string]string{
+		NAMESPACEMETADATALABELS: "pod-security.kubernetes.io/enforce=baseline\n" +
+			"cost-center={{ index .Labels \"cost-center\" }}\n",
+		NAMESPACEMETADATAANNOTATIONS: "platform.example.com/owner={{ .Owner }}",
+	})
+	require.NoError(t, err)
+	assert.Len(t, metadata.Labels, 2)
+	assert.Len(t, metadata.Annotations, 1)
+
+	for _, data := range []map[string]string{
+		{"label": "cost-center=1234"},
+		{NAMESPACEMETADATALABELS: "istio-injection=disabled"},
+		{NAMESPACEMETADATALABELS: "app.kubernetes.io/part-of=other"},
+		{NAMESPACEMETADATALABELS: "not a key=value"},
+		{NAMESPACEMETADATAANNOTATIONS: "owner={{ .Name }}"},
+		{NAMESPACEMETADATAANNOTATIONS: "platform.example.com/owner={{ .Owner"},
+	} {
+		_, err = parseNamespaceMetadataConfigMap(data)
+		assert.Error(t, err, data)
+	}
+}
+
+func TestNamespaceMetadataLabels(t *testing.T) {
+	metadata, err := parseNamespaceMetadataConfigMap(map[string]string{
+		NAMESPACEMETADATALABELS: "cost-center={{ index .Labels \"cost-center\" }}\nowner={{ .Owner }}",
+	})
+	require.NoError(t, err)
+	profile := newTestProfile("kubeflow-user1", "user1")
+	labels, err := metadata.labels(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "user1"}, labels, "empty labels are not set")
+
+	profile.Labels = map[string]string{"cost-center": "1234"}
+	labels, err = metadata.labels(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"cost-center": "1234", "owner": "user1"}, labels)
+
+	profile.Spec.Owner.Name = "user1@abcd.com"
+	_, err = metadata.labels(profile)
+	assert.Error(t, err, "invalid label value")
+
+	var disabled *NamespaceMetadata
+	labels, err = disabled.labels(profile)
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+}
+
+func TestReconcileNamespaceMetadataConfigMap(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Labels = map[string]string{"cost-center": "1234"}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "profile-namespace-metadata", Namespace: "kubeflow"},
+		Data: map[string]string{
+			NAMESPACEMETADATALABELS:      "cost-center={{ index .Labels \"cost-center\" }}",
+			NAMESPACEMETADATAANNOTATIONS: "platform.example.com/owner={{ .Owner }}",
+		},
+	}
+	r := newFakeReconciler(profile, configMap)
+	r.NamespaceMetadataConfigMap = types.NamespacedName{Namespace: configMap.Namespace, Name: configMap.Name}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	ns := getNamespace()
+	assert.Equal(t, "1234", ns.Labels["cost-center"])
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["platform.example.com/owner"])
+
+	// An update of the ConfigMap enqueues all profiles and is applied, dropped keys are removed.
+	require.NoError(t, r.Get(context.TODO(), r.NamespaceMetadataConfigMap, configMap))
+	configMap.Data = map[string]string{NAMESPACEMETADATALABELS: "pod-security.kubernetes.io/enforce=baseline"}
+	require.NoError(t, r.Update(context.TODO(), configMap))
+	requests := r.namespaceMetadataConfigMapToProfiles().ToRequests.Map(handler.MapObject{Meta: configMap, Object: configMap})
+	require.Equal(t, []ctrl.Request{request}, requests)
+	_, err = r.Reconcile(requests[0])
+	require.NoError(t, err)
+	ns = getNamespace()
+	assert.Equal(t, "baseline", ns.Labels["pod-security.kubernetes.io/enforce"])
+	assert.NotContains(t, ns.Labels, "cost-center")
+	assert.NotContains(t, ns.Annotations, "platform.example.com/owner")
+	assert.Equal(t, kubeflowNamespaceLabels["app.kubernetes.io/part-of"], ns.Labels["app.kubernetes.io/part-of"])
+
+	// An invalid ConfigMap fails the profile.
+	configMap.Data = map[string]string{NAMESPACEMETADATALABELS: "istio-injection=disabled"}
+	require.NoError(t, r.Update(context.TODO(), configMap))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	assert.Equal(t, profilev1.ProfileFailed, found.Status.Conditions[len(found.Status.Conditions)-1].Type)
+
+	// Other ConfigMaps are ignored.
+	other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "kubeflow"}}
+	assert.Empty(t, r.namespaceMetadataConfigMapToProfiles().ToRequests.Map(handler.MapObject{Meta: other, Object: other}))
+}
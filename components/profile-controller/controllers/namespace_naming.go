@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NamespaceNaming is the naming policy of the namespaces of profiles without spec.namespaceName: the profile name
+// between Prefix and Suffix, e.g. "kf-" for namespace "kf-<profile>".
+type NamespaceNaming struct {
+	Prefix string
+	Suffix string
+}
+
+// Validate checks that the policy makes DNS-1123 labels of profile names.
+func (n NamespaceNaming) Validate() error {
+	if errs := validation.IsDNS1123Label(n.Prefix + "a" + n.Suffix); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace prefix %q or suffix %q: %v", n.Prefix, n.Suffix, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// desiredNamespace returns the name of the namespace of a new profile: spec.namespaceName, otherwise the profile
+// name with the NamespaceNaming prefix and suffix.
+func (r *ProfileReconciler) desiredNamespace(profileIns *profilev1.Profile) string {
+	if profileIns.Spec.NamespaceName != "" {
+		return profileIns.Spec.NamespaceName
+	}
+	return r.NamespaceNaming.Prefix + profileIns.Name + r.NamespaceNaming.Suffix
+}
+
+// profileNamespace returns the name of the namespace of the profile: status.namespace, or the profile name for
+// profiles whose namespace was not yet resolved, e.g. reconciled by an older controller.
+func profileNamespace(profileIns *profilev1.Profile) string {
+	if profileIns.Status.Namespace != "" {
+		return profileIns.Status.Namespace
+	}
+	return profileIns.Name
+}
+
+// resolveNamespace sets status.namespace of the profile, in memory, if not set yet: to the desired namespace, or
+// the profile name if the profile controls a namespace of its name already, so namespaces created before the
+// naming policy are kept. Returns true if it was set.
+func (r *ProfileReconciler) resolveNamespace(ctx context.Context, profileIns *profilev1.Profile) (bool, error) {
+	if profileIns.Status.Namespace != "" {
+		return false, nil
+	}
+	namespace := r.desiredNamespace(profileIns)
+	if namespace != profileIns.Name {
+		ns := &corev1.Namespace{}
+		err := r.Get(ctx, types.NamespacedName{Name: profileIns.Name}, ns)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		if err == nil && metav1.IsControlledBy(ns, profileIns) {
+			namespace = profileIns.Name
+		}
+	}
+	profileIns.Status.Namespace = namespace
+	return true, nil
+}
+
+// forgetNamespace unsets status.namespace of a profile rejected for its namespace if it was resolved by this
+// reconcile, so a corrected spec.namespaceName is resolved again.
+func forgetNamespace(profileIns *profilev1.Profile, resolved bool) {
+	if resolved {
+		profileIns.Status.Namespace = ""
+	}
+}
+
+// validateNamespaceName checks that name, the namespace of a profile, is a DNS-1123 label.
+func validateNamespaceName(name string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("namespace name %q is not valid: %v", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// namespaceCollision returns the problem of the profile whose namespace ns is controlled by another profile, empty
+// if there is none.
+func namespaceCollision(profileIns *profilev1.Profile, ns *corev1.Namespace) string {
+	controller := metav1.GetControllerOf(ns)
+	if controller == nil || controller.Kind != "Profile" || controller.Name == profileIns.Name {
+		return ""
+	}
+	return fmt.Sprintf("namespace %v is used by profile %v", ns.Name, controller.Name)
+}
+
+// profileOfNamespace returns the name of the profile controlling namespace, the namespace name if it is not
+// controlled by a profile, e.g. not created yet.
+func (r *ProfileReconciler) profileOfNamespace(namespace string) string {
+	ns := &corev1.Namespace{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: namespace}, ns); err != nil {
+		return namespace
+	}
+	if controller := metav1.GetControllerOf(ns); controller != nil && controller.Kind == "Profile" {
+		return controller.Name
+	}
+	return namespace
+}
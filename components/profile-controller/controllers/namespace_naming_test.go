@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestNamespaceNamingValidate(t *testing.T) {
+	assert.NoError(t, NamespaceNaming{}.Validate())
+	assert.NoError(t, NamespaceNaming{Prefix: "kf-", Suffix: "-ns"}.Validate())
+	assert.Error(t, NamespaceNaming{Prefix: "KF_"}.Validate())
+	assert.Error(t, NamespaceNaming{Prefix: "-"}.Validate())
+}
+
+func TestReconcileNamespacePrefix(t *testing.T) {
+	profile := newTestProfile("user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.NamespaceNaming = NamespaceNaming{Prefix: "kf-"}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	assert.Equal(t, "kf-user1", found.Status.Namespace)
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "kf-user1"}, ns))
+	assert.Equal(t, profile.Name, metav1.GetControllerOf(ns).Name)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: "kf-user1"},
+		&corev1.ServiceAccount{}))
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "namespaceAdmin", Namespace: "kf-user1"},
+		&rbacv1.RoleBinding{}))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns)))
+
+	// The namespace is kept when the policy changes.
+	r.NamespaceNaming = NamespaceNaming{Prefix: "team-"}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	assert.Equal(t, "kf-user1", found.Status.Namespace)
+
+	// Objects in the namespace map to the profile.
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: DEFAULT_EDITOR, Namespace: "kf-user1"}}
+	assert.Equal(t, []ctrl.Request{request},
+		r.serviceAccountToProfile().ToRequests.Map(handler.MapObject{Meta: serviceAccount, Object: serviceAccount}))
+}
+
+func TestReconcileNamespaceName(t *testing.T) {
+	profile := newTestProfile("user1", "user1@abcd.com")
+	profile.Spec.NamespaceName = "ml-research"
+	r := newFakeReconciler(profile)
+	r.NamespaceNaming = NamespaceNaming{Prefix: "kf-"}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	assert.Equal(t, "ml-research", found.Status.Namespace)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "ml-research"}, &corev1.Namespace{}))
+}
+
+func TestReconcileNamespaceNamingKeepsExistingNamespace(t *testing.T) {
+	profile := newTestProfile("user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	// A namespace created before status.namespace was reported.
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Status.Namespace = ""
+	require.NoError(t, r.Status().Update(context.TODO(), found))
+
+	r.NamespaceNaming = NamespaceNaming{Prefix: "kf-"}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	assert.Equal(t, profile.Name, found.Status.Namespace)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), types.NamespacedName{Name: "kf-user1"},
+		&corev1.Namespace{})))
+}
+
+func TestReconcileNamespaceCollision(t *testing.T) {
+	first := newTestProfile("kf-user1", "user1@abcd.com")
+	second := newTestProfile("user1", "user1@abcd.com")
+	r := newFakeReconciler(first, second)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: first.Name}})
+	require.NoError(t, err)
+
+	// The prefix names the namespace of the second profile after the first one.
+	r.NamespaceNaming = NamespaceNaming{Prefix: "kf-"}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: second.Name}}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, hasFailedCondition(t, r, second.Name))
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	assert.Empty(t, found.Status.Namespace)
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "kf-user1"}, ns))
+	assert.Equal(t, first.Name, metav1.GetControllerOf(ns).Name)
+
+	problem, err := r.validateProfileNamespace(context.TODO(), newTestProfile("user1", "user1@abcd.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "namespace kf-user1 is used by profile kf-user1", problem)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Condition type set while the namespace cannot be created because of a cluster level namespace quota.
+const ClusterNamespaceQuotaExceeded = "ClusterNamespaceQuotaExceeded"
+
+// Default backoff of namespace creation retries on quota errors.
+const (
+	defaultNamespaceQuotaRetryBaseDelay = 5 * time.Second
+	defaultNamespaceQuotaRetryMaxDelay  = 5 * time.Minute
+)
+
+// isNamespaceQuotaExceeded tells if err is the rejection of a namespace by a quota, e.g. on the number of
+// namespaces of the cluster.
+func isNamespaceQuotaExceeded(err error) bool {
+	return errors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota")
+}
+
+// namespaceQuotaBackoff returns the exponential backoff of namespace creation retries, per profile.
+func (r *ProfileReconciler) namespaceQuotaBackoff() workqueue.RateLimiter {
+	r.namespaceQuotaBackoffOnce.Do(func() {
+		baseDelay := r.NamespaceQuotaRetryBaseDelay
+		if baseDelay <= 0 {
+			baseDelay = defaultNamespaceQuotaRetryBaseDelay
+		}
+		maxDelay := r.NamespaceQuotaRetryMaxDelay
+		if maxDelay < baseDelay {
+			maxDelay = defaultNamespaceQuotaRetryMaxDelay
+		}
+		r.namespaceQuotaRateLimiter = workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay)
+	})
+	return r.namespaceQuotaRateLimiter
+}
+
+// retryNamespaceQuotaExceeded sets the ClusterNamespaceQuotaExceeded condition and requeues the profile
+// with backoff, instead of failing the profile.
+func (r *ProfileReconciler) retryNamespaceQuotaExceeded(ctx context.Context, instance *profilev1.Profile,
+	err error) (ctrl.Result, error) {
+	delay := r.namespaceQuotaBackoff().When(instance.Name)
+	r.Log.Info("Namespace creation rejected by cluster quota, retrying", "profile", instance.Name,
+		"error", err.Error(), "retryAfter", delay.String())
+	IncRequestErrorCounter("namespace quota exceeded", SEVERITY_MINOR)
+	r.setProfileCondition(instance, ClusterNamespaceQuotaExceeded, "True",
+		fmt.Sprintf("namespace cannot be created, retrying in %v: %v", delay, err))
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: delay}, nil
+}
+
+// clearNamespaceQuotaExceeded resets the backoff and the ClusterNamespaceQuotaExceeded condition once the
+// namespace exists.
+func (r *ProfileReconciler) clearNamespaceQuotaExceeded(ctx context.Context, instance *profilev1.Profile) error {
+	r.namespaceQuotaBackoff().Forget(instance.Name)
+	for _, condition := range instance.Status.Conditions {
+		if condition.Type == ClusterNamespaceQuotaExceeded && condition.Status == "True" {
+			r.setProfileCondition(instance, ClusterNamespaceQuotaExceeded, "False", "namespace created")
+			return r.Status().Update(ctx, instance)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaceQuotaClient rejects namespace creation with a quota error while full is set.
+type namespaceQuotaClient struct {
+	client.Client
+	full bool
+}
+
+func (c *namespaceQuotaClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if ns, ok := obj.(*corev1.Namespace); ok && c.full {
+		return apierrors.NewForbidden(corev1.Resource("namespaces"), ns.Name,
+			fmt.Errorf("exceeded quota: namespace-count, requested: count/namespaces=1, used: count/namespaces=100, "+
+				"limited: count/namespaces=100"))
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestIsNamespaceQuotaExceeded(t *testing.T) {
+	c := &namespaceQuotaClient{full: true}
+	err := c.Create(context.TODO(), &corev1.Namespace{})
+	assert.True(t, isNamespaceQuotaExceeded(err))
+	assert.False(t, isNamespaceQuotaExceeded(apierrors.NewForbidden(corev1.Resource("namespaces"), "ns",
+		fmt.Errorf("user cannot create namespaces"))))
+	assert.False(t, isNamespaceQuotaExceeded(nil))
+}
+
+func TestReconcileNamespaceQuotaExceeded(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	quotaClient := &namespaceQuotaClient{Client: r.Client, full: true}
+	r.Client = quotaClient
+	r.NamespaceQuotaRetryBaseDelay = time.Second
+	r.NamespaceQuotaRetryMaxDelay = 3 * time.Second
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getCondition := func() *profilev1.ProfileCondition {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		for i := range found.Status.Conditions {
+			require.NotEqual(t, profilev1.ProfileFailed, found.Status.Conditions[i].Type)
+			if found.Status.Conditions[i].Type == ClusterNamespaceQuotaExceeded {
+				return &found.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	// Retries back off exponentially up to the maximum delay.
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		result, err := r.Reconcile(request)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result.RequeueAfter)
+	}
+	condition := getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, "True", condition.Status)
+	assert.Contains(t, condition.Message, "exceeded quota")
+
+	// The quota is raised, the namespace is created and the condition cleared.
+	quotaClient.full = false
+	result, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, &corev1.Namespace{}))
+	condition = getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, "False", condition.Status)
+	assert.Equal(t, time.Second, r.namespaceQuotaBackoff().When(profile.Name))
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Condition type set while the profile waits for the namespace of a deleted profile of the same name to
+// finish terminating.
+const NamespaceTerminating = "NamespaceTerminating"
+
+// Backoff of the checks of a terminating namespace.
+const (
+	namespaceTerminationRetryBaseDelay = 2 * time.Second
+	namespaceTerminationRetryMaxDelay  = time.Minute
+)
+
+// isNamespaceTerminating tells if ns is being deleted.
+func isNamespaceTerminating(ns *corev1.Namespace) bool {
+	return !ns.DeletionTimestamp.IsZero() || ns.Status.Phase == corev1.NamespaceTerminating
+}
+
+// namespaceTerminationBackoff returns the exponential backoff of the checks of terminating namespaces, per
+// profile.
+func (r *ProfileReconciler) namespaceTerminationBackoff() workqueue.RateLimiter {
+	r.namespaceTerminationBackoffOnce.Do(func() {
+		r.namespaceTerminationRateLimiter = workqueue.NewItemExponentialFailureRateLimiter(
+			namespaceTerminationRetryBaseDelay, namespaceTerminationRetryMaxDelay)
+	})
+	return r.namespaceTerminationRateLimiter
+}
+
+// waitNamespaceTermination sets the NamespaceTerminating condition and requeues the profile with backoff until
+// the terminating namespace is gone and can be created again.
+func (r *ProfileReconciler) waitNamespaceTermination(ctx context.Context, instance *profilev1.Profile) (
+	ctrl.Result, error) {
+	delay := r.namespaceTerminationBackoff().When(instance.Name)
+	r.Log.Info("Namespace is terminating, waiting before creating it", "profile", instance.Name,
+		"retryAfter", delay.String())
+	IncRequestCounter("namespace terminating")
+	r.setProfileCondition(instance, NamespaceTerminating, "True", fmt.Sprintf(
+		"waiting for the namespace of a previous profile %v to finish terminating, retrying in %v",
+		instance.Name, delay))
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: delay}, nil
+}
+
+// clearNamespaceTerminating resets the backoff and the NamespaceTerminating condition once the namespace is
+// created.
+func (r *ProfileReconciler) clearNamespaceTerminating(ctx context.Context, instance *profilev1.Profile) error {
+	r.namespaceTerminationBackoff().Forget(instance.Name)
+	for _, condition := range instance.Status.Conditions {
+		if condition.Type == NamespaceTerminating && condition.Status == "True" {
+			r.setProfileCondition(instance, NamespaceTerminating, "False", "namespace created")
+			return r.Status().Update(ctx, instance)
+		}
+	}
+	return nil
+}
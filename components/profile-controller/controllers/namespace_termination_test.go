@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileNamespaceTerminating(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	now := metav1.Now()
+	// The namespace of the previous profile, owned by another profile UID.
+	terminating := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              profile.Name,
+			Annotations:       map[string]string{"owner": "user0@abcd.com"},
+			DeletionTimestamp: &now,
+			Finalizers:        []string{"kubernetes"},
+		},
+		Status: corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+	r := newFakeReconciler(profile, terminating)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getCondition := func() *profilev1.ProfileCondition {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		for i := range found.Status.Conditions {
+			require.NotEqual(t, profilev1.ProfileFailed, found.Status.Conditions[i].Type)
+			if found.Status.Conditions[i].Type == NamespaceTerminating {
+				return &found.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	// Reconciles back off without errors while the namespace terminates.
+	for _, expected := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second} {
+		result, err := r.Reconcile(request)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result.RequeueAfter)
+	}
+	condition := getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, "True", condition.Status)
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "user0@abcd.com", ns.Annotations["owner"])
+
+	// The namespace is gone, it is created for the new profile and the condition cleared.
+	require.NoError(t, r.Delete(context.TODO(), ns))
+	result, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, profile.Spec.Owner.Name, ns.Annotations["owner"])
+	condition = getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, "False", condition.Status)
+}
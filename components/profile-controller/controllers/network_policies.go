@@ -0,0 +1,246 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Label marking the NetworkPolicies created from NetworkPolicies, only those are pruned.
+const NETWORKPOLICYLABEL = "profile.kubeflow.org/network-policy"
+
+// Names of the built-in baseline NetworkPolicies.
+const (
+	BASELINEINGRESSPOLICY = "profile-baseline-ingress"
+	BASELINEEGRESSPOLICY  = "profile-baseline-egress"
+)
+
+// Label set on every namespace by Kubernetes 1.21 and later, selecting a namespace by name.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// NetworkPolicies configures the baseline NetworkPolicies of every profile namespace.
+type NetworkPolicies struct {
+	// Template renders the NetworkPolicies, the built-in baseline if nil.
+	Template *template.Template
+	// GatewayNamespace is the namespace of the Istio ingress gateway, allowed ingress to every profile namespace.
+	GatewayNamespace string
+	// EgressAllowlist are the CIDRs pods may connect to outside the cluster. Egress is not restricted if empty.
+	EgressAllowlist []string
+}
+
+// NetworkPolicyTemplateData is the data exposed to NetworkPolicy templates.
+type NetworkPolicyTemplateData struct {
+	// Namespace of the profile.
+	Namespace string
+	// Owner is the name of the profile owner subject.
+	Owner string
+	// GatewayNamespace is the namespace of the Istio ingress gateway.
+	GatewayNamespace string
+	// EgressAllowlist are the CIDRs of the -network-policy-egress-allowlist.
+	EgressAllowlist []string
+}
+
+// ParseNetworkPolicyEgressAllowlist parses the -network-policy-egress-allowlist value: comma separated CIDRs, e.g.
+// "10.0.0.0/8,192.168.1.0/24".
+func ParseNetworkPolicyEgressAllowlist(value string) ([]string, error) {
+	cidrs := splitList(value)
+	for _, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, fmt.Errorf("invalid egress allowlist entry: %v", err)
+		}
+	}
+	return cidrs, nil
+}
+
+// LoadNetworkPolicyTemplate reads the go template of the NetworkPolicies in yaml, a map of NetworkPolicy names to
+// specs, usually mounted from a ConfigMap, e.g.
+//
+//	deny-ingress:
+//	  podSelector: {}
+//	  policyTypes: [Ingress]
+//	  ingress:
+//	  - from:
+//	    - podSelector: {}
+//	    - namespaceSelector:
+//	        matchLabels:
+//	          kubernetes.io/metadata.name: {{ .GatewayNamespace }}
+func LoadNetworkPolicyTemplate(path string) (*template.Template, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+}
+
+// specs returns the NetworkPolicy specs of the profile namespace by name, rendered from Template or the built-in
+// baseline.
+func (n *NetworkPolicies) specs(profileIns *profilev1.Profile) (map[string]networkingv1.NetworkPolicySpec, error) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.Template != nil {
+		var buf bytes.Buffer
+		if err := n.Template.Execute(&buf, NetworkPolicyTemplateData{
+			Namespace:        profileNamespace(profileIns),
+			Owner:            profileIns.Spec.Owner.Name,
+			GatewayNamespace: n.GatewayNamespace,
+			EgressAllowlist:  n.EgressAllowlist,
+		}); err != nil {
+			return nil, fmt.Errorf("error rendering NetworkPolicy template: %v", err)
+		}
+		specs := map[string]networkingv1.NetworkPolicySpec{}
+		if err := yaml.Unmarshal(buf.Bytes(), &specs); err != nil {
+			return nil, fmt.Errorf("invalid NetworkPolicies rendered from template: %v", err)
+		}
+		return specs, nil
+	}
+	return n.baseline(), nil
+}
+
+// baseline returns the built-in NetworkPolicies: ingress is only allowed from the namespace itself and the
+// gateway namespace. If an egress allowlist is configured, egress is only allowed to the namespace itself, DNS,
+// the gateway namespace for the Istio control plane and the allowlist.
+func (n *NetworkPolicies) baseline() map[string]networkingv1.NetworkPolicySpec {
+	gateway := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: n.GatewayNamespace}},
+	}
+	sameNamespace := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}}
+	specs := map[string]networkingv1.NetworkPolicySpec{
+		BASELINEINGRESSPOLICY: {
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{From: []networkingv1.NetworkPolicyPeer{sameNamespace, gateway}},
+			},
+		},
+	}
+	if len(n.EgressAllowlist) == 0 {
+		return specs
+	}
+	udp, tcp, dnsPort := corev1.ProtocolUDP, corev1.ProtocolTCP, intstr.FromInt(53)
+	var allowlist []networkingv1.NetworkPolicyPeer
+	for _, cidr := range n.EgressAllowlist {
+		allowlist = append(allowlist, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+	}
+	specs[BASELINEEGRESSPOLICY] = networkingv1.NetworkPolicySpec{
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		Egress: []networkingv1.NetworkPolicyEgressRule{
+			{To: []networkingv1.NetworkPolicyPeer{sameNamespace, gateway}},
+			{
+				To: []networkingv1.NetworkPolicyPeer{{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{namespaceNameLabel: "kube-system"},
+					},
+					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+				}},
+				Ports: []networkingv1.NetworkPolicyPort{
+					{Protocol: &udp, Port: &dnsPort},
+					{Protocol: &tcp, Port: &dnsPort},
+				},
+			},
+			{To: allowlist},
+		},
+	}
+	return specs
+}
+
+// updateNetworkPolicies creates or updates the configured NetworkPolicies of the profile namespace and deletes the
+// ones no longer configured, all of them if there are no NetworkPolicies.
+func (r *ProfileReconciler) updateNetworkPolicies(ctx context.Context, profileIns *profilev1.Profile) error {
+	specs, err := r.NetworkPolicies.specs(profileIns)
+	if err != nil {
+		return err
+	}
+	for name, spec := range specs {
+		policy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:    map[string]string{NETWORKPOLICYLABEL: "true"},
+				Name:      name,
+				Namespace: profileNamespace(profileIns),
+			},
+			Spec: spec,
+		}
+		if err := r.updateNetworkPolicy(ctx, profileIns, policy); err != nil {
+			return err
+		}
+	}
+
+	existing := &networkingv1.NetworkPolicyList{}
+	if err := r.List(ctx, existing, client.InNamespace(profileNamespace(profileIns)),
+		client.MatchingLabels{NETWORKPOLICYLABEL: "true"}); err != nil {
+		return err
+	}
+	for i := range existing.Items {
+		if _, ok := specs[existing.Items[i].Name]; ok || !metav1.IsControlledBy(&existing.Items[i], profileIns) {
+			continue
+		}
+		r.Log.Info("Deleting NetworkPolicy", "namespace", profileIns.Name, "name", existing.Items[i].Name)
+		if err := r.Delete(ctx, &existing.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateNetworkPolicy creates or updates NetworkPolicy policy, controlled by profileIns.
+func (r *ProfileReconciler) updateNetworkPolicy(ctx context.Context, profileIns *profilev1.Profile,
+	policy *networkingv1.NetworkPolicy) error {
+	logger := r.Log.WithValues("profile", profileIns.Name)
+	if err := controllerutil.SetControllerReference(profileIns, policy, r.Scheme); err != nil {
+		return err
+	}
+	found := &networkingv1.NetworkPolicy{}
+	err := r.Get(ctx, types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}, found)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Creating NetworkPolicy", "namespace", policy.Namespace, "name", policy.Name)
+		return r.Create(ctx, policy)
+	}
+	refUpdated, err := r.setMissingControllerReference(profileIns, found)
+	if err != nil {
+		return err
+	}
+	if refUpdated || !reflect.DeepEqual(policy.Spec, found.Spec) || found.Labels[NETWORKPOLICYLABEL] != "true" {
+		found.Spec = policy.Spec
+		if found.Labels == nil {
+			found.Labels = map[string]string{}
+		}
+		found.Labels[NETWORKPOLICYLABEL] = "true"
+		logger.Info("Updating NetworkPolicy", "namespace", policy.Namespace, "name", policy.Name)
+		return r.Update(ctx, found)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testNetworkPolicyTemplate = `
+allow-monitoring:
+  podSelector: {}
+  policyTypes: [Ingress]
+  ingress:
+  - from:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: monitoring
+{{- range .EgressAllowlist }}
+    - ipBlock:
+        cidr: {{ . }}
+{{- end }}
+`
+
+func TestParseNetworkPolicyEgressAllowlist(t *testing.T) {
+	allowlist, err := ParseNetworkPolicyEgressAllowlist("10.0.0.0/8, 192.168.1.0/24,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.0/24"}, allowlist)
+
+	allowlist, err = ParseNetworkPolicyEgressAllowlist("")
+	require.NoError(t, err)
+	assert.Empty(t, allowlist)
+
+	_, err = ParseNetworkPolicyEgressAllowlist("10.0.0.1")
+	assert.Error(t, err)
+}
+
+func TestReconcileNetworkPolicies(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	// A NetworkPolicy created by the namespace owner is never pruned.
+	require.NoError(t, r.Create(context.TODO(), &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: profile.Name},
+	}))
+	policies := func() map[string]networkingv1.NetworkPolicySpec {
+		list := &networkingv1.NetworkPolicyList{}
+		require.NoError(t, r.List(context.TODO(), list, client.InNamespace(profile.Name)))
+		specs := map[string]networkingv1.NetworkPolicySpec{}
+		for _, policy := range list.Items {
+			specs[policy.Name] = policy.Spec
+		}
+		return specs
+	}
+	names := func() []string {
+		var names []string
+		for name := range policies() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	reconcile := func() {
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	// Ingress only, egress is not restricted without allowlist.
+	r.NetworkPolicies = &NetworkPolicies{GatewayNamespace: "istio-system"}
+	reconcile()
+	assert.Equal(t, []string{"manual", BASELINEINGRESSPOLICY}, names())
+	ingress := policies()[BASELINEINGRESSPOLICY]
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, ingress.PolicyTypes)
+	require.Len(t, ingress.Ingress, 1)
+	assert.Equal(t, []networkingv1.NetworkPolicyPeer{
+		{PodSelector: &metav1.LabelSelector{}},
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: "istio-system"}}},
+	}, ingress.Ingress[0].From)
+
+	r.NetworkPolicies.EgressAllowlist = []string{"10.0.0.0/8"}
+	reconcile()
+	assert.Equal(t, []string{"manual", BASELINEEGRESSPOLICY, BASELINEINGRESSPOLICY}, names())
+	egress := policies()[BASELINEEGRESSPOLICY]
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, egress.PolicyTypes)
+	require.Len(t, egress.Egress, 3)
+	assert.Len(t, egress.Egress[1].Ports, 2, "DNS over UDP and TCP")
+	assert.Equal(t, []networkingv1.NetworkPolicyPeer{
+		{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}},
+	}, egress.Egress[2].To)
+
+	// The template replaces the built-in policies.
+	dir, err := ioutil.TempDir("", "network-policies")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "policies.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testNetworkPolicyTemplate), 0644))
+	r.NetworkPolicies.Template, err = LoadNetworkPolicyTemplate(path)
+	require.NoError(t, err)
+	reconcile()
+	assert.Equal(t, []string{"allow-monitoring", "manual"}, names())
+	assert.Equal(t, []networkingv1.NetworkPolicyPeer{
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: "monitoring"}}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}},
+	}, policies()["allow-monitoring"].Ingress[0].From)
+
+	// Disabled, the NetworkPolicies of the controller are deleted.
+	r.NetworkPolicies = nil
+	reconcile()
+	assert.Equal(t, []string{"manual"}, names())
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Name of the Role and RoleBinding granting the profile owner the management of NetworkPolicies.
+const OWNERNETWORKPOLICIES = "owner-network-policies"
+
+// getNetworkPolicyRole returns the Role to create and manage NetworkPolicies. RBAC cannot exclude names from a
+// rule, so the Role covers all NetworkPolicies of the namespace, which is why it is not granted with the baseline
+// NetworkPolicies: owners could delete them or override their deny with an allow-all policy.
+func getNetworkPolicyRole(namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OWNERNETWORKPOLICIES,
+			Namespace: namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"networking.k8s.io"},
+				Resources: []string{"networkpolicies"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			},
+		},
+	}
+}
+
+// updateOwnerNetworkPolicyAccess grants the profile owner the management of NetworkPolicies in target namespace
+// if OwnerNetworkPolicyAccess is enabled and the owner is approved, and removes it otherwise.
+func (r *ProfileReconciler) updateOwnerNetworkPolicyAccess(ctx context.Context, profileIns *profilev1.Profile) error {
+	if !r.OwnerNetworkPolicyAccess || !r.ownerApproved(profileIns) {
+		if err := r.deleteOwnedRoleBinding(ctx, profileIns, OWNERNETWORKPOLICIES); err != nil {
+			return err
+		}
+		return r.deleteOwnedRole(ctx, profileIns, OWNERNETWORKPOLICIES)
+	}
+	if err := r.updateRole(ctx, profileIns, getNetworkPolicyRole(profileNamespace(profileIns))); err != nil {
+		return err
+	}
+	return r.updateRoleBinding(ctx, profileIns, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OWNERNETWORKPOLICIES,
+			Namespace: profileNamespace(profileIns),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     OWNERNETWORKPOLICIES,
+		},
+		Subjects: []rbacv1.Subject{profileIns.Spec.Owner},
+	})
+}
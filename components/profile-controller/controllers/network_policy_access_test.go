@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileOwnerNetworkPolicyAccess(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.OwnerNetworkPolicyAccess = true
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: OWNERNETWORKPOLICIES, Namespace: profile.Name}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	role := &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	require.Len(t, role.Rules, 1)
+	assert.Equal(t, []string{"networking.k8s.io"}, role.Rules[0].APIGroups)
+	assert.Equal(t, []string{"networkpolicies"}, role.Rules[0].Resources)
+	assert.ElementsMatch(t, []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+		role.Rules[0].Verbs)
+	binding := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, binding))
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: OWNERNETWORKPOLICIES},
+		binding.RoleRef)
+	assert.Equal(t, []rbacv1.Subject{profile.Spec.Owner}, binding.Subjects)
+
+	// Drift of the Role is reasserted.
+	role.Rules[0].Verbs = []string{"get"}
+	require.NoError(t, r.Update(context.TODO(), role))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	role = &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	assert.Equal(t, getNetworkPolicyRole(profile.Name).Rules, role.Rules)
+
+	// Owners waiting for approval get no access.
+	r.OwnerApprovalAnnotation = "profile.kubeflow.org/approved"
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Annotations = map[string]string{"profile.kubeflow.org/approved": "true"}
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), key, &rbacv1.RoleBinding{}))
+
+	// Disabling NetworkPolicy access removes the Role and RoleBinding.
+	r.OwnerNetworkPolicyAccess = false
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.Role{})))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
@@ -0,0 +1,363 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/pmezard/go-difflib/difflib"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// observingClient records the changes written through it per profile instead of applying them. Objects
+// written are kept in an overlay served by Get, so a reconcile proceeds as if its changes were applied. List
+// is served by the wrapped client only.
+type observingClient struct {
+	client.Client
+	scheme *runtime.Scheme
+
+	mu      sync.Mutex
+	changes map[string][]string
+	// overlay holds the objects written per profile by description, nil for deleted objects.
+	overlay map[string]map[string]runtime.Object
+}
+
+// ObserveOnly switches the reconciler to observe-only mode: nothing is applied, the changes a reconcile would
+// make are recorded in ConfigMap configMap instead, keyed by profile name. Must be called before the
+// reconciler is started.
+func (r *ProfileReconciler) ObserveOnly(configMap types.NamespacedName) {
+	r.observer = &observingClient{
+		Client:  r.Client,
+		scheme:  r.Scheme,
+		changes: map[string][]string{},
+		overlay: map[string]map[string]runtime.Object{},
+	}
+	r.observeOnlyConfigMap = configMap
+	r.Client = r.observer
+}
+
+// profileOf returns the key the changes of obj are recorded under: the name of obj if it is cluster-scoped, e.g.
+// the profile or its namespace, otherwise the namespace of obj.
+func profileOf(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	if accessor.GetNamespace() != "" {
+		return accessor.GetNamespace()
+	}
+	return accessor.GetName()
+}
+
+// describe returns "<kind> <namespace>/<name>" of obj.
+func (c *observingClient) describe(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return c.kind(obj)
+	}
+	return c.describeKey(obj, types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()})
+}
+
+// describeKey returns "<kind> <namespace>/<name>" of the object of the type of obj with key.
+func (c *observingClient) describeKey(obj runtime.Object, key types.NamespacedName) string {
+	if key.Namespace == "" {
+		return c.kind(obj) + " " + key.Name
+	}
+	return c.kind(obj) + " " + key.Namespace + "/" + key.Name
+}
+
+func (c *observingClient) kind(obj runtime.Object) string {
+	if gvk, err := apiutil.GVKForObject(obj, c.scheme); err == nil {
+		return gvk.Kind
+	}
+	return fmt.Sprintf("%T", obj)
+}
+
+// record records change of obj. Unless obj is nil, it is kept in the overlay, as deleted if deleted is set.
+func (c *observingClient) record(obj runtime.Object, change string, written runtime.Object, deleted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	profile := profileOf(obj)
+	c.changes[profile] = append(c.changes[profile], change)
+	if written == nil {
+		return
+	}
+	if c.overlay[profile] == nil {
+		c.overlay[profile] = map[string]runtime.Object{}
+	}
+	if deleted {
+		c.overlay[profile][c.describe(obj)] = nil
+	} else {
+		c.overlay[profile][c.describe(obj)] = written.DeepCopyObject()
+	}
+}
+
+// take returns and forgets the changes recorded for profile.
+func (c *observingClient) take(profile string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changes := c.changes[profile]
+	delete(c.changes, profile)
+	delete(c.overlay, profile)
+	return changes
+}
+
+// Get serves the objects written through the client from the overlay, other objects from the wrapped client.
+func (c *observingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	profile := key.Namespace
+	if profile == "" {
+		profile = key.Name
+	}
+	c.mu.Lock()
+	written, ok := c.overlay[profile][c.describeKey(obj, key)]
+	c.mu.Unlock()
+	if !ok {
+		return c.Client.Get(ctx, key, obj)
+	}
+	if written == nil {
+		return errors.NewNotFound(schema.GroupResource{Resource: c.kind(obj)}, key.Name)
+	}
+	if reflect.TypeOf(written) == reflect.TypeOf(obj) {
+		reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(written.DeepCopyObject()).Elem())
+		return nil
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		// Typed objects carry no kind, keep the one requested.
+		gvk := u.GroupVersionKind()
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(written)
+		if err != nil {
+			return err
+		}
+		u.SetUnstructuredContent(content)
+		u.SetGroupVersionKind(gvk)
+		return nil
+	}
+	data, err := json.Marshal(written)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, obj)
+}
+
+// diff returns the unified diff of the yaml of the current state of obj and obj.
+func (c *observingClient) diff(ctx context.Context, obj runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	// Read into an empty object, Get merges into the maps of a populated one.
+	current := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+	if u, ok := current.(*unstructured.Unstructured); ok {
+		u.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	}
+	key := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	if err := c.Get(ctx, key, current); err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+	from, err := yaml.Marshal(current)
+	if err != nil {
+		return "", err
+	}
+	to, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(from)),
+		B:        difflib.SplitLines(string(to)),
+		FromFile: "current",
+		ToFile:   "desired",
+		Context:  1,
+	})
+}
+
+func (c *observingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	c.record(obj, "create "+c.describe(obj)+"\n"+string(out), obj, false)
+	return nil
+}
+
+func (c *observingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	diff, err := c.diff(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		return nil
+	}
+	c.record(obj, "update "+c.describe(obj)+"\n"+diff, obj, false)
+	return nil
+}
+
+func (c *observingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	if patch.Type() == types.ApplyPatchType {
+		return c.apply(ctx, obj, data)
+	}
+	c.record(obj, "patch "+c.describe(obj)+"\n"+string(data), nil, false)
+	return nil
+}
+
+// apply records a server-side apply of obj as the create of obj, or as the update of the current object with the
+// applied configuration data decoded into it, which keeps the fields obj does not set like apply does.
+func (c *observingClient) apply(ctx context.Context, obj runtime.Object, data []byte) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	current := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+	key := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	if err := c.Get(ctx, key, current); errors.IsNotFound(err) {
+		return c.Create(ctx, obj)
+	} else if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, current); err != nil {
+		return err
+	}
+	return c.Update(ctx, current)
+}
+
+func (c *observingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	// Deleting a missing object changes nothing.
+	key := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	if err := c.Get(ctx, key, obj.DeepCopyObject()); err != nil {
+		return err
+	}
+	c.record(obj, "delete "+c.describe(obj), obj, true)
+	return nil
+}
+
+func (c *observingClient) DeleteAllOf(ctx context.Context, obj runtime.Object,
+	opts ...client.DeleteAllOfOption) error {
+	c.record(obj, "delete all "+c.describe(obj), nil, false)
+	return nil
+}
+
+func (c *observingClient) Status() client.StatusWriter {
+	return observingStatusWriter{c}
+}
+
+// observingStatusWriter records status updates instead of applying them.
+type observingStatusWriter struct {
+	c *observingClient
+}
+
+func (w observingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	diff, err := w.c.diff(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		return nil
+	}
+	w.c.record(obj, "update status "+w.c.describe(obj)+"\n"+diff, obj, false)
+	return nil
+}
+
+func (w observingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	w.c.record(obj, "patch status "+w.c.describe(obj)+"\n"+string(data), nil, false)
+	return nil
+}
+
+// reportObservedChanges writes the changes observed while reconciling profile into the observe-only
+// ConfigMap, removing the profile from it if nothing would change.
+func (r *ProfileReconciler) reportObservedChanges(profile string) error {
+	ctx := context.Background()
+	// The ConfigMap is written for real, through the wrapped client.
+	c := r.observer.Client
+	// The changes in the profile namespace are recorded under its name, which can differ from the profile name.
+	namespace := profile
+	profileIns := &profilev1.Profile{}
+	if err := c.Get(ctx, types.NamespacedName{Name: profile}, profileIns); err == nil {
+		if _, err := r.resolveNamespace(ctx, profileIns); err != nil {
+			return err
+		}
+		namespace = profileNamespace(profileIns)
+	}
+	changes := r.observer.take(profile)
+	if namespace != profile {
+		changes = append(changes, r.observer.take(namespace)...)
+	}
+	if r.dryRun != nil {
+		r.recordDryRun(profile, changes)
+		return nil
+	}
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, r.observeOnlyConfigMap, configMap)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.observeOnlyConfigMap.Name,
+				Namespace: r.observeOnlyConfigMap.Namespace,
+			},
+			Data: map[string]string{profile: strings.Join(changes, "\n")},
+		}
+		return c.Create(ctx, configMap)
+	}
+	report := strings.Join(changes, "\n")
+	if current, ok := configMap.Data[profile]; ok == (len(changes) > 0) && current == report {
+		return nil
+	}
+	if len(changes) == 0 {
+		delete(configMap.Data, profile)
+	} else {
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[profile] = report
+	}
+	return c.Update(ctx, configMap)
+}
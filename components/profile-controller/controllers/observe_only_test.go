@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileObserveOnly(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        profile.Name,
+		Annotations: map[string]string{"owner": "user1@abcd.com"},
+	}}
+	newProfile := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	r := newFakeReconciler(profile, ns, newProfile)
+	report := types.NamespacedName{Namespace: "kubeflow", Name: "profile-controller-diff"}
+	r.ObserveOnly(report)
+	getReport := func() map[string]string {
+		configMap := &corev1.ConfigMap{}
+		require.NoError(t, r.observer.Client.Get(context.TODO(), report, configMap))
+		return configMap.Data
+	}
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	diff := getReport()[profile.Name]
+	assert.Contains(t, diff, "update Namespace kubeflow-user1\n")
+	assert.Contains(t, diff, "+    katib-metricscollector-injection: enabled\n")
+	assert.Contains(t, diff, "create ServiceAccount kubeflow-user1/default-editor\n")
+	assert.Contains(t, diff, "create RoleBinding kubeflow-user1/namespaceAdmin\n")
+	assert.Contains(t, diff, "update Profile kubeflow-user1\n")
+
+	// Nothing was applied.
+	err = r.Get(context.TODO(), types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name},
+		&corev1.ServiceAccount{})
+	assert.True(t, apierrors.IsNotFound(err))
+	found := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+	assert.NotContains(t, found.Labels, "katib-metricscollector-injection")
+
+	// A new profile is observed as if its namespace was created, the report of other profiles is kept.
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: newProfile.Name}})
+	require.NoError(t, err)
+	data := getReport()
+	assert.Equal(t, diff, data[profile.Name])
+	assert.Contains(t, data[newProfile.Name], "create Namespace kubeflow-user2\n")
+	assert.Contains(t, data[newProfile.Name], "create ServiceAccount kubeflow-user2/default-editor\n")
+	assert.NotContains(t, data[newProfile.Name], "delete PodDefault", "deleting missing objects is no change")
+	err = r.Get(context.TODO(), types.NamespacedName{Name: newProfile.Name}, &corev1.Namespace{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestReportObservedChangesRemovesUnchanged(t *testing.T) {
+	report := types.NamespacedName{Namespace: "kubeflow", Name: "profile-controller-diff"}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: report.Name, Namespace: report.Namespace},
+		Data:       map[string]string{"kubeflow-user1": "create ServiceAccount kubeflow-user1/default-editor"},
+	}
+	r := newFakeReconciler(configMap)
+	r.ObserveOnly(report)
+	require.NoError(t, r.reportObservedChanges("kubeflow-user1"))
+	found := &corev1.ConfigMap{}
+	require.NoError(t, r.Get(context.TODO(), report, found))
+	assert.NotContains(t, found.Data, "kubeflow-user1")
+}
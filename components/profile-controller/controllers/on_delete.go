@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Policies of the -on-delete flag, deciding which user data survives the deletion of a profile.
+const (
+	// ONDELETEDELETE deletes the profile namespace with all its data.
+	ONDELETEDELETE = "delete"
+	// ONDELETERETAINNAMESPACE keeps the profile namespace, only the resources of the profile are deleted.
+	ONDELETERETAINNAMESPACE = "retain-namespace"
+	// ONDELETERETAINPVCS deletes the profile namespace but keeps the PersistentVolumes bound to its claims.
+	ONDELETERETAINPVCS = "retain-pvcs"
+)
+
+// PersistentVolume annotation recording the claim a volume retained by ONDELETERETAINPVCS was bound to.
+const RETAINEDFROMANNOTATION = "profile.kubeflow.org/retained-from"
+
+// ParseOnDeletePolicy parses the -on-delete value, empty for ONDELETEDELETE.
+func ParseOnDeletePolicy(value string) (string, error) {
+	switch value {
+	case "":
+		return ONDELETEDELETE, nil
+	case ONDELETEDELETE, ONDELETERETAINNAMESPACE, ONDELETERETAINPVCS:
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid on-delete policy %q, expected one of %v, %v or %v", value, ONDELETEDELETE,
+		ONDELETERETAINNAMESPACE, ONDELETERETAINPVCS)
+}
+
+// retainsNamespace tells if the namespace of deleted profiles is kept.
+func (r *ProfileReconciler) retainsNamespace() bool {
+	return r.OnDelete == ONDELETERETAINNAMESPACE
+}
+
+// retainUserData applies the OnDelete policy to the profile under deletion, before its resources are deleted.
+func (r *ProfileReconciler) retainUserData(ctx context.Context, profileIns *profilev1.Profile) error {
+	switch r.OnDelete {
+	case ONDELETERETAINNAMESPACE:
+		return r.orphanNamespace(ctx, profileIns)
+	case ONDELETERETAINPVCS:
+		return r.retainPersistentVolumes(ctx, profileIns)
+	}
+	return nil
+}
+
+// orphanNamespace removes the owner reference of the profile from its namespace, so the namespace is not garbage
+// collected with the profile. The owner annotation is kept, a profile of the same owner adopts the namespace
+// again.
+func (r *ProfileReconciler) orphanNamespace(ctx context.Context, profileIns *profilev1.Profile) error {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: profileNamespace(profileIns)}, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	var ownerReferences []metav1.OwnerReference
+	for _, ref := range ns.OwnerReferences {
+		if ref.UID != profileIns.UID {
+			ownerReferences = append(ownerReferences, ref)
+		}
+	}
+	if len(ownerReferences) == len(ns.OwnerReferences) {
+		return nil
+	}
+	r.Log.Info("Retaining namespace of deleted profile", "namespace", ns.Name)
+	// A merge patch replaces the owner references only, applying them would drop the other fields the
+	// controller applied to the namespace.
+	patch := client.MergeFromWithOptions(ns.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	ns.OwnerReferences = ownerReferences
+	return r.Patch(ctx, ns, patch, client.FieldOwner(FIELDMANAGER))
+}
+
+// retainPersistentVolumes sets the reclaim policy of the PersistentVolumes bound to the claims of the profile
+// namespace to Retain, so their data survives the deletion of the namespace. Each volume records the claim it
+// was bound to in RETAINEDFROMANNOTATION.
+func (r *ProfileReconciler) retainPersistentVolumes(ctx context.Context, profileIns *profilev1.Profile) error {
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(profileNamespace(profileIns))); err != nil {
+		return err
+	}
+	for _, claim := range claims.Items {
+		if claim.Spec.VolumeName == "" {
+			continue
+		}
+		pv := &corev1.PersistentVolume{}
+		if err := r.Get(ctx, types.NamespacedName{Name: claim.Spec.VolumeName}, pv); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		retainedFrom := claim.Namespace + "/" + claim.Name
+		if pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain &&
+			pv.Annotations[RETAINEDFROMANNOTATION] == retainedFrom {
+			continue
+		}
+		r.Log.Info("Retaining PersistentVolume of deleted profile", "namespace", profileIns.Name,
+			"claim", claim.Name, "volume", pv.Name)
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		if pv.Annotations == nil {
+			pv.Annotations = map[string]string{}
+		}
+		pv.Annotations[RETAINEDFROMANNOTATION] = retainedFrom
+		if err := r.Update(ctx, pv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseOnDeletePolicy(t *testing.T) {
+	for value, expected := range map[string]string{
+		"":                 ONDELETEDELETE,
+		"delete":           ONDELETEDELETE,
+		"retain-namespace": ONDELETERETAINNAMESPACE,
+		"retain-pvcs":      ONDELETERETAINPVCS,
+	} {
+		policy, err := ParseOnDeletePolicy(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, policy, value)
+	}
+	_, err := ParseOnDeletePolicy("retain")
+	assert.Error(t, err)
+}
+
+func TestOnDeleteRetainNamespace(t *testing.T) {
+	r, recorder := newDeletedProfileReconciler(t, "RoleBinding", "Namespace")
+	r.OnDelete = ONDELETERETAINNAMESPACE
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "kubeflow-user1"}})
+	require.NoError(t, err)
+
+	// The namespace is neither deleted nor garbage collected with the profile, the profile resources are.
+	assert.NotContains(t, recorder.deleted, "Namespace")
+	assert.Contains(t, recorder.deleted, "RoleBinding")
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "kubeflow-user1"}, ns))
+	assert.Empty(t, ns.OwnerReferences)
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["owner"])
+}
+
+func TestOnDeleteRetainPVCs(t *testing.T) {
+	r, recorder := newDeletedProfileReconciler(t, "Namespace")
+	r.OnDelete = ONDELETERETAINPVCS
+	ctx := context.TODO()
+	for _, pv := range []*corev1.PersistentVolume{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-bound"},
+			Spec:       corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-other"},
+			Spec:       corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete},
+		},
+	} {
+		require.NoError(t, r.Create(ctx, pv))
+	}
+	for _, pvc := range []*corev1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "workspace", Namespace: "kubeflow-user1"},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-bound"},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "kubeflow-user1"}},
+	} {
+		require.NoError(t, r.Create(ctx, pvc))
+	}
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "kubeflow-user1"}})
+	require.NoError(t, err)
+
+	assert.Contains(t, recorder.deleted, "Namespace")
+	err = r.Get(ctx, types.NamespacedName{Name: "kubeflow-user1"}, &corev1.Namespace{})
+	assert.True(t, errors.IsNotFound(err))
+	pv := &corev1.PersistentVolume{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: "pv-bound"}, pv))
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, pv.Spec.PersistentVolumeReclaimPolicy)
+	assert.Equal(t, "kubeflow-user1/workspace", pv.Annotations[RETAINEDFROMANNOTATION])
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: "pv-other"}, pv))
+	assert.Equal(t, corev1.PersistentVolumeReclaimDelete, pv.Spec.PersistentVolumeReclaimPolicy)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Platforms the profile namespaces are created on.
+const (
+	// PLATFORMKUBERNETES creates plain Namespaces.
+	PLATFORMKUBERNETES = "kubernetes"
+	// PLATFORMOPENSHIFT requests Projects, so that the OpenShift project lifecycle and RBAC defaults apply.
+	PLATFORMOPENSHIFT = "openshift"
+)
+
+// ProjectRequests are served by the OpenShift API server, handled as unstructured objects here.
+var projectRequestGVK = schema.GroupVersionKind{Group: "project.openshift.io", Version: "v1", Kind: "ProjectRequest"}
+
+// ValidatePlatform checks platform is one of the supported platforms.
+func ValidatePlatform(platform string) error {
+	switch platform {
+	case PLATFORMKUBERNETES, PLATFORMOPENSHIFT:
+		return nil
+	}
+	return fmt.Errorf("unsupported platform %q, expected %v or %v", platform, PLATFORMKUBERNETES,
+		PLATFORMOPENSHIFT)
+}
+
+func newProjectRequest(ns *corev1.Namespace) *unstructured.Unstructured {
+	projectRequest := &unstructured.Unstructured{}
+	projectRequest.SetGroupVersionKind(projectRequestGVK)
+	projectRequest.SetName(ns.Name)
+	projectRequest.Object["displayName"] = ns.Name
+	projectRequest.Object["description"] = "Kubeflow profile of " + ns.Annotations["owner"]
+	return projectRequest
+}
+
+// createNamespace creates the profile namespace ns, on OpenShift through a ProjectRequest.
+func (r *ProfileReconciler) createNamespace(ctx context.Context, ns *corev1.Namespace) error {
+	if r.Platform != PLATFORMOPENSHIFT {
+		return r.Create(ctx, ns)
+	}
+	return r.Create(ctx, newProjectRequest(ns))
+}
+
+// adoptProjectNamespace applies the labels, annotations and controller reference of ns to the namespace
+// OpenShift created for the ProjectRequest, which takes none of them. Nothing is done on other platforms.
+func (r *ProfileReconciler) adoptProjectNamespace(ctx context.Context, ns *corev1.Namespace,
+	found *corev1.Namespace) error {
+	if r.Platform != PLATFORMOPENSHIFT {
+		return nil
+	}
+	applied := ns.DeepCopy()
+	if metav1.GetControllerOf(found) != nil {
+		applied.OwnerReferences = nil
+	}
+	r.Log.Info("Adopting Project namespace", "namespace", found.Name)
+	return r.apply(ctx, applied)
+}
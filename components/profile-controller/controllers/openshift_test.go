@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// projectClient records the kinds of namespace objects created and, like OpenShift, creates a bare namespace
+// for every ProjectRequest.
+type projectClient struct {
+	client.Client
+	created []string
+}
+
+func (c *projectClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	switch o := obj.(type) {
+	case *corev1.Namespace:
+		c.created = append(c.created, "Namespace")
+	case *unstructured.Unstructured:
+		if o.GroupVersionKind() == projectRequestGVK {
+			c.created = append(c.created, "ProjectRequest")
+			return c.Client.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: o.GetName()}})
+		}
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestValidatePlatform(t *testing.T) {
+	assert.NoError(t, ValidatePlatform(PLATFORMKUBERNETES))
+	assert.NoError(t, ValidatePlatform(PLATFORMOPENSHIFT))
+	assert.Error(t, ValidatePlatform("nomad"))
+}
+
+func TestReconcilePlatform(t *testing.T) {
+	for _, tc := range []struct {
+		platform string
+		created  []string
+	}{
+		{platform: "", created: []string{"Namespace"}},
+		{platform: PLATFORMKUBERNETES, created: []string{"Namespace"}},
+		{platform: PLATFORMOPENSHIFT, created: []string{"ProjectRequest"}},
+	} {
+		t.Run(tc.platform, func(t *testing.T) {
+			profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+			r := newFakeReconciler(profile)
+			c := &projectClient{Client: r.Client}
+			r.Client = c
+			r.Platform = tc.platform
+
+			_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+			require.NoError(t, err)
+			assert.Equal(t, tc.created, c.created)
+
+			// The rest of the reconcile targets the resulting namespace.
+			ns := &corev1.Namespace{}
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+			assert.Equal(t, "user1@abcd.com", ns.Annotations["owner"])
+			assert.Equal(t, "enabled", ns.Labels[istioInjectionLabel])
+			require.NotNil(t, metav1.GetControllerOf(ns))
+			assert.Equal(t, profile.Name, metav1.GetControllerOf(ns).Name)
+			sa := &corev1.ServiceAccount{}
+			assert.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: DEFAULT_EDITOR,
+				Namespace: profile.Name}, sa))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+)
+
+// Condition type set while the owner access of the profile waits for the OwnerApprovalAnnotation.
+const OwnerApprovalPending = "OwnerApprovalPending"
+
+// ownerApproved returns true if the profile owner may be granted access to the namespace, i.e. approval is
+// disabled or the profile is annotated with OwnerApprovalAnnotation "true".
+func (r *ProfileReconciler) ownerApproved(profileIns *profilev1.Profile) bool {
+	return r.OwnerApprovalAnnotation == "" || profileIns.Annotations[r.OwnerApprovalAnnotation] == "true"
+}
+
+// updateOwnerApproval sets the OwnerApprovalPending condition of the profile and returns whether the owner is
+// approved. The condition is only written when it changes.
+func (r *ProfileReconciler) updateOwnerApproval(ctx context.Context, profileIns *profilev1.Profile) (bool, error) {
+	approved := r.ownerApproved(profileIns)
+	status, message := "False", "owner access approved"
+	if !approved {
+		status, message = "True", fmt.Sprintf("owner access waits for the approval annotation %v: \"true\"",
+			r.OwnerApprovalAnnotation)
+	}
+	for _, condition := range profileIns.Status.Conditions {
+		if condition.Type == OwnerApprovalPending {
+			if condition.Status == status && condition.Message == message {
+				return approved, nil
+			}
+			return approved, r.writeOwnerApproval(ctx, profileIns, status, message)
+		}
+	}
+	if approved {
+		// Profiles which never waited for approval get no condition.
+		return true, nil
+	}
+	return false, r.writeOwnerApproval(ctx, profileIns, status, message)
+}
+
+func (r *ProfileReconciler) writeOwnerApproval(ctx context.Context, profileIns *profilev1.Profile, status string,
+	message string) error {
+	r.Log.Info("Updating owner approval", "profile", profileIns.Name, "pending", status)
+	r.setProfileCondition(profileIns, OwnerApprovalPending, status, message)
+	return r.Status().Update(ctx, profileIns)
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileOwnerApproval(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.OwnerApprovalAnnotation = "profile.kubeflow.org/approved"
+	r.OwnerScaleAccess = true
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getCondition := func() (profilev1.ProfileCondition, bool) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		for _, condition := range found.Status.Conditions {
+			if condition.Type == OwnerApprovalPending {
+				return condition, true
+			}
+		}
+		return profilev1.ProfileCondition{}, false
+	}
+	getBinding := func(name string) error {
+		return r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: profile.Name}, &rbacv1.RoleBinding{})
+	}
+	setApproved := func(value string) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		found.Annotations = map[string]string{"profile.kubeflow.org/approved": value}
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	// Pending: the namespace is created without owner access.
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	condition, ok := getCondition()
+	require.True(t, ok)
+	assert.Equal(t, "True", condition.Status)
+	assert.Contains(t, condition.Message, "profile.kubeflow.org/approved")
+	assert.True(t, errors.IsNotFound(getBinding("namespaceAdmin")))
+	assert.True(t, errors.IsNotFound(getBinding(OWNERSCALE)))
+	assert.NoError(t, getBinding(DEFAULT_EDITOR))
+
+	setApproved("false")
+	assert.True(t, errors.IsNotFound(getBinding("namespaceAdmin")))
+
+	// Approved: the owner is bound.
+	setApproved("true")
+	condition, _ = getCondition()
+	assert.Equal(t, "False", condition.Status)
+	assert.NoError(t, getBinding("namespaceAdmin"))
+	assert.NoError(t, getBinding(OWNERSCALE))
+
+	// Withdrawn approval revokes the owner access.
+	setApproved("")
+	condition, _ = getCondition()
+	assert.Equal(t, "True", condition.Status)
+	assert.True(t, errors.IsNotFound(getBinding("namespaceAdmin")))
+	assert.True(t, errors.IsNotFound(getBinding(OWNERSCALE)))
+}
+
+func TestReconcileOwnerApprovalDisabled(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	for _, condition := range found.Status.Conditions {
+		assert.NotEqual(t, OwnerApprovalPending, condition.Type)
+	}
+	assert.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "namespaceAdmin", Namespace: profile.Name},
+		&rbacv1.RoleBinding{}))
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Name of the RoleBinding granting the profile owner admin access to the profile namespace.
+const OWNERBINDING = "namespaceAdmin"
+
+// getOwnerRoleBinding returns the RoleBinding granting the profile owner admin access to the profile namespace.
+// When ClusterRole was referred by namespaced roleBinding, the result permission will be namespaced as well.
+func getOwnerRoleBinding(profileIns *profilev1.Profile) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{USER: profileIns.Spec.Owner.Name, ROLE: ADMIN},
+			Name:        OWNERBINDING,
+			Namespace:   profileNamespace(profileIns),
+		},
+		// Use default ClusterRole 'admin' for profile/namespace owner
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     kubeflowAdmin,
+		},
+		Subjects: []rbacv1.Subject{
+			profileIns.Spec.Owner,
+		},
+	}
+}
+
+// updateOwnerRoleBinding grants the profile owner admin access to target namespace. When the owner changed, the
+// binding of the previous owner is deleted before the binding of the new owner is created, so the previous owner
+// keeps no access, neither directly nor through kfam which reads the USER annotation.
+func (r *ProfileReconciler) updateOwnerRoleBinding(ctx context.Context, profileIns *profilev1.Profile) error {
+	roleBinding := getOwnerRoleBinding(profileIns)
+	found := &rbacv1.RoleBinding{}
+	err := r.Get(ctx, types.NamespacedName{Name: roleBinding.Name, Namespace: roleBinding.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && (found.Annotations[USER] != profileIns.Spec.Owner.Name ||
+		!reflect.DeepEqual(found.Subjects, roleBinding.Subjects)) {
+		r.Log.Info("Revoking RoleBinding of previous owner", "namespace", found.Namespace, "name", found.Name,
+			"previousOwner", found.Annotations[USER], "owner", profileIns.Spec.Owner.Name)
+		if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return r.updateRoleBinding(ctx, profileIns, roleBinding)
+}
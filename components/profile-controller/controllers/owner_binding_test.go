@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileOwnerChange(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.OwnerScaleAccess = true
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	// ownerBindings returns the RoleBindings granting admin access, keyed by name.
+	ownerBindings := func() map[string]rbacv1.RoleBinding {
+		list := &rbacv1.RoleBindingList{}
+		require.NoError(t, r.List(context.TODO(), list, client.InNamespace(profile.Name)))
+		bindings := map[string]rbacv1.RoleBinding{}
+		for _, rb := range list.Items {
+			if rb.RoleRef.Name == kubeflowAdmin {
+				bindings[rb.Name] = rb
+			}
+		}
+		return bindings
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	require.Len(t, ownerBindings(), 1)
+
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Spec.Owner.Name = "user2@abcd.com"
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+
+	bindings := ownerBindings()
+	require.Len(t, bindings, 1, "exactly one owner binding remains")
+	rb := bindings[OWNERBINDING]
+	assert.Equal(t, []rbacv1.Subject{{Kind: "User", Name: "user2@abcd.com"}}, rb.Subjects)
+	assert.Equal(t, "user2@abcd.com", rb.Annotations[USER])
+
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "user2@abcd.com", ns.Annotations["owner"])
+	scale := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: OWNERSCALE, Namespace: profile.Name}, scale))
+	assert.Equal(t, []rbacv1.Subject{{Kind: "User", Name: "user2@abcd.com"}}, scale.Subjects)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+)
+
+// Condition type set while the reconciliation of the profile is paused with spec.paused.
+const ProfilePaused = "Paused"
+
+// updatePaused sets the Paused condition of the profile from spec.paused and returns whether the profile is
+// paused. The condition is only written when it changes, profiles which were never paused get none.
+func (r *ProfileReconciler) updatePaused(ctx context.Context, profileIns *profilev1.Profile) (bool, error) {
+	paused := profileIns.Spec.Paused
+	status, message := "False", "reconciliation resumed"
+	if paused {
+		status, message = "True", "reconciliation paused with spec.paused, no changes are made to the profile resources"
+	}
+	for _, condition := range profileIns.Status.Conditions {
+		if condition.Type == ProfilePaused {
+			if condition.Status == status {
+				return paused, nil
+			}
+			return paused, r.writePaused(ctx, profileIns, status, message)
+		}
+	}
+	if !paused {
+		return false, nil
+	}
+	return true, r.writePaused(ctx, profileIns, status, message)
+}
+
+func (r *ProfileReconciler) writePaused(ctx context.Context, profileIns *profilev1.Profile, status string,
+	message string) error {
+	r.Log.Info("Updating paused condition", "profile", profileIns.Name, "paused", status)
+	r.setProfileCondition(profileIns, ProfilePaused, status, message)
+	return r.Status().Update(ctx, profileIns)
+}
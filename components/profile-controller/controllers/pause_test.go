@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcilePaused(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getLabel := func() string {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns.Labels["katib-metricscollector-injection"]
+	}
+	getCondition := func() profilev1.ProfileCondition {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		for _, condition := range found.Status.Conditions {
+			if condition.Type == ProfilePaused {
+				return condition
+			}
+		}
+		return profilev1.ProfileCondition{}
+	}
+	setPaused := func(paused bool) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		found.Spec.Paused = paused
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "enabled", getLabel())
+	assert.Empty(t, getCondition().Type, "profiles which were never paused get no condition")
+
+	setPaused(true)
+	assert.Equal(t, "True", getCondition().Status)
+
+	// Drift is not corrected while paused.
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	delete(ns.Labels, "katib-metricscollector-injection")
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Empty(t, getLabel())
+
+	// Resuming reasserts the desired state.
+	setPaused(false)
+	assert.Equal(t, "False", getCondition().Status)
+	assert.Equal(t, "enabled", getLabel())
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Name of the RoleBinding granting the notebook controller access to profile namespaces.
+const NOTEBOOKCONTROLLERBINDING = "notebook-controller"
+
+// Name of the RoleBinding granting the chaos-engineering tool access to opted-in profile namespaces.
+const CHAOSBINDING = "chaos-engineering"
+
+// Name of the RoleBinding granting the backup operator access to profile namespaces.
+const BACKUPBINDING = "backup-operator"
+
+// Profile annotation opting the profile namespace into chaos testing when set to "true".
+const CHAOSOPTIN = "profile.kubeflow.org/chaos-engineering"
+
+// PlatformBinding binds a platform service account, e.g. of an operator running in the kubeflow namespace,
+// to a ClusterRole in every profile namespace.
+type PlatformBinding struct {
+	ServiceAccount rbacv1.Subject
+	ClusterRole    string
+}
+
+// ParsePlatformBinding parses a service account "namespace/name" bound to clusterRole.
+func ParsePlatformBinding(serviceAccount string, clusterRole string) (*PlatformBinding, error) {
+	parts := strings.Split(serviceAccount, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid service account %q, expected namespace/name", serviceAccount)
+	}
+	if clusterRole == "" {
+		return nil, fmt.Errorf("missing ClusterRole for service account %v", serviceAccount)
+	}
+	return &PlatformBinding{
+		ServiceAccount: rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: parts[0], Name: parts[1]},
+		ClusterRole:    clusterRole,
+	}, nil
+}
+
+// updatePlatformBinding creates or updates RoleBinding "name" for binding in the profile namespace. If binding
+// is nil the RoleBinding is deleted, provided it is owned by the profile.
+func (r *ProfileReconciler) updatePlatformBinding(ctx context.Context, profileIns *profilev1.Profile, name string,
+	binding *PlatformBinding) error {
+	if binding == nil {
+		return r.deleteOwnedRoleBinding(ctx, profileIns, name)
+	}
+	return r.updateRoleBinding(ctx, profileIns, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: profileNamespace(profileIns),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     binding.ClusterRole,
+		},
+		Subjects: []rbacv1.Subject{binding.ServiceAccount},
+	})
+}
+
+// deleteOwnedRoleBinding deletes RoleBinding "name" in the profile namespace if it is controlled by the profile.
+func (r *ProfileReconciler) deleteOwnedRoleBinding(ctx context.Context, profileIns *profilev1.Profile,
+	name string) error {
+	found := &rbacv1.RoleBinding{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: profileNamespace(profileIns)}, found); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(found, profileIns) {
+		return nil
+	}
+	r.Log.Info("Deleting RoleBinding", "namespace", profileIns.Name, "name", name)
+	if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// chaosBinding returns the ChaosBinding if the profile opted into chaos testing with the CHAOSOPTIN annotation,
+// nil otherwise.
+func (r *ProfileReconciler) chaosBinding(profileIns *profilev1.Profile) *PlatformBinding {
+	if profileIns.Annotations[CHAOSOPTIN] != "true" {
+		return nil
+	}
+	return r.ChaosBinding
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParsePlatformBinding(t *testing.T) {
+	binding, err := ParsePlatformBinding("kubeflow/notebook-controller-service-account", kubeflowEdit)
+	require.NoError(t, err)
+	assert.Equal(t, rbacv1.Subject{
+		Kind:      rbacv1.ServiceAccountKind,
+		Namespace: "kubeflow",
+		Name:      "notebook-controller-service-account",
+	}, binding.ServiceAccount)
+	assert.Equal(t, kubeflowEdit, binding.ClusterRole)
+
+	for _, sa := range []string{"notebook-controller", "kubeflow/", "/notebook-controller", "a/b/c"} {
+		_, err = ParsePlatformBinding(sa, kubeflowEdit)
+		assert.Error(t, err, sa)
+	}
+	_, err = ParsePlatformBinding("kubeflow/notebook-controller", "")
+	assert.Error(t, err)
+}
+
+func TestReconcileNotebookControllerBinding(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	binding, err := ParsePlatformBinding("kubeflow/notebook-controller-service-account", kubeflowEdit)
+	require.NoError(t, err)
+	r.NotebookControllerBinding = binding
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: NOTEBOOKCONTROLLERBINDING, Namespace: profile.Name}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, rb))
+	assert.Equal(t, kubeflowEdit, rb.RoleRef.Name)
+	assert.Equal(t, []rbacv1.Subject{binding.ServiceAccount}, rb.Subjects)
+
+	// Binding disabled, the RoleBinding is cleaned up.
+	r.NotebookControllerBinding = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
+
+func TestReconcileBackupBinding(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	binding, err := ParsePlatformBinding("velero/velero", "backup-reader")
+	require.NoError(t, err)
+	r.BackupBinding = binding
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: BACKUPBINDING, Namespace: profile.Name}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, rb))
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "backup-reader"}, rb.RoleRef)
+	assert.Equal(t, []rbacv1.Subject{binding.ServiceAccount}, rb.Subjects)
+	assert.True(t, metav1.IsControlledBy(rb, profile))
+
+	// Changed role is applied.
+	r.BackupBinding = &PlatformBinding{ServiceAccount: binding.ServiceAccount, ClusterRole: "backup-admin"}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	rb = &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, rb))
+	assert.Equal(t, "backup-admin", rb.RoleRef.Name)
+
+	// Binding disabled, the RoleBinding is cleaned up.
+	r.BackupBinding = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+
+	// A backup service account needs a role.
+	_, err = ParsePlatformBinding("velero/velero", "")
+	assert.Error(t, err)
+}
+
+func TestReconcileChaosBinding(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	binding, err := ParsePlatformBinding("chaos/chaos-runner", "chaos-runner")
+	require.NoError(t, err)
+	r.ChaosBinding = binding
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: CHAOSBINDING, Namespace: profile.Name}
+	setOptIn := func(value string) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		found.Annotations = map[string]string{CHAOSOPTIN: value}
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	// Not opted in.
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+
+	setOptIn("true")
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, rb))
+	assert.Equal(t, "chaos-runner", rb.RoleRef.Name)
+	assert.Equal(t, []rbacv1.Subject{binding.ServiceAccount}, rb.Subjects)
+
+	// Opted out, the RoleBinding is cleaned up.
+	setOptIn("false")
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+
+	// Opted in without a chaos service account configured.
+	r.ChaosBinding = nil
+	setOptIn("true")
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
+
+func TestDeleteOwnedRoleBindingKeepsForeign(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	// Created by someone else, not owned by the profile.
+	foreign := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: NOTEBOOKCONTROLLERBINDING, Namespace: profile.Name},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: kubeflowView},
+	}
+	r := newFakeReconciler(profile, foreign)
+
+	require.NoError(t, r.updatePlatformBinding(context.TODO(), profile, NOTEBOOKCONTROLLERBINDING, nil))
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: NOTEBOOKCONTROLLERBINDING, Namespace: profile.Name}, foreign))
+}
@@ -30,28 +30,28 @@ type AwsIAMForServiceAccount struct {
 }
 
 // ApplyPlugin annotate service account with the ARN of the IAM role and update trust relationship of IAM role
-func (aws *AwsIAMForServiceAccount) ApplyPlugin(r *ProfileReconciler, profile *profilev1.Profile) error {
+func (aws *AwsIAMForServiceAccount) ApplyPlugin(ctx context.Context, r *ProfileReconciler, profile *profilev1.Profile) error {
 	logger := r.Log.WithValues("profile", profile.Name)
-	if err := aws.patchAnnotation(r, profile.Name, DEFAULT_EDITOR, addIAMRoleAnnotation, logger); err != nil {
+	if err := aws.patchAnnotation(ctx, r, profileNamespace(profile), DEFAULT_EDITOR, addIAMRoleAnnotation, logger); err != nil {
 		return err
 	}
 	logger.Info("Setting up iam roles and policy for service account.", "ServiceAccount", aws.AwsIAMRole)
-	return aws.updateIAMForServiceAccount(profile.Name, DEFAULT_EDITOR, addServiceAccountInAssumeRolePolicy)
+	return aws.updateIAMForServiceAccount(profileNamespace(profile), DEFAULT_EDITOR, addServiceAccountInAssumeRolePolicy)
 }
 
 // RevokePlugin remove role in service account annotation and delete service account record in IAM trust relationship.
-func (aws *AwsIAMForServiceAccount) RevokePlugin(r *ProfileReconciler, profile *profilev1.Profile) error {
+func (aws *AwsIAMForServiceAccount) RevokePlugin(ctx context.Context, r *ProfileReconciler, profile *profilev1.Profile) error {
 	logger := r.Log.WithValues("profile", profile.Name)
-	if err := aws.patchAnnotation(r, profile.Name, DEFAULT_EDITOR, removeIAMRoleAnnotation, logger); err != nil {
+	if err := aws.patchAnnotation(ctx, r, profileNamespace(profile), DEFAULT_EDITOR, removeIAMRoleAnnotation, logger); err != nil {
 		return err
 	}
 	logger.Info("Clean up AWS IAM Role for Service Account.", "ServiceAccount", aws.AwsIAMRole)
-	return aws.updateIAMForServiceAccount(profile.Name, DEFAULT_EDITOR, removeServiceAccountInAssumeRolePolicy)
+	return aws.updateIAMForServiceAccount(profileNamespace(profile), DEFAULT_EDITOR, removeServiceAccountInAssumeRolePolicy)
 }
 
 // patchAnnotation will patch annotation to k8s service account in order to pair up with GCP identity
-func (aws *AwsIAMForServiceAccount) patchAnnotation(r *ProfileReconciler, namespace string, ksa string, annotationFunc func(*corev1.ServiceAccount, string), logger logr.Logger) error {
-	ctx := context.Background()
+func (aws *AwsIAMForServiceAccount) patchAnnotation(ctx context.Context, r *ProfileReconciler, namespace string,
+	ksa string, annotationFunc func(*corev1.ServiceAccount, string), logger logr.Logger) error {
 	found := &corev1.ServiceAccount{}
 	err := r.Get(ctx, types.NamespacedName{Name: ksa, Namespace: namespace}, found)
 	if err != nil {
@@ -35,19 +35,128 @@ const GCP_ANNOTATION_KEY = "iam.gke.io/gcp-service-account"
 const GCP_SA_SUFFIX = ".iam.gserviceaccount.com"
 const WORKLOAD_IDENTITY_ROLE = "roles/iam.workloadIdentityUser"
 
+// Condition type reporting whether the workload identity binding of the profile was verified.
+const WORKLOAD_IDENTITY_READY = "WorkloadIdentityReady"
+
+// IAMPolicyClient reads IAM policies of GCP service accounts.
+type IAMPolicyClient interface {
+	// GetServiceAccountPolicy returns the IAM policy of the service account resource
+	// projects/<project>/serviceAccounts/<email>.
+	GetServiceAccountPolicy(ctx context.Context, resource string) (*iam.Policy, error)
+	// IdentityProject returns the project of the default credentials, empty if unknown.
+	IdentityProject(ctx context.Context) string
+}
+
+// gcpIAMClient is the IAMPolicyClient using the default GCP credentials.
+type gcpIAMClient struct{}
+
+func (c gcpIAMClient) GetServiceAccountPolicy(ctx context.Context, resource string) (*iam.Policy, error) {
+	client, err := google.DefaultClient(ctx, iam.CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	iamService, err := iam.New(client)
+	if err != nil {
+		return nil, err
+	}
+	return iamService.Projects.ServiceAccounts.GetIamPolicy(resource).Context(ctx).Do()
+}
+
+func (c gcpIAMClient) IdentityProject(ctx context.Context) string {
+	credentials, err := google.FindDefaultCredentials(ctx, iam.CloudPlatformScope)
+	if err != nil {
+		return ""
+	}
+	return credentials.ProjectID
+}
+
 // GcpWorkloadIdentity: plugin that setup GKE workload identity (credentials for GCP API) for target profile namespace.
 type GcpWorkloadIdentity struct {
 	GcpServiceAccount string `json:"gcpServiceAccount,omitempty"`
 }
 
 // ApplyPlugin will grant GCP workload identity to service account DEFAULT_EDITOR
-func (gcp *GcpWorkloadIdentity) ApplyPlugin(r *ProfileReconciler, profile *profilev1.Profile) error {
+func (gcp *GcpWorkloadIdentity) ApplyPlugin(ctx context.Context, r *ProfileReconciler, profile *profilev1.Profile) error {
 	logger := r.Log.WithValues("profile", profile.Name)
-	if err := gcp.patchAnnotation(r, profile.Name, DEFAULT_EDITOR, logger); err != nil {
+	if err := gcp.patchAnnotation(ctx, r, profileNamespace(profile), DEFAULT_EDITOR, logger); err != nil {
 		return err
 	}
 	logger.Info("Setting up iam policy.", "ServiceAccount", gcp.GcpServiceAccount)
-	return gcp.updateWorkloadIdentity(profile.Name, DEFAULT_EDITOR, addBinding)
+	if err := gcp.updateWorkloadIdentity(ctx, profileNamespace(profile), DEFAULT_EDITOR, addBinding); err != nil {
+		return err
+	}
+	if r.VerifyWorkloadIdentity {
+		return gcp.VerifyWorkloadIdentity(ctx, r, profile)
+	}
+	return nil
+}
+
+// VerifyWorkloadIdentity checks that service account DEFAULT_EDITOR is bound to GcpServiceAccount with
+// WORKLOAD_IDENTITY_ROLE and reports the result in condition WORKLOAD_IDENTITY_READY of the profile.
+func (gcp *GcpWorkloadIdentity) VerifyWorkloadIdentity(ctx context.Context, r *ProfileReconciler,
+	profile *profilev1.Profile) error {
+	status, message := "True", fmt.Sprintf("%v is bound to %v", DEFAULT_EDITOR, gcp.GcpServiceAccount)
+	if bound, err := gcp.hasWorkloadIdentityBinding(ctx, r.iamClient(), profileNamespace(profile), DEFAULT_EDITOR); err != nil {
+		status, message = "Unknown", fmt.Sprintf("unable to verify workload identity binding: %v", err)
+	} else if !bound {
+		status, message = "False", fmt.Sprintf("%v is missing %v binding for %v", gcp.GcpServiceAccount,
+			WORKLOAD_IDENTITY_ROLE, DEFAULT_EDITOR)
+	}
+	r.setProfileCondition(profile, WORKLOAD_IDENTITY_READY, status, message)
+	return r.Status().Update(ctx, profile)
+}
+
+// hasWorkloadIdentityBinding reports whether the IAM policy of GcpServiceAccount binds ksa with WORKLOAD_IDENTITY_ROLE.
+func (gcp *GcpWorkloadIdentity) hasWorkloadIdentityBinding(ctx context.Context, client IAMPolicyClient,
+	namespace string, ksa string) (bool, error) {
+	projectID, err := gcp.GetProjectID()
+	if err != nil {
+		return false, err
+	}
+	policy, err := client.GetServiceAccountPolicy(ctx,
+		fmt.Sprintf("projects/%v/serviceAccounts/%v", projectID, gcp.GcpServiceAccount))
+	if err != nil {
+		return false, err
+	}
+	ksaProjectID := client.IdentityProject(ctx)
+	if ksaProjectID == "" {
+		ksaProjectID = projectID
+	}
+	member := workloadIdentityMember(ksaProjectID, namespace, ksa)
+	for _, binding := range policy.Bindings {
+		if binding.Role == WORKLOAD_IDENTITY_ROLE && containsString(binding.Members, member) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// workloadIdentityMember returns the IAM member of kubernetes service account ksa in namespace.
+func workloadIdentityMember(projectID string, namespace string, ksa string) string {
+	return fmt.Sprintf("serviceAccount:%v.svc.id.goog[%v/%v]", projectID, namespace, ksa)
+}
+
+// gcpServiceAccountPattern matches the email of a user-managed GCP service account,
+// "<name>@<project>.iam.gserviceaccount.com".
+var gcpServiceAccountPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]@[a-z][a-z0-9-]{4,28}[a-z0-9]` +
+	regexp.QuoteMeta(GCP_SA_SUFFIX) + `$`)
+
+// validateGcpServiceAccount checks gsa is the email of a GCP service account.
+func validateGcpServiceAccount(gsa string) error {
+	if !gcpServiceAccountPattern.MatchString(gsa) {
+		return fmt.Errorf("gcpServiceAccount %q is not a GCP service account email, "+
+			"expected <name>@<project>%v", gsa, GCP_SA_SUFFIX)
+	}
+	return nil
+}
+
+// workloadIdentityServiceAccount returns the GCP service account of the profile, spec.gcpServiceAccount if set
+// and the -workload-identity default otherwise.
+func (r *ProfileReconciler) workloadIdentityServiceAccount(profileIns *profilev1.Profile) string {
+	if profileIns.Spec.GcpServiceAccount != "" {
+		return profileIns.Spec.GcpServiceAccount
+	}
+	return r.WorkloadIdentity
 }
 
 // GetProjectID will return GCP project id of GcpServiceAccount. Will return empty string if cannot parse GcpServiceAccount
@@ -65,8 +174,8 @@ func (gcp *GcpWorkloadIdentity) GetProjectID() (string, error) {
 }
 
 // patchAnnotation will patch annotation to k8s service account in order to pair up with GCP identity
-func (gcp *GcpWorkloadIdentity) patchAnnotation(r *ProfileReconciler, namespace string, ksa string, logger logr.Logger) error {
-	ctx := context.Background()
+func (gcp *GcpWorkloadIdentity) patchAnnotation(ctx context.Context, r *ProfileReconciler, namespace string,
+	ksa string, logger logr.Logger) error {
 	found := &corev1.ServiceAccount{}
 	err := r.Get(ctx, types.NamespacedName{Name: ksa, Namespace: namespace}, found)
 	if err != nil {
@@ -82,12 +191,12 @@ func (gcp *GcpWorkloadIdentity) patchAnnotation(r *ProfileReconciler, namespace
 }
 
 // updateWorkloadIdentity update GCP service account IAM binding with provided binding update function f
-func (gcp *GcpWorkloadIdentity) updateWorkloadIdentity(namespace string, ksa string, f func(*iam.Policy, string)) error {
+func (gcp *GcpWorkloadIdentity) updateWorkloadIdentity(ctx context.Context, namespace string, ksa string,
+	f func(*iam.Policy, string)) error {
 	projectID, err := gcp.GetProjectID()
 	if err != nil {
 		return err
 	}
-	ctx := context.Background()
 	gcpSa := gcp.GcpServiceAccount
 	// Get client.
 	client, err := google.DefaultClient(ctx, iam.CloudPlatformScope)
@@ -120,7 +229,7 @@ func (gcp *GcpWorkloadIdentity) updateWorkloadIdentity(namespace string, ksa str
 	if ksaProjectID == "" {
 		ksaProjectID = projectID
 	}
-	bindingMember := fmt.Sprintf("serviceAccount:%v.svc.id.goog[%v/%v]", ksaProjectID, namespace, ksa)
+	bindingMember := workloadIdentityMember(ksaProjectID, namespace, ksa)
 	f(currentPolicy, bindingMember)
 
 	// Set iam policy
@@ -153,8 +262,8 @@ func revokeBinding(currentPolicy *iam.Policy, member string) {
 }
 
 // RevokePlugin: undo changes made by ApplyPlugin.
-func (gcp *GcpWorkloadIdentity) RevokePlugin(r *ProfileReconciler, profile *profilev1.Profile) error {
+func (gcp *GcpWorkloadIdentity) RevokePlugin(ctx context.Context, r *ProfileReconciler, profile *profilev1.Profile) error {
 	logger := r.Log.WithValues("profile", profile.Name)
 	logger.Info("Clean up Gcp Workload Identity.", "ServiceAccount", gcp.GcpServiceAccount)
-	return gcp.updateWorkloadIdentity(profile.Name, DEFAULT_EDITOR, revokeBinding)
+	return gcp.updateWorkloadIdentity(ctx, profileNamespace(profile), DEFAULT_EDITOR, revokeBinding)
 }
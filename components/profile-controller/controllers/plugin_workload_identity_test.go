@@ -17,11 +17,23 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/onsi/gomega"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/api/iam/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"reflect"
 	"testing"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
 )
 
 func TestGetProjectID(t *testing.T) {
@@ -132,3 +144,166 @@ func TestRevokeBinding(t *testing.T) {
 		}
 	}
 }
+
+// fakeIAMClient returns a fixed policy for the expected service account resource.
+type fakeIAMClient struct {
+	resource string
+	policy   *iam.Policy
+	project  string
+	err      error
+}
+
+func (c *fakeIAMClient) GetServiceAccountPolicy(ctx context.Context, resource string) (*iam.Policy, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if resource != c.resource {
+		return nil, fmt.Errorf("unexpected resource %v", resource)
+	}
+	return c.policy, nil
+}
+
+func (c *fakeIAMClient) IdentityProject(ctx context.Context) string {
+	return c.project
+}
+
+func TestVerifyWorkloadIdentity(t *testing.T) {
+	resource := "projects/project-id/serviceAccounts/kubeflow@project-id.iam.gserviceaccount.com"
+	tests := []struct {
+		name           string
+		client         *fakeIAMClient
+		expectedStatus string
+	}{
+		{
+			name: "binding exists",
+			client: &fakeIAMClient{
+				resource: resource,
+				policy: &iam.Policy{Bindings: []*iam.Binding{{
+					Role:    WORKLOAD_IDENTITY_ROLE,
+					Members: []string{"serviceAccount:project-id.svc.id.goog[kubeflow-user1/default-editor]"},
+				}}},
+			},
+			expectedStatus: "True",
+		},
+		{
+			name: "binding in identity project of the credentials",
+			client: &fakeIAMClient{
+				resource: resource,
+				project:  "cluster-project",
+				policy: &iam.Policy{Bindings: []*iam.Binding{{
+					Role:    WORKLOAD_IDENTITY_ROLE,
+					Members: []string{"serviceAccount:cluster-project.svc.id.goog[kubeflow-user1/default-editor]"},
+				}}},
+			},
+			expectedStatus: "True",
+		},
+		{
+			name: "binding missing",
+			client: &fakeIAMClient{
+				resource: resource,
+				policy: &iam.Policy{Bindings: []*iam.Binding{{
+					Role:    "roles/viewer",
+					Members: []string{"serviceAccount:project-id.svc.id.goog[kubeflow-user1/default-editor]"},
+				}}},
+			},
+			expectedStatus: "False",
+		},
+		{
+			name:           "IAM API error",
+			client:         &fakeIAMClient{err: errors.New("permission denied")},
+			expectedStatus: "Unknown",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newFakeReconciler(newTestProfile("kubeflow-user1", "user1@abcd.com"))
+			r.IAMClient = test.client
+			profile := &profilev1.Profile{}
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "kubeflow-user1"}, profile))
+
+			gcp := &GcpWorkloadIdentity{GcpServiceAccount: "kubeflow@project-id.iam.gserviceaccount.com"}
+			require.NoError(t, gcp.VerifyWorkloadIdentity(context.TODO(), r, profile))
+
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "kubeflow-user1"}, profile))
+			require.Len(t, profile.Status.Conditions, 1)
+			assert.Equal(t, WORKLOAD_IDENTITY_READY, profile.Status.Conditions[0].Type)
+			assert.Equal(t, test.expectedStatus, profile.Status.Conditions[0].Status)
+		})
+	}
+}
+
+func TestValidateGcpServiceAccount(t *testing.T) {
+	assert.NoError(t, validateGcpServiceAccount("team-a@project-id.iam.gserviceaccount.com"))
+	for _, gsa := range []string{
+		"team-a",
+		"team-a@project-id",
+		"team-a@gmail.com",
+		"Team-A@project-id.iam.gserviceaccount.com",
+		"team-a@project-id.iam.gserviceaccount.com.evil",
+	} {
+		assert.Error(t, validateGcpServiceAccount(gsa), gsa)
+	}
+}
+
+func TestPatchDefaultPluginSpecGcpServiceAccount(t *testing.T) {
+	override := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	override.Spec.GcpServiceAccount = "team-a@project-id.iam.gserviceaccount.com"
+	fallback := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	r := newFakeReconciler(override, fallback)
+	r.WorkloadIdentity = "kubeflow@project-id.iam.gserviceaccount.com"
+
+	for _, test := range []struct {
+		profile     *profilev1.Profile
+		expectedGSA string
+	}{
+		{override, "team-a@project-id.iam.gserviceaccount.com"},
+		{fallback, "kubeflow@project-id.iam.gserviceaccount.com"},
+	} {
+		t.Run(test.profile.Name, func(t *testing.T) {
+			profile := &profilev1.Profile{}
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: test.profile.Name}, profile))
+			require.NoError(t, r.PatchDefaultPluginSpec(context.TODO(), profile))
+			plugins, err := r.GetPluginSpec(profile)
+			require.NoError(t, err)
+			require.Len(t, plugins, 1)
+			gcp := plugins[0].(*GcpWorkloadIdentity)
+			assert.Equal(t, test.expectedGSA, gcp.GcpServiceAccount)
+
+			// The ServiceAccount is annotated with the GCP service account of the profile.
+			sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: DEFAULT_EDITOR, Namespace: profile.Name}}
+			require.NoError(t, r.Create(context.TODO(), sa))
+			require.NoError(t, gcp.patchAnnotation(context.TODO(), r, profile.Name, DEFAULT_EDITOR, r.Log))
+			sa = &corev1.ServiceAccount{}
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name}, sa))
+			assert.Equal(t, test.expectedGSA, sa.Annotations[GCP_ANNOTATION_KEY])
+		})
+	}
+
+	// A changed spec.gcpServiceAccount replaces the one of the patched plugin.
+	profile := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: override.Name}, profile))
+	profile.Spec.GcpServiceAccount = "team-b@project-id.iam.gserviceaccount.com"
+	require.NoError(t, r.PatchDefaultPluginSpec(context.TODO(), profile))
+	plugins, err := r.GetPluginSpec(profile)
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "team-b@project-id.iam.gserviceaccount.com", plugins[0].(*GcpWorkloadIdentity).GcpServiceAccount)
+}
+
+func TestReconcileInvalidGcpServiceAccount(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.GcpServiceAccount = "team-a"
+	r := newFakeReconciler(profile)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, profile))
+	// Marked not ready, then failed.
+	require.Len(t, profile.Status.Conditions, 2)
+	assert.Equal(t, ProfileReady, profile.Status.Conditions[0].Type)
+	assert.Equal(t, "False", profile.Status.Conditions[0].Status)
+	assert.Equal(t, profilev1.ProfileFailed, profile.Status.Conditions[1].Type)
+	assert.Contains(t, profile.Status.Conditions[1].Message, "not a GCP service account email")
+	err = r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, &corev1.Namespace{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
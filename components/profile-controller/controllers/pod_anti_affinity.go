@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Name of the PodDefault spreading the pods labeled with it set to "true" apart from each other.
+const ANTIAFFINITYPODDEFAULT = "default-pod-anti-affinity"
+
+// Modes of the -pod-anti-affinity terms.
+const (
+	ANTIAFFINITYREQUIRED  = "required"
+	ANTIAFFINITYPREFERRED = "preferred"
+)
+
+// Weight of the preferred anti-affinity terms, they are the only scheduling preference the PodDefault adds.
+const antiAffinityWeight = 100
+
+// ParsePodAntiAffinity parses the -pod-anti-affinity value: comma separated "<mode>:<topologyKey>" terms, mode
+// being "required" or "preferred", e.g. "preferred:kubernetes.io/hostname". Every term keeps the pods labeled
+// with the ANTIAFFINITYPODDEFAULT PodDefault apart in the topology domain. An empty value returns nil.
+func ParsePodAntiAffinity(value string) (*corev1.PodAntiAffinity, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	antiAffinity := &corev1.PodAntiAffinity{}
+	seen := map[string]bool{}
+	for _, term := range strings.Split(value, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		modeKey := strings.SplitN(term, ":", 2)
+		if len(modeKey) != 2 {
+			return nil, fmt.Errorf("invalid pod anti-affinity term %q, expected <mode>:<topologyKey>", term)
+		}
+		mode, topologyKey := strings.TrimSpace(modeKey[0]), strings.TrimSpace(modeKey[1])
+		if seen[mode+":"+topologyKey] {
+			return nil, fmt.Errorf("duplicate pod anti-affinity term %q", term)
+		}
+		seen[mode+":"+topologyKey] = true
+		affinityTerm := corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{ANTIAFFINITYPODDEFAULT: "true"}},
+			TopologyKey:   topologyKey,
+		}
+		switch mode {
+		case ANTIAFFINITYREQUIRED:
+			antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+				antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, affinityTerm)
+		case ANTIAFFINITYPREFERRED:
+			antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+				antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+				corev1.WeightedPodAffinityTerm{Weight: antiAffinityWeight, PodAffinityTerm: affinityTerm})
+		default:
+			return nil, fmt.Errorf("invalid mode %q of pod anti-affinity term %q, expected %v or %v", mode, term,
+				ANTIAFFINITYREQUIRED, ANTIAFFINITYPREFERRED)
+		}
+	}
+	if err := ValidatePodAntiAffinity(antiAffinity); err != nil {
+		return nil, err
+	}
+	return antiAffinity, nil
+}
+
+// ValidatePodAntiAffinity checks antiAffinity against the PodAntiAffinity schema the API server enforces on pods,
+// so an invalid PodDefault does not fail the creation of every pod it selects.
+func ValidatePodAntiAffinity(antiAffinity *corev1.PodAntiAffinity) error {
+	if len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) == 0 &&
+		len(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) == 0 {
+		return fmt.Errorf("pod anti-affinity has no terms")
+	}
+	for _, term := range antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		if err := validatePodAffinityTerm(term); err != nil {
+			return err
+		}
+	}
+	for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if term.Weight < 1 || term.Weight > 100 {
+			return fmt.Errorf("invalid weight %d of pod anti-affinity term %v, expected 1 to 100", term.Weight,
+				term.PodAffinityTerm.TopologyKey)
+		}
+		if err := validatePodAffinityTerm(term.PodAffinityTerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePodAffinityTerm(term corev1.PodAffinityTerm) error {
+	if term.TopologyKey == "" {
+		return fmt.Errorf("pod anti-affinity term has no topology key")
+	}
+	if errs := validation.IsQualifiedName(term.TopologyKey); len(errs) > 0 {
+		return fmt.Errorf("invalid pod anti-affinity topology key %q: %v", term.TopologyKey, strings.Join(errs, ", "))
+	}
+	if term.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(term.LabelSelector); err != nil {
+			return fmt.Errorf("invalid label selector of pod anti-affinity term %v: %v", term.TopologyKey, err)
+		}
+	}
+	return nil
+}
+
+// getAntiAffinityPodDefault returns the PodDefault adding the PodAntiAffinity to the pods of the namespace labeled
+// with its name, nil if no PodAntiAffinity is configured.
+func (r *ProfileReconciler) getAntiAffinityPodDefault(profileIns *profilev1.Profile) (*unstructured.Unstructured, error) {
+	if r.PodAntiAffinity == nil {
+		return nil, nil
+	}
+	affinity, err := runtime.DefaultUnstructuredConverter.ToUnstructured(
+		&corev1.Affinity{PodAntiAffinity: r.PodAntiAffinity})
+	if err != nil {
+		return nil, err
+	}
+	return newPodDefault(profileNamespace(profileIns), ANTIAFFINITYPODDEFAULT, map[string]interface{}{
+		"desc": "Spread the pods apart from each other",
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{ANTIAFFINITYPODDEFAULT: "true"},
+		},
+		"affinity": affinity,
+	}), nil
+}
+
+// updateAntiAffinityPodDefault reconciles the PodDefault for the configured PodAntiAffinity.
+func (r *ProfileReconciler) updateAntiAffinityPodDefault(ctx context.Context, profileIns *profilev1.Profile) error {
+	podDefault, err := r.getAntiAffinityPodDefault(profileIns)
+	if err != nil {
+		return err
+	}
+	if podDefault == nil {
+		return r.deletePodDefault(ctx, profileIns, ANTIAFFINITYPODDEFAULT)
+	}
+	return r.updatePodDefault(ctx, profileIns, podDefault)
+}
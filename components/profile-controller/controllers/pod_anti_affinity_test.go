@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParsePodAntiAffinity(t *testing.T) {
+	antiAffinity, err := ParsePodAntiAffinity("preferred:kubernetes.io/hostname, required:topology.kubernetes.io/zone")
+	require.NoError(t, err)
+	require.Len(t, antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	preferred := antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0]
+	assert.Equal(t, int32(100), preferred.Weight)
+	assert.Equal(t, "kubernetes.io/hostname", preferred.PodAffinityTerm.TopologyKey)
+	assert.Equal(t, map[string]string{ANTIAFFINITYPODDEFAULT: "true"}, preferred.PodAffinityTerm.LabelSelector.MatchLabels)
+	require.Len(t, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	assert.Equal(t, "topology.kubernetes.io/zone", antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey)
+
+	antiAffinity, err = ParsePodAntiAffinity("")
+	require.NoError(t, err)
+	assert.Nil(t, antiAffinity)
+
+	for _, value := range []string{
+		"kubernetes.io/hostname",
+		"spread:kubernetes.io/hostname",
+		"preferred:",
+		"preferred:not a key",
+		"preferred:kubernetes.io/hostname,preferred:kubernetes.io/hostname",
+		",",
+	} {
+		_, err = ParsePodAntiAffinity(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestValidatePodAntiAffinity(t *testing.T) {
+	assert.Error(t, ValidatePodAntiAffinity(&corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+			{Weight: 101, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+		},
+	}))
+	assert.NoError(t, ValidatePodAntiAffinity(&corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+			{TopologyKey: "kubernetes.io/hostname"},
+		},
+	}))
+}
+
+func TestReconcileAntiAffinityPodDefault(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	antiAffinity, err := ParsePodAntiAffinity("preferred:kubernetes.io/hostname")
+	require.NoError(t, err)
+	r.PodAntiAffinity = antiAffinity
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getPodDefault := func() (*unstructured.Unstructured, error) {
+		pd := &unstructured.Unstructured{}
+		pd.SetGroupVersionKind(podDefaultGVK)
+		err := r.Get(context.TODO(), types.NamespacedName{Name: ANTIAFFINITYPODDEFAULT, Namespace: profile.Name}, pd)
+		return pd, err
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	pd, err := getPodDefault()
+	require.NoError(t, err)
+	selector, _, _ := unstructured.NestedStringMap(pd.Object, "spec", "selector", "matchLabels")
+	assert.Equal(t, map[string]string{ANTIAFFINITYPODDEFAULT: "true"}, selector)
+	affinityObject, found, _ := unstructured.NestedMap(pd.Object, "spec", "affinity")
+	require.True(t, found)
+	affinity := &corev1.Affinity{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(affinityObject, affinity))
+	assert.Equal(t, antiAffinity, affinity.PodAntiAffinity)
+	assert.Nil(t, affinity.NodeAffinity)
+
+	// Kept by the pruning of the configured PodDefaults.
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	_, err = getPodDefault()
+	require.NoError(t, err)
+
+	// Anti-affinity disabled, the PodDefault is deleted.
+	r.PodAntiAffinity = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	_, err = getPodDefault()
+	assert.True(t, errors.IsNotFound(err))
+}
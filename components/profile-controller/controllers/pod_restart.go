@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Name of the Role and RoleBinding granting the profile owner the restart of pods.
+const OWNERPODRESTART = "owner-pod-restart"
+
+// getPodRestartRole returns the Role to restart pods by deleting them, their controllers recreate them. It grants
+// nothing on the workloads themselves.
+func getPodRestartRole(namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OWNERPODRESTART,
+			Namespace: namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get", "list", "delete"},
+			},
+		},
+	}
+}
+
+// updateOwnerPodRestartAccess grants the profile owner the restart of pods in target namespace if
+// OwnerPodRestartAccess is enabled and the owner is approved, and removes it otherwise.
+func (r *ProfileReconciler) updateOwnerPodRestartAccess(ctx context.Context, profileIns *profilev1.Profile) error {
+	if !r.OwnerPodRestartAccess || !r.ownerApproved(profileIns) {
+		if err := r.deleteOwnedRoleBinding(ctx, profileIns, OWNERPODRESTART); err != nil {
+			return err
+		}
+		return r.deleteOwnedRole(ctx, profileIns, OWNERPODRESTART)
+	}
+	if err := r.updateRole(ctx, profileIns, getPodRestartRole(profileNamespace(profileIns))); err != nil {
+		return err
+	}
+	return r.updateRoleBinding(ctx, profileIns, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OWNERPODRESTART,
+			Namespace: profileNamespace(profileIns),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     OWNERPODRESTART,
+		},
+		Subjects: []rbacv1.Subject{profileIns.Spec.Owner},
+	})
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileOwnerPodRestartAccess(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.OwnerPodRestartAccess = true
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: OWNERPODRESTART, Namespace: profile.Name}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	role := &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	require.Len(t, role.Rules, 1)
+	assert.Equal(t, []string{""}, role.Rules[0].APIGroups)
+	assert.Equal(t, []string{"pods"}, role.Rules[0].Resources)
+	assert.ElementsMatch(t, []string{"get", "list", "delete"}, role.Rules[0].Verbs)
+	binding := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, binding))
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: OWNERPODRESTART}, binding.RoleRef)
+	assert.Equal(t, []rbacv1.Subject{profile.Spec.Owner}, binding.Subjects)
+
+	// Broadened Role is reasserted to the restart-only rules.
+	role.Rules[0].Verbs = append(role.Rules[0].Verbs, "update", "patch")
+	require.NoError(t, r.Update(context.TODO(), role))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	role = &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	assert.Equal(t, getPodRestartRole(profile.Name).Rules, role.Rules)
+
+	// Owners waiting for approval get no access.
+	r.OwnerApprovalAnnotation = "profile.kubeflow.org/approved"
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+	r.OwnerApprovalAnnotation = ""
+
+	// Disabling restart access removes the Role and RoleBinding.
+	r.OwnerPodRestartAccess = false
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.Role{})))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
@@ -0,0 +1,512 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// PodDefaults are served by the admission-webhook component, handled as unstructured objects here.
+var podDefaultGVK = schema.GroupVersionKind{Group: "kubeflow.org", Version: "v1alpha1", Kind: "PodDefault"}
+
+// Profile annotation selecting the priority class of pods in the profile namespace without one.
+const DEFAULTPRIORITYCLASS = "profile.kubeflow.org/default-priority-class"
+
+// Name of the PodDefault applying the default priority class.
+const PRIORITYPODDEFAULT = "default-priority-class"
+
+// Labels of every PodDefault created by the controller, PodDefaults without them are never pruned.
+const (
+	MANAGEDBYLABEL = "app.kubernetes.io/managed-by"
+	MANAGEDBYVALUE = "profile-controller"
+	PROFILELABEL   = "profile.kubeflow.org/profile"
+)
+
+func newPodDefault(namespace string, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	pd := &unstructured.Unstructured{}
+	pd.SetGroupVersionKind(podDefaultGVK)
+	pd.SetNamespace(namespace)
+	pd.SetName(name)
+	pd.Object["spec"] = spec
+	return pd
+}
+
+// getPriorityPodDefault returns the PodDefault setting the profile's default priority class on all pods
+// of the namespace, nil if the profile has no default priority class.
+func getPriorityPodDefault(profileIns *profilev1.Profile) *unstructured.Unstructured {
+	priorityClassName := profileIns.Annotations[DEFAULTPRIORITYCLASS]
+	if priorityClassName == "" {
+		return nil
+	}
+	return newPodDefault(profileNamespace(profileIns), PRIORITYPODDEFAULT, map[string]interface{}{
+		"desc": "Default priority class of the profile",
+		// Empty selector matches all pods.
+		"selector":          map[string]interface{}{},
+		"priorityClassName": priorityClassName,
+	})
+}
+
+// ParsePodDefaultLabels parses the -poddefault-labels value: comma separated key=value labels set on every
+// PodDefault the controller creates. Values can be double quoted like in the -pd value.
+func ParsePodDefaultLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	pairs, err := splitQuoted(value, ',')
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv, err := splitQuotedN(pair, '=', 2)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid PodDefault label %q, expected key=value", pair)
+		}
+		if key == MANAGEDBYLABEL || key == PROFILELABEL {
+			return nil, fmt.Errorf("PodDefault label %v is set by the controller", key)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid PodDefault label key %q: %v", key, strings.Join(errs, ", "))
+		}
+		labelValue, err := unquote(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of PodDefault label %v: %v", key, err)
+		}
+		if errs := validation.IsValidLabelValue(labelValue); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value of PodDefault label %v: %v", key, strings.Join(errs, ", "))
+		}
+		labels[key] = labelValue
+	}
+	return labels, nil
+}
+
+// podDefaultLabels returns the labels of the PodDefaults created in the profile namespace: the PodDefaultLabels
+// and the ownership labels.
+func (r *ProfileReconciler) podDefaultLabels(profileIns *profilev1.Profile) map[string]string {
+	labels := make(map[string]string, len(r.PodDefaultLabels)+2)
+	for k, v := range r.PodDefaultLabels {
+		labels[k] = v
+	}
+	labels[MANAGEDBYLABEL] = MANAGEDBYVALUE
+	labels[PROFILELABEL] = profileIns.Name
+	return labels
+}
+
+// isManagedPodDefault tells if podDefault was created by the controller for the profile.
+func isManagedPodDefault(profileIns *profilev1.Profile, podDefault metav1.Object) bool {
+	labels := podDefault.GetLabels()
+	return labels[MANAGEDBYLABEL] == MANAGEDBYVALUE && labels[PROFILELABEL] == profileIns.Name
+}
+
+// updatePodDefault create or update PodDefault "podDefault" in target namespace owned by "profileIns"
+func (r *ProfileReconciler) updatePodDefault(ctx context.Context, profileIns *profilev1.Profile,
+	podDefault *unstructured.Unstructured) error {
+	logger := r.Log.WithValues("profile", profileIns.Name)
+	if err := controllerutil.SetControllerReference(profileIns, podDefault, r.Scheme); err != nil {
+		return err
+	}
+	podDefault.SetLabels(r.podDefaultLabels(profileIns))
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(podDefaultGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: podDefault.GetName(), Namespace: podDefault.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Creating PodDefault", "namespace", podDefault.GetNamespace(), "name", podDefault.GetName())
+			return r.Create(ctx, podDefault)
+		}
+		return err
+	}
+	refUpdated, err := r.setMissingControllerReference(profileIns, found)
+	if err != nil {
+		return err
+	}
+	labels := found.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labelsUpdated := false
+	for k, v := range podDefault.GetLabels() {
+		if current, ok := labels[k]; !ok || current != v {
+			labels[k] = v
+			labelsUpdated = true
+		}
+	}
+	if refUpdated || labelsUpdated || !reflect.DeepEqual(podDefault.Object["spec"], found.Object["spec"]) {
+		found.Object["spec"] = podDefault.Object["spec"]
+		found.SetLabels(labels)
+		logger.Info("Updating PodDefault", "namespace", podDefault.GetNamespace(), "name", podDefault.GetName())
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// deletePodDefault deletes PodDefault "name" in target namespace if it was created by the controller.
+// PodDefaults created before the ownership labels are recognized by their controller reference.
+func (r *ProfileReconciler) deletePodDefault(ctx context.Context, profileIns *profilev1.Profile, name string) error {
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(podDefaultGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: profileNamespace(profileIns)}, found); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !isManagedPodDefault(profileIns, found) && !metav1.IsControlledBy(found, profileIns) {
+		return nil
+	}
+	r.Log.Info("Deleting PodDefault", "namespace", profileIns.Name, "name", name)
+	if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// updatePriorityPodDefault reconciles the PodDefault for the profile's default priority class.
+func (r *ProfileReconciler) updatePriorityPodDefault(ctx context.Context, profileIns *profilev1.Profile) error {
+	podDefault := getPriorityPodDefault(profileIns)
+	if podDefault == nil {
+		return r.deletePodDefault(ctx, profileIns, PRIORITYPODDEFAULT)
+	}
+	return r.updatePodDefault(ctx, profileIns, podDefault)
+}
+
+// PodDefaults maps the names of PodDefaults created in every profile namespace to their fields. A PodDefault
+// applies to pods labeled with its name set to "true". Fields are paths into the PodDefault spec:
+// "desc", "serviceAccountName", "priorityClassName", "env.<NAME>", "labels.<key>" or "annotations.<key>".
+type PodDefaults map[string]map[string]string
+
+// ParsePodDefaults parses the -pd value: PodDefaults separated by ";", each "<name>:<field>=<value>,...", e.g.
+//
+//	add-gcp-secret:desc=Add GCP credentials,env.GOOGLE_APPLICATION_CREDENTIALS=/secret/gcp/user-gcp-sa.json
+//
+// Values can be double quoted to contain separators, e.g. desc="Mount data, read only".
+func ParsePodDefaults(value string) (PodDefaults, error) {
+	podDefaults, errs := ParsePodDefaultsSkipInvalid(value)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return podDefaults, nil
+}
+
+// ParsePodDefaultsSkipInvalid parses the -pd value like ParsePodDefaults, but skips invalid entries and returns
+// their errors next to the valid PodDefaults. Every invalid entry counts as a PodDefault parse error.
+func ParsePodDefaultsSkipInvalid(value string) (PodDefaults, []error) {
+	podDefaults := PodDefaults{}
+	entries, err := splitQuoted(value, ';')
+	if err != nil {
+		podDefaultParseErrors.Inc()
+		return podDefaults, []error{err}
+	}
+	var errs []error
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, fields, err := parsePodDefault(entry)
+		if err == nil {
+			if _, ok := podDefaults[name]; ok {
+				err = fmt.Errorf("duplicate PodDefault %v", name)
+			}
+		}
+		if err != nil {
+			podDefaultParseErrors.Inc()
+			errs = append(errs, err)
+			continue
+		}
+		podDefaults[name] = fields
+	}
+	return podDefaults, errs
+}
+
+// parsePodDefault parses a single "<name>:<field>=<value>,..." entry of the -pd value.
+func parsePodDefault(entry string) (string, map[string]string, error) {
+	nameFields, err := splitQuotedN(strings.TrimSpace(entry), ':', 2)
+	if err != nil {
+		return "", nil, err
+	}
+	name := strings.TrimSpace(nameFields[0])
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", nil, fmt.Errorf("invalid PodDefault name %q: %v", name, strings.Join(errs, ", "))
+	}
+	fields := map[string]string{}
+	if len(nameFields) == 2 {
+		pairs, err := splitQuoted(nameFields[1], ',')
+		if err != nil {
+			return "", nil, err
+		}
+		for _, pair := range pairs {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			kv, err := splitQuotedN(pair, '=', 2)
+			if err != nil {
+				return "", nil, err
+			}
+			field := strings.TrimSpace(kv[0])
+			if len(kv) != 2 || field == "" {
+				return "", nil, fmt.Errorf("invalid field %q of PodDefault %v, expected field=value", pair, name)
+			}
+			fieldValue, err := unquote(strings.TrimSpace(kv[1]))
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid value of field %v of PodDefault %v: %v", field, name, err)
+			}
+			fields[field] = fieldValue
+		}
+	}
+	return name, fields, nil
+}
+
+// splitQuoted splits value on sep outside of double quoted strings.
+func splitQuoted(value string, sep byte) ([]string, error) {
+	return splitQuotedN(value, sep, -1)
+}
+
+// splitQuotedN splits value on sep outside of double quoted strings into at most n parts, n < 0 means all.
+func splitQuotedN(value string, sep byte, n int) ([]string, error) {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch {
+		case quoted && value[i] == '\\':
+			i++
+		case value[i] == '"':
+			quoted = !quoted
+		case value[i] == sep && !quoted && (n < 0 || len(parts) < n-1):
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", value)
+	}
+	return append(parts, value[start:]), nil
+}
+
+// unquote returns value without surrounding double quotes, if any.
+func unquote(value string) (string, error) {
+	if strings.HasPrefix(value, `"`) {
+		return strconv.Unquote(value)
+	}
+	return value, nil
+}
+
+// podDefaultSpec builds the spec of PodDefault name from its fields.
+func podDefaultSpec(name string, fields map[string]string) map[string]interface{} {
+	spec := map[string]interface{}{
+		"desc": name,
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{name: "true"},
+		},
+	}
+	var env []corev1.EnvVar
+	for path, value := range fields {
+		field := strings.SplitN(path, ".", 2)
+		switch {
+		case field[0] == "env" && len(field) == 2:
+			env = append(env, corev1.EnvVar{Name: field[1], Value: value})
+		case len(field) == 2:
+			nested, _ := spec[field[0]].(map[string]interface{})
+			if nested == nil {
+				nested = map[string]interface{}{}
+				spec[field[0]] = nested
+			}
+			nested[field[1]] = value
+		default:
+			spec[path] = value
+		}
+	}
+	if len(env) > 0 {
+		sort.Slice(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+		envList := make([]interface{}, 0, len(env))
+		for _, e := range env {
+			envList = append(envList, map[string]interface{}{"name": e.Name, "value": e.Value})
+		}
+		spec["env"] = envList
+	}
+	return spec
+}
+
+// podDefaultFields are the PodDefault spec fields settable with -pd, true for map fields set as "<field>.<key>".
+// The other PodDefault spec fields, e.g. volumes or tolerations, cannot be expressed as -pd values.
+var podDefaultFields = map[string]bool{
+	"desc":               false,
+	"serviceAccountName": false,
+	"priorityClassName":  false,
+	"env":                true,
+	"labels":             true,
+	"annotations":        true,
+}
+
+// ValidateFields checks the fields of every PodDefault against the PodDefault spec, e.g. rejecting "lables.team"
+// which would silently have no effect. It returns one error per invalid field, in name and field order.
+func (p PodDefaults) ValidateFields() []error {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		paths := make([]string, 0, len(p[name]))
+		for path := range p[name] {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			field := strings.SplitN(path, ".", 2)
+			isMap, ok := podDefaultFields[field[0]]
+			switch {
+			case !ok:
+				errs = append(errs, fmt.Errorf("unknown field %q of PodDefault %v, expected one of %v", path, name,
+					strings.Join(knownPodDefaultFields(), ", ")))
+			case isMap && (len(field) != 2 || field[1] == ""):
+				errs = append(errs, fmt.Errorf("field %q of PodDefault %v expects a key, e.g. %v.<key>", path, name,
+					field[0]))
+			case !isMap && len(field) == 2:
+				errs = append(errs, fmt.Errorf("field %q of PodDefault %v takes no key, use %v", path, name, field[0]))
+			}
+		}
+	}
+	return errs
+}
+
+// knownPodDefaultFields returns the -pd fields in the format of their paths, sorted.
+func knownPodDefaultFields() []string {
+	fields := make([]string, 0, len(podDefaultFields))
+	for field, isMap := range podDefaultFields {
+		if isMap {
+			field += ".<key>"
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Specs returns the spec of every PodDefault by name, as created in profile namespaces.
+func (p PodDefaults) Specs() map[string]map[string]interface{} {
+	specs := make(map[string]map[string]interface{}, len(p))
+	for name, fields := range p {
+		specs[name] = podDefaultSpec(name, fields)
+	}
+	return specs
+}
+
+// updateConfiguredPodDefaults creates or updates the PodDefaults configured with -pd or -pd-configmap in the
+// profile namespace, and prunes the PodDefaults the controller created which are no longer configured.
+func (r *ProfileReconciler) updateConfiguredPodDefaults(ctx context.Context, profileIns *profilev1.Profile) error {
+	podDefaults, err := r.configuredPodDefaults(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(podDefaults))
+	for name := range podDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		podDefault := newPodDefault(profileNamespace(profileIns), name, podDefaultSpec(name, podDefaults[name]))
+		if err := r.updatePodDefault(ctx, profileIns, podDefault); err != nil {
+			return err
+		}
+		podDefaultsApplied.WithLabelValues(profileIns.Name).Inc()
+	}
+	return r.pruneConfiguredPodDefaults(ctx, profileIns, podDefaults)
+}
+
+// pruneConfiguredPodDefaults deletes the PodDefaults bearing the ownership labels of the profile which are not
+// in podDefaults. The priority, anti-affinity and cost labels PodDefaults are reconciled on their own and kept.
+func (r *ProfileReconciler) pruneConfiguredPodDefaults(ctx context.Context, profileIns *profilev1.Profile,
+	podDefaults PodDefaults) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(podDefaultGVK.GroupVersion().WithKind(podDefaultGVK.Kind + "List"))
+	if err := r.List(ctx, list, client.InNamespace(profileNamespace(profileIns)),
+		client.MatchingLabels{MANAGEDBYLABEL: MANAGEDBYVALUE, PROFILELABEL: profileIns.Name}); err != nil {
+		if len(podDefaults) == 0 && meta.IsNoMatchError(err) {
+			// Clusters without PodDefaults have nothing to prune.
+			return nil
+		}
+		return err
+	}
+	for i := range list.Items {
+		name := list.Items[i].GetName()
+		if _, ok := podDefaults[name]; ok || name == PRIORITYPODDEFAULT || name == ANTIAFFINITYPODDEFAULT ||
+			name == COSTLABELSPODDEFAULT {
+			continue
+		}
+		r.Log.Info("Deleting PodDefault no longer configured", "namespace", profileIns.Name, "name", name)
+		if err := r.Delete(ctx, &list.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResyncPodDefaults reasserts the configured PodDefaults in the namespaces of all existing profiles, so a changed
+// -pd value propagates without touching every Profile. Profiles and namespaces being deleted are skipped.
+func (r *ProfileReconciler) ResyncPodDefaults(ctx context.Context) error {
+	profiles := &profilev1.ProfileList{}
+	if err := r.List(ctx, profiles); err != nil {
+		return err
+	}
+	var errs []error
+	for i := range profiles.Items {
+		profileIns := &profiles.Items[i]
+		if !r.managesProfile(profileIns.Labels) || !profileIns.DeletionTimestamp.IsZero() {
+			continue
+		}
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: profileNamespace(profileIns)}, ns); err != nil {
+			if !errors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if isNamespaceTerminating(ns) {
+			continue
+		}
+		if err := r.updateConfiguredPodDefaults(ctx, profileIns); err != nil {
+			r.Log.Error(err, "error resyncing PodDefaults", "namespace", profileIns.Name)
+			IncRequestErrorCounter("error resyncing PodDefaults", SEVERITY_MINOR)
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// podDefaultsCache holds the PodDefaults parsed from the PodDefaultsConfigMap at resourceVersion.
+type podDefaultsCache struct {
+	mu              sync.Mutex
+	resourceVersion string
+	podDefaults     PodDefaults
+}
+
+// parsePodDefaultsConfigMap parses the data of a PodDefaults ConfigMap. Every value holds PodDefaults in the -pd
+// grammar, the values are joined in key order.
+func parsePodDefaultsConfigMap(data map[string]string) (PodDefaults, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, data[key])
+	}
+	return ParsePodDefaults(strings.Join(values, ";"))
+}
+
+// configuredPodDefaults returns the PodDefaults read from PodDefaultsConfigMap if set, PodDefaults otherwise.
+// A ConfigMap is only parsed again once its resourceVersion changed.
+func (r *ProfileReconciler) configuredPodDefaults(ctx context.Context) (PodDefaults, error) {
+	if r.PodDefaultsConfigMap.Name == "" {
+		return r.PodDefaults, nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, r.PodDefaultsConfigMap, configMap); err != nil {
+		return nil, fmt.Errorf("error reading PodDefaults ConfigMap %v: %v", r.PodDefaultsConfigMap, err)
+	}
+	r.podDefaultsCache.mu.Lock()
+	defer r.podDefaultsCache.mu.Unlock()
+	if r.podDefaultsCache.podDefaults != nil && r.podDefaultsCache.resourceVersion == configMap.ResourceVersion {
+		return r.podDefaultsCache.podDefaults, nil
+	}
+	podDefaults, err := parsePodDefaultsConfigMap(configMap.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PodDefaults ConfigMap %v: %v", r.PodDefaultsConfigMap, err)
+	}
+	r.Log.Info("Loaded PodDefaults", "configmap", r.PodDefaultsConfigMap, "count", len(podDefaults))
+	r.podDefaultsCache.resourceVersion = configMap.ResourceVersion
+	r.podDefaultsCache.podDefaults = podDefaults
+	return podDefaults, nil
+}
+
+// podDefaultsConfigMapToProfiles maps the PodDefaults ConfigMap to all profiles managed by the controller, so a
+// changed ConfigMap is applied to every profile namespace.
+func (r *ProfileReconciler) podDefaultsConfigMapToProfiles() *handler.EnqueueRequestsFromMapFunc {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			key := types.NamespacedName{Namespace: obj.Meta.GetNamespace(), Name: obj.Meta.GetName()}
+			if key != r.PodDefaultsConfigMap {
+				return nil
+			}
+			profiles := &profilev1.ProfileList{}
+			if err := r.List(context.Background(), profiles); err != nil {
+				r.Log.Error(err, "error listing profiles for PodDefaults ConfigMap", "configmap", key)
+				IncRequestErrorCounter("error listing profiles for PodDefaults ConfigMap", SEVERITY_MINOR)
+				return nil
+			}
+			var requests []reconcile.Request
+			for _, profileIns := range profiles.Items {
+				if r.managesProfile(profileIns.Labels) {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: profileIns.Name}})
+				}
+			}
+			return requests
+		}),
+	}
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestParsePodDefaultsConfigMapData(t *testing.T) {
+	podDefaults, err := parsePodDefaultsConfigMap(map[string]string{
+		"proxy":   "add-proxy:env.HTTP_PROXY=http://proxy:3128",
+		"secrets": "add-gcp-secret:desc=Add GCP credentials;add-aws-secret:desc=Add AWS credentials",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PodDefaults{
+		"add-proxy":      {"env.HTTP_PROXY": "http://proxy:3128"},
+		"add-gcp-secret": {"desc": "Add GCP credentials"},
+		"add-aws-secret": {"desc": "Add AWS credentials"},
+	}, podDefaults)
+
+	_, err = parsePodDefaultsConfigMap(map[string]string{
+		"a": "add-proxy:env.HTTP_PROXY=http://proxy:3128",
+		"b": "add-proxy:env.HTTP_PROXY=http://other:3128",
+	})
+	assert.Error(t, err, "duplicate PodDefaults across keys")
+}
+
+func TestReconcilePodDefaultsConfigMap(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "profile-poddefaults", Namespace: "kubeflow"},
+		Data:       map[string]string{"proxy": "add-proxy:env.HTTP_PROXY=http://proxy:3128"},
+	}
+	r := newFakeReconciler(profile, configMap)
+	r.PodDefaultsConfigMap = types.NamespacedName{Namespace: configMap.Namespace, Name: configMap.Name}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getEnv := func() []interface{} {
+		pd := &unstructured.Unstructured{}
+		pd.SetGroupVersionKind(podDefaultGVK)
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "add-proxy", Namespace: profile.Name}, pd))
+		env, _, _ := unstructured.NestedSlice(pd.Object, "spec", "env")
+		return env
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "HTTP_PROXY", "value": "http://proxy:3128"}}, getEnv())
+
+	// An update of the ConfigMap enqueues all profiles and is applied.
+	require.NoError(t, r.Get(context.TODO(), r.PodDefaultsConfigMap, configMap))
+	configMap.Data["proxy"] = "add-proxy:env.HTTP_PROXY=http://new-proxy:3128"
+	require.NoError(t, r.Update(context.TODO(), configMap))
+	requests := r.podDefaultsConfigMapToProfiles().ToRequests.Map(handler.MapObject{Meta: configMap, Object: configMap})
+	require.Equal(t, []ctrl.Request{request}, requests)
+	_, err = r.Reconcile(requests[0])
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "HTTP_PROXY", "value": "http://new-proxy:3128"}},
+		getEnv())
+
+	// Other ConfigMaps are ignored.
+	other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "kubeflow"}}
+	assert.Empty(t, r.podDefaultsConfigMapToProfiles().ToRequests.Map(handler.MapObject{Meta: other, Object: other}))
+}
@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcilePriorityPodDefault(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Annotations = map[string]string{DEFAULTPRIORITYCLASS: "low-priority"}
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getPodDefault := func() (*unstructured.Unstructured, error) {
+		pd := &unstructured.Unstructured{}
+		pd.SetGroupVersionKind(podDefaultGVK)
+		err := r.Get(context.TODO(), types.NamespacedName{Name: PRIORITYPODDEFAULT, Namespace: profile.Name}, pd)
+		return pd, err
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	pd, err := getPodDefault()
+	require.NoError(t, err)
+	priorityClassName, _, _ := unstructured.NestedString(pd.Object, "spec", "priorityClassName")
+	assert.Equal(t, "low-priority", priorityClassName)
+	selector, found, _ := unstructured.NestedMap(pd.Object, "spec", "selector")
+	assert.True(t, found)
+	assert.Empty(t, selector)
+	require.Len(t, pd.GetOwnerReferences(), 1)
+	assert.Equal(t, profile.Name, pd.GetOwnerReferences()[0].Name)
+
+	// Priority class changed.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	profile.Annotations[DEFAULTPRIORITYCLASS] = "high-priority"
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	pd, err = getPodDefault()
+	require.NoError(t, err)
+	priorityClassName, _, _ = unstructured.NestedString(pd.Object, "spec", "priorityClassName")
+	assert.Equal(t, "high-priority", priorityClassName)
+
+	// Annotation removed, the PodDefault is deleted.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	delete(profile.Annotations, DEFAULTPRIORITYCLASS)
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	_, err = getPodDefault()
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestParsePodDefaults(t *testing.T) {
+	podDefaults, err := ParsePodDefaults(
+		`add-gcp-secret:desc="Add GCP credentials, read only",env.GOOGLE_APPLICATION_CREDENTIALS=/secret/gcp/user-gcp-sa.json;` +
+			` add-proxy : env.HTTP_PROXY = "http://proxy:3128;8080" ;;empty`)
+	require.NoError(t, err)
+	assert.Equal(t, PodDefaults{
+		"add-gcp-secret": {
+			"desc":                               "Add GCP credentials, read only",
+			"env.GOOGLE_APPLICATION_CREDENTIALS": "/secret/gcp/user-gcp-sa.json",
+		},
+		"add-proxy": {"env.HTTP_PROXY": "http://proxy:3128;8080"},
+		"empty":     {},
+	}, podDefaults)
+
+	podDefaults, err = ParsePodDefaults("")
+	require.NoError(t, err)
+	assert.Empty(t, podDefaults)
+
+	for _, value := range []string{
+		"Invalid_Name:desc=a",
+		"a:desc=a;a:desc=b",
+		"a:desc",
+		"a:=b",
+		`a:desc="unterminated`,
+	} {
+		_, err = ParsePodDefaults(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestPodDefaultsValidateFields(t *testing.T) {
+	podDefaults, err := ParsePodDefaults("add-team:desc=Team,labels.team=ml,annotations.owner=ml,env.TEAM=ml," +
+		"priorityClassName=high,serviceAccountName=team")
+	require.NoError(t, err)
+	assert.Empty(t, podDefaults.ValidateFields())
+
+	podDefaults, err = ParsePodDefaults("add-team:lables.team=ml,desc=Team;add-proxy:env=x,desc.text=x")
+	require.NoError(t, err)
+	errs := podDefaults.ValidateFields()
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], `field "desc.text" of PodDefault add-proxy takes no key, use desc`)
+	assert.EqualError(t, errs[1], `field "env" of PodDefault add-proxy expects a key, e.g. env.<key>`)
+	assert.EqualError(t, errs[2], `unknown field "lables.team" of PodDefault add-team, expected one of `+
+		`annotations.<key>, desc, env.<key>, labels.<key>, priorityClassName, serviceAccountName`)
+}
+
+func TestParsePodDefaultsSkipInvalid(t *testing.T) {
+	parseErrors := testutil.ToFloat64(podDefaultParseErrors)
+	podDefaults, errs := ParsePodDefaultsSkipInvalid("add-proxy:env.HTTP_PROXY=http://proxy:3128;" +
+		"Invalid_Name:desc=a;add-proxy:desc=duplicate;add-secret:desc")
+	assert.Len(t, errs, 3)
+	assert.Equal(t, PodDefaults{"add-proxy": {"env.HTTP_PROXY": "http://proxy:3128"}}, podDefaults)
+	assert.Equal(t, parseErrors+3, testutil.ToFloat64(podDefaultParseErrors))
+
+	// An unterminated quote makes the whole value invalid.
+	podDefaults, errs = ParsePodDefaultsSkipInvalid(`add-proxy:desc="unterminated`)
+	assert.Len(t, errs, 1)
+	assert.Empty(t, podDefaults)
+	assert.Equal(t, parseErrors+4, testutil.ToFloat64(podDefaultParseErrors))
+}
+
+func TestReconcilePodDefaultsAppliedMetric(t *testing.T) {
+	profile := newTestProfile("kubeflow-metrics", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	podDefaults, err := ParsePodDefaults("add-proxy:env.HTTP_PROXY=http://proxy:3128;add-secret:desc=Add secret")
+	require.NoError(t, err)
+	r.PodDefaults = podDefaults
+	applied := testutil.ToFloat64(podDefaultsApplied.WithLabelValues(profile.Name))
+
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	assert.Equal(t, applied+2, testutil.ToFloat64(podDefaultsApplied.WithLabelValues(profile.Name)))
+}
+
+func TestPodDefaultSpec(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"desc": "Add proxy",
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"add-proxy": "true"},
+		},
+		"env": []interface{}{
+			map[string]interface{}{"name": "HTTPS_PROXY", "value": "http://proxy:3128"},
+			map[string]interface{}{"name": "HTTP_PROXY", "value": "http://proxy:3128"},
+		},
+		"labels":             map[string]interface{}{"app.kubernetes.io/part-of": "kubeflow"},
+		"serviceAccountName": "proxy",
+	}, podDefaultSpec("add-proxy", map[string]string{
+		"desc":                             "Add proxy",
+		"env.HTTP_PROXY":                   "http://proxy:3128",
+		"env.HTTPS_PROXY":                  "http://proxy:3128",
+		"labels.app.kubernetes.io/part-of": "kubeflow",
+		"serviceAccountName":               "proxy",
+	}))
+}
+
+func TestResyncPodDefaults(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: profile.Name}}
+	terminating := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	terminatingNs := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: terminating.Name},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+	// Namespace not created yet.
+	pending := newTestProfile("kubeflow-user3", "user3@abcd.com")
+	r := newFakeReconciler(profile, ns, terminating, terminatingNs, pending)
+	getEnv := func(namespace string) ([]interface{}, error) {
+		pd := &unstructured.Unstructured{}
+		pd.SetGroupVersionKind(podDefaultGVK)
+		err := r.Get(context.TODO(), types.NamespacedName{Name: "add-proxy", Namespace: namespace}, pd)
+		env, _, _ := unstructured.NestedSlice(pd.Object, "spec", "env")
+		return env, err
+	}
+
+	podDefaults, err := ParsePodDefaults("add-proxy:env.HTTP_PROXY=http://proxy:3128")
+	require.NoError(t, err)
+	r.PodDefaults = podDefaults
+	require.NoError(t, r.ResyncPodDefaults(context.TODO()))
+	env, err := getEnv(profile.Name)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "HTTP_PROXY", "value": "http://proxy:3128"}}, env)
+	_, err = getEnv(terminating.Name)
+	assert.True(t, errors.IsNotFound(err))
+
+	// Restarted with an updated -pd value.
+	podDefaults, err = ParsePodDefaults("add-proxy:env.HTTP_PROXY=http://new-proxy:3128")
+	require.NoError(t, err)
+	r.PodDefaults = podDefaults
+	require.NoError(t, r.ResyncPodDefaults(context.TODO()))
+	env, err = getEnv(profile.Name)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "HTTP_PROXY", "value": "http://new-proxy:3128"}}, env)
+}
+
+func TestParsePodDefaultLabels(t *testing.T) {
+	labels, err := ParsePodDefaultLabels(`team=ml, cost-center="cc-1,2"`)
+	require.Error(t, err, "label values cannot contain commas")
+
+	labels, err = ParsePodDefaultLabels(`team=ml, example.com/tier="gold"`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "ml", "example.com/tier": "gold"}, labels)
+
+	labels, err = ParsePodDefaultLabels("")
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+
+	for _, value := range []string{"team", "=ml", "team=m l", `team="ml`, MANAGEDBYLABEL + "=me",
+		PROFILELABEL + "=other"} {
+		_, err = ParsePodDefaultLabels(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestReconcilePodDefaultLabels(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	userPodDefault := newPodDefault(profile.Name, "user-defined", map[string]interface{}{"desc": "user"})
+	r := newFakeReconciler(profile, userPodDefault)
+	podDefaults, err := ParsePodDefaults("add-proxy:env.HTTP_PROXY=http://proxy:3128;add-secret:desc=Add secret")
+	require.NoError(t, err)
+	r.PodDefaults = podDefaults
+	r.PodDefaultLabels = map[string]string{"team": "ml"}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getPodDefault := func(name string) (*unstructured.Unstructured, error) {
+		pd := &unstructured.Unstructured{}
+		pd.SetGroupVersionKind(podDefaultGVK)
+		err := r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: profile.Name}, pd)
+		return pd, err
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	pd, err := getPodDefault("add-proxy")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		MANAGEDBYLABEL: MANAGEDBYVALUE,
+		PROFILELABEL:   profile.Name,
+		"team":         "ml",
+	}, pd.GetLabels())
+
+	// A PodDefault removed from the configuration is pruned, the one created by the user survives.
+	r.PodDefaults = PodDefaults{"add-secret": podDefaults["add-secret"]}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	_, err = getPodDefault("add-proxy")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = getPodDefault("add-secret")
+	assert.NoError(t, err)
+	_, err = getPodDefault("user-defined")
+	assert.NoError(t, err)
+}
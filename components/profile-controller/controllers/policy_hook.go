@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// PolicyHook validates the objects generated for a profile before they are created or updated, e.g. to
+// enforce organization policies. The profile is the namespace of obj, or obj itself for the Namespace.
+type PolicyHook interface {
+	// Validate returns an error explaining why obj is denied, nil to allow it.
+	Validate(ctx context.Context, obj runtime.Object) error
+}
+
+// AllowAllPolicyHook allows every object.
+type AllowAllPolicyHook struct{}
+
+func (AllowAllPolicyHook) Validate(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+// PolicyDeniedError is returned when the PolicyHook denied a generated object.
+type PolicyDeniedError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Reason    error
+}
+
+func (e *PolicyDeniedError) Error() string {
+	return fmt.Sprintf("%v %v/%v denied by policy: %v", e.Kind, e.Namespace, e.Name, e.Reason)
+}
+
+// validatePolicy runs obj through the PolicyHook. Profiles are not generated, so not validated.
+func (r *ProfileReconciler) validatePolicy(ctx context.Context, obj runtime.Object) error {
+	if _, ok := obj.(*profilev1.Profile); ok {
+		return nil
+	}
+	hook := r.PolicyHook
+	if hook == nil {
+		hook = AllowAllPolicyHook{}
+	}
+	reason := hook.Validate(ctx, obj)
+	if reason == nil {
+		return nil
+	}
+	denied := &PolicyDeniedError{Reason: reason}
+	if gvk, err := apiutil.GVKForObject(obj, r.Scheme); err == nil {
+		denied.Kind = gvk.Kind
+	}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		denied.Namespace = accessor.GetNamespace()
+		denied.Name = accessor.GetName()
+	}
+	return denied
+}
+
+// Create validates obj against the PolicyHook before creating it.
+func (r *ProfileReconciler) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := r.validatePolicy(ctx, obj); err != nil {
+		return err
+	}
+	return r.Client.Create(ctx, obj, opts...)
+}
+
+// Patch validates the configurations applied with server-side apply against the PolicyHook, other patches
+// carry partial objects and are not validated.
+func (r *ProfileReconciler) Patch(ctx context.Context, obj runtime.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+	if patch.Type() == types.ApplyPatchType {
+		if err := r.validatePolicy(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return r.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Update validates obj against the PolicyHook before updating it.
+func (r *ProfileReconciler) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := r.validatePolicy(ctx, obj); err != nil {
+		return err
+	}
+	return r.Client.Update(ctx, obj, opts...)
+}
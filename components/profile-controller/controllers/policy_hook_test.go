@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// denyServiceAccountsHook denies all ServiceAccounts.
+type denyServiceAccountsHook struct{}
+
+func (denyServiceAccountsHook) Validate(ctx context.Context, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.ServiceAccount); ok {
+		return fmt.Errorf("service accounts are managed by the platform team")
+	}
+	return nil
+}
+
+func TestReconcilePolicyHookDenies(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.PolicyHook = denyServiceAccountsHook{}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	require.NotEmpty(t, found.Status.Conditions)
+	condition := found.Status.Conditions[len(found.Status.Conditions)-1]
+	assert.Equal(t, profilev1.ProfileFailed, condition.Type)
+	assert.Equal(t, "ServiceAccount kubeflow-user1/default-editor denied by policy: "+
+		"service accounts are managed by the platform team", condition.Message)
+
+	err = r.Get(context.TODO(), types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name},
+		&corev1.ServiceAccount{})
+	assert.True(t, apierrors.IsNotFound(err))
+	// Objects allowed by the hook are applied.
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, &corev1.Namespace{}))
+}
+
+func TestReconcilePolicyHookSeesAllObjects(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	hook := &recordingPolicyHook{}
+	r.PolicyHook = hook
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	assert.Subset(t, hook.kinds, []string{"*v1.Namespace", "*v1beta1.AuthorizationPolicy",
+		"*v1.ServiceAccount", "*v1.RoleBinding"})
+	assert.NotContains(t, hook.kinds, "*v1.Profile")
+}
+
+// recordingPolicyHook allows all objects and records their types.
+type recordingPolicyHook struct {
+	kinds []string
+}
+
+func (h *recordingPolicyHook) Validate(ctx context.Context, obj runtime.Object) error {
+	h.kinds = append(h.kinds, fmt.Sprintf("%T", obj))
+	return nil
+}
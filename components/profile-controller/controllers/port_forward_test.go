@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileOwnerPortForwardAccess(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.OwnerPortForwardAccess = true
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: OWNERPORTFORWARD, Namespace: profile.Name}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	role := &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	assert.Equal(t, getPortForwardRole(profile.Name).Rules, role.Rules)
+	binding := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, binding))
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: OWNERPORTFORWARD}, binding.RoleRef)
+	assert.Equal(t, []rbacv1.Subject{profile.Spec.Owner}, binding.Subjects)
+
+	// Disabling port-forward access removes the Role and RoleBinding.
+	r.OwnerPortForwardAccess = false
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.Role{})))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
+
+func TestCleanupOwnerPortForwardAccess(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.OwnerPortForwardAccess = true
+	r.CleanupOrder = []string{"RoleBinding", "Role"}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: OWNERPORTFORWARD, Namespace: profile.Name}
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), key, &rbacv1.Role{}))
+
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, profile))
+	deletedAt := metav1.Now()
+	profile.DeletionTimestamp = &deletedAt
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.Role{})))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
@@ -18,16 +18,26 @@ package controllers
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"reflect"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/ghodss/yaml"
+	"golang.org/x/time/rate"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/cenkalti/backoff"
 	"github.com/go-logr/logr"
@@ -36,8 +46,10 @@ import (
 
 	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
 	istioSecurity "istio.io/api/security/v1beta1"
+	istioNetworkingClient "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -50,14 +62,21 @@ const ISTIOALLOWALL = "allow-all"
 const KFQUOTA = "kf-resource-quota"
 const PROFILEFINALIZER = "profile-finalizer"
 
+// Condition type set when plugins could not be revoked within FinalizerTimeout.
+const ProfileFinalizerTimeout = "FinalizerTimeout"
+
+// Interval to check again on a blocked deletion after FinalizerTimeout expired.
+const finalizerTimeoutRequeue = 30 * time.Second
+
+var errFinalizerTimeout = goerrors.New("finalizer timeout exceeded")
+
 // annotation key, consumed by kfam API
 const USER = "user"
 const ROLE = "role"
 const ADMIN = "admin"
 
-// Kubeflow default role names
-// TODO: Make kubeflow roles configurable (krishnadurai)
-// This will enable customization of roles.
+// Kubeflow default role names, the editor and viewer roles are configurable with EditorClusterRole and
+// ViewerClusterRole.
 const (
 	kubeflowAdmin       = "kubeflow-admin"
 	kubeflowEdit        = "kubeflow-edit"
@@ -72,15 +91,22 @@ var kubeflowNamespaceLabels = map[string]string{
 	"app.kubernetes.io/part-of":             "kubeflow-profile",
 }
 
+// Annotation of the namespaces the controller disabled istio-injection in with spec.disableIstioSidecar, holding
+// the label value restored once the field is cleared, empty if the label was not set.
+const ISTIOINJECTIONRESTORE = "profile.kubeflow.org/istio-injection-restore"
+
+// Default namespace annotation holding the version of the controller which last reconciled the namespace.
+const CONTROLLERVERSION = "profile.kubeflow.org/controller-version"
+
 const DEFAULT_EDITOR = "default-editor"
 const DEFAULT_VIEWER = "default-viewer"
 
 type Plugin interface {
 	// Called when profile CR is created / updated
-	ApplyPlugin(*ProfileReconciler, *profilev1.Profile) error
+	ApplyPlugin(context.Context, *ProfileReconciler, *profilev1.Profile) error
 	// Called when profile CR is being deleted, to cleanup any non-k8s resources created via ApplyPlugin
 	// RevokePlugin logic need to be IDEMPOTENT
-	RevokePlugin(*ProfileReconciler, *profilev1.Profile) error
+	RevokePlugin(context.Context, *ProfileReconciler, *profilev1.Profile) error
 }
 
 // ProfileReconciler reconciles a Profile object
@@ -91,25 +117,297 @@ type ProfileReconciler struct {
 	UserIdHeader     string
 	UserIdPrefix     string
 	WorkloadIdentity string
+	// VerifyWorkloadIdentity checks the GCP IAM binding created by the workload identity plugin.
+	VerifyWorkloadIdentity bool
+	// GroupClaim is the JWT claim listing the groups of the user, Group subjects are not matched in the mesh if
+	// empty.
+	GroupClaim string
+	// IAMClient is used to verify workload identity bindings, defaults to the GCP IAM API.
+	IAMClient IAMPolicyClient
+	// MembershipResolver resolves additional namespace members, defaults to NoopMembershipResolver.
+	MembershipResolver MembershipResolver
+	// PolicyHook validates the generated objects before they are applied, defaults to AllowAllPolicyHook.
+	PolicyHook PolicyHook
+	// FinalizerTimeout bounds how long plugin revocation may block profile deletion, 0 means no limit.
+	FinalizerTimeout time.Duration
+	// FinalizerTimeoutForce removes the finalizer once FinalizerTimeout expired, otherwise deletion stays blocked.
+	FinalizerTimeoutForce bool
+	// LogRoutingAnnotations are rendered onto the namespace for the cluster logging agent.
+	LogRoutingAnnotations AnnotationTemplates
+	// CatalogAnnotations are rendered onto the namespace to register it with the service catalog.
+	CatalogAnnotations AnnotationTemplates
+	// ExternalDNSAnnotations are rendered onto the namespace for external-dns to create DNS records.
+	ExternalDNSAnnotations AnnotationTemplates
+	// CertReminderAnnotations are rendered onto the namespace for the job reminding owners of expiring
+	// certificates.
+	CertReminderAnnotations AnnotationTemplates
+	// DocumentationAnnotations are rendered onto the namespace for the bot generating namespace documentation,
+	// e.g. the owner, purpose and chat channel of the namespace.
+	DocumentationAnnotations AnnotationTemplates
+	// GatewayTLS sets the namespace annotations exposing profile namespaces through a shared gateway with TLS, nil
+	// disables it.
+	GatewayTLS *GatewayTLS
+	// DefaultEditorAnnotations are rendered onto the default-editor service account, e.g. for Knative eventing.
+	DefaultEditorAnnotations AnnotationTemplates
+	// VaultInjection sets the Vault Agent Injector annotations on the namespace and its default service accounts.
+	VaultInjection *VaultInjection
+	// DefaultImagePullSecrets are set as imagePullSecrets of the default-editor and default-viewer service
+	// accounts, e.g. to pull from a private registry.
+	DefaultImagePullSecrets ImagePullSecretTemplates
+	// AuthorizationPolicyTemplate renders the owner AuthorizationPolicy spec, defaults to the built-in policy.
+	AuthorizationPolicyTemplate *template.Template
+	// ProfileSelector restricts the Profiles managed by this controller, nil means all Profiles.
+	ProfileSelector labels.Selector
+	// WatchSelector restricts the Profiles reconciled to the ones whose labels, merged with the labels of their
+	// namespace, match it. nil means all Profiles.
+	WatchSelector labels.Selector
+	// IgnoredNamespaces are the namespaces whose Profiles are not reconciled.
+	IgnoredNamespaces []string
+	// NotebookControllerBinding binds the notebook controller service account in every namespace, nil disables it.
+	NotebookControllerBinding *PlatformBinding
+	// ChaosBinding binds the chaos-engineering tool service account in the namespaces of profiles opted in with
+	// the CHAOSOPTIN annotation, nil disables it.
+	ChaosBinding *PlatformBinding
+	// BackupBinding binds the backup operator service account in every namespace, e.g. to read PVCs and
+	// VolumeSnapshots, nil disables it.
+	BackupBinding *PlatformBinding
+	// AuditorAccess grants auditors read access to every profile namespace, nil disables it.
+	AuditorAccess *AuditorAccess
+	// PodDefaults are created in every profile namespace. The map is read-only once the controller started.
+	PodDefaults PodDefaults
+	// PodDefaultsConfigMap is the ConfigMap the PodDefaults are read from instead of PodDefaults, if set. Changes of
+	// the ConfigMap are applied to every profile namespace.
+	PodDefaultsConfigMap types.NamespacedName
+	// NamespaceMetadataConfigMap is the ConfigMap holding label and annotation templates set on every profile
+	// namespace, none if not set. Changes of the ConfigMap are applied to every profile namespace.
+	NamespaceMetadataConfigMap types.NamespacedName
+	// ProfileDefaults is the name of the ProfileDefault the defaulting webhook applies to new profiles.
+	ProfileDefaults string
+	// PodDefaultLabels are set on every PodDefault the controller creates, next to the ownership labels.
+	PodDefaultLabels map[string]string
+	// PodAntiAffinity is added by the ANTIAFFINITYPODDEFAULT PodDefault of every profile namespace to the pods
+	// labeled with it, nil disables the PodDefault.
+	PodAntiAffinity *corev1.PodAntiAffinity
+	// RateLimit is applied to inbound traffic of every profile namespace, nil disables rate limiting.
+	RateLimit *RateLimit
+	// CleanupOrder lists the kinds of resources deleted one after the other when the profile is deleted, before
+	// the finalizer is removed. Resources not listed are garbage collected.
+	CleanupOrder []string
+	// OnDelete is the policy applied to the user data of deleted profiles, one of ONDELETEDELETE,
+	// ONDELETERETAINNAMESPACE or ONDELETERETAINPVCS. Empty deletes the data.
+	OnDelete string
+	// QuotaSummaryConfigMap is the name of the ConfigMap summarizing quota and limits in every profile
+	// namespace, empty disables it.
+	QuotaSummaryConfigMap string
+	// WorkspaceDefaults configures the workspace PVC of every profile namespace, only profiles with
+	// spec.workspace get one if nil.
+	WorkspaceDefaults *WorkspaceDefaults
+	// NamespaceNaming names the namespaces of profiles without spec.namespaceName.
+	NamespaceNaming NamespaceNaming
+	// AdoptNamespaces lets every profile adopt the namespace of its name if it already exists, otherwise only
+	// profiles annotated with ADOPTNAMESPACE do.
+	AdoptNamespaces bool
+	// AdoptLegacyLabels migrates RoleBindings labeled by older profile controllers instead of duplicating them.
+	AdoptLegacyLabels bool
+	// GPUFairShare sets the GPU fair-share weight annotation of profile namespaces, nil disables it.
+	GPUFairShare *GPUFairShare
+	// GPUReservation sets the namespace annotations of the GPU reservation of profiles, nil disables it.
+	GPUReservation *GPUReservation
+	// CleanupPolicy sets the namespace annotations of the cleanup policy of profiles, nil disables it.
+	CleanupPolicy *CleanupPolicy
+	// ProfileExpiry deletes or suspends profiles expired with spec.ttl or spec.expiresAt, nil disables it.
+	ProfileExpiry *ProfileExpiry
+	// RegistryMirror sets the namespace annotations of the registry pull-through caches, nil disables it.
+	RegistryMirror *RegistryMirror
+	// VPAInclusion sets the namespace labels and annotations including profile namespaces in VPA recommendations,
+	// nil disables it.
+	VPAInclusion *VPAInclusion
+	// TracingSampling sets the trace sampling rate annotation of profile namespaces, nil disables it.
+	TracingSampling *TracingSampling
+	// Version of the controller, written to the VersionAnnotation namespace annotation, disabled if empty.
+	Version           string
+	VersionAnnotation string
+	// OwnerScaleAccess grants profile owners scale access to deployments and statefulsets through a Role.
+	OwnerScaleAccess bool
+	// ReconcileTimeout is the deadline of the reconcile of one Profile, after which its client and plugin calls are
+	// cancelled and the Profile is requeued with backoff. Disabled if 0.
+	ReconcileTimeout time.Duration
+	// ResyncPeriod requeues every reconciled Profile after it, so drift of its resources is corrected without a
+	// watch event. Disabled if 0.
+	ResyncPeriod time.Duration
+	// OwnerPortForwardAccess grants profile owners port-forward access to pods through a Role.
+	OwnerPortForwardAccess bool
+	// NetworkPolicies are the baseline NetworkPolicies of profile namespaces, nil disables them.
+	NetworkPolicies *NetworkPolicies
+	// OwnerNetworkPolicyAccess grants profile owners the management of NetworkPolicies through a Role.
+	OwnerNetworkPolicyAccess bool
+	// OwnerPodRestartAccess grants profile owners the deletion of pods, to restart them, through a Role.
+	OwnerPodRestartAccess bool
+	// DefaultEditorTokenRequest grants the default-editor service account the creation of TokenRequests for itself
+	// through a Role.
+	DefaultEditorTokenRequest bool
+	// OwnerApprovalAnnotation is the Profile annotation which must be "true" before the owner is granted access to
+	// the namespace, e.g. by an admin. Approval is not required if empty.
+	OwnerApprovalAnnotation string
+	// DataClassification validates the data classification annotation of profiles and propagates it to their
+	// namespace, nil disables it.
+	DataClassification *DataClassification
+	// AdmissionWebhook is asked whether the namespace of a profile may be created before creating it, nil
+	// disables it.
+	AdmissionWebhook *AdmissionWebhook
+	// NamespaceQuotaRetryBaseDelay and NamespaceQuotaRetryMaxDelay bound the exponential backoff of namespace
+	// creation retries when a cluster level quota rejects the namespace.
+	NamespaceQuotaRetryBaseDelay time.Duration
+	NamespaceQuotaRetryMaxDelay  time.Duration
+	// MaxManagedNamespaces caps the number of namespaces controlled by Profiles, no namespace is created beyond it.
+	// Unlimited if 0.
+	MaxManagedNamespaces int
+	// MaxConcurrentReconciles is the number of Profiles reconciled in parallel, defaults to 1.
+	MaxConcurrentReconciles int
+	// WorkqueueRateLimit configures the rate limiter of the Profile workqueue, the controller-runtime one if nil.
+	WorkqueueRateLimit *WorkqueueRateLimit
+	// GlobalReconcileRPS caps the reconciles per second across all Profiles, to protect a shared API server.
+	// Disabled if 0.
+	GlobalReconcileRPS float64
+	// QuotaTemplates are the named ResourceQuotas profiles can select with spec.quotaTemplate.
+	QuotaTemplates QuotaTemplates
+	// EditorClusterRole is bound to default-editor, the contributors and the members, defaults to kubeflow-edit.
+	// It can be an aggregated ClusterRole.
+	EditorClusterRole string
+	// ViewerClusterRole is bound to default-viewer, defaults to kubeflow-view.
+	ViewerClusterRole string
+	// BaselineQuota is added to the ResourceQuota of every profile namespace for the resources the profile does
+	// not set, e.g. services.loadbalancers.
+	BaselineQuota corev1.ResourceList
+	// GPUQuota makes the quota of profile namespaces enforce GPU resources and caps them, nil disables it.
+	GPUQuota *GPUQuota
+	// PVCStorageLimit is the PersistentVolumeClaim storage limit added to the LimitRange of profiles whose
+	// limitRangeSpec has none, nil disables it.
+	PVCStorageLimit *corev1.LimitRangeItem
+	// ContainerDefaults holds the default container requests and limits added to the LimitRange of profiles whose
+	// limitRangeSpec has no Container limit, nil disables them.
+	ContainerDefaults *corev1.LimitRangeItem
+	// Platform is PLATFORMKUBERNETES or PLATFORMOPENSHIFT, on which profile namespaces are requested as
+	// Projects. Defaults to PLATFORMKUBERNETES.
+	Platform string
+	// Recorder emits events on profiles, e.g. when the profile name is invalid. Events are disabled if nil.
+	Recorder record.EventRecorder
+
+	// revocations tracks plugin revocations running in the background, keyed by profile name.
+	revocations   map[string]*pluginRevocation
+	revocationsMu sync.Mutex
+
+	// observer records the changes instead of applying them in observe-only mode.
+	observer             *observingClient
+	observeOnlyConfigMap types.NamespacedName
+	// dryRun holds the changes recorded in dry-run mode, nil otherwise.
+	dryRun *dryRunReport
+
+	// namespaceQuotaRateLimiter is the per profile backoff of namespace creation retries.
+	namespaceQuotaRateLimiter workqueue.RateLimiter
+	namespaceQuotaBackoffOnce sync.Once
+	// namespaceTerminationRateLimiter is the per profile backoff of the checks of terminating namespaces.
+	namespaceTerminationRateLimiter workqueue.RateLimiter
+	namespaceTerminationBackoffOnce sync.Once
+
+	// reconcileTimeoutRateLimiter is the per profile backoff of reconciles which timed out.
+	reconcileTimeoutRateLimiter workqueue.RateLimiter
+	reconcileTimeoutBackoffOnce sync.Once
+
+	// globalReconcileRateLimiter is the token bucket of GlobalReconcileRPS.
+	globalReconcileRateLimiter *rate.Limiter
+	globalReconcileLimiterOnce sync.Once
+
+	// podDefaultsCache holds the PodDefaults last read from PodDefaultsConfigMap.
+	podDefaultsCache podDefaultsCache
+	// namespaceMetadataCache holds the NamespaceMetadata last read from NamespaceMetadataConfigMap.
+	namespaceMetadataCache namespaceMetadataCache
+}
+
+// pluginRevocation is a revocation of all plugins of one profile. err is set before done is closed.
+type pluginRevocation struct {
+	done chan struct{}
+	err  error
 }
 
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs="*"
+// +kubebuilder:rbac:groups=project.openshift.io,resources=projectrequests,verbs=create
 // +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs="*"
-// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs="*"
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs="*"
+// +kubebuilder:rbac:groups=core,resources=resourcequotas;limitranges,verbs="*"
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs="*"
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;delete;deletecollection
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;create
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;update
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=pods/portforward,verbs=create
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts/token,verbs=create
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=apps,resources=deployments/scale;statefulsets/scale,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.istio.io,resources=envoyfilters,verbs="*"
 // +kubebuilder:rbac:groups=security.istio.io,resources=authorizationpolicies,verbs="*"
+// +kubebuilder:rbac:groups=security.istio.io,resources=peerauthentications,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=kubeflow.org,resources=poddefaults,verbs="*"
+// +kubebuilder:rbac:groups=kubeflow.org,resources=notebooks,verbs=get;list;update
 // +kubebuilder:rbac:groups=kubeflow.org,resources=profiles;profiles/status;profiles/finalizers,verbs="*"
+// +kubebuilder:rbac:groups=kubeflow.org,resources=clusterprofiletemplates,verbs=get;list;watch
 
 // Reconcile reads that state of the cluster for a Profile object and makes changes based on the state read
 // and what is in the Profile.Spec
 // Automatically generate RBAC rules to allow the Controller to read and write Deployments
 func (r *ProfileReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
+	// Waiting for the global rate does not count against ReconcileTimeout.
+	if err := r.waitGlobalReconcileLimit(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	if r.ReconcileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.ReconcileTimeout)
+		defer cancel()
+	}
+	result, err := r.reconcileProfile(ctx, request)
+	var denied *PolicyDeniedError
+	if err != nil && goerrors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result, err = r.retryReconcileTimeout(request, err)
+	} else if goerrors.As(err, &denied) {
+		result, err = r.rejectByPolicy(ctx, request, denied)
+	} else if err == nil {
+		err = r.clearReconcileTimeout(ctx, request)
+	}
+	if r.observer != nil {
+		if reportErr := r.reportObservedChanges(request.Name); reportErr != nil {
+			r.Log.Error(reportErr, "error reporting observed changes", "profile", request.Name)
+			IncRequestErrorCounter("error reporting observed changes", SEVERITY_MINOR)
+			if err == nil {
+				err = reportErr
+			}
+		}
+	}
+	return result, err
+}
+
+// rejectByPolicy marks the profile failed with the reason the PolicyHook denied one of its objects.
+func (r *ProfileReconciler) rejectByPolicy(ctx context.Context, request ctrl.Request,
+	denied *PolicyDeniedError) (ctrl.Result, error) {
+	logger := r.Log.WithValues("profile", request.NamespacedName)
+	logger.Info("Profile rejected by policy", "reason", denied.Error())
+	IncRequestCounter("reject profile denied by policy")
+	instance := &profilev1.Profile{}
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+	return r.appendErrorConditionAndReturn(ctx, instance, denied.Error())
+}
+
+func (r *ProfileReconciler) reconcileProfile(ctx context.Context, request ctrl.Request) (_ ctrl.Result, err error) {
 	logger := r.Log.WithValues("profile", request.NamespacedName)
 
 	// Fetch the Profile instance
 	instance := &profilev1.Profile{}
 	logger.Info("Start to Reconcile.", "namespace", request.Namespace, "name", request.Name)
-	err := r.Get(ctx, request.NamespacedName, instance)
+	err = r.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
@@ -122,6 +420,120 @@ func (r *ProfileReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error)
 		logger.Error(err, "error reading the profile object")
 		return reconcile.Result{}, err
 	}
+	if !r.managesProfile(instance.Labels) {
+		logger.Info("Profile not matching the profile selector, ignored")
+		return reconcile.Result{}, nil
+	}
+	if watched, err := r.watchesProfile(ctx, instance); err != nil {
+		IncRequestErrorCounter("error reading the profile namespace", SEVERITY_MAJOR)
+		logger.Error(err, "error reading the profile namespace")
+		return reconcile.Result{}, err
+	} else if !watched {
+		logger.Info("Profile not matching the watch label selector or in an ignored namespace, ignored")
+		return reconcile.Result{}, nil
+	}
+
+	// examine DeletionTimestamp to determine if object is under deletion. Profiles being deleted are not
+	// reconciled anymore, which would recreate the resources being cleaned up.
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		// The object is being deleted
+		if containsString(instance.ObjectMeta.Finalizers, PROFILEFINALIZER) {
+			// our finalizer is present, so lets revoke all Plugins to clean up any external dependencies
+			plugins, err := r.GetPluginSpec(instance)
+			if err != nil {
+				// Nothing can be revoked for plugins which cannot be parsed.
+				plugins = nil
+			}
+			if result, err := r.finalizeProfile(ctx, instance, plugins); err != nil || !result.IsZero() {
+				return result, err
+			}
+		}
+		IncRequestCounter("reconcile")
+		return ctrl.Result{}, nil
+	}
+
+	// Paused profiles are left alone until resumed, e.g. during incident response.
+	paused, err := r.updatePaused(ctx, instance)
+	if err != nil {
+		logger.Error(err, "error updating paused condition")
+		IncRequestErrorCounter("error updating paused condition", SEVERITY_MINOR)
+		return reconcile.Result{}, err
+	}
+	if paused {
+		logger.Info("Profile paused, ignored")
+		IncRequestCounter("reconcile paused")
+		return reconcile.Result{}, nil
+	}
+
+	if err := validateProfileName(instance.Name); err != nil {
+		return r.rejectInvalidProfileName(ctx, instance, err)
+	}
+	// The namespace of the profile is resolved once, before it is created, and kept in status.namespace.
+	namespaceResolved, err := r.resolveNamespace(ctx, instance)
+	if err != nil {
+		IncRequestErrorCounter("error resolving namespace name", SEVERITY_MAJOR)
+		logger.Error(err, "error resolving namespace name")
+		return reconcile.Result{}, err
+	}
+	if err := validateNamespaceName(profileNamespace(instance)); err != nil {
+		IncRequestErrorCounter("invalid namespace name", SEVERITY_MINOR)
+		logger.Error(err, "invalid namespace name")
+		forgetNamespace(instance, namespaceResolved)
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+
+	// Profiles with a missing or invalid data classification are rejected before creating their namespace.
+	rejected, err := r.updateDataClassification(ctx, instance)
+	if err != nil {
+		logger.Error(err, "error updating data classification condition")
+		IncRequestErrorCounter("error updating data classification condition", SEVERITY_MINOR)
+		return reconcile.Result{}, err
+	}
+	if rejected {
+		logger.Info("Profile data classification is missing or invalid, ignored")
+		IncRequestErrorCounter("invalid data classification", SEVERITY_MINOR)
+		return reconcile.Result{}, nil
+	}
+
+	if instance.Spec.GcpServiceAccount != "" {
+		if err := validateGcpServiceAccount(instance.Spec.GcpServiceAccount); err != nil {
+			IncRequestErrorCounter("invalid GCP service account", SEVERITY_MINOR)
+			logger.Error(err, "invalid GCP service account")
+			return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+		}
+	}
+
+	if err := validateCostLabels(instance); err != nil {
+		IncRequestErrorCounter("invalid cost labels", SEVERITY_MINOR)
+		logger.Error(err, "invalid cost labels")
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+	if _, err := r.getWorkspacePVC(instance); err != nil {
+		IncRequestErrorCounter("invalid workspace", SEVERITY_MINOR)
+		logger.Error(err, "invalid workspace")
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+
+	// Report the stages the reconcile passed, or failed in, in the readiness conditions of the profile.
+	progress := &reconcileProgress{}
+	defer func() {
+		if statusErr := r.updateReadinessConditions(ctx, instance, progress, err); statusErr != nil {
+			logger.Error(statusErr, "error updating readiness conditions")
+			IncRequestErrorCounter("error updating readiness conditions", SEVERITY_MINOR)
+			if err == nil {
+				err = statusErr
+			}
+		}
+	}()
+	progress.enter(NamespaceCreated)
+	template, err := r.profileTemplate(ctx, instance)
+	if err != nil {
+		IncRequestErrorCounter("error reading profile template", SEVERITY_MAJOR)
+		logger.Error(err, "error reading profile template", "template", instance.Spec.Template)
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+	// The resources of the profile are rendered from the profile completed with its template.
+	expanded := expandProfileTemplate(instance, template)
 
 	// Update namespace
 	ns := &corev1.Namespace{
@@ -131,10 +543,32 @@ func (r *ProfileReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error)
 			Labels: map[string]string{
 				istioInjectionLabel: "enabled",
 			},
-			Name: instance.Name,
+			Name: profileNamespace(instance),
 		},
 	}
+	nsMetadata, err := r.configuredNamespaceMetadata(ctx)
+	if err != nil {
+		IncRequestErrorCounter("error reading namespace metadata", SEVERITY_MAJOR)
+		logger.Error(err, "error reading namespace metadata")
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+	nsLabels, err := r.namespaceLabels(instance, nsMetadata)
+	if err != nil {
+		IncRequestErrorCounter("error rendering namespace labels", SEVERITY_MAJOR)
+		logger.Error(err, "error rendering namespace labels")
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+	nsLabels = templateLabels(template, nsLabels)
 	updateNamespaceLabels(ns)
+	updateIstioInjectionLabel(ns, instance.Spec.DisableIstioSidecar)
+	updateManagedLabels(ns, nsLabels)
+	nsAnnotations, err := r.namespaceAnnotations(instance, nsMetadata)
+	if err != nil {
+		IncRequestErrorCounter("error rendering namespace annotations", SEVERITY_MAJOR)
+		logger.Error(err, "error rendering namespace annotations")
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+	updateManagedAnnotations(ns, nsAnnotations)
 	if err := controllerutil.SetControllerReference(instance, ns, r.Scheme); err != nil {
 		IncRequestErrorCounter("error setting ControllerReference", SEVERITY_MAJOR)
 		logger.Error(err, "error setting ControllerReference")
@@ -144,8 +578,17 @@ func (r *ProfileReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error)
 	err = r.Get(ctx, types.NamespacedName{Name: ns.Name}, foundNs)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			if result, ok, err := r.checkMaxManagedNamespaces(ctx, instance); !ok {
+				return result, err
+			}
+			if result, admitted, err := r.admitNamespace(ctx, instance); !admitted {
+				return result, err
+			}
 			logger.Info("Creating Namespace: " + ns.Name)
-			err = r.Create(ctx, ns)
+			err = r.createNamespace(ctx, ns)
+			if isNamespaceQuotaExceeded(err) {
+				return r.retryNamespaceQuotaExceeded(ctx, instance, err)
+			}
 			if err != nil {
 				IncRequestErrorCounter("error creating namespace", SEVERITY_MAJOR)
 				logger.Error(err, "error creating namespace")
@@ -163,24 +606,76 @@ func (r *ProfileReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error)
 				return r.appendErrorConditionAndReturn(ctx, instance,
 					"Owning namespace failed to create within 15 seconds")
 			}
+			if err = r.adoptProjectNamespace(ctx, ns, foundNs); err != nil {
+				IncRequestErrorCounter("error adopting project namespace", SEVERITY_MAJOR)
+				logger.Error(err, "error adopting project namespace")
+				return reconcile.Result{}, err
+			}
 			logger.Info("Created Namespace: "+foundNs.Name, "status", foundNs.Status.Phase)
+			if err = r.clearNamespaceQuotaExceeded(ctx, instance); err != nil {
+				logger.Error(err, "error updating profile status", "namespace", instance.Name)
+				return reconcile.Result{}, err
+			}
+			if err = r.clearNamespaceTerminating(ctx, instance); err != nil {
+				logger.Error(err, "error updating profile status", "namespace", instance.Name)
+				return reconcile.Result{}, err
+			}
 		} else {
 			IncRequestErrorCounter("error reading namespace", SEVERITY_MAJOR)
 			logger.Error(err, "error reading namespace")
 			return reconcile.Result{}, err
 		}
+	} else if isNamespaceTerminating(foundNs) {
+		// The namespace of a deleted profile of the same name is still terminating, it is created once gone.
+		return r.waitNamespaceTermination(ctx, instance)
+	} else if collision := namespaceCollision(instance, foundNs); collision != "" {
+		// The namespace is controlled by another profile, e.g. named after this one by the naming policy.
+		logger.Info(collision)
+		IncRequestCounter("reject profile namespace collision")
+		forgetNamespace(instance, namespaceResolved)
+		return r.appendErrorConditionAndReturn(ctx, instance, collision)
 	} else {
-		// Check exising namespace ownership before move forward
+		// Check exising namespace ownership before move forward. The owner of a namespace controlled by the
+		// profile can be changed on the profile. Pre-existing namespaces are taken over if adoption is enabled.
+		before := foundNs.DeepCopy()
+		adopted := false
+		if !metav1.IsControlledBy(foundNs, instance) && r.adoptsNamespace(instance, foundNs) {
+			logger.Info("Adopting namespace", "previousOwner", foundNs.Annotations["owner"],
+				"owner", instance.Spec.Owner.Name)
+			if err := r.adoptNamespace(instance, foundNs); err != nil {
+				IncRequestErrorCounter("error adopting namespace", SEVERITY_MAJOR)
+				logger.Error(err, "error adopting namespace")
+				return reconcile.Result{}, err
+			}
+			adopted = true
+		}
 		owner, ok := foundNs.Annotations["owner"]
-		if ok && owner == instance.Spec.Owner.Name {
-			if updated := updateNamespaceLabels(foundNs); updated {
-				err = r.Update(ctx, foundNs)
+		ownerChanged := ok && owner != instance.Spec.Owner.Name && metav1.IsControlledBy(foundNs, instance)
+		if ownerChanged {
+			logger.Info("Changing namespace owner", "previousOwner", owner, "owner", instance.Spec.Owner.Name)
+			foundNs.Annotations["owner"] = instance.Spec.Owner.Name
+		}
+		if ok && (ownerChanged || owner == instance.Spec.Owner.Name) {
+			labelsUpdated := updateNamespaceLabels(foundNs)
+			labelsUpdated = updateIstioInjectionLabel(foundNs, instance.Spec.DisableIstioSidecar) || labelsUpdated
+			labelsUpdated = updateManagedLabels(foundNs, nsLabels) || labelsUpdated
+			annotationsUpdated := updateManagedAnnotations(foundNs, nsAnnotations)
+			if adopted || ownerChanged || labelsUpdated || annotationsUpdated {
+				countDriftCorrection(instance, "Namespace")
+				err = r.applyNamespace(ctx, ns, before, foundNs)
 				if err != nil {
 					IncRequestErrorCounter("error updating namespace label", SEVERITY_MAJOR)
 					logger.Error(err, "error updating namespace label")
 					return reconcile.Result{}, err
 				}
 			}
+			if adopted {
+				IncRequestCounter("adopt existing namespace")
+				if r.Recorder != nil {
+					r.Recorder.Event(instance, corev1.EventTypeNormal, NamespaceAdopted,
+						fmt.Sprintf("adopted existing namespace %v", foundNs.Name))
+				}
+			}
 		} else {
 			logger.Info(fmt.Sprintf("namespace already exist, but not owned by profile creator %v",
 				instance.Spec.Owner.Name))
@@ -189,75 +684,272 @@ func (r *ProfileReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error)
 				"namespace already exist, but not owned by profile creator %v", instance.Spec.Owner.Name))
 		}
 	}
+	if namespaceResolved {
+		if err := r.Status().Update(ctx, instance); err != nil {
+			IncRequestErrorCounter("error updating profile namespace", SEVERITY_MAJOR)
+			logger.Error(err, "error updating profile namespace")
+			return reconcile.Result{}, err
+		}
+	}
 
+	progress.enter(IstioPolicyReady)
 	// Update Istio AuthorizationPolicy
 	// Create Istio AuthorizationPolicy in target namespace, which will give ns owner permission to access services in ns.
-	if err = r.updateIstioAuthorizationPolicy(instance); err != nil {
+	if err = r.updateIstioAuthorizationPolicy(ctx, instance); err != nil {
 		logger.Error(err, "error Updating Istio AuthorizationPolicy permission", "namespace", instance.Name)
 		IncRequestErrorCounter("error updating Istio AuthorizationPolicy permission", SEVERITY_MAJOR)
 		return reconcile.Result{}, err
 	}
+	// Grant auditors read access to the namespace and its services, if configured.
+	if err = r.updateAuditorAccess(ctx, instance); err != nil {
+		logger.Error(err, "error updating auditor access", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating auditor access", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+
+	// Restrict the traffic of target namespace with the baseline NetworkPolicies, if configured.
+	if err = r.updateNetworkPolicies(ctx, instance); err != nil {
+		logger.Error(err, "error updating NetworkPolicies", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating NetworkPolicies", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+
+	// Rate limit inbound traffic of target namespace if configured.
+	if err = r.updateRateLimitEnvoyFilter(ctx, instance); err != nil {
+		logger.Error(err, "error updating rate limit EnvoyFilter", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating rate limit EnvoyFilter", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
 
+	progress.enter(RBACReady)
 	// Update service accounts
 	// Create service account "default-editor" in target namespace.
 	// "default-editor" would have kubeflowEdit permission: edit all resources in target namespace except rbac.
-	if err = r.updateServiceAccount(instance, DEFAULT_EDITOR, kubeflowEdit); err != nil {
+	if err = r.updateServiceAccount(ctx, instance, DEFAULT_EDITOR, r.editorClusterRole()); err != nil {
 		logger.Error(err, "error Updating ServiceAccount", "namespace", instance.Name, "name",
 			"defaultEditor")
 		IncRequestErrorCounter("error updating ServiceAccount", SEVERITY_MAJOR)
 		return reconcile.Result{}, err
 	}
+	if err = r.updateServiceAccountAnnotations(ctx, instance, DEFAULT_EDITOR); err != nil {
+		logger.Error(err, "error updating ServiceAccount annotations", "namespace", instance.Name, "name",
+			"defaultEditor")
+		IncRequestErrorCounter("error updating ServiceAccount annotations", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	if err = r.updateServiceAccountImagePullSecrets(ctx, instance, DEFAULT_EDITOR); err != nil {
+		logger.Error(err, "error updating ServiceAccount imagePullSecrets", "namespace", instance.Name, "name",
+			"defaultEditor")
+		IncRequestErrorCounter("error updating ServiceAccount imagePullSecrets", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
 	// Create service account "default-viewer" in target namespace.
 	// "default-viewer" would have k8s default "view" permission: view all resources in target namespace.
-	if err = r.updateServiceAccount(instance, DEFAULT_VIEWER, kubeflowView); err != nil {
+	if err = r.updateServiceAccount(ctx, instance, DEFAULT_VIEWER, r.viewerClusterRole()); err != nil {
 		logger.Error(err, "error Updating ServiceAccount", "namespace", instance.Name, "name",
 			"defaultViewer")
 		IncRequestErrorCounter("error updating ServiceAccount", SEVERITY_MAJOR)
 		return reconcile.Result{}, err
 	}
+	if err = r.updateServiceAccountAnnotations(ctx, instance, DEFAULT_VIEWER); err != nil {
+		logger.Error(err, "error updating ServiceAccount annotations", "namespace", instance.Name, "name",
+			"defaultViewer")
+		IncRequestErrorCounter("error updating ServiceAccount annotations", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	if err = r.updateServiceAccountImagePullSecrets(ctx, instance, DEFAULT_VIEWER); err != nil {
+		logger.Error(err, "error updating ServiceAccount imagePullSecrets", "namespace", instance.Name, "name",
+			"defaultViewer")
+		IncRequestErrorCounter("error updating ServiceAccount imagePullSecrets", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
 
 	// TODO: add role for impersonate permission
 
 	// Update owner rbac permission
-	// When ClusterRole was referred by namespaced roleBinding, the result permission will be namespaced as well.
-	roleBinding := &rbacv1.RoleBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			Annotations: map[string]string{USER: instance.Spec.Owner.Name, ROLE: ADMIN},
-			Name:        "namespaceAdmin",
-			Namespace:   instance.Name,
-		},
-		// Use default ClusterRole 'admin' for profile/namespace owner
-		RoleRef: rbacv1.RoleRef{
-			APIGroup: "rbac.authorization.k8s.io",
-			Kind:     "ClusterRole",
-			Name:     kubeflowAdmin,
-		},
-		Subjects: []rbacv1.Subject{
-			instance.Spec.Owner,
-		},
+	// The owner is only bound once approved, if approval is required.
+	approved, err := r.updateOwnerApproval(ctx, instance)
+	if err != nil {
+		logger.Error(err, "error updating owner approval", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating owner approval", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	if approved {
+		err = r.updateOwnerRoleBinding(ctx, instance)
+	} else {
+		err = r.deleteOwnedRoleBinding(ctx, instance, OWNERBINDING)
 	}
-	if err = r.updateRoleBinding(instance, roleBinding); err != nil {
+	if err != nil {
 		logger.Error(err, "error Updating Owner Rolebinding", "namespace", instance.Name, "name",
 			"defaultEdittor")
 		IncRequestErrorCounter("error updating Owner Rolebinding", SEVERITY_MAJOR)
 		return reconcile.Result{}, err
 	}
-	// Create resource quota for target namespace if resources are specified in profile.
-	if len(instance.Spec.ResourceQuotaSpec.Hard) > 0 {
+	// Grant the notebook controller access to target namespace.
+	if err = r.updatePlatformBinding(ctx, instance, NOTEBOOKCONTROLLERBINDING, r.NotebookControllerBinding); err != nil {
+		logger.Error(err, "error updating notebook controller Rolebinding", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating notebook controller Rolebinding", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant the chaos-engineering tool access to target namespace if the profile opted in.
+	if err = r.updatePlatformBinding(ctx, instance, CHAOSBINDING, r.chaosBinding(instance)); err != nil {
+		logger.Error(err, "error updating chaos-engineering Rolebinding", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating chaos-engineering Rolebinding", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant the backup operator access to target namespace.
+	if err = r.updatePlatformBinding(ctx, instance, BACKUPBINDING, r.BackupBinding); err != nil {
+		logger.Error(err, "error updating backup operator Rolebinding", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating backup operator Rolebinding", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant the owner scale access to workloads in target namespace.
+	if err = r.updateOwnerScaleAccess(ctx, instance); err != nil {
+		logger.Error(err, "error updating owner scale access", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating owner scale access", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant the owner port-forward access to pods in target namespace.
+	if err = r.updateOwnerPortForwardAccess(ctx, instance); err != nil {
+		logger.Error(err, "error updating owner port-forward access", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating owner port-forward access", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant the owner the management of NetworkPolicies in target namespace.
+	if err = r.updateOwnerNetworkPolicyAccess(ctx, instance); err != nil {
+		logger.Error(err, "error updating owner NetworkPolicy access", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating owner NetworkPolicy access", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant the owner the restart of pods in target namespace.
+	if err = r.updateOwnerPodRestartAccess(ctx, instance); err != nil {
+		logger.Error(err, "error updating owner pod restart access", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating owner pod restart access", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant default-editor the creation of its own tokens.
+	if err = r.updateDefaultEditorTokenRequest(ctx, instance); err != nil {
+		logger.Error(err, "error updating default-editor TokenRequest access", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating default-editor TokenRequest access", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Bind every subject of the owner, the contributors and the members resolved from an external membership
+	// source once, with its highest privilege.
+	members, err := r.resolveMembers(ctx, instance)
+	if err != nil {
+		logger.Error(err, "error resolving members", "namespace", instance.Name)
+		IncRequestErrorCounter("error resolving members", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	contributors, members := r.dedupeSubjects(instance, members)
+	// Grant contributors edit or view access to target namespace and to its workloads in the mesh.
+	if err = r.updateContributorRoleBindings(ctx, instance, contributors); err != nil {
+		logger.Error(err, "error updating contributor Rolebindings", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating contributor Rolebindings", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	if err = r.updateContributorAuthorizationPolicies(ctx, instance, contributors); err != nil {
+		logger.Error(err, "error updating contributor AuthorizationPolicies", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating contributor AuthorizationPolicies", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Grant access to members resolved from an external membership source.
+	if err = r.updateMemberRoleBindings(ctx, instance, members); err != nil {
+		logger.Error(err, "error updating member Rolebindings", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating member Rolebindings", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	progress.enter("")
+	// Create resource quota for target namespace if resources, a quota template or a baseline quota are specified.
+	quotaSpec, hasQuota, err := r.resourceQuotaSpec(expanded)
+	if err != nil {
+		IncRequestErrorCounter("error resolving resource quota", SEVERITY_MAJOR)
+		logger.Error(err, "error resolving resource quota", "namespace", instance.Name)
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+	if hasQuota {
 		resourceQuota := &corev1.ResourceQuota{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      KFQUOTA,
-				Namespace: instance.Name,
+				Namespace: profileNamespace(instance),
 			},
-			Spec: instance.Spec.ResourceQuotaSpec,
+			Spec: quotaSpec,
 		}
-		if err = r.updateResourceQuota(instance, resourceQuota); err != nil {
+		if err = r.updateResourceQuota(ctx, instance, resourceQuota); err != nil {
 			logger.Error(err, "error Updating resource quota", "namespace", instance.Name)
 			IncRequestErrorCounter("error updating resource quota", SEVERITY_MAJOR)
 			return reconcile.Result{}, err
 		}
-	} else {
-		logger.Info("No update on resource quota", "spec", instance.Spec.ResourceQuotaSpec.String())
+	} else if err = r.deleteOwnedResourceQuota(ctx, instance, KFQUOTA); err != nil {
+		// The quota was removed from the profile, drop the one previously applied.
+		logger.Error(err, "error deleting resource quota", "namespace", instance.Name)
+		IncRequestErrorCounter("error deleting resource quota", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Report the GPU quotas of target namespace and their usage.
+	if err = r.updateGPUQuotaStatus(ctx, instance); err != nil {
+		logger.Error(err, "error updating GPU quota status", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating GPU quota status", SEVERITY_MINOR)
+		return reconcile.Result{}, err
+	}
+	// Suspend or resume the workloads of target namespace.
+	if err = r.updateSuspended(ctx, instance); err != nil {
+		logger.Error(err, "error updating suspended workloads", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating suspended workloads", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Create LimitRange for target namespace if limits are specified in profile or a PVC storage limit is set.
+	limitRangeSpec, err := r.limitRangeSpec(expanded)
+	if err != nil {
+		IncRequestErrorCounter("invalid LimitRange", SEVERITY_MINOR)
+		logger.Error(err, "invalid LimitRange", "namespace", instance.Name)
+		return r.appendErrorConditionAndReturn(ctx, instance, err.Error())
+	}
+	if err = r.updateLimitRange(ctx, instance, limitRangeSpec); err != nil {
+		logger.Error(err, "error updating LimitRange", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating LimitRange", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Default the priority class of pods in target namespace if the profile requests one.
+	if err = r.updatePriorityPodDefault(ctx, instance); err != nil {
+		logger.Error(err, "error updating priority PodDefault", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating priority PodDefault", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Label the pods of target namespace with the cost labels of the profile.
+	if err = r.updateCostLabelsPodDefault(ctx, instance); err != nil {
+		logger.Error(err, "error updating cost labels PodDefault", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating cost labels PodDefault", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Spread the pods opted in target namespace apart if an anti-affinity is configured.
+	if err = r.updateAntiAffinityPodDefault(ctx, instance); err != nil {
+		logger.Error(err, "error updating anti-affinity PodDefault", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating anti-affinity PodDefault", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Summarize the effective quota and limits of target namespace.
+	if err = r.updateQuotaSummary(ctx, instance); err != nil {
+		logger.Error(err, "error updating quota summary", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating quota summary", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	if err = r.updateConfiguredPodDefaults(ctx, instance); err != nil {
+		logger.Error(err, "error updating PodDefaults", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating PodDefaults", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	// Provision the workspace volume of target namespace.
+	if err = r.updateWorkspace(ctx, instance); err != nil {
+		logger.Error(err, "error provisioning workspace", "namespace", instance.Name)
+		IncRequestErrorCounter("error provisioning workspace", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	progress.enter(PluginError)
+	if err := r.patchTemplatePlugins(ctx, instance, template); err != nil {
+		IncRequestErrorCounter("error patching template plugins", SEVERITY_MAJOR)
+		logger.Error(err, "Failed patching template plugins", "namespace", instance.Name)
+		return reconcile.Result{}, err
 	}
 	if err := r.PatchDefaultPluginSpec(ctx, instance); err != nil {
 		IncRequestErrorCounter("error patching DefaultPluginSpec", SEVERITY_MAJOR)
@@ -266,57 +958,212 @@ func (r *ProfileReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error)
 	}
 	if plugins, err := r.GetPluginSpec(instance); err == nil {
 		for _, plugin := range plugins {
-			if err2 := plugin.ApplyPlugin(r, instance); err2 != nil {
+			if r.dryRun != nil {
+				r.observer.record(instance, "apply plugin "+pluginKind(plugin), nil, false)
+				continue
+			}
+			if err2 := plugin.ApplyPlugin(ctx, r, instance); err2 != nil {
 				logger.Error(err2, "Failed applying plugin", "namespace", instance.Name)
 				IncRequestErrorCounter("error applying plugin", SEVERITY_MAJOR)
 				return reconcile.Result{}, err2
 			}
 		}
 	}
+	progress.enter("")
+	// Record a hash of the applied configuration on the namespace.
+	hash, err := r.configHash(ctx, instance, quotaSpec, nsAnnotations)
+	if err != nil {
+		logger.Error(err, "error computing config hash", "namespace", instance.Name)
+		IncRequestErrorCounter("error computing config hash", SEVERITY_MINOR)
+		return reconcile.Result{}, err
+	}
+	if err = r.updateConfigHash(ctx, instance, hash); err != nil {
+		logger.Error(err, "error updating config hash", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating config hash", SEVERITY_MINOR)
+		return reconcile.Result{}, err
+	}
+	// List the resources managed for the profile in its status.
+	if err = r.updateManagedResources(ctx, instance); err != nil {
+		logger.Error(err, "error updating managed resources status", "namespace", instance.Name)
+		IncRequestErrorCounter("error updating managed resources status", SEVERITY_MINOR)
+		return reconcile.Result{}, err
+	}
+
+	// The object is not being deleted, so if it does not have our finalizer,
+	// then lets add the finalizer and update the object. This is equivalent
+	// registering our finalizer.
+	if !containsString(instance.ObjectMeta.Finalizers, PROFILEFINALIZER) {
+		instance.ObjectMeta.Finalizers = append(instance.ObjectMeta.Finalizers, PROFILEFINALIZER)
+		if err := r.Update(ctx, instance); err != nil {
+			logger.Error(err, "error updating finalizer", "namespace", instance.Name)
+			IncRequestErrorCounter("error updating finalizer", SEVERITY_MAJOR)
+			return ctrl.Result{}, err
+		}
+	}
+	progress.finish()
+	IncRequestCounter("reconcile")
+	return ctrl.Result{RequeueAfter: r.resyncAfter()}, nil
+}
 
-	// examine DeletionTimestamp to determine if object is under deletion
-	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
-		// The object is not being deleted, so if it does not have our finalizer,
-		// then lets add the finalizer and update the object. This is equivalent
-		// registering our finalizer.
-		if !containsString(instance.ObjectMeta.Finalizers, PROFILEFINALIZER) {
-			instance.ObjectMeta.Finalizers = append(instance.ObjectMeta.Finalizers, PROFILEFINALIZER)
-			if err := r.Update(ctx, instance); err != nil {
-				logger.Error(err, "error updating finalizer", "namespace", instance.Name)
-				IncRequestErrorCounter("error updating finalizer", SEVERITY_MAJOR)
-				return ctrl.Result{}, err
+// finalizeProfile revokes plugins of a profile under deletion, retains its user data as configured by OnDelete,
+// deletes its resources in CleanupOrder and removes the profile finalizer.
+func (r *ProfileReconciler) finalizeProfile(ctx context.Context, instance *profilev1.Profile,
+	plugins []Plugin) (ctrl.Result, error) {
+	logger := r.Log.WithValues("profile", instance.Name)
+	if err := r.revokePlugins(ctx, instance, plugins); err != nil {
+		if !goerrors.Is(err, errFinalizerTimeout) {
+			logger.Error(err, "error revoking plugin", "namespace", instance.Name)
+			IncRequestErrorCounter("error revoking plugin", SEVERITY_MAJOR)
+			return reconcile.Result{}, err
+		}
+		logger.Error(err, "plugins not revoked within finalizer timeout", "namespace", instance.Name,
+			"timeout", r.FinalizerTimeout.String(), "force", r.FinalizerTimeoutForce)
+		IncRequestErrorCounter("finalizer timeout", SEVERITY_MINOR)
+		r.setProfileCondition(instance, ProfileFinalizerTimeout, "True", fmt.Sprintf(
+			"plugins were not revoked within finalizer timeout %v: %v", r.FinalizerTimeout, err))
+		if err := r.Status().Update(ctx, instance); err != nil {
+			logger.Error(err, "error updating profile status", "namespace", instance.Name)
+			return reconcile.Result{}, err
+		}
+		if !r.FinalizerTimeoutForce {
+			return reconcile.Result{RequeueAfter: finalizerTimeoutRequeue}, nil
+		}
+		// Stop tracking a revocation which may still be running, the profile goes away anyway.
+		r.forgetRevocation(instance.Name)
+	}
+	if err := r.retainUserData(ctx, instance); err != nil {
+		logger.Error(err, "error retaining profile data", "namespace", instance.Name, "policy", r.OnDelete)
+		IncRequestErrorCounter("error retaining profile data", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	}
+	if pending, err := r.cleanupResources(ctx, instance); err != nil {
+		logger.Error(err, "error cleaning up profile resources", "namespace", instance.Name)
+		IncRequestErrorCounter("error cleaning up profile resources", SEVERITY_MAJOR)
+		return reconcile.Result{}, err
+	} else if pending {
+		return reconcile.Result{RequeueAfter: cleanupRequeue}, nil
+	}
+
+	// remove our finalizer from the list and update it.
+	instance.ObjectMeta.Finalizers = removeString(instance.ObjectMeta.Finalizers, PROFILEFINALIZER)
+	if err := r.Update(ctx, instance); err != nil {
+		logger.Error(err, "error removing finalizer", "namespace", instance.Name)
+		IncRequestErrorCounter("error removing finalizer", SEVERITY_MAJOR)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// revokePlugins revokes all plugins of a profile under deletion.
+// Without FinalizerTimeout plugins are revoked synchronously. Otherwise the revocation runs in the
+// background, at most once per profile at a time, and errFinalizerTimeout is returned if it did not
+// succeed before the deadline measured from the deletion timestamp. Later calls pick up the result of
+// the running revocation instead of starting a new one.
+func (r *ProfileReconciler) revokePlugins(ctx context.Context, instance *profilev1.Profile, plugins []Plugin) error {
+	if len(plugins) == 0 {
+		return nil
+	}
+	if r.dryRun != nil {
+		for _, plugin := range plugins {
+			r.observer.record(instance, "revoke plugin "+pluginKind(plugin), nil, false)
+		}
+		return nil
+	}
+	if r.FinalizerTimeout <= 0 || instance.DeletionTimestamp == nil {
+		for _, plugin := range plugins {
+			if err := plugin.RevokePlugin(ctx, r, instance); err != nil {
+				return err
 			}
 		}
+		return nil
+	}
+
+	revocation := r.startRevocation(instance, plugins)
+	remaining := time.Until(instance.DeletionTimestamp.Add(r.FinalizerTimeout))
+	var err error
+	if remaining > 0 {
+		timer := time.NewTimer(remaining)
+		defer timer.Stop()
+		select {
+		case <-revocation.done:
+			err = revocation.err
+		case <-timer.C:
+			return errFinalizerTimeout
+		}
 	} else {
-		// The object is being deleted
-		if containsString(instance.ObjectMeta.Finalizers, PROFILEFINALIZER) {
-			// our finalizer is present, so lets revoke all Plugins to clean up any external dependencies
-			if plugins, err := r.GetPluginSpec(instance); err == nil {
-				for _, plugin := range plugins {
-					if err := plugin.RevokePlugin(r, instance); err != nil {
-						logger.Error(err, "error revoking plugin", "namespace", instance.Name)
-						IncRequestErrorCounter("error revoking plugin", SEVERITY_MAJOR)
-						return reconcile.Result{}, err
-					}
-				}
-			}
+		select {
+		case <-revocation.done:
+			err = revocation.err
+		default:
+			return errFinalizerTimeout
+		}
+	}
+	// The revocation completed, a failed one is retried by the next reconcile.
+	r.forgetRevocation(instance.Name)
+	if err != nil && time.Until(instance.DeletionTimestamp.Add(r.FinalizerTimeout)) <= 0 {
+		return fmt.Errorf("%w: %v", errFinalizerTimeout, err)
+	}
+	return err
+}
 
-			// remove our finalizer from the list and update it.
-			instance.ObjectMeta.Finalizers = removeString(instance.ObjectMeta.Finalizers, PROFILEFINALIZER)
-			if err := r.Update(ctx, instance); err != nil {
-				logger.Error(err, "error removing finalizer", "namespace", instance.Name)
-				IncRequestErrorCounter("error removing finalizer", SEVERITY_MAJOR)
-				return ctrl.Result{}, err
+// startRevocation revokes plugins in the background unless a revocation of the profile is already running.
+// The goroutine works on a copy of the profile, so it can be updated while the revocation is running.
+func (r *ProfileReconciler) startRevocation(instance *profilev1.Profile, plugins []Plugin) *pluginRevocation {
+	r.revocationsMu.Lock()
+	defer r.revocationsMu.Unlock()
+	if revocation, ok := r.revocations[instance.Name]; ok {
+		return revocation
+	}
+	if r.revocations == nil {
+		r.revocations = map[string]*pluginRevocation{}
+	}
+	revocation := &pluginRevocation{done: make(chan struct{})}
+	r.revocations[instance.Name] = revocation
+	profile := instance.DeepCopy()
+	go func() {
+		defer close(revocation.done)
+		// The revocation outlives the reconcile which started it, it is bound by FinalizerTimeout instead.
+		ctx := context.Background()
+		for _, plugin := range plugins {
+			if err := plugin.RevokePlugin(ctx, r, profile); err != nil {
+				revocation.err = err
+				return
 			}
 		}
+	}()
+	return revocation
+}
+
+func (r *ProfileReconciler) forgetRevocation(name string) {
+	r.revocationsMu.Lock()
+	defer r.revocationsMu.Unlock()
+	delete(r.revocations, name)
+}
+
+func (r *ProfileReconciler) iamClient() IAMPolicyClient {
+	if r.IAMClient == nil {
+		return gcpIAMClient{}
 	}
-	IncRequestCounter("reconcile")
-	return ctrl.Result{}, nil
+	return r.IAMClient
+}
+
+// setProfileCondition sets the condition of type condType on the profile, replacing an existing one of the same type.
+func (r *ProfileReconciler) setProfileCondition(instance *profilev1.Profile, condType string, status string,
+	message string) {
+	condition := profilev1.ProfileCondition{Type: condType, Status: status, Message: message}
+	for i := range instance.Status.Conditions {
+		if instance.Status.Conditions[i].Type == condType {
+			instance.Status.Conditions[i] = condition
+			return
+		}
+	}
+	instance.Status.Conditions = append(instance.Status.Conditions, condition)
 }
 
 // appendErrorConditionAndReturn append failure status to profile CR and mark Reconcile done. If update condition failed, request will be requeued.
 func (r *ProfileReconciler) appendErrorConditionAndReturn(ctx context.Context, instance *profilev1.Profile,
 	message string) (ctrl.Result, error) {
+	r.setProfileCondition(instance, ProfileReady, "False", message)
 	instance.Status.Conditions = append(instance.Status.Conditions, profilev1.ProfileCondition{
 		Type:    profilev1.ProfileFailed,
 		Message: message,
@@ -327,41 +1174,81 @@ func (r *ProfileReconciler) appendErrorConditionAndReturn(ctx context.Context, i
 	return reconcile.Result{}, nil
 }
 
+// controllerOptions returns the options of the Profile controller. Reconcile keeps no mutable state on the
+// reconciler outside of revocations, which is guarded by revocationsMu, so different Profiles can be
+// reconciled concurrently.
+func (r *ProfileReconciler) controllerOptions() controller.Options {
+	maxConcurrentReconciles := r.MaxConcurrentReconciles
+	if maxConcurrentReconciles < 1 {
+		maxConcurrentReconciles = 1
+	}
+	options := controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}
+	if r.WorkqueueRateLimit != nil {
+		options.RateLimiter = r.WorkqueueRateLimit.rateLimiter()
+	}
+	return options
+}
+
 func (r *ProfileReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&profilev1.Profile{}).
+	b := ctrl.NewControllerManagedBy(mgr)
+	if r.RateLimit != nil {
+		// EnvoyFilters are only watched when used, so rate limiting stays optional.
+		b = b.Owns(&istioNetworkingClient.EnvoyFilter{})
+	}
+	if r.OwnerScaleAccess || r.OwnerPortForwardAccess || r.OwnerNetworkPolicyAccess || r.OwnerPodRestartAccess ||
+		r.DefaultEditorTokenRequest {
+		b = b.Owns(&rbacv1.Role{})
+	}
+	if r.NetworkPolicies != nil {
+		b = b.Owns(&networkingv1.NetworkPolicy{})
+	}
+	if r.PodDefaultsConfigMap.Name != "" {
+		b = b.Watches(&source.Kind{Type: &corev1.ConfigMap{}}, r.podDefaultsConfigMapToProfiles())
+	}
+	if r.NamespaceMetadataConfigMap.Name != "" {
+		b = b.Watches(&source.Kind{Type: &corev1.ConfigMap{}}, r.namespaceMetadataConfigMapToProfiles())
+	}
+	if r.QuotaSummaryConfigMap != "" {
+		// Quota and limits not created by the controller change the summary as well.
+		b = b.Owns(&corev1.ConfigMap{}).
+			Watches(&source.Kind{Type: &corev1.ResourceQuota{}}, r.namespaceToProfile()).
+			Watches(&source.Kind{Type: &corev1.LimitRange{}}, r.namespaceToProfile())
+	}
+	if r.GPUQuota != nil && r.QuotaSummaryConfigMap == "" {
+		// The usage of GPUs is reported in the profile status.
+		b = b.Owns(&corev1.ResourceQuota{})
+	}
+	return b.
+		For(&profilev1.Profile{}, builder.WithPredicates(r.profilePredicate())).
+		WithOptions(r.controllerOptions()).
 		Owns(&corev1.Namespace{}).
 		Owns(&istioSecurityClient.AuthorizationPolicy{}).
-		Owns(&corev1.ServiceAccount{}).
-		Owns(&rbacv1.RoleBinding{}).
+		// Deleted or edited ServiceAccounts and RoleBindings are recreated and reasserted.
+		Watches(&source.Kind{Type: &corev1.ServiceAccount{}}, r.serviceAccountToProfile()).
+		Watches(&source.Kind{Type: &rbacv1.RoleBinding{}}, r.roleBindingToProfile()).
+		Owns(&corev1.LimitRange{}).
+		Watches(&source.Kind{Type: &profilev1.ClusterProfileTemplate{}}, r.profileTemplateToProfiles()).
 		Complete(r)
 }
 
-func (r *ProfileReconciler) getAuthorizationPolicy(profileIns *profilev1.Profile) istioSecurity.AuthorizationPolicy {
+// getAuthorizationPolicy returns the AuthorizationPolicy spec rendered from AuthorizationPolicyTemplate, or the
+// built-in policy if no template is configured.
+func (r *ProfileReconciler) getAuthorizationPolicy(profileIns *profilev1.Profile) (istioSecurity.AuthorizationPolicy, error) {
+	if r.AuthorizationPolicyTemplate != nil {
+		return r.renderAuthorizationPolicy(r.AuthorizationPolicyTemplate, profileIns)
+	}
 	return istioSecurity.AuthorizationPolicy{
 		Action: istioSecurity.AuthorizationPolicy_ALLOW,
 		// Empty selector == match all workloads in namespace
 		Selector: nil,
-		Rules: []*istioSecurity.Rule{
-			{
-				When: []*istioSecurity.Condition{
-					{
-						// Namespace Owner can access all workloads in the
-						// namespace
-						Key: fmt.Sprintf("request.headers[%v]", r.UserIdHeader),
-						Values: []string{
-							r.UserIdPrefix + profileIns.Spec.Owner.Name,
-						},
-					},
-				},
-			},
+		Rules: append(r.ownerRules(profileIns), []*istioSecurity.Rule{
 			{
 				When: []*istioSecurity.Condition{
 					{
 						// Workloads in the same namespace can access all other
 						// workloads in the namespace
 						Key:    fmt.Sprintf("source.namespace"),
-						Values: []string{profileIns.Name},
+						Values: []string{profileNamespace(profileIns)},
 					},
 				},
 			},
@@ -381,31 +1268,40 @@ func (r *ProfileReconciler) getAuthorizationPolicy(profileIns *profilev1.Profile
 					},
 				},
 			},
-		},
-	}
+		}...),
+	}, nil
 }
 
 // updateIstioAuthorizationPolicy create or update Istio AuthorizationPolicy
 // resources in target namespace owned by "profileIns". The goal is to allow
 // service access for profile owner.
-func (r *ProfileReconciler) updateIstioAuthorizationPolicy(profileIns *profilev1.Profile) error {
-	logger := r.Log.WithValues("profile", profileIns.Name)
-
+func (r *ProfileReconciler) updateIstioAuthorizationPolicy(ctx context.Context, profileIns *profilev1.Profile) error {
+	policy, err := r.getAuthorizationPolicy(profileIns)
+	if err != nil {
+		return err
+	}
 	istioAuth := &istioSecurityClient.AuthorizationPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Annotations: map[string]string{USER: profileIns.Spec.Owner.Name, ROLE: ADMIN},
 			Name:        AUTHZPOLICYISTIO,
-			Namespace:   profileIns.Name,
+			Namespace:   profileNamespace(profileIns),
 		},
-		Spec: r.getAuthorizationPolicy(profileIns),
+		Spec: policy,
 	}
+	return r.applyAuthorizationPolicy(ctx, profileIns, istioAuth)
+}
 
+// applyAuthorizationPolicy creates or updates AuthorizationPolicy istioAuth, controlled by profileIns, with
+// server-side apply.
+func (r *ProfileReconciler) applyAuthorizationPolicy(ctx context.Context, profileIns *profilev1.Profile,
+	istioAuth *istioSecurityClient.AuthorizationPolicy) error {
+	logger := r.Log.WithValues("profile", profileIns.Name)
 	if err := controllerutil.SetControllerReference(profileIns, istioAuth, r.Scheme); err != nil {
 		return err
 	}
 	foundAuthorizationPolicy := &istioSecurityClient.AuthorizationPolicy{}
 	err := r.Get(
-		context.TODO(),
+		ctx,
 		types.NamespacedName{
 			Name:      istioAuth.ObjectMeta.Name,
 			Namespace: istioAuth.ObjectMeta.Namespace,
@@ -416,31 +1312,39 @@ func (r *ProfileReconciler) updateIstioAuthorizationPolicy(profileIns *profilev1
 		if errors.IsNotFound(err) {
 			logger.Info("Creating Istio AuthorizationPolicy", "namespace", istioAuth.ObjectMeta.Namespace,
 				"name", istioAuth.ObjectMeta.Name)
-			err = r.Create(context.TODO(), istioAuth)
-			if err != nil {
-				return err
-			}
-		} else {
-			return err
-		}
-	} else {
-		if !reflect.DeepEqual(istioAuth, foundAuthorizationPolicy) {
-			foundAuthorizationPolicy.Spec = istioAuth.Spec
-			logger.Info("Updating Istio AuthorizationPolicy", "namespace", istioAuth.ObjectMeta.Namespace,
-				"name", istioAuth.ObjectMeta.Name)
-			err = r.Update(context.TODO(), foundAuthorizationPolicy)
-			if err != nil {
-				return err
-			}
+			countDriftCorrection(profileIns, "AuthorizationPolicy")
+			return r.apply(ctx, istioAuth)
 		}
+		return err
+	}
+	refUpdated, err := r.setMissingControllerReference(profileIns, foundAuthorizationPolicy)
+	if err != nil {
+		return err
+	}
+	if refUpdated || !reflect.DeepEqual(istioAuth.Spec, foundAuthorizationPolicy.Spec) ||
+		!containsAll(foundAuthorizationPolicy.Annotations, istioAuth.Annotations) {
+		istioAuth.OwnerReferences = foundAuthorizationPolicy.OwnerReferences
+		logger.Info("Updating Istio AuthorizationPolicy", "namespace", istioAuth.ObjectMeta.Namespace,
+			"name", istioAuth.ObjectMeta.Name)
+		countDriftCorrection(profileIns, "AuthorizationPolicy")
+		return r.apply(ctx, istioAuth)
 	}
 	return nil
 }
 
+// containsAll tells if m has every entry of entries.
+func containsAll(m map[string]string, entries map[string]string) bool {
+	for k, v := range entries {
+		if current, ok := m[k]; !ok || current != v {
+			return false
+		}
+	}
+	return true
+}
+
 // updateResourceQuota create or update ResourceQuota for target namespace
-func (r *ProfileReconciler) updateResourceQuota(profileIns *profilev1.Profile,
+func (r *ProfileReconciler) updateResourceQuota(ctx context.Context, profileIns *profilev1.Profile,
 	resourceQuota *corev1.ResourceQuota) error {
-	ctx := context.Background()
 	logger := r.Log.WithValues("profile", profileIns.Name)
 	if err := controllerutil.SetControllerReference(profileIns, resourceQuota, r.Scheme); err != nil {
 		return err
@@ -458,7 +1362,11 @@ func (r *ProfileReconciler) updateResourceQuota(profileIns *profilev1.Profile,
 			return err
 		}
 	} else {
-		if !(reflect.DeepEqual(resourceQuota.Spec, found.Spec)) {
+		refUpdated, err := r.setMissingControllerReference(profileIns, found)
+		if err != nil {
+			return err
+		}
+		if refUpdated || !(reflect.DeepEqual(resourceQuota.Spec, found.Spec)) {
 			found.Spec = resourceQuota.Spec
 			logger.Info("Updating ResourceQuota", "namespace", resourceQuota.Namespace, "name", resourceQuota.Name)
 			err = r.Update(ctx, found)
@@ -470,37 +1378,72 @@ func (r *ProfileReconciler) updateResourceQuota(profileIns *profilev1.Profile,
 	return nil
 }
 
+// deleteOwnedResourceQuota deletes ResourceQuota "name" in the profile namespace if it is controlled by the
+// profile, quotas managed by hand are kept.
+func (r *ProfileReconciler) deleteOwnedResourceQuota(ctx context.Context, profileIns *profilev1.Profile,
+	name string) error {
+	found := &corev1.ResourceQuota{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: profileNamespace(profileIns)}, found); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(found, profileIns) {
+		return nil
+	}
+	r.Log.Info("Deleting ResourceQuota", "namespace", profileIns.Name, "name", name)
+	if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 // updateServiceAccount create or update service account "saName" with role "ClusterRoleName" in target namespace owned by "profileIns"
-func (r *ProfileReconciler) updateServiceAccount(profileIns *profilev1.Profile, saName string,
+func (r *ProfileReconciler) updateServiceAccount(ctx context.Context, profileIns *profilev1.Profile, saName string,
 	ClusterRoleName string) error {
 	logger := r.Log.WithValues("profile", profileIns.Name)
 	serviceAccount := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      saName,
-			Namespace: profileIns.Name,
+			Namespace: profileNamespace(profileIns),
 		},
 	}
 	if err := controllerutil.SetControllerReference(profileIns, serviceAccount, r.Scheme); err != nil {
 		return err
 	}
 	found := &corev1.ServiceAccount{}
-	err := r.Get(context.TODO(), types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, found)
+	err := r.Get(ctx, types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("Creating ServiceAccount", "namespace", serviceAccount.Namespace,
 				"name", serviceAccount.Name)
-			err = r.Create(context.TODO(), serviceAccount)
+			countDriftCorrection(profileIns, "ServiceAccount")
+			err = r.apply(ctx, serviceAccount)
 			if err != nil {
 				return err
 			}
 		} else {
 			return err
 		}
+	} else {
+		refUpdated, err := r.setMissingControllerReference(profileIns, found)
+		if err != nil {
+			return err
+		}
+		if refUpdated {
+			serviceAccount.OwnerReferences = found.OwnerReferences
+			logger.Info("Updating ServiceAccount", "namespace", serviceAccount.Namespace, "name", serviceAccount.Name)
+			countDriftCorrection(profileIns, "ServiceAccount")
+			if err = r.apply(ctx, serviceAccount); err != nil {
+				return err
+			}
+		}
 	}
 	roleBinding := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      saName,
-			Namespace: profileIns.Name,
+			Namespace: profileNamespace(profileIns),
 		},
 		// Use default ClusterRole 'admin' for profile/namespace owner
 		RoleRef: rbacv1.RoleRef{
@@ -512,38 +1455,92 @@ func (r *ProfileReconciler) updateServiceAccount(profileIns *profilev1.Profile,
 			{
 				Kind:      rbacv1.ServiceAccountKind,
 				Name:      saName,
-				Namespace: profileIns.Name,
+				Namespace: profileNamespace(profileIns),
 			},
 		},
 	}
-	return r.updateRoleBinding(profileIns, roleBinding)
+	return r.updateRoleBinding(ctx, profileIns, roleBinding)
+}
+
+// editorClusterRole returns the ClusterRole bound to editors of profile namespaces.
+func (r *ProfileReconciler) editorClusterRole() string {
+	if r.EditorClusterRole == "" {
+		return kubeflowEdit
+	}
+	return r.EditorClusterRole
+}
+
+// viewerClusterRole returns the ClusterRole bound to viewers of profile namespaces.
+func (r *ProfileReconciler) viewerClusterRole() string {
+	if r.ViewerClusterRole == "" {
+		return kubeflowView
+	}
+	return r.ViewerClusterRole
+}
+
+// setMissingControllerReference sets profileIns as controller of obj, so it is garbage collected with the
+// profile, if obj was created without one (e.g. by an older controller version). Objects controlled by
+// something else are left alone. Only namespace-scoped objects within the profile namespace and the
+// namespace itself are owned, anything else has to be cleaned up by the profile finalizer.
+func (r *ProfileReconciler) setMissingControllerReference(profileIns *profilev1.Profile, obj metav1.Object) (bool, error) {
+	if metav1.GetControllerOf(obj) != nil {
+		return false, nil
+	}
+	if err := controllerutil.SetControllerReference(profileIns, obj, r.Scheme); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // updateRoleBinding create or update roleBinding "roleBinding" in target namespace owned by "profileIns"
-func (r *ProfileReconciler) updateRoleBinding(profileIns *profilev1.Profile,
+func (r *ProfileReconciler) updateRoleBinding(ctx context.Context, profileIns *profilev1.Profile,
 	roleBinding *rbacv1.RoleBinding) error {
 	logger := r.Log.WithValues("profile", profileIns.Name)
 	if err := controllerutil.SetControllerReference(profileIns, roleBinding, r.Scheme); err != nil {
 		return err
 	}
 	found := &rbacv1.RoleBinding{}
-	err := r.Get(context.TODO(), types.NamespacedName{Name: roleBinding.Name, Namespace: roleBinding.Namespace}, found)
+	err := r.Get(ctx, types.NamespacedName{Name: roleBinding.Name, Namespace: roleBinding.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("Creating RoleBinding", "namespace", roleBinding.Namespace, "name", roleBinding.Name)
-			err = r.Create(context.TODO(), roleBinding)
+			countDriftCorrection(profileIns, "RoleBinding")
+			err = r.apply(ctx, roleBinding)
 			if err != nil {
 				return err
 			}
+			if r.AdoptLegacyLabels {
+				return r.deleteLegacyRoleBinding(ctx, profileIns, roleBinding)
+			}
 		} else {
 			return err
 		}
 	} else {
-		if !(reflect.DeepEqual(roleBinding.RoleRef, found.RoleRef) && reflect.DeepEqual(roleBinding.Subjects, found.Subjects)) {
-			found.RoleRef = roleBinding.RoleRef
-			found.Subjects = roleBinding.Subjects
+		if !reflect.DeepEqual(roleBinding.RoleRef, found.RoleRef) {
+			// The roleRef of a RoleBinding is immutable.
+			logger.Info("Recreating RoleBinding with new roleRef", "namespace", roleBinding.Namespace,
+				"name", roleBinding.Name, "roleRef", roleBinding.RoleRef.Name)
+			countDriftCorrection(profileIns, "RoleBinding")
+			if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			return r.apply(ctx, roleBinding)
+		}
+		if r.AdoptLegacyLabels && dropLegacyLabel(found) {
+			// The legacy label was set by an older controller version, apply does not remove it.
+			if err := r.removeMetadata(ctx, found, []string{LEGACYMANAGEDBYLABEL}, nil); err != nil {
+				return err
+			}
+		}
+		refUpdated, err := r.setMissingControllerReference(profileIns, found)
+		if err != nil {
+			return err
+		}
+		if refUpdated || !reflect.DeepEqual(roleBinding.Subjects, found.Subjects) {
+			roleBinding.OwnerReferences = found.OwnerReferences
 			logger.Info("Updating RoleBinding", "namespace", roleBinding.Namespace, "name", roleBinding.Name)
-			err = r.Update(context.TODO(), found)
+			countDriftCorrection(profileIns, "RoleBinding")
+			err = r.apply(ctx, roleBinding)
 			if err != nil {
 				return err
 			}
@@ -589,23 +1586,28 @@ func (r *ProfileReconciler) GetPluginSpec(profileIns *profilev1.Profile) ([]Plug
 }
 
 // PatchDefaultPluginSpec patch default plugins to profile CR instance if user doesn't specify plugin of same kind in CR.
+// spec.gcpServiceAccount overrides the GCP service account of the workload identity plugin, also of one specified
+// in the CR, the binding of the previous GCP service account is not revoked.
 func (r *ProfileReconciler) PatchDefaultPluginSpec(ctx context.Context, profileIns *profilev1.Profile) error {
 	// read existing plugins into map
-	plugins := make(map[string]profilev1.Plugin)
-	for _, p := range profileIns.Spec.Plugins {
-		plugins[p.Kind] = p
+	plugins := make(map[string]int)
+	for i, p := range profileIns.Spec.Plugins {
+		plugins[p.Kind] = i
 	}
 	// Patch default plugins if same kind doesn't exist yet.
-	if r.WorkloadIdentity != "" {
-		if _, ok := plugins[KIND_WORKLOAD_IDENTITY]; !ok {
+	if gsa := r.workloadIdentityServiceAccount(profileIns); gsa != "" {
+		spec := &runtime.RawExtension{
+			Raw: []byte(fmt.Sprintf(`{"gcpServiceAccount": "%v"}`, gsa)),
+		}
+		if i, ok := plugins[KIND_WORKLOAD_IDENTITY]; !ok {
 			profileIns.Spec.Plugins = append(profileIns.Spec.Plugins, profilev1.Plugin{
 				TypeMeta: metav1.TypeMeta{
 					Kind: KIND_WORKLOAD_IDENTITY,
 				},
-				Spec: &runtime.RawExtension{
-					Raw: []byte(fmt.Sprintf(`{"gcpServiceAccount": "%v"}`, r.WorkloadIdentity)),
-				},
+				Spec: spec,
 			})
+		} else if profileIns.Spec.GcpServiceAccount != "" {
+			profileIns.Spec.Plugins[i].Spec = spec
 		}
 	}
 	if err := r.Update(ctx, profileIns); err != nil {
@@ -646,3 +1648,39 @@ func updateNamespaceLabels(ns *corev1.Namespace) bool {
 	}
 	return updated
 }
+
+// updateIstioInjectionLabel disables the istio-injection label if the profile's DisableIstioSidecar is set,
+// keeping the previous value in ISTIOINJECTIONRESTORE. Once cleared the previous value is restored, if the label
+// was not changed since. The label is left alone otherwise, so existing namespaces keep their injection. Returns
+// true if ns was changed.
+func updateIstioInjectionLabel(ns *corev1.Namespace, disabled bool) bool {
+	previous, restore := ns.Annotations[ISTIOINJECTIONRESTORE]
+	if !disabled {
+		if !restore {
+			return false
+		}
+		delete(ns.Annotations, ISTIOINJECTIONRESTORE)
+		if ns.Labels[istioInjectionLabel] == "disabled" {
+			if previous == "" {
+				delete(ns.Labels, istioInjectionLabel)
+			} else {
+				ns.Labels[istioInjectionLabel] = previous
+			}
+		}
+		return true
+	}
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string)
+	}
+	if ns.Labels[istioInjectionLabel] == "disabled" {
+		return false
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	if !restore {
+		ns.Annotations[ISTIOINJECTIONRESTORE] = ns.Labels[istioInjectionLabel]
+	}
+	ns.Labels[istioInjectionLabel] = "disabled"
+	return true
+}
@@ -1,13 +1,70 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioNetworkingClient "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
+// newFakeReconciler returns a ProfileReconciler backed by a fake client seeded with objs.
+func newFakeReconciler(objs ...runtime.Object) *ProfileReconciler {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = profilev1.AddToScheme(scheme)
+	_ = istioSecurityClient.AddToScheme(scheme)
+	_ = istioNetworkingClient.AddToScheme(scheme)
+	// PodDefaults, ProfileDefaults and Notebooks have no Go types, they are handled as unstructured objects.
+	scheme.AddKnownTypeWithName(podDefaultGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(podDefaultGVK.GroupVersion().WithKind(podDefaultGVK.Kind+"List"),
+		&unstructured.UnstructuredList{})
+	scheme.AddKnownTypeWithName(profileDefaultGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(notebookGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(notebookGVK.GroupVersion().WithKind(notebookGVK.Kind+"List"),
+		&unstructured.UnstructuredList{})
+	return &ProfileReconciler{
+		Client:       newApplyClient(fake.NewFakeClientWithScheme(scheme, objs...)),
+		Scheme:       scheme,
+		Log:          ctrl.Log.WithName("test"),
+		UserIdHeader: "x-goog-authenticated-user-email",
+		UserIdPrefix: "accounts.google.com:",
+	}
+}
+
+func newTestProfile(name string, owner string) *profilev1.Profile {
+	return &profilev1.Profile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: profilev1.ProfileSpec{
+			Owner: rbacv1.Subject{
+				Kind: "User",
+				Name: owner,
+			},
+		},
+	}
+}
+
 func TestUpdateNamespaceLabels(t *testing.T) {
 	name := "test-namespace"
 	tests := []map[string]*corev1.Namespace{
@@ -60,3 +117,296 @@ func TestUpdateNamespaceLabels(t *testing.T) {
 		}
 	}
 }
+
+// fakePlugin is a Plugin whose revocation blocks until release is closed, then returns err.
+type fakePlugin struct {
+	release chan struct{}
+	err     error
+	revoked int32
+}
+
+func (p *fakePlugin) ApplyPlugin(context.Context, *ProfileReconciler, *profilev1.Profile) error {
+	return nil
+}
+
+func (p *fakePlugin) RevokePlugin(context.Context, *ProfileReconciler, *profilev1.Profile) error {
+	atomic.AddInt32(&p.revoked, 1)
+	<-p.release
+	return p.err
+}
+
+func TestFinalizerTimeout(t *testing.T) {
+	tests := []struct {
+		name              string
+		force             bool
+		deletedAgo        time.Duration
+		hang              bool
+		expectedFinalizer bool
+		expectedRequeue   bool
+		expectedCondition bool
+	}{
+		{
+			name:              "hanging revoke is forced once the timeout expires",
+			force:             true,
+			hang:              true,
+			expectedCondition: true,
+		},
+		{
+			name:              "timeout already expired is forced",
+			force:             true,
+			deletedAgo:        time.Hour,
+			hang:              true,
+			expectedCondition: true,
+		},
+		{
+			name:              "hanging revoke blocks deletion when not forced",
+			deletedAgo:        time.Hour,
+			hang:              true,
+			expectedFinalizer: true,
+			expectedRequeue:   true,
+			expectedCondition: true,
+		},
+		{
+			name:  "revoke completing within the timeout removes the finalizer",
+			force: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+			deletedAt := metav1.NewTime(time.Now().Add(-test.deletedAgo))
+			profile.DeletionTimestamp = &deletedAt
+			profile.Finalizers = []string{PROFILEFINALIZER}
+			r := newFakeReconciler(profile)
+			r.FinalizerTimeout = 2 * time.Second
+			r.FinalizerTimeoutForce = test.force
+
+			plugin := &fakePlugin{release: make(chan struct{})}
+			if test.hang {
+				defer close(plugin.release)
+			} else {
+				close(plugin.release)
+			}
+			found := &profilev1.Profile{}
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+			result, err := r.finalizeProfile(context.TODO(), found, []Plugin{plugin})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRequeue, result.RequeueAfter > 0)
+
+			require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+			assert.Equal(t, test.expectedFinalizer, containsString(found.Finalizers, PROFILEFINALIZER))
+			if test.expectedCondition {
+				require.Len(t, found.Status.Conditions, 1)
+				assert.Equal(t, ProfileFinalizerTimeout, found.Status.Conditions[0].Type)
+			} else {
+				assert.Empty(t, found.Status.Conditions)
+			}
+
+			if test.expectedRequeue {
+				// The next reconcile reuses the running revocation instead of starting another one.
+				_, err = r.finalizeProfile(context.TODO(), found, []Plugin{plugin})
+				require.NoError(t, err)
+				assert.Eventually(t, func() bool { return atomic.LoadInt32(&plugin.revoked) > 0 },
+					time.Second, 10*time.Millisecond)
+				time.Sleep(50 * time.Millisecond)
+				assert.Equal(t, int32(1), atomic.LoadInt32(&plugin.revoked))
+			}
+		})
+	}
+}
+
+func TestFinalizerWithoutTimeout(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	deletedAt := metav1.Now()
+	profile.DeletionTimestamp = &deletedAt
+	profile.Finalizers = []string{PROFILEFINALIZER}
+	r := newFakeReconciler(profile)
+
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+	plugin := &fakePlugin{release: make(chan struct{}), err: errors.New("revoke failed")}
+	close(plugin.release)
+	_, err := r.finalizeProfile(context.TODO(), found, []Plugin{plugin})
+	assert.Error(t, err)
+
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+	assert.True(t, containsString(found.Finalizers, PROFILEFINALIZER))
+	assert.Empty(t, found.Status.Conditions)
+}
+
+func TestReconcileDisableIstioSidecar(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.DisableIstioSidecar = true
+	r := newFakeReconciler(profile)
+	key := types.NamespacedName{Name: profile.Name}
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	assert.Equal(t, "disabled", ns.Labels[istioInjectionLabel])
+
+	// Drift is reasserted.
+	ns.Labels[istioInjectionLabel] = "enabled"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	assert.Equal(t, "disabled", ns.Labels[istioInjectionLabel])
+
+	// Clearing the field restores the value before the controller disabled it.
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), key, found))
+	found.Spec.DisableIstioSidecar = false
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	assert.Equal(t, "enabled", ns.Labels[istioInjectionLabel])
+	assert.NotContains(t, ns.Annotations, ISTIOINJECTIONRESTORE)
+}
+
+func TestReconcileKeepsIstioInjectionOfExistingNamespace(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	key := types.NamespacedName{Name: profile.Name}
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	// The label removed by an admin is not forced back.
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	delete(ns.Labels, istioInjectionLabel)
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	assert.NotContains(t, ns.Labels, istioInjectionLabel)
+
+	// Disabling and re-enabling the sidecar leaves the namespace without the label again.
+	setDisabled := func(disabled bool) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), key, found))
+		found.Spec.DisableIstioSidecar = disabled
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+		require.NoError(t, err)
+		ns = &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), key, ns))
+	}
+	setDisabled(true)
+	assert.Equal(t, "disabled", ns.Labels[istioInjectionLabel])
+	setDisabled(false)
+	assert.NotContains(t, ns.Labels, istioInjectionLabel)
+	assert.NotContains(t, ns.Annotations, ISTIOINJECTIONRESTORE)
+}
+
+func TestControllerOptions(t *testing.T) {
+	r := newFakeReconciler()
+	assert.Equal(t, 1, r.controllerOptions().MaxConcurrentReconciles)
+	r.MaxConcurrentReconciles = 8
+	assert.Equal(t, 8, r.controllerOptions().MaxConcurrentReconciles)
+	assert.Nil(t, r.controllerOptions().RateLimiter, "the controller-runtime default")
+	r.WorkqueueRateLimit = &WorkqueueRateLimit{BaseDelay: time.Second, MaxDelay: time.Minute, QPS: 50, Burst: 500}
+	assert.NotNil(t, r.controllerOptions().RateLimiter)
+}
+
+func TestReconcileOwnerReferences(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.ResourceQuotaSpec = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+	}
+	// Created before the controller set owner references.
+	orphan := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: DEFAULT_EDITOR, Namespace: profile.Name}}
+	r := newFakeReconciler(profile, orphan)
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	objects := map[string]metav1.Object{
+		"namespace":                     &corev1.Namespace{},
+		DEFAULT_EDITOR:                  &corev1.ServiceAccount{},
+		DEFAULT_VIEWER:                  &corev1.ServiceAccount{},
+		"rolebinding/" + DEFAULT_EDITOR: &rbacv1.RoleBinding{},
+		"rolebinding/namespaceAdmin":    &rbacv1.RoleBinding{},
+		AUTHZPOLICYISTIO:                &istioSecurityClient.AuthorizationPolicy{},
+		KFQUOTA:                         &corev1.ResourceQuota{},
+	}
+	for name, obj := range objects {
+		key := types.NamespacedName{Namespace: profile.Name, Name: strings.TrimPrefix(name, "rolebinding/")}
+		if name == "namespace" {
+			key = types.NamespacedName{Name: profile.Name}
+		}
+		require.NoError(t, r.Get(context.TODO(), key, obj.(runtime.Object)), name)
+		owner := metav1.GetControllerOf(obj)
+		if assert.NotNil(t, owner, name) {
+			assert.Equal(t, "Profile", owner.Kind, name)
+			assert.Equal(t, profile.Name, owner.Name, name)
+		}
+	}
+}
+
+func TestReconcileResourceQuota(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.ResourceQuotaSpec = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{
+			corev1.ResourceCPU:             resource.MustParse("8"),
+			corev1.ResourceMemory:          resource.MustParse("16Gi"),
+			corev1.ResourceRequestsStorage: resource.MustParse("100Gi"),
+			corev1.ResourcePods:            resource.MustParse("50"),
+		},
+	}
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: KFQUOTA, Namespace: profile.Name}
+	updateProfile := func(update func(*profilev1.Profile)) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	quota := &corev1.ResourceQuota{}
+	require.NoError(t, r.Get(context.TODO(), key, quota))
+	assert.Equal(t, profile.Spec.ResourceQuotaSpec, quota.Spec)
+	assert.True(t, metav1.IsControlledBy(quota, profile))
+
+	// Drift is reverted.
+	quota.Spec.Hard[corev1.ResourcePods] = resource.MustParse("500")
+	require.NoError(t, r.Update(context.TODO(), quota))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	quota = &corev1.ResourceQuota{}
+	require.NoError(t, r.Get(context.TODO(), key, quota))
+	assert.Equal(t, profile.Spec.ResourceQuotaSpec, quota.Spec)
+
+	// Spec changes are applied.
+	updateProfile(func(p *profilev1.Profile) {
+		p.Spec.ResourceQuotaSpec.Hard[corev1.ResourceCPU] = resource.MustParse("16")
+	})
+	quota = &corev1.ResourceQuota{}
+	require.NoError(t, r.Get(context.TODO(), key, quota))
+	cpu := quota.Spec.Hard[corev1.ResourceCPU]
+	assert.Equal(t, "16", cpu.String())
+
+	// Removing the quota from the spec deletes the owned ResourceQuota.
+	updateProfile(func(p *profilev1.Profile) { p.Spec.ResourceQuotaSpec = corev1.ResourceQuotaSpec{} })
+	err = r.Get(context.TODO(), key, &corev1.ResourceQuota{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestDeleteOwnedResourceQuotaKeepsForeign(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	foreign := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: KFQUOTA, Namespace: profile.Name}}
+	r := newFakeReconciler(profile, foreign)
+	require.NoError(t, r.deleteOwnedResourceQuota(context.TODO(), profile, KFQUOTA))
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: KFQUOTA, Namespace: profile.Name},
+		&corev1.ResourceQuota{}))
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ProfileDefaults are served by the settings v1alpha1 API of the admission-webhook component, handled as
+// unstructured objects here.
+var profileDefaultGVK = schema.GroupVersionKind{Group: "kubeflow.org", Version: "v1alpha1", Kind: "ProfileDefault"}
+
+// Path the defaulting webhook of profiles is served at.
+const PROFILEDEFAULTINGPATH = "/mutate-kubeflow-org-v1-profile"
+
+// +kubebuilder:webhook:path=/mutate-kubeflow-org-v1-profile,mutating=true,failurePolicy=fail,groups=kubeflow.org,resources=profiles,verbs=create,versions=v1,name=mprofile.kubeflow.org,sideEffects=None
+// +kubebuilder:rbac:groups=kubeflow.org,resources=profiledefaults,verbs=get
+
+// profileDefaultSpec is the spec of a ProfileDefault.
+type profileDefaultSpec struct {
+	ResourceQuotaSpec *corev1.ResourceQuotaSpec `json:"resourceQuotaSpec,omitempty"`
+	OwnerPrefixes     []string                  `json:"ownerPrefixes,omitempty"`
+	Labels            map[string]string         `json:"labels,omitempty"`
+}
+
+// profileDefaulter applies the ProfileDefaults ProfileDefault to new profiles.
+type profileDefaulter struct {
+	r       *ProfileReconciler
+	decoder *admission.Decoder
+}
+
+// SetupDefaultingWebhookWithManager serves the defaulting webhook of profiles at PROFILEDEFAULTINGPATH.
+func (r *ProfileReconciler) SetupDefaultingWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(PROFILEDEFAULTINGPATH, &webhook.Admission{Handler: &profileDefaulter{r: r}})
+	return nil
+}
+
+func (d *profileDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle applies the defaults to created profiles managed by the controller. Profiles are admitted unchanged
+// while the ProfileDefault does not exist.
+func (d *profileDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create {
+		return admission.Allowed("")
+	}
+	profileIns := &profilev1.Profile{}
+	if err := d.decoder.Decode(req, profileIns); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !d.r.managesProfile(profileIns.Labels) {
+		return admission.Allowed("")
+	}
+	defaults, err := d.r.profileDefaults(ctx)
+	if err != nil {
+		d.r.Log.Error(err, "error reading profile defaults", "name", d.r.ProfileDefaults)
+		IncRequestErrorCounter("error reading profile defaults", SEVERITY_MAJOR)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if defaults == nil || !applyProfileDefaults(profileIns, defaults) {
+		return admission.Allowed("")
+	}
+	defaulted, err := json.Marshal(profileIns)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, defaulted)
+}
+
+// profileDefaults returns the spec of the ProfileDefaults ProfileDefault, nil if it does not exist.
+func (r *ProfileReconciler) profileDefaults(ctx context.Context) (*profileDefaultSpec, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(profileDefaultGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: r.ProfileDefaults}, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	spec := &profileDefaultSpec{}
+	content, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// applyProfileDefaults sets the defaults on the fields of profileIns which are unset. The quota is not defaulted
+// for profiles with a quota template or a profile template, which provide it. The first matching owner prefix is
+// removed from the owner name. Returns true if profileIns was changed.
+func applyProfileDefaults(profileIns *profilev1.Profile, defaults *profileDefaultSpec) bool {
+	changed := false
+	if defaults.ResourceQuotaSpec != nil && len(profileIns.Spec.ResourceQuotaSpec.Hard) == 0 &&
+		profileIns.Spec.QuotaTemplate == "" && profileIns.Spec.Template == "" {
+		profileIns.Spec.ResourceQuotaSpec = *defaults.ResourceQuotaSpec.DeepCopy()
+		changed = true
+	}
+	for _, prefix := range defaults.OwnerPrefixes {
+		if prefix != "" && strings.HasPrefix(profileIns.Spec.Owner.Name, prefix) {
+			profileIns.Spec.Owner.Name = strings.TrimPrefix(profileIns.Spec.Owner.Name, prefix)
+			changed = true
+			break
+		}
+	}
+	for k, v := range defaults.Labels {
+		if _, ok := profileIns.Labels[k]; ok {
+			continue
+		}
+		if profileIns.Labels == nil {
+			profileIns.Labels = map[string]string{}
+		}
+		profileIns.Labels[k] = v
+		changed = true
+	}
+	return changed
+}
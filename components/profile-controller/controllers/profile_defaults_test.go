@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jsonpatch "gomodules.xyz/jsonpatch/v2"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newTestProfileDefault(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	pd := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	pd.SetGroupVersionKind(profileDefaultGVK)
+	pd.SetName(name)
+	return pd
+}
+
+func TestApplyProfileDefaults(t *testing.T) {
+	defaults := &profileDefaultSpec{
+		ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+		},
+		OwnerPrefixes: []string{"accounts.google.com:"},
+		Labels:        map[string]string{"team": "unassigned", "tier": "standard"},
+	}
+	profile := newTestProfile("kubeflow-user1", "accounts.google.com:user1@abcd.com")
+	profile.Labels = map[string]string{"team": "ml"}
+	assert.True(t, applyProfileDefaults(profile, defaults))
+	assert.Equal(t, "user1@abcd.com", profile.Spec.Owner.Name)
+	assert.Equal(t, map[string]string{"team": "ml", "tier": "standard"}, profile.Labels)
+	assert.Equal(t, *defaults.ResourceQuotaSpec, profile.Spec.ResourceQuotaSpec)
+
+	// Applying again changes nothing.
+	assert.False(t, applyProfileDefaults(profile, defaults))
+
+	// Profiles with a quota, a quota template or a profile template keep it.
+	for _, update := range []func(*profilev1.Profile){
+		func(p *profilev1.Profile) {
+			p.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+		},
+		func(p *profilev1.Profile) { p.Spec.QuotaTemplate = "small" },
+		func(p *profilev1.Profile) { p.Spec.Template = "small" },
+	} {
+		profile = newTestProfile("kubeflow-user1", "user1@abcd.com")
+		update(profile)
+		expected := profile.Spec.ResourceQuotaSpec.DeepCopy()
+		applyProfileDefaults(profile, defaults)
+		assert.Equal(t, *expected, profile.Spec.ResourceQuotaSpec)
+	}
+}
+
+func TestProfileDefaulter(t *testing.T) {
+	pd := newTestProfileDefault("default", map[string]interface{}{
+		"ownerPrefixes": []interface{}{"accounts.google.com:"},
+		"labels":        map[string]interface{}{"tier": "standard"},
+	})
+	r := newFakeReconciler(pd)
+	r.ProfileDefaults = "default"
+	decoder, err := admission.NewDecoder(r.Scheme)
+	require.NoError(t, err)
+	d := &profileDefaulter{r: r}
+	require.NoError(t, d.InjectDecoder(decoder))
+	profile := newTestProfile("kubeflow-user1", "accounts.google.com:user1@abcd.com")
+
+	resp := d.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create, profile, nil))
+	require.True(t, resp.Allowed, resp.Result)
+	assert.ElementsMatch(t, []jsonpatch.JsonPatchOperation{
+		{Operation: "replace", Path: "/spec/owner/name", Value: "user1@abcd.com"},
+		{Operation: "add", Path: "/metadata/labels", Value: map[string]interface{}{"tier": "standard"}},
+	}, resp.Patches)
+
+	// Updates are not defaulted.
+	resp = d.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Update, profile, profile))
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+
+	// Without a ProfileDefault profiles are admitted unchanged.
+	r.ProfileDefaults = "missing"
+	resp = d.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create, profile, nil))
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+}
+
+func TestProfileDefaultsSpec(t *testing.T) {
+	pd := newTestProfileDefault("default", map[string]interface{}{
+		"resourceQuotaSpec": map[string]interface{}{"hard": map[string]interface{}{"cpu": "8"}},
+	})
+	r := newFakeReconciler(pd)
+	r.ProfileDefaults = "default"
+	spec, err := r.profileDefaults(context.TODO())
+	require.NoError(t, err)
+	raw, err := json.Marshal(spec.ResourceQuotaSpec)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"hard":{"cpu":"8"}}`, string(raw))
+}
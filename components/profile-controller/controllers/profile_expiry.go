@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Condition type set once the profile expired with spec.ttl or spec.expiresAt.
+const ProfileExpired = "Expired"
+
+// Event reasons of the profile expiry.
+const (
+	ProfileExpiring         = "ProfileExpiring"
+	ProfileExpiredDeleted   = "ProfileExpiredDeleted"
+	ProfileExpiredSuspended = "ProfileExpiredSuspended"
+	ProfileExpiryResumed    = "ProfileExpiryResumed"
+)
+
+// Actions taken on expired profiles.
+const (
+	PROFILEEXPIRYDELETE  = "delete"
+	PROFILEEXPIRYSUSPEND = "suspend"
+)
+
+// Interval of the warning events while a profile is about to expire.
+const expiryWarningInterval = 24 * time.Hour
+
+// ProfileExpiry configures the expiry of profiles with spec.ttl or spec.expiresAt.
+type ProfileExpiry struct {
+	// Action is taken on expired profiles: PROFILEEXPIRYDELETE deletes the profile with its namespace,
+	// PROFILEEXPIRYSUSPEND keeps the profile and its data but suspends its workloads like spec.suspend.
+	Action string
+	// WarningPeriod before the expiry warning events are emitted on the profile, every expiryWarningInterval.
+	WarningPeriod time.Duration
+}
+
+// Validate checks the action.
+func (e *ProfileExpiry) Validate() error {
+	if e.Action != PROFILEEXPIRYDELETE && e.Action != PROFILEEXPIRYSUSPEND {
+		return fmt.Errorf("invalid profile expiry action %q, expected %v or %v", e.Action, PROFILEEXPIRYDELETE,
+			PROFILEEXPIRYSUSPEND)
+	}
+	if e.WarningPeriod < 0 {
+		return fmt.Errorf("invalid profile expiry warning period %v: must not be negative", e.WarningPeriod)
+	}
+	return nil
+}
+
+// profileExpiresAt returns the time the profile expires at: spec.expiresAt, otherwise its creation plus
+// spec.ttl. ok is false if the profile does not expire.
+func profileExpiresAt(profileIns *profilev1.Profile) (expiresAt time.Time, ok bool) {
+	if profileIns.Spec.ExpiresAt != nil {
+		return profileIns.Spec.ExpiresAt.Time, true
+	}
+	if profileIns.Spec.TTL != nil {
+		return profileIns.CreationTimestamp.Add(profileIns.Spec.TTL.Duration), true
+	}
+	return time.Time{}, false
+}
+
+// profileExpiryReconciler is the sub-controller expiring profiles, with the configuration of the profile
+// controller. It runs next to the profile controller and is not affected by spec.paused.
+type profileExpiryReconciler struct {
+	r *ProfileReconciler
+}
+
+// SetupExpiryWithManager starts the expiry sub-controller of profiles, if ProfileExpiry is set.
+func (r *ProfileReconciler) SetupExpiryWithManager(mgr ctrl.Manager) error {
+	if r.ProfileExpiry == nil {
+		return nil
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("profile-expiry").
+		For(&profilev1.Profile{}, builder.WithPredicates(r.profilePredicate())).
+		Complete(&profileExpiryReconciler{r: r})
+}
+
+// Reconcile warns about, then expires, the profile. Profiles are requeued until the next warning or their expiry.
+func (e *profileExpiryReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	r := e.r
+	logger := r.Log.WithValues("profile", request.NamespacedName, "controller", "profile-expiry")
+	instance := &profilev1.Profile{}
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		IncRequestErrorCounter("error reading the profile object", SEVERITY_MAJOR)
+		logger.Error(err, "error reading the profile object")
+		return reconcile.Result{}, err
+	}
+	if !r.managesProfile(instance.Labels) || !instance.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	expiresAt, ok := profileExpiresAt(instance)
+	remaining := time.Until(expiresAt)
+	if !ok || remaining > 0 {
+		// The expiry was removed or extended, an expired profile is resumed.
+		if err := r.resumeExpiredProfile(ctx, instance); err != nil {
+			IncRequestErrorCounter("error resuming expired profile", SEVERITY_MAJOR)
+			logger.Error(err, "error resuming expired profile")
+			return reconcile.Result{}, err
+		}
+		if !ok {
+			return reconcile.Result{}, nil
+		}
+		if remaining > r.ProfileExpiry.WarningPeriod {
+			return reconcile.Result{RequeueAfter: remaining - r.ProfileExpiry.WarningPeriod}, nil
+		}
+		r.expiryEvent(instance, corev1.EventTypeWarning, ProfileExpiring, fmt.Sprintf(
+			"profile expires at %v, in %v, and will be %v", expiresAt.UTC().Format(time.RFC3339),
+			remaining.Round(time.Minute), expiryActionDone(r.ProfileExpiry.Action)))
+		if remaining > expiryWarningInterval {
+			return reconcile.Result{RequeueAfter: expiryWarningInterval}, nil
+		}
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	expired := false
+	for _, condition := range instance.Status.Conditions {
+		if condition.Type == ProfileExpired {
+			expired = condition.Status == "True"
+		}
+	}
+	message := fmt.Sprintf("profile expired at %v", expiresAt.UTC().Format(time.RFC3339))
+	if err := r.updateExpiredCondition(ctx, instance, "True", message); err != nil {
+		IncRequestErrorCounter("error updating expired condition", SEVERITY_MINOR)
+		logger.Error(err, "error updating expired condition")
+		return reconcile.Result{}, err
+	}
+	switch r.ProfileExpiry.Action {
+	case PROFILEEXPIRYDELETE:
+		logger.Info("Deleting expired profile", "expiresAt", expiresAt)
+		if err := r.Delete(ctx, instance); err != nil && !errors.IsNotFound(err) {
+			IncRequestErrorCounter("error deleting expired profile", SEVERITY_MAJOR)
+			logger.Error(err, "error deleting expired profile")
+			return reconcile.Result{}, err
+		}
+		IncRequestCounter("profile expired deleted")
+		r.expiryEvent(instance, corev1.EventTypeNormal, ProfileExpiredDeleted, message+", deleted")
+	case PROFILEEXPIRYSUSPEND:
+		if err := r.updateSuspended(ctx, instance); err != nil {
+			IncRequestErrorCounter("error suspending expired profile", SEVERITY_MAJOR)
+			logger.Error(err, "error suspending expired profile")
+			return reconcile.Result{}, err
+		}
+		if !expired {
+			logger.Info("Suspended expired profile", "expiresAt", expiresAt)
+			IncRequestCounter("profile expired suspended")
+			r.expiryEvent(instance, corev1.EventTypeNormal, ProfileExpiredSuspended, message+", suspended")
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func expiryActionDone(action string) string {
+	if action == PROFILEEXPIRYDELETE {
+		return "deleted"
+	}
+	return "suspended"
+}
+
+func (r *ProfileReconciler) expiryEvent(profileIns *profilev1.Profile, eventType string, reason string,
+	message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(profileIns, eventType, reason, message)
+	}
+}
+
+// updateExpiredCondition sets the Expired condition of the profile, only written when it changes.
+func (r *ProfileReconciler) updateExpiredCondition(ctx context.Context, profileIns *profilev1.Profile,
+	status string, message string) error {
+	for _, condition := range profileIns.Status.Conditions {
+		if condition.Type == ProfileExpired && condition.Status == status && condition.Message == message {
+			return nil
+		}
+	}
+	r.setProfileCondition(profileIns, ProfileExpired, status, message)
+	return r.Status().Update(ctx, profileIns)
+}
+
+// resumeExpiredProfile removes the Expired condition of a profile which no longer expired and resumes its
+// workloads, unless they are suspended with spec.suspend.
+func (r *ProfileReconciler) resumeExpiredProfile(ctx context.Context, profileIns *profilev1.Profile) error {
+	expired := false
+	for _, condition := range profileIns.Status.Conditions {
+		if condition.Type == ProfileExpired && condition.Status == "True" {
+			expired = true
+		}
+	}
+	if !expired {
+		return nil
+	}
+	message := "profile expiry removed, resumed"
+	if _, ok := profileExpiresAt(profileIns); ok {
+		message = "profile expiry extended, resumed"
+	}
+	r.Log.Info("Resuming expired profile", "profile", profileIns.Name)
+	if err := r.updateExpiredCondition(ctx, profileIns, "False", message); err != nil {
+		return err
+	}
+	if err := r.updateSuspended(ctx, profileIns); err != nil {
+		return err
+	}
+	r.expiryEvent(profileIns, corev1.EventTypeNormal, ProfileExpiryResumed, message)
+	return nil
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestProfileExpiresAt(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	created := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	profile.CreationTimestamp = metav1.NewTime(created)
+	_, ok := profileExpiresAt(profile)
+	assert.False(t, ok)
+
+	profile.Spec.TTL = &metav1.Duration{Duration: 48 * time.Hour}
+	expiresAt, ok := profileExpiresAt(profile)
+	assert.True(t, ok)
+	assert.Equal(t, created.Add(48*time.Hour), expiresAt)
+
+	// expiresAt takes precedence over the TTL.
+	at := metav1.NewTime(created.Add(time.Hour))
+	profile.Spec.ExpiresAt = &at
+	expiresAt, _ = profileExpiresAt(profile)
+	assert.Equal(t, at.Time, expiresAt)
+
+	assert.Error(t, (&ProfileExpiry{Action: "archive"}).Validate())
+	assert.Error(t, (&ProfileExpiry{Action: PROFILEEXPIRYDELETE, WarningPeriod: -time.Hour}).Validate())
+	assert.NoError(t, (&ProfileExpiry{Action: PROFILEEXPIRYSUSPEND}).Validate())
+}
+
+func newExpiryReconciler(action string, objs ...runtime.Object) (*profileExpiryReconciler, *record.FakeRecorder) {
+	r := newFakeReconciler(objs...)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	r.ProfileExpiry = &ProfileExpiry{Action: action, WarningPeriod: 72 * time.Hour}
+	return &profileExpiryReconciler{r: r}, recorder
+}
+
+func TestReconcileProfileExpiring(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	at := metav1.NewTime(time.Now().Add(10 * 24 * time.Hour))
+	profile.Spec.ExpiresAt = &at
+	e, recorder := newExpiryReconciler(PROFILEEXPIRYDELETE, profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	// Requeued at the start of the warning period.
+	result, err := e.Reconcile(request)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(7*24*time.Hour), float64(result.RequeueAfter), float64(time.Minute))
+	assert.Len(t, recorder.Events, 0)
+
+	// Warned daily in the warning period.
+	found := &profilev1.Profile{}
+	require.NoError(t, e.r.Get(context.TODO(), request.NamespacedName, found))
+	at = metav1.NewTime(time.Now().Add(48 * time.Hour))
+	found.Spec.ExpiresAt = &at
+	require.NoError(t, e.r.Update(context.TODO(), found))
+	result, err = e.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, expiryWarningInterval, result.RequeueAfter)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Warning ProfileExpiring")
+}
+
+func TestReconcileProfileExpiredDelete(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	profile.Spec.TTL = &metav1.Duration{Duration: time.Hour}
+	e, recorder := newExpiryReconciler(PROFILEEXPIRYDELETE, profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := e.Reconcile(request)
+	require.NoError(t, err)
+	err = e.r.Get(context.TODO(), request.NamespacedName, &profilev1.Profile{})
+	assert.True(t, errors.IsNotFound(err))
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal ProfileExpiredDeleted")
+}
+
+func TestReconcileProfileExpiredSuspend(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	at := metav1.NewTime(time.Now().Add(-time.Hour))
+	profile.Spec.ExpiresAt = &at
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: profile.Name},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "workspace", Namespace: profile.Name}}
+	e, recorder := newExpiryReconciler(PROFILEEXPIRYSUSPEND, profile, deployment, pvc)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getCondition := func(condType string) profilev1.ProfileCondition {
+		found := &profilev1.Profile{}
+		require.NoError(t, e.r.Get(context.TODO(), request.NamespacedName, found))
+		for _, c := range found.Status.Conditions {
+			if c.Type == condType {
+				return c
+			}
+		}
+		return profilev1.ProfileCondition{}
+	}
+	getReplicas := func() int32 {
+		found := &appsv1.Deployment{}
+		require.NoError(t, e.r.Get(context.TODO(), types.NamespacedName{Name: deployment.Name,
+			Namespace: deployment.Namespace}, found))
+		return *found.Spec.Replicas
+	}
+	quotaKey := types.NamespacedName{Name: SUSPENDQUOTA, Namespace: profile.Name}
+
+	_, err := e.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "True", getCondition(ProfileExpired).Status)
+	assert.Equal(t, "True", getCondition(ProfileSuspended).Status)
+	quota := &corev1.ResourceQuota{}
+	require.NoError(t, e.r.Get(context.TODO(), quotaKey, quota))
+	assert.True(t, quota.Spec.Hard.Pods().IsZero())
+	assert.Equal(t, int32(0), getReplicas())
+	require.NoError(t, e.r.Get(context.TODO(), types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace},
+		&corev1.PersistentVolumeClaim{}))
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal ProfileExpiredSuspended")
+
+	// Suspended once.
+	_, err = e.Reconcile(request)
+	require.NoError(t, err)
+	assert.Len(t, recorder.Events, 0)
+
+	// Extending the expiry resumes the profile.
+	found := &profilev1.Profile{}
+	require.NoError(t, e.r.Get(context.TODO(), request.NamespacedName, found))
+	found.Spec.ExpiresAt = nil
+	found.Spec.TTL = &metav1.Duration{Duration: 24 * time.Hour}
+	found.CreationTimestamp = metav1.Now()
+	require.NoError(t, e.r.Update(context.TODO(), found))
+	_, err = e.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "False", getCondition(ProfileExpired).Status)
+	assert.Equal(t, "False", getCondition(ProfileSuspended).Status)
+	err = e.r.Get(context.TODO(), quotaKey, &corev1.ResourceQuota{})
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, int32(2), getReplicas())
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, "Normal ProfileExpiryResumed")
+	// The new expiry is in the warning period.
+	assert.Contains(t, <-recorder.Events, "Warning ProfileExpiring")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Condition type and event reason set when the profile name is not a valid namespace name.
+const InvalidProfileName = "InvalidProfileName"
+
+// validateProfileName checks that the profile name can be used as namespace name, i.e. is a DNS-1123 label. The
+// error lists the characters which are not allowed.
+func validateProfileName(name string) error {
+	errs := validation.IsDNS1123Label(name)
+	if len(errs) == 0 {
+		return nil
+	}
+	invalid := map[string]bool{}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			invalid[fmt.Sprintf("%q", c)] = true
+		}
+	}
+	message := fmt.Sprintf("profile name %q is not a valid namespace name: %v", name, strings.Join(errs, ", "))
+	if len(invalid) > 0 {
+		chars := make([]string, 0, len(invalid))
+		for c := range invalid {
+			chars = append(chars, c)
+		}
+		sort.Strings(chars)
+		message += fmt.Sprintf(", invalid characters: %v", strings.Join(chars, " "))
+	}
+	return goerrors.New(message)
+}
+
+// rejectInvalidProfileName sets the InvalidProfileName condition and emits a warning event. The profile is not
+// requeued, a retry cannot fix the name.
+func (r *ProfileReconciler) rejectInvalidProfileName(ctx context.Context, instance *profilev1.Profile,
+	err error) (ctrl.Result, error) {
+	r.Log.Info("Profile name is not a valid namespace name, ignored", "profile", instance.Name,
+		"error", err.Error())
+	IncRequestErrorCounter("invalid profile name", SEVERITY_MINOR)
+	for _, condition := range instance.Status.Conditions {
+		if condition.Type == InvalidProfileName && condition.Status == "True" && condition.Message == err.Error() {
+			return reconcile.Result{}, nil
+		}
+	}
+	if r.Recorder != nil {
+		r.Recorder.Event(instance, corev1.EventTypeWarning, InvalidProfileName, err.Error())
+	}
+	r.setProfileCondition(instance, InvalidProfileName, "True", err.Error())
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaceCreateCountingClient counts the attempts to create a Namespace.
+type namespaceCreateCountingClient struct {
+	client.Client
+	namespaceCreates int
+}
+
+func (c *namespaceCreateCountingClient) Create(ctx context.Context, obj runtime.Object,
+	opts ...client.CreateOption) error {
+	if _, ok := obj.(*corev1.Namespace); ok {
+		c.namespaceCreates++
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestValidateProfileName(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		invalid string
+	}{
+		{name: "kubeflow-user1"},
+		{name: "Kubeflow-User1", invalid: `invalid characters: 'K' 'U'`},
+		{name: "kubeflow_user1", invalid: `invalid characters: '_'`},
+		{name: "kubeflow.user1", invalid: `invalid characters: '.'`},
+		{name: "-kubeflow-user1", invalid: "must consist of lower case alphanumeric characters"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateProfileName(tc.name)
+			if tc.invalid == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.invalid)
+		})
+	}
+}
+
+func TestReconcileRejectsInvalidProfileName(t *testing.T) {
+	for _, name := range []string{"Kubeflow-User1", "kubeflow_user1"} {
+		t.Run(name, func(t *testing.T) {
+			profile := newTestProfile(name, "user1@abcd.com")
+			r := newFakeReconciler(profile)
+			countingClient := &namespaceCreateCountingClient{Client: r.Client}
+			r.Client = countingClient
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}
+
+			result, err := r.Reconcile(request)
+			require.NoError(t, err)
+			assert.True(t, result.IsZero(), "invalid names are not requeued")
+			assert.Equal(t, 0, countingClient.namespaceCreates)
+
+			found := &profilev1.Profile{}
+			require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+			require.Len(t, found.Status.Conditions, 1)
+			assert.Equal(t, InvalidProfileName, found.Status.Conditions[0].Type)
+			assert.Equal(t, "True", found.Status.Conditions[0].Status)
+			assert.Contains(t, found.Status.Conditions[0].Message, "invalid characters")
+			require.Len(t, recorder.Events, 1)
+			assert.Contains(t, <-recorder.Events, "Warning InvalidProfileName")
+
+			// Reconciling again neither duplicates the event nor the condition.
+			_, err = r.Reconcile(request)
+			require.NoError(t, err)
+			assert.Len(t, recorder.Events, 0)
+			require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+			assert.Len(t, found.Status.Conditions, 1)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// managesProfile returns true if the profile matches ProfileSelector, a nil selector matches all profiles.
+func (r *ProfileReconciler) managesProfile(profileLabels map[string]string) bool {
+	return r.ProfileSelector == nil || r.ProfileSelector.Matches(labels.Set(profileLabels))
+}
+
+// profilePredicate filters out events of Profiles not matching ProfileSelector, so they never trigger
+// Reconcile. Events of owned objects are mapped to their Profile regardless of its labels, Reconcile
+// checks the selector again for those.
+func (r *ProfileReconciler) profilePredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(meta metav1.Object, object runtime.Object) bool {
+		if profileIns, ok := object.(*profilev1.Profile); ok && r.ignoresNamespace(r.watchedNamespace(profileIns)) {
+			return false
+		}
+		return r.managesProfile(meta.GetLabels())
+	})
+}
+
+// ParseIgnoredNamespaces parses the -ignore-namespaces value, comma separated namespace names.
+func ParseIgnoredNamespaces(value string) ([]string, error) {
+	var namespaces []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid namespace %q: %v", name, strings.Join(errs, ", "))
+		}
+		namespaces = append(namespaces, name)
+	}
+	return namespaces, nil
+}
+
+// ignoresNamespace tells if the profiles of namespace are not reconciled, as listed in IgnoredNamespaces.
+func (r *ProfileReconciler) ignoresNamespace(namespace string) bool {
+	return containsString(r.IgnoredNamespaces, namespace)
+}
+
+// watchedNamespace returns the namespace of the profile, or the one it gets if it has none yet.
+func (r *ProfileReconciler) watchedNamespace(profileIns *profilev1.Profile) string {
+	if profileIns.Status.Namespace != "" {
+		return profileIns.Status.Namespace
+	}
+	return r.desiredNamespace(profileIns)
+}
+
+// watchesProfile tells if the profile is reconciled: its namespace is not in IgnoredNamespaces and its labels,
+// merged with the ones of its namespace if it exists, match WatchSelector. Labeling either the profile or its
+// namespace selects it, e.g. for canary rollouts of controller versions.
+func (r *ProfileReconciler) watchesProfile(ctx context.Context, profileIns *profilev1.Profile) (bool, error) {
+	namespace := r.watchedNamespace(profileIns)
+	if r.ignoresNamespace(namespace) {
+		return false, nil
+	}
+	if r.WatchSelector == nil {
+		return true, nil
+	}
+	set := labels.Set{}
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err == nil {
+		for k, v := range ns.Labels {
+			set[k] = v
+		}
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+	for k, v := range profileIns.Labels {
+		set[k] = v
+	}
+	return r.WatchSelector.Matches(set), nil
+}
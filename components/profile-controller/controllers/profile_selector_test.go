@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestProfilePredicate(t *testing.T) {
+	r := newFakeReconciler()
+	selector, err := labels.Parse("team=ml")
+	require.NoError(t, err)
+	r.ProfileSelector = selector
+	p := r.profilePredicate()
+
+	matching := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	matching.Labels = map[string]string{"team": "ml"}
+	other := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	other.Labels = map[string]string{"team": "data"}
+
+	assert.True(t, p.Create(event.CreateEvent{Meta: matching, Object: matching}))
+	assert.True(t, p.Update(event.UpdateEvent{MetaOld: matching, ObjectOld: matching, MetaNew: matching, ObjectNew: matching}))
+	assert.True(t, p.Delete(event.DeleteEvent{Meta: matching, Object: matching}))
+	assert.False(t, p.Create(event.CreateEvent{Meta: other, Object: other}))
+	assert.False(t, p.Update(event.UpdateEvent{MetaOld: other, ObjectOld: other, MetaNew: other, ObjectNew: other}))
+	assert.False(t, p.Delete(event.DeleteEvent{Meta: other, Object: other}))
+	assert.False(t, p.Generic(event.GenericEvent{Meta: other, Object: other}))
+
+	// Without selector all profiles are managed.
+	r.ProfileSelector = nil
+	assert.True(t, r.profilePredicate().Create(event.CreateEvent{Meta: other, Object: other}))
+}
+
+func TestReconcileIgnoresUnselectedProfile(t *testing.T) {
+	profile := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	profile.Labels = map[string]string{"team": "data"}
+	r := newFakeReconciler(profile)
+	selector, err := labels.Parse("team=ml")
+	require.NoError(t, err)
+	r.ProfileSelector = selector
+
+	// E.g. triggered by an event of an object owned by the profile.
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	err = r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, &corev1.Namespace{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestParseIgnoredNamespaces(t *testing.T) {
+	namespaces, err := ParseIgnoredNamespaces("kubeflow-user1, team-ml,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubeflow-user1", "team-ml"}, namespaces)
+	_, err = ParseIgnoredNamespaces("Team_ML")
+	assert.Error(t, err)
+}
+
+func TestReconcileWatchLabelSelector(t *testing.T) {
+	canary := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	canary.Labels = map[string]string{"canary": "true"}
+	stable := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	// Selected by the label of its namespace.
+	controller := true
+	labeled := newTestProfile("kubeflow-user3", "user3@abcd.com")
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        labeled.Name,
+		Labels:      map[string]string{"canary": "true"},
+		Annotations: map[string]string{"owner": "user3@abcd.com"},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "kubeflow.org/v1", Kind: "Profile", Name: labeled.Name, Controller: &controller,
+		}},
+	}}
+	r := newFakeReconciler(canary, stable, labeled, ns)
+	selector, err := labels.Parse("canary=true")
+	require.NoError(t, err)
+	r.WatchSelector = selector
+
+	for _, profile := range []*profilev1.Profile{canary, stable, labeled} {
+		_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: canary.Name}, &corev1.Namespace{}))
+	err = r.Get(context.TODO(), types.NamespacedName{Name: stable.Name}, &corev1.Namespace{})
+	assert.True(t, errors.IsNotFound(err))
+	key := types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: labeled.Name}
+	require.NoError(t, r.Get(context.TODO(), key, &corev1.ServiceAccount{}))
+}
+
+func TestReconcileIgnoredNamespaces(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.IgnoredNamespaces = []string{"kubeflow-user1"}
+	assert.False(t, r.profilePredicate().Create(event.CreateEvent{Meta: profile, Object: profile}))
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	err = r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, &corev1.Namespace{})
+	assert.True(t, errors.IsNotFound(err))
+}
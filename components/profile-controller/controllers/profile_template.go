@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// profileTemplate returns the spec of the ClusterProfileTemplate referenced by spec.template, nil if the profile
+// references none.
+func (r *ProfileReconciler) profileTemplate(ctx context.Context,
+	profileIns *profilev1.Profile) (*profilev1.ClusterProfileTemplateSpec, error) {
+	if profileIns.Spec.Template == "" {
+		return nil, nil
+	}
+	template := &profilev1.ClusterProfileTemplate{}
+	if err := r.Get(ctx, types.NamespacedName{Name: profileIns.Spec.Template}, template); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("unknown profile template %q", profileIns.Spec.Template)
+		}
+		return nil, err
+	}
+	return &template.Spec, nil
+}
+
+// expandProfileTemplate returns a copy of the profile completed with the quota and limit range of the template
+// it does not set. The expanded profile is only used to render the resources of the profile, it is never
+// written.
+func expandProfileTemplate(profileIns *profilev1.Profile,
+	template *profilev1.ClusterProfileTemplateSpec) *profilev1.Profile {
+	if template == nil {
+		return profileIns
+	}
+	expanded := profileIns.DeepCopy()
+	if len(expanded.Spec.ResourceQuotaSpec.Hard) == 0 && expanded.Spec.QuotaTemplate == "" &&
+		template.ResourceQuotaSpec != nil {
+		expanded.Spec.ResourceQuotaSpec = *template.ResourceQuotaSpec.DeepCopy()
+	}
+	if expanded.Spec.LimitRangeSpec == nil && template.LimitRangeSpec != nil {
+		expanded.Spec.LimitRangeSpec = template.LimitRangeSpec.DeepCopy()
+	}
+	return expanded
+}
+
+// templateLabels returns the namespace labels of the template under labels, which take precedence.
+func templateLabels(template *profilev1.ClusterProfileTemplateSpec, labels map[string]string) map[string]string {
+	if template == nil || len(template.Labels) == 0 {
+		return labels
+	}
+	merged := make(map[string]string, len(template.Labels)+len(labels))
+	for k, v := range template.Labels {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged
+}
+
+// patchTemplatePlugins adds the plugins of the template to the profile CR if it has no plugin of the same kind,
+// as PatchDefaultPluginSpec does for the controller defaults. Template plugins take precedence over those.
+func (r *ProfileReconciler) patchTemplatePlugins(ctx context.Context, profileIns *profilev1.Profile,
+	template *profilev1.ClusterProfileTemplateSpec) error {
+	if template == nil {
+		return nil
+	}
+	kinds := map[string]bool{}
+	for _, p := range profileIns.Spec.Plugins {
+		kinds[p.Kind] = true
+	}
+	patched := false
+	for _, p := range template.Plugins {
+		if kinds[p.Kind] {
+			continue
+		}
+		kinds[p.Kind] = true
+		profileIns.Spec.Plugins = append(profileIns.Spec.Plugins, *p.DeepCopy())
+		patched = true
+	}
+	if !patched {
+		return nil
+	}
+	return r.Update(ctx, profileIns)
+}
+
+// profileTemplateToProfiles maps a ClusterProfileTemplate to the profiles referencing it, so a changed template
+// is applied to their namespaces.
+func (r *ProfileReconciler) profileTemplateToProfiles() *handler.EnqueueRequestsFromMapFunc {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			profiles := &profilev1.ProfileList{}
+			if err := r.List(context.Background(), profiles); err != nil {
+				r.Log.Error(err, "error listing profiles for profile template", "template", obj.Meta.GetName())
+				IncRequestErrorCounter("error listing profiles for profile template", SEVERITY_MINOR)
+				return nil
+			}
+			var requests []reconcile.Request
+			for _, p := range profiles.Items {
+				if p.Spec.Template == obj.Meta.GetName() && r.managesProfile(p.Labels) {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: p.Name}})
+				}
+			}
+			return requests
+		}),
+	}
+}
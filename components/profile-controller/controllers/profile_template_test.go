@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newTestProfileTemplate(name string) *profilev1.ClusterProfileTemplate {
+	return &profilev1.ClusterProfileTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: profilev1.ClusterProfileTemplateSpec{
+			ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			},
+			LimitRangeSpec: &corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+				Type:    corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}}},
+			Labels:  map[string]string{"tier": name},
+			Plugins: []profilev1.Plugin{newPluginWithSpec("Custom", `{"enabled":true}`)},
+		},
+	}
+}
+
+func TestExpandProfileTemplate(t *testing.T) {
+	template := &newTestProfileTemplate("small").Spec
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	expanded := expandProfileTemplate(profile, template)
+	assert.Equal(t, *template.ResourceQuotaSpec, expanded.Spec.ResourceQuotaSpec)
+	assert.Equal(t, template.LimitRangeSpec, expanded.Spec.LimitRangeSpec)
+	// The profile itself is left unchanged.
+	assert.Empty(t, profile.Spec.ResourceQuotaSpec.Hard)
+	assert.Nil(t, profile.Spec.LimitRangeSpec)
+
+	// The quota, quota template and limit range of the profile take precedence.
+	profile.Spec.QuotaTemplate = "large"
+	profile.Spec.LimitRangeSpec = &corev1.LimitRangeSpec{}
+	expanded = expandProfileTemplate(profile, template)
+	assert.Empty(t, expanded.Spec.ResourceQuotaSpec.Hard)
+	assert.Equal(t, &corev1.LimitRangeSpec{}, expanded.Spec.LimitRangeSpec)
+
+	assert.Same(t, profile, expandProfileTemplate(profile, nil))
+
+	assert.Equal(t, map[string]string{"tier": "small", "team": "ml"},
+		templateLabels(template, map[string]string{"team": "ml"}))
+	assert.Equal(t, map[string]string{"tier": "large"}, templateLabels(template, map[string]string{"tier": "large"}))
+}
+
+func TestReconcileProfileTemplate(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.Template = "small"
+	r := newFakeReconciler(profile, newTestProfileTemplate("small"), newTestProfileTemplate("large"))
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	quota := &corev1.ResourceQuota{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: KFQUOTA, Namespace: profile.Name}, quota))
+	assert.Equal(t, resource.MustParse("4"), quota.Spec.Hard[corev1.ResourceCPU])
+	limitRange := &corev1.LimitRange{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: KFLIMITRANGE, Namespace: profile.Name},
+		limitRange))
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "small", ns.Labels["tier"])
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	require.Len(t, found.Spec.Plugins, 1)
+	assert.Equal(t, "Custom", found.Spec.Plugins[0].Kind)
+	// The quota and limit range of the template are not written to the profile.
+	assert.Empty(t, found.Spec.ResourceQuotaSpec.Hard)
+	assert.Nil(t, found.Spec.LimitRangeSpec)
+
+	// The labels of another template replace the previous ones on the existing namespace.
+	found.Spec.Template = "large"
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "large", ns.Labels["tier"])
+
+	// Unknown templates fail the profile.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Spec.Template = "huge"
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	failed := false
+	for _, c := range found.Status.Conditions {
+		if c.Type == profilev1.ProfileFailed {
+			failed = true
+			assert.Contains(t, c.Message, `unknown profile template "huge"`)
+		}
+	}
+	assert.True(t, failed)
+}
+
+func TestProfileTemplateToProfiles(t *testing.T) {
+	small := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	small.Spec.Template = "small"
+	large := newTestProfile("kubeflow-user2", "user2@abcd.com")
+	large.Spec.Template = "large"
+	template := newTestProfileTemplate("small")
+	r := newFakeReconciler(small, large, newTestProfile("kubeflow-user3", "user3@abcd.com"), template)
+	requests := r.profileTemplateToProfiles().ToRequests.Map(handler.MapObject{Meta: template, Object: template})
+	require.Len(t, requests, 1)
+	assert.Equal(t, small.Name, requests[0].Name)
+}
+
+func TestReconcileProfileTemplateWithProfileDefaults(t *testing.T) {
+	pd := newTestProfileDefault("default", map[string]interface{}{
+		"resourceQuotaSpec": map[string]interface{}{"hard": map[string]interface{}{"cpu": "8"}},
+	})
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.Template = "small"
+	r := newFakeReconciler(pd, profile, newTestProfileTemplate("small"))
+	r.ProfileDefaults = "default"
+	decoder, err := admission.NewDecoder(r.Scheme)
+	require.NoError(t, err)
+	d := &profileDefaulter{r: r}
+	require.NoError(t, d.InjectDecoder(decoder))
+
+	// The default quota is not set on profiles using a template.
+	resp := d.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create, profile, nil))
+	require.True(t, resp.Allowed, resp.Result)
+	assert.Empty(t, resp.Patches)
+
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+	quota := &corev1.ResourceQuota{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: KFQUOTA, Namespace: profile.Name}, quota))
+	assert.Equal(t, resource.MustParse("4"), quota.Spec.Hard[corev1.ResourceCPU])
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Path the validating webhook of profiles is served at.
+const PROFILEVALIDATIONPATH = "/validate-kubeflow-org-v1-profile"
+
+// +kubebuilder:webhook:path=/validate-kubeflow-org-v1-profile,mutating=false,failurePolicy=fail,groups=kubeflow.org,resources=profiles,verbs=create;update,versions=v1,name=vprofile.kubeflow.org,sideEffects=None
+
+// profileValidator rejects profiles the reconcile would fail, at admission time.
+type profileValidator struct {
+	r       *ProfileReconciler
+	decoder *admission.Decoder
+}
+
+// SetupValidatingWebhookWithManager serves the validating webhook of profiles at PROFILEVALIDATIONPATH.
+func (r *ProfileReconciler) SetupValidatingWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(PROFILEVALIDATIONPATH, &webhook.Admission{Handler: &profileValidator{r: r}})
+	return nil
+}
+
+func (v *profileValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates created and updated profiles. An update is only rejected for problems the previous version
+// of the profile did not have, so profiles already invalid can still be updated by the controller, e.g. to
+// remove their finalizer. Profiles being deleted or not managed by the controller are not validated.
+func (v *profileValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	profileIns := &profilev1.Profile{}
+	if err := v.decoder.Decode(req, profileIns); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !profileIns.DeletionTimestamp.IsZero() || !v.r.managesProfile(profileIns.Labels) {
+		return admission.Allowed("")
+	}
+	problems := v.r.validateProfile(profileIns)
+	switch req.Operation {
+	case admissionv1beta1.Create:
+		problem, err := v.r.validateProfileNamespace(ctx, profileIns)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	case admissionv1beta1.Update:
+		old := &profilev1.Profile{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		problems = newProblems(problems, v.r.validateProfile(old))
+		if old.Status.Namespace != "" && profileIns.Spec.NamespaceName != old.Spec.NamespaceName {
+			problems = append(problems, fmt.Sprintf("spec.namespaceName cannot be changed, the profile uses namespace %v",
+				old.Status.Namespace))
+		}
+	}
+	if len(problems) > 0 {
+		IncRequestCounter("reject invalid profile at admission")
+		return admission.Denied(strings.Join(problems, "; "))
+	}
+	return admission.Allowed("")
+}
+
+// newProblems returns the problems not in previous.
+func newProblems(problems []string, previous []string) []string {
+	known := make(map[string]bool, len(previous))
+	for _, p := range previous {
+		known[p] = true
+	}
+	var added []string
+	for _, p := range problems {
+		if !known[p] {
+			added = append(added, p)
+		}
+	}
+	return added
+}
+
+// validateProfile returns the problems of the profile the reconcile would fail it for: an invalid name, namespace
+// name, owner or contributor, invalid quota, limits or namespace annotations, and unknown or invalid plugins.
+func (r *ProfileReconciler) validateProfile(profileIns *profilev1.Profile) []string {
+	var problems []string
+	add := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	add(validateProfileName(profileIns.Name))
+	namespace := profileIns.Status.Namespace
+	if namespace == "" {
+		namespace = r.desiredNamespace(profileIns)
+	}
+	if namespace != profileIns.Name {
+		add(validateNamespaceName(namespace))
+	}
+	add(validateSubject("owner", profileIns.Spec.Owner))
+	for _, c := range profileIns.Spec.Contributors {
+		add(validateSubject("contributor", c.Subject))
+	}
+	add(validateCostLabels(profileIns))
+	if _, err := r.getWorkspacePVC(profileIns); err != nil {
+		add(err)
+	}
+	if profileIns.Spec.GcpServiceAccount != "" {
+		add(validateGcpServiceAccount(profileIns.Spec.GcpServiceAccount))
+	}
+	add(validatePlugins(profileIns.Spec.Plugins))
+	add(validateResourceList("resourceQuotaSpec", profileIns.Spec.ResourceQuotaSpec.Hard))
+	if _, _, err := r.resourceQuotaSpec(profileIns); err != nil {
+		add(err)
+	}
+	if spec := profileIns.Spec.LimitRangeSpec; spec != nil {
+		for _, item := range spec.Limits {
+			for _, limits := range []corev1.ResourceList{item.Max, item.Min, item.Default, item.DefaultRequest} {
+				add(validateResourceList("limitRangeSpec", limits))
+			}
+		}
+	}
+	if _, err := r.limitRangeSpec(profileIns); err != nil {
+		add(err)
+	}
+	if _, err := r.profileNamespaceAnnotations(profileIns); err != nil {
+		add(err)
+	}
+	if ttl := profileIns.Spec.TTL; ttl != nil && ttl.Duration <= 0 {
+		add(fmt.Errorf("invalid ttl %v: must be positive", ttl.Duration))
+	}
+	return problems
+}
+
+// validateSubject checks the kind and name of a subject, Users must be email addresses.
+func validateSubject(role string, subject rbacv1.Subject) error {
+	if subject.Name == "" {
+		return fmt.Errorf("%v has no name", role)
+	}
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		if address, err := mail.ParseAddress(subject.Name); err != nil || address.Address != subject.Name {
+			return fmt.Errorf("%v %q is not an email address", role, subject.Name)
+		}
+	case rbacv1.GroupKind:
+	case rbacv1.ServiceAccountKind:
+		if errs := validation.IsDNS1123Label(subject.Namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace of %v service account %v: %v", role, subject.Name,
+				strings.Join(errs, ", "))
+		}
+	default:
+		return fmt.Errorf("invalid kind %q of %v %v, expected %v, %v or %v", subject.Kind, role, subject.Name,
+			rbacv1.UserKind, rbacv1.GroupKind, rbacv1.ServiceAccountKind)
+	}
+	return nil
+}
+
+// validatePlugins checks that the plugins are of a known kind and have a valid spec.
+func validatePlugins(plugins []profilev1.Plugin) error {
+	for _, p := range plugins {
+		var spec interface{}
+		switch p.Kind {
+		case KIND_WORKLOAD_IDENTITY:
+			spec = &GcpWorkloadIdentity{}
+		case KIND_AWS_IAM_FOR_SERVICE_ACCOUNT:
+			spec = &AwsIAMForServiceAccount{}
+		default:
+			return fmt.Errorf("unknown plugin kind %q, expected %v or %v", p.Kind, KIND_WORKLOAD_IDENTITY,
+				KIND_AWS_IAM_FOR_SERVICE_ACCOUNT)
+		}
+		if p.Spec != nil {
+			if err := json.Unmarshal(p.Spec.Raw, spec); err != nil {
+				return fmt.Errorf("invalid spec of plugin %v: %v", p.Kind, err)
+			}
+		}
+		switch s := spec.(type) {
+		case *GcpWorkloadIdentity:
+			if err := validateGcpServiceAccount(s.GcpServiceAccount); err != nil {
+				return fmt.Errorf("invalid spec of plugin %v: %v", p.Kind, err)
+			}
+		case *AwsIAMForServiceAccount:
+			if s.AwsIAMRole == "" {
+				return fmt.Errorf("invalid spec of plugin %v: awsIamRole is not set", p.Kind)
+			}
+		}
+	}
+	return nil
+}
+
+// validateResourceList checks that the resource names are qualified names and the quantities not negative.
+func validateResourceList(field string, resources corev1.ResourceList) error {
+	for name, quantity := range resources {
+		if errs := validation.IsQualifiedName(string(name)); len(errs) > 0 {
+			return fmt.Errorf("invalid resource name %q in %v: %v", name, field, strings.Join(errs, ", "))
+		}
+		if quantity.Cmp(resource.Quantity{}) < 0 {
+			return fmt.Errorf("invalid %v of %v in %v: must not be negative", quantity.String(), name, field)
+		}
+	}
+	return nil
+}
+
+// validateProfileNamespace returns the problem of a new profile whose namespace already exists without being
+// owned by the profile owner or adopted by the profile, or is used by another profile, empty if there is none.
+func (r *ProfileReconciler) validateProfileNamespace(ctx context.Context, profileIns *profilev1.Profile) (string,
+	error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: r.desiredNamespace(profileIns)}, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if collision := namespaceCollision(profileIns, ns); collision != "" {
+		return collision, nil
+	}
+	if r.adoptsNamespace(profileIns, ns) {
+		return "", nil
+	}
+	if owner, ok := ns.Annotations["owner"]; ok && owner == profileIns.Spec.Owner.Name {
+		return "", nil
+	}
+	return fmt.Sprintf("namespace %v already exists, but not owned by profile creator %v", ns.Name,
+		profileIns.Spec.Owner.Name), nil
+}
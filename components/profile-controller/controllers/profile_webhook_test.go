@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newProfileValidator(t *testing.T, objs ...runtime.Object) *profileValidator {
+	r := newFakeReconciler(objs...)
+	decoder, err := admission.NewDecoder(r.Scheme)
+	require.NoError(t, err)
+	v := &profileValidator{r: r}
+	require.NoError(t, v.InjectDecoder(decoder))
+	return v
+}
+
+func newAdmissionRequest(t *testing.T, operation admissionv1beta1.Operation, profile *profilev1.Profile,
+	old *profilev1.Profile) admission.Request {
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{Operation: operation}}
+	raw, err := json.Marshal(profile)
+	require.NoError(t, err)
+	req.Object = runtime.RawExtension{Raw: raw}
+	if old != nil {
+		raw, err = json.Marshal(old)
+		require.NoError(t, err)
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}
+
+func newPluginWithSpec(kind string, spec string) profilev1.Plugin {
+	plugin := profilev1.Plugin{Spec: &runtime.RawExtension{Raw: []byte(spec)}}
+	plugin.Kind = kind
+	return plugin
+}
+
+func TestValidateProfile(t *testing.T) {
+	r := newFakeReconciler()
+	r.QuotaTemplates = QuotaTemplates{"small": {}}
+	for _, tc := range []struct {
+		name    string
+		update  func(*profilev1.Profile)
+		invalid bool
+	}{
+		{name: "valid", update: func(p *profilev1.Profile) {
+			p.Spec.Contributors = []profilev1.Contributor{
+				{Subject: rbacv1.Subject{Kind: "Group", Name: "team-a"}},
+				{Subject: rbacv1.Subject{Kind: "ServiceAccount", Name: "pipeline-runner", Namespace: "kubeflow"}},
+			}
+			p.Spec.Plugins = []profilev1.Plugin{
+				newPluginWithSpec(KIND_WORKLOAD_IDENTITY, `{"gcpServiceAccount":"user1-sa@my-project.iam.gserviceaccount.com"}`),
+			}
+			p.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{"requests.nvidia.com/gpu": resource.MustParse("2")}
+			p.Spec.QuotaTemplate = "small"
+		}},
+		{name: "invalid name", update: func(p *profilev1.Profile) { p.Name = "Kubeflow_User1" }, invalid: true},
+		{name: "owner not an email", update: func(p *profilev1.Profile) { p.Spec.Owner.Name = "user1@" },
+			invalid: true},
+		{name: "owner with display name", update: func(p *profilev1.Profile) {
+			p.Spec.Owner.Name = "User 1 <user1@abcd.com>"
+		}, invalid: true},
+		{name: "unknown owner kind", update: func(p *profilev1.Profile) { p.Spec.Owner.Kind = "Robot" }, invalid: true},
+		{name: "contributor not an email", update: func(p *profilev1.Profile) {
+			p.Spec.Contributors = []profilev1.Contributor{{Subject: rbacv1.Subject{Kind: "User", Name: "user2"}}}
+		}, invalid: true},
+		{name: "unknown plugin", update: func(p *profilev1.Profile) {
+			p.Spec.Plugins = []profilev1.Plugin{newPluginWithSpec("CustomPlugin", `{}`)}
+		}, invalid: true},
+		{name: "invalid plugin spec", update: func(p *profilev1.Profile) {
+			p.Spec.Plugins = []profilev1.Plugin{newPluginWithSpec(KIND_WORKLOAD_IDENTITY, `{"gcpServiceAccount":"user1"}`)}
+		}, invalid: true},
+		{name: "negative quota", update: func(p *profilev1.Profile) {
+			p.Spec.ResourceQuotaSpec.Hard = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("-1")}
+		}, invalid: true},
+		{name: "unknown quota template", update: func(p *profilev1.Profile) { p.Spec.QuotaTemplate = "huge" },
+			invalid: true},
+		{name: "negative limit", update: func(p *profilev1.Profile) {
+			p.Spec.LimitRangeSpec = &corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+				Type: corev1.LimitTypeContainer,
+				Max:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("-1Gi")},
+			}}}
+		}, invalid: true},
+		{name: "controller annotation", update: func(p *profilev1.Profile) {
+			p.Spec.NamespaceAnnotations = map[string]string{"owner": "user2@abcd.com"}
+		}, invalid: true},
+		{name: "negative ttl", update: func(p *profilev1.Profile) {
+			p.Spec.TTL = &metav1.Duration{Duration: -time.Hour}
+		}, invalid: true},
+	} {
+		profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+		tc.update(profile)
+		problems := r.validateProfile(profile)
+		if tc.invalid {
+			assert.Len(t, problems, 1, tc.name)
+		} else {
+			assert.Empty(t, problems, tc.name)
+		}
+	}
+}
+
+func TestProfileValidatorCreate(t *testing.T) {
+	taken := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "kubeflow-user2",
+		Annotations: map[string]string{"owner": "user2@abcd.com"},
+	}}
+	v := newProfileValidator(t, taken)
+
+	resp := v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create,
+		newTestProfile("kubeflow-user1", "user1@abcd.com"), nil))
+	assert.True(t, resp.Allowed)
+
+	// The namespace is owned by someone else.
+	resp = v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create,
+		newTestProfile("kubeflow-user2", "user1@abcd.com"), nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Reason, "namespace kubeflow-user2 already exists")
+
+	// The owner of the namespace recreates the profile.
+	resp = v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create,
+		newTestProfile("kubeflow-user2", "user2@abcd.com"), nil))
+	assert.True(t, resp.Allowed)
+}
+
+func TestProfileValidatorUpdate(t *testing.T) {
+	v := newProfileValidator(t)
+	old := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	old.Spec.Plugins = []profilev1.Plugin{newPluginWithSpec("CustomPlugin", `{}`)}
+
+	// Problems of the previous version do not block updates, e.g. of the controller.
+	updated := old.DeepCopy()
+	updated.Finalizers = []string{PROFILEFINALIZER}
+	resp := v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Update, updated, old))
+	assert.True(t, resp.Allowed)
+
+	// New problems do.
+	updated.Spec.Owner.Name = "user1"
+	resp = v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Update, updated, old))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Reason, `owner "user1" is not an email address`)
+
+	// Profiles being deleted are not validated.
+	now := metav1.Now()
+	updated.DeletionTimestamp = &now
+	resp = v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Update, updated, old))
+	assert.True(t, resp.Allowed)
+}
+
+func TestProfileValidatorNamespaceName(t *testing.T) {
+	v := newProfileValidator(t)
+	old := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	updated := old.DeepCopy()
+	updated.Spec.NamespaceName = "ml-research"
+	resp := v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Update, updated, old))
+	assert.True(t, resp.Allowed, "not created yet")
+
+	old.Status.Namespace = "kubeflow-user1"
+	resp = v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Update, updated, old))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Reason, "spec.namespaceName cannot be changed")
+
+	updated.Spec.NamespaceName = "ML_Research"
+	resp = v.Handle(context.TODO(), newAdmissionRequest(t, admissionv1beta1.Create, updated, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Reason, `namespace name "ML_Research" is not valid`)
+}
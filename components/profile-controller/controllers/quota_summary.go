@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/ghodss/yaml"
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Keys of the quota summary ConfigMap.
+const (
+	QUOTASUMMARYRESOURCEQUOTAS = "resourceQuotas"
+	QUOTASUMMARYLIMITRANGES    = "limitRanges"
+)
+
+// getQuotaSummary returns the data of the quota summary ConfigMap: the hard limits of every ResourceQuota
+// and the limits of every LimitRange in the namespace, by name, in yaml.
+func (r *ProfileReconciler) getQuotaSummary(ctx context.Context, namespace string) (map[string]string, error) {
+	quotas := &corev1.ResourceQuotaList{}
+	if err := r.List(ctx, quotas, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	limitRanges := &corev1.LimitRangeList{}
+	if err := r.List(ctx, limitRanges, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	hard := map[string]corev1.ResourceList{}
+	for _, quota := range quotas.Items {
+		hard[quota.Name] = quota.Spec.Hard
+	}
+	limits := map[string][]corev1.LimitRangeItem{}
+	for _, limitRange := range limitRanges.Items {
+		limits[limitRange.Name] = limitRange.Spec.Limits
+	}
+	quotaData, err := yaml.Marshal(hard)
+	if err != nil {
+		return nil, err
+	}
+	limitData, err := yaml.Marshal(limits)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		QUOTASUMMARYRESOURCEQUOTAS: string(quotaData),
+		QUOTASUMMARYLIMITRANGES:    string(limitData),
+	}, nil
+}
+
+// updateQuotaSummary create or update the ConfigMap QuotaSummaryConfigMap summarizing the effective quota and
+// limits of the profile namespace, for apps to read them without access to ResourceQuotas and LimitRanges.
+func (r *ProfileReconciler) updateQuotaSummary(ctx context.Context, profileIns *profilev1.Profile) error {
+	if r.QuotaSummaryConfigMap == "" {
+		return nil
+	}
+	logger := r.Log.WithValues("profile", profileIns.Name)
+	data, err := r.getQuotaSummary(ctx, profileNamespace(profileIns))
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.QuotaSummaryConfigMap,
+			Namespace: profileNamespace(profileIns),
+		},
+		Data: data,
+	}
+	if err := controllerutil.SetControllerReference(profileIns, configMap, r.Scheme); err != nil {
+		return err
+	}
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Creating quota summary ConfigMap", "namespace", configMap.Namespace, "name", configMap.Name)
+			return r.Create(ctx, configMap)
+		}
+		return err
+	}
+	refUpdated, err := r.setMissingControllerReference(profileIns, found)
+	if err != nil {
+		return err
+	}
+	if refUpdated || !reflect.DeepEqual(configMap.Data, found.Data) {
+		found.Data = configMap.Data
+		logger.Info("Updating quota summary ConfigMap", "namespace", configMap.Namespace, "name", configMap.Name)
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// namespaceToProfile maps objects in a profile namespace to the profile controlling the namespace.
+func (r *ProfileReconciler) namespaceToProfile() *handler.EnqueueRequestsFromMapFunc {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			profile := r.profileOfNamespace(obj.Meta.GetNamespace())
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: profile}}}
+		}),
+	}
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileQuotaSummary(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.ResourceQuotaSpec = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+	}
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: profile.Name},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type:    corev1.LimitTypeContainer,
+			Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		}}},
+	}
+	r := newFakeReconciler(profile, limitRange)
+	r.QuotaSummaryConfigMap = "quota-summary"
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+
+	getSummary := func() *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{}
+		require.NoError(t, r.Get(context.TODO(),
+			types.NamespacedName{Name: "quota-summary", Namespace: profile.Name}, configMap))
+		return configMap
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	summary := getSummary()
+	assert.Equal(t, "kf-resource-quota:\n  cpu: \"4\"\n", summary.Data[QUOTASUMMARYRESOURCEQUOTAS])
+	assert.Equal(t, "defaults:\n- default:\n    memory: 1Gi\n  type: Container\n",
+		summary.Data[QUOTASUMMARYLIMITRANGES])
+	require.Len(t, summary.OwnerReferences, 1)
+	assert.Equal(t, profile.Name, summary.OwnerReferences[0].Name)
+
+	// The summary follows the quota.
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, profile))
+	profile.Spec.ResourceQuotaSpec.Hard[corev1.ResourceCPU] = resource.MustParse("8")
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "kf-resource-quota:\n  cpu: \"8\"\n", getSummary().Data[QUOTASUMMARYRESOURCEQUOTAS])
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// QuotaTemplates maps template names to the ResourceQuota applied to profiles selecting them.
+type QuotaTemplates map[string]corev1.ResourceQuotaSpec
+
+// LoadQuotaTemplates reads quota templates from a yaml file, usually mounted from a ConfigMap, e.g.
+//
+//	small:
+//	  hard:
+//	    cpu: "4"
+//	    memory: 16Gi
+func LoadQuotaTemplates(path string) (QuotaTemplates, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	templates := QuotaTemplates{}
+	if err := yaml.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("invalid quota templates in %v: %v", path, err)
+	}
+	return templates, nil
+}
+
+// resourceQuotaSpec returns the ResourceQuotaSpec to apply to the profile namespace: the inline
+// ResourceQuotaSpec if any, otherwise the selected quota template. ok is false if no quota applies.
+func (t QuotaTemplates) resourceQuotaSpec(profileIns *profilev1.Profile) (spec corev1.ResourceQuotaSpec, ok bool, err error) {
+	if len(profileIns.Spec.ResourceQuotaSpec.Hard) > 0 {
+		return profileIns.Spec.ResourceQuotaSpec, true, nil
+	}
+	name := profileIns.Spec.QuotaTemplate
+	if name == "" {
+		return corev1.ResourceQuotaSpec{}, false, nil
+	}
+	spec, ok = t[name]
+	if !ok {
+		return corev1.ResourceQuotaSpec{}, false, fmt.Errorf("unknown quota template %q", name)
+	}
+	return spec, true, nil
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestLoadQuotaTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota-templates")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "templates.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+small:
+  hard:
+    cpu: "4"
+large:
+  hard:
+    cpu: "32"
+    memory: 128Gi
+`), 0644))
+
+	templates, err := LoadQuotaTemplates(path)
+	require.NoError(t, err)
+	assert.Len(t, templates, 2)
+	cpu := templates["large"].Hard[corev1.ResourceCPU]
+	assert.Equal(t, "32", cpu.String())
+
+	_, err = LoadQuotaTemplates(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestResolveQuotaTemplate(t *testing.T) {
+	templates := QuotaTemplates{
+		"small": {Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+	}
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+
+	_, ok, err := templates.resourceQuotaSpec(profile)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	profile.Spec.QuotaTemplate = "small"
+	spec, ok, err := templates.resourceQuotaSpec(profile)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, templates["small"], spec)
+
+	// The inline spec takes precedence over the template.
+	profile.Spec.ResourceQuotaSpec = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+	}
+	spec, _, err = templates.resourceQuotaSpec(profile)
+	require.NoError(t, err)
+	assert.Equal(t, profile.Spec.ResourceQuotaSpec, spec)
+
+	profile.Spec = profilev1.ProfileSpec{QuotaTemplate: "unknown"}
+	_, _, err = templates.resourceQuotaSpec(profile)
+	assert.EqualError(t, err, `unknown quota template "unknown"`)
+}
+
+func TestReconcileQuotaTemplate(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.QuotaTemplate = "small"
+	r := newFakeReconciler(profile)
+	r.QuotaTemplates = QuotaTemplates{
+		"small": {Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+		"large": {Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("32")}},
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	quotaCPU := func() string {
+		quota := &corev1.ResourceQuota{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: KFQUOTA, Namespace: profile.Name}, quota))
+		cpu := quota.Spec.Hard[corev1.ResourceCPU]
+		return cpu.String()
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "4", quotaCPU())
+
+	// Switch to another template.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	profile.Spec.QuotaTemplate = "large"
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "32", quotaCPU())
+
+	// Unknown template fails the profile.
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	profile.Spec.QuotaTemplate = "unknown"
+	require.NoError(t, r.Update(context.TODO(), profile))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, profile))
+	require.NotEmpty(t, profile.Status.Conditions)
+	last := profile.Status.Conditions[len(profile.Status.Conditions)-1]
+	assert.Equal(t, profilev1.ProfileFailed, last.Type)
+	assert.Equal(t, `unknown quota template "unknown"`, last.Message)
+	// The previously applied quota is left in place.
+	assert.Equal(t, "32", quotaCPU())
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	istioNetworking "istio.io/api/networking/v1alpha3"
+	istioNetworkingClient "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Name of the EnvoyFilter rate limiting inbound traffic of profile namespaces.
+const RATELIMITENVOYFILTER = "ns-rate-limit"
+
+// RateLimit configures the local rate limit applied by the sidecars of every profile namespace, as a token bucket.
+type RateLimit struct {
+	// MaxTokens is the bucket size, i.e. the maximum burst of requests.
+	MaxTokens uint32
+	// TokensPerFill are added to the bucket every FillInterval.
+	TokensPerFill uint32
+	FillInterval  time.Duration
+}
+
+// getRateLimitEnvoyFilter returns the EnvoyFilter spec inserting the Envoy local rate limit filter into the
+// inbound listeners of all sidecars in the namespace.
+func getRateLimitEnvoyFilter(rateLimit *RateLimit) (istioNetworking.EnvoyFilter, error) {
+	filter := istioNetworking.EnvoyFilter{}
+	if rateLimit.MaxTokens == 0 || rateLimit.TokensPerFill == 0 || rateLimit.FillInterval <= 0 {
+		return filter, fmt.Errorf("invalid rate limit %+v, tokens and fill interval must be positive", *rateLimit)
+	}
+	percent := map[string]interface{}{"numerator": 100, "denominator": "HUNDRED"}
+	spec := map[string]interface{}{
+		"configPatches": []interface{}{map[string]interface{}{
+			"applyTo": "HTTP_FILTER",
+			"match": map[string]interface{}{
+				"context": "SIDECAR_INBOUND",
+				"listener": map[string]interface{}{
+					"filterChain": map[string]interface{}{
+						"filter": map[string]interface{}{"name": "envoy.filters.network.http_connection_manager"},
+					},
+				},
+			},
+			"patch": map[string]interface{}{
+				"operation": "INSERT_BEFORE",
+				"value": map[string]interface{}{
+					"name": "envoy.filters.http.local_ratelimit",
+					"typed_config": map[string]interface{}{
+						"@type":    "type.googleapis.com/udpa.type.v1.TypedStruct",
+						"type_url": "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit",
+						"value": map[string]interface{}{
+							"stat_prefix": "http_local_rate_limiter",
+							"token_bucket": map[string]interface{}{
+								"max_tokens":      rateLimit.MaxTokens,
+								"tokens_per_fill": rateLimit.TokensPerFill,
+								"fill_interval":   fmt.Sprintf("%vs", rateLimit.FillInterval.Seconds()),
+							},
+							"filter_enabled": map[string]interface{}{
+								"runtime_key":   "local_rate_limit_enabled",
+								"default_value": percent,
+							},
+							"filter_enforced": map[string]interface{}{
+								"runtime_key":   "local_rate_limit_enforced",
+								"default_value": percent,
+							},
+						},
+					},
+				},
+			},
+		}},
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return filter, err
+	}
+	err = json.Unmarshal(data, &filter)
+	return filter, err
+}
+
+// updateRateLimitEnvoyFilter creates or updates the rate limit EnvoyFilter in the profile namespace, or deletes
+// it if rate limiting is disabled.
+func (r *ProfileReconciler) updateRateLimitEnvoyFilter(ctx context.Context, profileIns *profilev1.Profile) error {
+	logger := r.Log.WithValues("profile", profileIns.Name)
+	found := &istioNetworkingClient.EnvoyFilter{}
+	err := r.Get(ctx, types.NamespacedName{Name: RATELIMITENVOYFILTER, Namespace: profileNamespace(profileIns)}, found)
+	if err != nil && !errors.IsNotFound(err) && !(r.RateLimit == nil && meta.IsNoMatchError(err)) {
+		return err
+	}
+	exists := err == nil
+	if r.RateLimit == nil {
+		if !exists || !metav1.IsControlledBy(found, profileIns) {
+			return nil
+		}
+		logger.Info("Deleting rate limit EnvoyFilter", "namespace", profileIns.Name)
+		if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	spec, err := getRateLimitEnvoyFilter(r.RateLimit)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		envoyFilter := &istioNetworkingClient.EnvoyFilter{
+			ObjectMeta: metav1.ObjectMeta{Name: RATELIMITENVOYFILTER, Namespace: profileNamespace(profileIns)},
+			Spec:       spec,
+		}
+		if err := controllerutil.SetControllerReference(profileIns, envoyFilter, r.Scheme); err != nil {
+			return err
+		}
+		logger.Info("Creating rate limit EnvoyFilter", "namespace", profileIns.Name)
+		return r.Create(ctx, envoyFilter)
+	}
+	refUpdated, err := r.setMissingControllerReference(profileIns, found)
+	if err != nil {
+		return err
+	}
+	if refUpdated || !reflect.DeepEqual(spec, found.Spec) {
+		found.Spec = spec
+		logger.Info("Updating rate limit EnvoyFilter", "namespace", profileIns.Name)
+		return r.Update(ctx, found)
+	}
+	return nil
+}
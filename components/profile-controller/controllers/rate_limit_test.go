@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioNetworking "istio.io/api/networking/v1alpha3"
+	istioNetworkingClient "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestGetRateLimitEnvoyFilter(t *testing.T) {
+	filter, err := getRateLimitEnvoyFilter(&RateLimit{MaxTokens: 200, TokensPerFill: 100, FillInterval: time.Minute})
+	require.NoError(t, err)
+	require.Len(t, filter.ConfigPatches, 1)
+	patch := filter.ConfigPatches[0]
+	assert.Equal(t, istioNetworking.EnvoyFilter_HTTP_FILTER, patch.ApplyTo)
+	assert.Equal(t, istioNetworking.EnvoyFilter_SIDECAR_INBOUND, patch.Match.Context)
+	assert.Equal(t, istioNetworking.EnvoyFilter_Patch_INSERT_BEFORE, patch.Patch.Operation)
+	assert.Equal(t, "envoy.filters.http.local_ratelimit", patch.Patch.Value.Fields["name"].GetStringValue())
+
+	bucket := patch.Patch.Value.Fields["typed_config"].GetStructValue().
+		Fields["value"].GetStructValue().Fields["token_bucket"].GetStructValue().Fields
+	assert.Equal(t, float64(200), bucket["max_tokens"].GetNumberValue())
+	assert.Equal(t, float64(100), bucket["tokens_per_fill"].GetNumberValue())
+	assert.Equal(t, "60s", bucket["fill_interval"].GetStringValue())
+
+	_, err = getRateLimitEnvoyFilter(&RateLimit{MaxTokens: 200, TokensPerFill: 100})
+	assert.Error(t, err)
+}
+
+func TestReconcileRateLimitEnvoyFilter(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.RateLimit = &RateLimit{MaxTokens: 200, TokensPerFill: 100, FillInterval: time.Minute}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: RATELIMITENVOYFILTER, Namespace: profile.Name}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	found := &istioNetworkingClient.EnvoyFilter{}
+	require.NoError(t, r.Get(context.TODO(), key, found))
+	expected, err := getRateLimitEnvoyFilter(r.RateLimit)
+	require.NoError(t, err)
+	assert.Equal(t, expected, found.Spec)
+
+	// Rate limit changed.
+	r.RateLimit = &RateLimit{MaxTokens: 50, TokensPerFill: 50, FillInterval: time.Second}
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	found = &istioNetworkingClient.EnvoyFilter{}
+	require.NoError(t, r.Get(context.TODO(), key, found))
+	expected, err = getRateLimitEnvoyFilter(r.RateLimit)
+	require.NoError(t, err)
+	assert.Equal(t, expected, found.Spec)
+
+	// Rate limiting disabled.
+	r.RateLimit = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, &istioNetworkingClient.EnvoyFilter{})))
+}
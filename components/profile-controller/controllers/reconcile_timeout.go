@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Condition type set while the reconcile of the profile does not finish within ReconcileTimeout.
+const ReconcileTimedOut = "ReconcileTimedOut"
+
+// Backoff of the retries of reconciles which timed out.
+const (
+	reconcileTimeoutRetryBaseDelay = 5 * time.Second
+	reconcileTimeoutRetryMaxDelay  = 5 * time.Minute
+)
+
+// reconcileTimeoutBackoff returns the exponential backoff of the retries of reconciles which timed out, per profile.
+func (r *ProfileReconciler) reconcileTimeoutBackoff() workqueue.RateLimiter {
+	r.reconcileTimeoutBackoffOnce.Do(func() {
+		r.reconcileTimeoutRateLimiter = workqueue.NewItemExponentialFailureRateLimiter(
+			reconcileTimeoutRetryBaseDelay, reconcileTimeoutRetryMaxDelay)
+	})
+	return r.reconcileTimeoutRateLimiter
+}
+
+// retryReconcileTimeout sets the ReconcileTimedOut condition and requeues the profile with backoff. The
+// reconcile context is expired, so the condition is written with a new one.
+func (r *ProfileReconciler) retryReconcileTimeout(request ctrl.Request, err error) (ctrl.Result, error) {
+	ctx := context.Background()
+	delay := r.reconcileTimeoutBackoff().When(request.Name)
+	r.Log.Info("Reconcile timed out, retrying", "profile", request.Name, "timeout", r.ReconcileTimeout.String(),
+		"error", err.Error(), "retryAfter", delay.String())
+	IncRequestErrorCounter("reconcile timeout", SEVERITY_MINOR)
+	instance := &profilev1.Profile{}
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.setProfileCondition(instance, ReconcileTimedOut, "True",
+		fmt.Sprintf("reconcile did not finish within %v, retrying in %v: %v", r.ReconcileTimeout, delay, err))
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: delay}, nil
+}
+
+// clearReconcileTimeout resets the backoff and the ReconcileTimedOut condition once a reconcile of the profile
+// finished in time.
+func (r *ProfileReconciler) clearReconcileTimeout(ctx context.Context, request ctrl.Request) error {
+	if r.reconcileTimeoutBackoff().NumRequeues(request.Name) == 0 {
+		return nil
+	}
+	r.reconcileTimeoutBackoff().Forget(request.Name)
+	instance := &profilev1.Profile{}
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, condition := range instance.Status.Conditions {
+		if condition.Type == ReconcileTimedOut && condition.Status == "True" {
+			r.setProfileCondition(instance, ReconcileTimedOut, "False", "reconcile finished in time")
+			return r.Status().Update(ctx, instance)
+		}
+	}
+	return nil
+}
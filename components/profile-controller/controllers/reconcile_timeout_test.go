@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// slowClient blocks the apply of ServiceAccounts until the context is done, like a hung API call.
+type slowClient struct {
+	client.Client
+}
+
+func (c *slowClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+	if _, ok := obj.(*corev1.ServiceAccount); ok {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestReconcileTimeout(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	fastClient := r.Client
+	r.Client = &slowClient{Client: fastClient}
+	r.ReconcileTimeout = 50 * time.Millisecond
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getCondition := func() profilev1.ProfileCondition {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		for _, condition := range found.Status.Conditions {
+			if condition.Type == ReconcileTimedOut {
+				return condition
+			}
+		}
+		return profilev1.ProfileCondition{}
+	}
+
+	start := time.Now()
+	result, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second), "the hung call is cancelled")
+	assert.Equal(t, reconcileTimeoutRetryBaseDelay, result.RequeueAfter)
+	assert.Equal(t, "True", getCondition().Status)
+
+	// Retries back off.
+	result, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, 2*reconcileTimeoutRetryBaseDelay, result.RequeueAfter)
+
+	// A reconcile finishing in time clears the condition and the backoff.
+	r.Client = fastClient
+	result, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, result.IsZero())
+	assert.Equal(t, "False", getCondition().Status)
+	assert.Equal(t, 0, r.reconcileTimeoutBackoff().NumRequeues(request.Name))
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Profile annotation opting the profile namespace out of the registry mirrors when set to "false".
+const REGISTRYMIRRORS = "profile.kubeflow.org/registry-mirrors"
+
+// RegistryMirror configures the namespace annotations pointing the image pulls of profile namespaces at
+// pull-through caches, to stay under the pull rate limits of public registries.
+type RegistryMirror struct {
+	// Mirrors maps registries to the pull-through cache mirroring them, e.g. "docker.io" to
+	// "mirror.example.com/dockerhub".
+	Mirrors map[string]string
+	// Annotations are rendered with RegistryMirrorTemplateData, e.g.
+	// `registry.example.com/mirrors={{ .MirrorList }}`. They are removed from the namespace of opted out profiles.
+	Annotations AnnotationTemplates
+}
+
+// RegistryMirrorTemplateData is the data exposed to registry mirror annotation templates.
+type RegistryMirrorTemplateData struct {
+	ProfileTemplateData
+	// Mirrors maps registries to their mirror, e.g. `{{ index .Mirrors "docker.io" }}`.
+	Mirrors map[string]string
+	// MirrorList holds the mirrors as comma separated registry=mirror pairs, sorted by registry.
+	MirrorList string
+}
+
+// ParseRegistryMirrors parses the -registry-mirrors value: comma separated registry=mirror pairs, e.g.
+// "docker.io=mirror.example.com/dockerhub,quay.io=mirror.example.com/quay".
+func ParseRegistryMirrors(value string) (map[string]string, error) {
+	mirrors := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid registry mirror %q, expected registry=mirror", pair)
+		}
+		registry, mirror := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if err := validateRegistryHost(registry); err != nil {
+			return nil, err
+		}
+		host := strings.SplitN(mirror, "/", 2)[0]
+		if err := validateRegistryHost(host); err != nil {
+			return nil, fmt.Errorf("invalid mirror of registry %v: %v", registry, err)
+		}
+		if _, ok := mirrors[registry]; ok {
+			return nil, fmt.Errorf("duplicate mirror of registry %v", registry)
+		}
+		mirrors[registry] = mirror
+	}
+	return mirrors, nil
+}
+
+// validateRegistryHost checks that host is a DNS name with an optional port, e.g. "registry.example.com:5000".
+func validateRegistryHost(host string) error {
+	name := host
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		name = host[:i]
+		port, err := strconv.Atoi(host[i+1:])
+		if err != nil {
+			return fmt.Errorf("invalid registry port in %q: %v", host, err)
+		}
+		if errs := validation.IsValidPortNum(port); len(errs) > 0 {
+			return fmt.Errorf("invalid registry port in %q: %v", host, strings.Join(errs, ", "))
+		}
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid registry %q: %v", host, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// mirrorList returns the mirrors as comma separated registry=mirror pairs, sorted by registry.
+func (m *RegistryMirror) mirrorList() string {
+	pairs := make([]string, 0, len(m.Mirrors))
+	for registry, mirror := range m.Mirrors {
+		pairs = append(pairs, registry+"="+mirror)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// annotations returns the registry mirror namespace annotations of the profile, with empty values if the profile
+// opted out so that previous ones are removed.
+func (m *RegistryMirror) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if m == nil || len(m.Annotations) == 0 {
+		return nil, nil
+	}
+	if profileIns.Annotations[REGISTRYMIRRORS] == "false" {
+		annotations := make(map[string]string, len(m.Annotations))
+		for key := range m.Annotations {
+			annotations[key] = ""
+		}
+		return annotations, nil
+	}
+	return m.Annotations.render(RegistryMirrorTemplateData{
+		ProfileTemplateData: newProfileTemplateData(profileIns),
+		Mirrors:             m.Mirrors,
+		MirrorList:          m.mirrorList(),
+	})
+}
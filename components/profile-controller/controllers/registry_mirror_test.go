@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseRegistryMirrors(t *testing.T) {
+	mirrors, err := ParseRegistryMirrors("docker.io=mirror.example.com/dockerhub, quay.io=mirror.example.com:5000,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"docker.io": "mirror.example.com/dockerhub",
+		"quay.io":   "mirror.example.com:5000",
+	}, mirrors)
+
+	for _, value := range []string{
+		"docker.io",
+		"Docker Hub=mirror.example.com",
+		"docker.io=mirror.example.com:http",
+		"docker.io=mirror.example.com:70000",
+		"docker.io=mirror.example.com,docker.io=other.example.com",
+	} {
+		_, err = ParseRegistryMirrors(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRegistryMirrorAnnotations(t *testing.T) {
+	templates, err := ParseAnnotationTemplates(`registry.example.com/mirrors={{ .MirrorList }},` +
+		`registry.example.com/dockerhub={{ index .Mirrors "docker.io" }}/{{ .Name }}`)
+	require.NoError(t, err)
+	m := &RegistryMirror{
+		Mirrors: map[string]string{
+			"quay.io":   "mirror.example.com/quay",
+			"docker.io": "mirror.example.com/dockerhub",
+		},
+		Annotations: templates,
+	}
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	annotations, err := m.annotations(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"registry.example.com/mirrors":   "docker.io=mirror.example.com/dockerhub,quay.io=mirror.example.com/quay",
+		"registry.example.com/dockerhub": "mirror.example.com/dockerhub/kubeflow-user1",
+	}, annotations)
+
+	profile.Annotations = map[string]string{REGISTRYMIRRORS: "false"}
+	annotations, err = m.annotations(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"registry.example.com/mirrors":   "",
+		"registry.example.com/dockerhub": "",
+	}, annotations)
+
+	var disabled *RegistryMirror
+	annotations, err = disabled.annotations(profile)
+	require.NoError(t, err)
+	assert.Nil(t, annotations)
+}
+
+func TestReconcileRegistryMirror(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	templates, err := ParseAnnotationTemplates("registry.example.com/mirrors={{ .MirrorList }}")
+	require.NoError(t, err)
+	r.RegistryMirror = &RegistryMirror{
+		Mirrors:     map[string]string{"docker.io": "mirror.example.com/dockerhub"},
+		Annotations: templates,
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getMirrors := func() (string, bool) {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		mirrors, ok := ns.Annotations["registry.example.com/mirrors"]
+		return mirrors, ok
+	}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	mirrors, ok := getMirrors()
+	assert.True(t, ok)
+	assert.Equal(t, "docker.io=mirror.example.com/dockerhub", mirrors)
+
+	// Opting out removes the namespace annotation.
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Annotations = map[string]string{REGISTRYMIRRORS: "false"}
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	_, ok = getMirrors()
+	assert.False(t, ok)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Maximum share of ResyncPeriod added to it at random, so the resyncs of profiles reconciled together spread out.
+const resyncJitter = 0.1
+
+// resyncAfter returns the delay after which a reconciled profile is reconciled again, 0 if ResyncPeriod is not set.
+func (r *ProfileReconciler) resyncAfter() time.Duration {
+	if r.ResyncPeriod <= 0 {
+		return 0
+	}
+	return wait.Jitter(r.ResyncPeriod, resyncJitter)
+}
+
+// countDriftCorrection counts the creation or update of a managed resource of kind for a profile whose current
+// generation was already reconciled: the resource was deleted or edited since, or the controller configuration
+// changed.
+func countDriftCorrection(profileIns *profilev1.Profile, kind string) {
+	if profileIns.Generation == 0 || profileIns.Status.ObservedGeneration != profileIns.Generation {
+		return
+	}
+	driftCorrections.With(prometheus.Labels{KIND: kind}).Inc()
+}
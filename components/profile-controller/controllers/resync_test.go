@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestResyncAfter(t *testing.T) {
+	r := newFakeReconciler()
+	assert.Zero(t, r.resyncAfter())
+	r.ResyncPeriod = 30 * time.Minute
+	for i := 0; i < 10; i++ {
+		after := r.resyncAfter()
+		assert.GreaterOrEqual(t, int64(after), int64(30*time.Minute))
+		assert.LessOrEqual(t, int64(after), int64(33*time.Minute))
+	}
+}
+
+func TestReconcileResyncCorrectsDrift(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Generation = 1
+	r := newFakeReconciler(profile)
+	r.ResyncPeriod = 30 * time.Minute
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	corrections := func() float64 {
+		return testutil.ToFloat64(driftCorrections.WithLabelValues("RoleBinding"))
+	}
+
+	result, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(result.RequeueAfter), int64(r.ResyncPeriod))
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	assert.Equal(t, int64(1), found.Status.ObservedGeneration)
+
+	// Resyncs of profiles without drift correct nothing.
+	before := corrections()
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, before, corrections())
+
+	// A RoleBinding deleted by hand is recreated and counted.
+	key := types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name}
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, rb))
+	require.NoError(t, r.Delete(context.TODO(), rb))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), key, &rbacv1.RoleBinding{}))
+	assert.Equal(t, before+1, corrections())
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Name of the Role and RoleBinding granting the profile owner scale access to workloads.
+const OWNERSCALE = "owner-scale"
+
+// getScaleRole returns the least-privilege Role to read deployments and statefulsets and scale them.
+func getScaleRole(namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OWNERSCALE,
+			Namespace: namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"apps"},
+				Resources: []string{"deployments", "statefulsets"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{"apps"},
+				Resources: []string{"deployments/scale", "statefulsets/scale"},
+				Verbs:     []string{"get", "update", "patch"},
+			},
+		},
+	}
+}
+
+// updateRole create or update Role "role" in target namespace owned by "profileIns"
+func (r *ProfileReconciler) updateRole(ctx context.Context, profileIns *profilev1.Profile, role *rbacv1.Role) error {
+	logger := r.Log.WithValues("profile", profileIns.Name)
+	if err := controllerutil.SetControllerReference(profileIns, role, r.Scheme); err != nil {
+		return err
+	}
+	found := &rbacv1.Role{}
+	err := r.Get(ctx, types.NamespacedName{Name: role.Name, Namespace: role.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Creating Role", "namespace", role.Namespace, "name", role.Name)
+			return r.Create(ctx, role)
+		}
+		return err
+	}
+	refUpdated, err := r.setMissingControllerReference(profileIns, found)
+	if err != nil {
+		return err
+	}
+	if refUpdated || !reflect.DeepEqual(role.Rules, found.Rules) {
+		found.Rules = role.Rules
+		logger.Info("Updating Role", "namespace", role.Namespace, "name", role.Name)
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// deleteOwnedRole deletes Role "name" in the profile namespace if it is controlled by the profile.
+func (r *ProfileReconciler) deleteOwnedRole(ctx context.Context, profileIns *profilev1.Profile, name string) error {
+	found := &rbacv1.Role{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: profileNamespace(profileIns)}, found); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(found, profileIns) {
+		return nil
+	}
+	r.Log.Info("Deleting Role", "namespace", profileIns.Name, "name", name)
+	if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// updateOwnerScaleAccess grants the profile owner scale access to deployments and statefulsets in target
+// namespace if OwnerScaleAccess is enabled and the owner is approved, and removes it otherwise.
+func (r *ProfileReconciler) updateOwnerScaleAccess(ctx context.Context, profileIns *profilev1.Profile) error {
+	if !r.OwnerScaleAccess || !r.ownerApproved(profileIns) {
+		if err := r.deleteOwnedRoleBinding(ctx, profileIns, OWNERSCALE); err != nil {
+			return err
+		}
+		return r.deleteOwnedRole(ctx, profileIns, OWNERSCALE)
+	}
+	if err := r.updateRole(ctx, profileIns, getScaleRole(profileNamespace(profileIns))); err != nil {
+		return err
+	}
+	return r.updateRoleBinding(ctx, profileIns, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OWNERSCALE,
+			Namespace: profileNamespace(profileIns),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     OWNERSCALE,
+		},
+		Subjects: []rbacv1.Subject{profileIns.Spec.Owner},
+	})
+}
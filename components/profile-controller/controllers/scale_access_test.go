@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileOwnerScaleAccess(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.OwnerScaleAccess = true
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: OWNERSCALE, Namespace: profile.Name}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	role := &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	assert.Equal(t, getScaleRole(profile.Name).Rules, role.Rules)
+	for _, rule := range role.Rules {
+		assert.NotContains(t, rule.APIGroups, rbacv1.GroupName, "scale role must not grant RBAC access")
+		assert.NotContains(t, rule.Verbs, "*")
+	}
+	binding := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, binding))
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: OWNERSCALE}, binding.RoleRef)
+	assert.Equal(t, []rbacv1.Subject{profile.Spec.Owner}, binding.Subjects)
+
+	// Drift of the rules is corrected.
+	role.Rules = append(role.Rules, rbacv1.PolicyRule{
+		APIGroups: []string{rbacv1.GroupName}, Resources: []string{"rolebindings"}, Verbs: []string{"*"}})
+	require.NoError(t, r.Update(context.TODO(), role))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	role = &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	assert.Equal(t, getScaleRole(profile.Name).Rules, role.Rules)
+
+	// Disabling scale access removes the Role and RoleBinding.
+	r.OwnerScaleAccess = false
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.Role{})))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
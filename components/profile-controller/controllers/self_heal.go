@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+)
+
+// managedToProfile maps objects to the Profile controlling them. Objects which lost their controller reference,
+// e.g. by a manual edit, map to the profile of their namespace if they have one of the names the controller manages,
+// so the reconcile reasserts them.
+func (r *ProfileReconciler) managedToProfile(names ...string) *handler.EnqueueRequestsFromMapFunc {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			if owner := metav1.GetControllerOf(obj.Meta); owner != nil {
+				gv, err := schema.ParseGroupVersion(owner.APIVersion)
+				if err != nil || gv.Group != profilev1.GroupVersion.Group || owner.Kind != "Profile" {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: owner.Name}}}
+			}
+			for _, name := range names {
+				if obj.Meta.GetName() == name {
+					profile := r.profileOfNamespace(obj.Meta.GetNamespace())
+					return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: profile}}}
+				}
+			}
+			return nil
+		}),
+	}
+}
+
+// serviceAccountToProfile maps the ServiceAccounts created in every profile namespace to their profile.
+func (r *ProfileReconciler) serviceAccountToProfile() *handler.EnqueueRequestsFromMapFunc {
+	return r.managedToProfile(DEFAULT_EDITOR, DEFAULT_VIEWER)
+}
+
+// roleBindingToProfile maps the RoleBindings created in every profile namespace to their profile.
+func (r *ProfileReconciler) roleBindingToProfile() *handler.EnqueueRequestsFromMapFunc {
+	return r.managedToProfile(DEFAULT_EDITOR, DEFAULT_VIEWER, OWNERBINDING)
+}
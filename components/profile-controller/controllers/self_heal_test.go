@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestReconcileRecreatesDeletedServiceAccount(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+
+	key := types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name}
+	serviceAccount := &corev1.ServiceAccount{}
+	require.NoError(t, r.Get(context.TODO(), key, serviceAccount))
+	require.NoError(t, r.Delete(context.TODO(), serviceAccount))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &corev1.ServiceAccount{})))
+
+	// The deletion event of the ServiceAccount enqueues its profile.
+	requests := r.serviceAccountToProfile().ToRequests.Map(handler.MapObject{Meta: serviceAccount, Object: serviceAccount})
+	require.Equal(t, []ctrl.Request{request}, requests)
+	_, err = r.Reconcile(requests[0])
+	require.NoError(t, err)
+	recreated := &corev1.ServiceAccount{}
+	require.NoError(t, r.Get(context.TODO(), key, recreated))
+	assert.Equal(t, profile.Name, metav1.GetControllerOf(recreated).Name)
+}
+
+func TestManagedToProfile(t *testing.T) {
+	controller := true
+	owned := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+		Name:      "user-abcd-com-clusterrole-edit",
+		Namespace: "kubeflow-user1",
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "kubeflow.org/v1", Kind: "Profile", Name: "kubeflow-user1", Controller: &controller,
+		}},
+	}}
+	// Lost its controller reference but has a managed name.
+	edited := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "namespaceAdmin", Namespace: "kubeflow-user1"}}
+	unmanaged := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "kubeflow-user1"}}
+	foreign := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+		Name:      DEFAULT_EDITOR,
+		Namespace: "kubeflow-user1",
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "app", Controller: &controller,
+		}},
+	}}
+
+	r := newFakeReconciler()
+	expected := []ctrl.Request{{NamespacedName: types.NamespacedName{Name: "kubeflow-user1"}}}
+	for _, tc := range []struct {
+		name     string
+		obj      *rbacv1.RoleBinding
+		expected []ctrl.Request
+	}{
+		{"owned", owned, expected},
+		{"edited", edited, expected},
+		{"unmanaged", unmanaged, nil},
+		{"foreign", foreign, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, r.roleBindingToProfile().ToRequests.Map(handler.MapObject{Meta: tc.obj, Object: tc.obj}))
+		})
+	}
+}
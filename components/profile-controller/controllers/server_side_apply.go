@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Field manager of the resources the controller applies with server-side apply.
+const FIELDMANAGER = "profile-controller"
+
+// Field managers of the annotations the controller applies next to the apply of their object, see
+// applyAnnotations.
+const (
+	CONFIGHASHFIELDMANAGER    = FIELDMANAGER + "-config-hash"
+	WORKSPACEFIELDMANAGER     = FIELDMANAGER + "-workspace"
+	SAANNOTATIONSFIELDMANAGER = FIELDMANAGER + "-sa-annotations"
+)
+
+// apply creates or updates obj with server-side apply as FIELDMANAGER. The controller only owns the fields set
+// on obj, the ones added by others, e.g. namespace annotations or RoleBinding labels set by admins, are kept.
+// Conflicts with other managers are forced, the fields of the controller are authoritative.
+func (r *ProfileReconciler) apply(ctx context.Context, obj runtime.Object) error {
+	return r.applyAs(ctx, obj, FIELDMANAGER)
+}
+
+// applyAs applies obj with server-side apply as manager.
+func (r *ProfileReconciler) applyAs(ctx context.Context, obj runtime.Object, manager string) error {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return err
+	}
+	// The applied configuration carries its apiVersion and kind.
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return r.Patch(ctx, obj, client.Apply, client.FieldOwner(manager), client.ForceOwnership)
+}
+
+// applyAnnotations applies annotations to the existing obj, which only sets its name and namespace, as manager and
+// removes the annotations of removed. The annotations are set next to the apply of the object as FIELDMANAGER,
+// which owns the other fields: applying them as FIELDMANAGER too would drop those. Annotations set by updates of
+// older controller versions are only removed explicitly, like in applyNamespace.
+func (r *ProfileReconciler) applyAnnotations(ctx context.Context, obj runtime.Object, manager string,
+	annotations map[string]string, removed []string) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	accessor.SetAnnotations(annotations)
+	if err := r.applyAs(ctx, obj, manager); err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return r.removeMetadata(ctx, obj, nil, removed)
+}
+
+// applyNamespace applies the labels, annotations and controller reference of desired to the existing namespace
+// found, which the reconcile changed from before. The labels of desired take their value from found, so the
+// kubeflow namespace labels admins changed are kept, and the ones missing from found are not applied. Labels and annotations the reconcile dropped are removed
+// explicitly: apply only removes the fields it set before, not the ones set by updates of older controller
+// versions.
+func (r *ProfileReconciler) applyNamespace(ctx context.Context, desired *corev1.Namespace,
+	before *corev1.Namespace, found *corev1.Namespace) error {
+	applied := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            found.Name,
+			Labels:          map[string]string{},
+			Annotations:     map[string]string{},
+			OwnerReferences: found.OwnerReferences,
+		},
+	}
+	for k := range desired.Labels {
+		if v, ok := found.Labels[k]; ok {
+			applied.Labels[k] = v
+		}
+	}
+	for k := range desired.Annotations {
+		if v, ok := found.Annotations[k]; ok {
+			applied.Annotations[k] = v
+		}
+	}
+	if err := r.apply(ctx, applied); err != nil {
+		return err
+	}
+	labels, annotations := droppedKeys(before.Labels, found.Labels), droppedKeys(before.Annotations, found.Annotations)
+	if len(labels) == 0 && len(annotations) == 0 {
+		return nil
+	}
+	return r.removeMetadata(ctx, applied, labels, annotations)
+}
+
+// droppedKeys returns the keys of before missing from after, sorted.
+func droppedKeys(before, after map[string]string) []string {
+	var dropped []string
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			dropped = append(dropped, k)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// removeMetadata removes labels and annotations from obj with a merge patch.
+func (r *ProfileReconciler) removeMetadata(ctx context.Context, obj runtime.Object, labels []string,
+	annotations []string) error {
+	removed := map[string]map[string]interface{}{}
+	for _, k := range labels {
+		if removed["labels"] == nil {
+			removed["labels"] = map[string]interface{}{}
+		}
+		removed["labels"][k] = nil
+	}
+	for _, k := range annotations {
+		if removed["annotations"] == nil {
+			removed["annotations"] = map[string]interface{}{}
+		}
+		removed["annotations"][k] = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": removed})
+	if err != nil {
+		return err
+	}
+	return r.Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch), client.FieldOwner(FIELDMANAGER))
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applyClient emulates server-side apply, which the fake client does not support, like client-side apply: with
+// a three-way merge of the configuration last applied to the object by the field manager, the applied one and the
+// object.
+type applyClient struct {
+	client.Client
+	mu      sync.Mutex
+	applied map[string][]byte
+}
+
+func newApplyClient(c client.Client) *applyClient {
+	return &applyClient{Client: c, applied: map[string][]byte{}}
+}
+
+func (c *applyClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	modified, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	key := types.NamespacedName{Name: accessor.GetName(), Namespace: accessor.GetNamespace()}
+	// Each field manager only changes the fields it applied before.
+	manager := (&client.PatchOptions{}).ApplyOptions(opts).FieldManager
+	id := obj.GetObjectKind().GroupVersionKind().String() + "/" + key.String() + "/" + manager
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+	if err := c.Get(ctx, key, current); apierrors.IsNotFound(err) {
+		if err := c.Client.Create(ctx, obj); err != nil {
+			return err
+		}
+		c.applied[id] = modified
+		return nil
+	} else if err != nil {
+		return err
+	}
+	currentData, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	mergePatch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(c.applied[id], modified, currentData)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, mergePatch)); err != nil {
+		return err
+	}
+	c.applied[id] = modified
+	return nil
+}
+
+func TestDroppedKeys(t *testing.T) {
+	assert.Equal(t, []string{"a", "c"}, droppedKeys(map[string]string{"a": "1", "b": "2", "c": "3"},
+		map[string]string{"b": "2"}))
+	assert.Nil(t, droppedKeys(nil, map[string]string{"a": "1"}))
+}
+
+func TestReconcileKeepsFieldsSetByAdmins(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	ns.Annotations["example.com/contact"] = "ml-team"
+	ns.Labels[istioInjectionLabel] = "disabled"
+	delete(ns.Labels, "katib-metricscollector-injection")
+	require.NoError(t, r.Update(context.TODO(), ns))
+	key := types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name}
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, rb))
+	rb.Labels = map[string]string{"example.com/audit": "true"}
+	rb.Subjects = nil
+	require.NoError(t, r.Update(context.TODO(), rb))
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "ml-team", ns.Annotations["example.com/contact"])
+	assert.Equal(t, "disabled", ns.Labels[istioInjectionLabel], "istio-injection is left to admins")
+	assert.Equal(t, "enabled", ns.Labels["katib-metricscollector-injection"],
+		"the fields of the controller are reasserted")
+	rb = &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, rb))
+	assert.Equal(t, "true", rb.Labels["example.com/audit"])
+	require.Len(t, rb.Subjects, 1)
+	assert.Equal(t, DEFAULT_EDITOR, rb.Subjects[0].Name)
+}
+
+func TestApplyAnnotationsKeepsAppliedFields(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	// The drift is corrected with an apply of the namespace.
+	key := types.NamespacedName{Name: profile.Name}
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	delete(ns.Labels, "katib-metricscollector-injection")
+	require.NoError(t, r.Update(context.TODO(), ns))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+
+	applied := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: key.Name}}
+	require.NoError(t, r.applyAnnotations(context.TODO(), applied, CONFIGHASHFIELDMANAGER,
+		map[string]string{CONFIGHASH: "changed"}, nil))
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	assert.Equal(t, "changed", ns.Annotations[CONFIGHASH])
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["owner"], "the fields applied as FIELDMANAGER are kept")
+	assert.Equal(t, "enabled", ns.Labels["katib-metricscollector-injection"])
+	assert.NotNil(t, metav1.GetControllerOf(ns))
+
+	// The namespace apply keeps the annotation.
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	ns = &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), key, ns))
+	assert.NotEmpty(t, ns.Annotations[CONFIGHASH])
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["owner"])
+}
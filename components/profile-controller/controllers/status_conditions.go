@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+)
+
+// Condition types summarizing the health of a profile, updated by the reconciles that reach the profile namespace.
+const (
+	ProfileReady     = "Ready"
+	NamespaceCreated = "NamespaceCreated"
+	IstioPolicyReady = "IstioPolicyReady"
+	RBACReady        = "RBACReady"
+	// PluginError is True while a plugin of the profile fails to apply.
+	PluginError = "PluginError"
+)
+
+// Messages of the conditions of the stages a reconcile passed.
+var stageReadyMessages = map[string]string{
+	NamespaceCreated: "namespace created",
+	IstioPolicyReady: "Istio AuthorizationPolicies and NetworkPolicies applied",
+	RBACReady:        "ServiceAccounts and RoleBindings applied",
+	PluginError:      "plugins applied",
+}
+
+// reconcileProgress tracks the stages a reconcile of a profile passed, a stage being named after its condition.
+type reconcileProgress struct {
+	// stage is the current stage, empty between stages.
+	stage  string
+	passed []string
+	done   bool
+}
+
+// enter passes the current stage, if any, and enters stage.
+func (p *reconcileProgress) enter(stage string) {
+	if p.stage != "" {
+		p.passed = append(p.passed, p.stage)
+	}
+	p.stage = stage
+}
+
+// finish passes the current stage and marks the reconcile done.
+func (p *reconcileProgress) finish() {
+	p.enter("")
+	p.done = true
+}
+
+// stageCondition returns the status of the condition of stage, passed or not.
+func stageCondition(stage string, passed bool) string {
+	// PluginError is the only condition reporting a failure.
+	if passed == (stage == PluginError) {
+		return "False"
+	}
+	return "True"
+}
+
+// updateReadinessConditions reports the progress of a reconcile of the profile in its readiness conditions and,
+// once the reconcile is done, its generation in status.observedGeneration. The conditions of the stages passed
+// are healthy, the one of the stage reconcileErr happened in reports it. Reconciles stopped without error, e.g.
+// waiting for their namespace, leave the conditions as they are, the condition of the wait explains it.
+func (r *ProfileReconciler) updateReadinessConditions(ctx context.Context, profileIns *profilev1.Profile,
+	progress *reconcileProgress, reconcileErr error) error {
+	if !progress.done && reconcileErr == nil {
+		return nil
+	}
+	before := profileIns.Status.DeepCopy()
+	for _, stage := range progress.passed {
+		r.setProfileCondition(profileIns, stage, stageCondition(stage, true), stageReadyMessages[stage])
+	}
+	if progress.done {
+		r.setProfileCondition(profileIns, ProfileReady, "True", "profile reconciled")
+		profileIns.Status.ObservedGeneration = profileIns.Generation
+	} else {
+		if progress.stage != "" {
+			r.setProfileCondition(profileIns, progress.stage, stageCondition(progress.stage, false),
+				reconcileErr.Error())
+		}
+		r.setProfileCondition(profileIns, ProfileReady, "False", reconcileErr.Error())
+	}
+	if reflect.DeepEqual(before, &profileIns.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, profileIns)
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type failingMembershipResolver struct{}
+
+func (failingMembershipResolver) ResolveMembers(ctx context.Context, profileIns *profilev1.Profile) ([]Member, error) {
+	return nil, fmt.Errorf("membership service unavailable")
+}
+
+// conditionStatuses returns the status of the readiness conditions of the profile by type.
+func conditionStatuses(profileIns *profilev1.Profile) map[string]string {
+	statuses := map[string]string{}
+	for _, condition := range profileIns.Status.Conditions {
+		switch condition.Type {
+		case ProfileReady, NamespaceCreated, IstioPolicyReady, RBACReady, PluginError:
+			statuses[condition.Type] = condition.Status
+		}
+	}
+	return statuses
+}
+
+func TestUpdateReadinessConditions(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Generation = 2
+	r := newFakeReconciler(profile)
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, found))
+
+	// Failed in the plugins stage.
+	progress := &reconcileProgress{}
+	progress.enter(NamespaceCreated)
+	progress.enter(IstioPolicyReady)
+	progress.enter(RBACReady)
+	progress.enter("")
+	progress.enter(PluginError)
+	require.NoError(t, r.updateReadinessConditions(context.TODO(), found, progress, fmt.Errorf("plugin failed")))
+	assert.Equal(t, map[string]string{
+		NamespaceCreated: "True",
+		IstioPolicyReady: "True",
+		RBACReady:        "True",
+		PluginError:      "True",
+		ProfileReady:     "False",
+	}, conditionStatuses(found))
+	for _, condition := range found.Status.Conditions {
+		if condition.Type == PluginError || condition.Type == ProfileReady {
+			assert.Equal(t, "plugin failed", condition.Message)
+		}
+	}
+	assert.Zero(t, found.Status.ObservedGeneration)
+
+	// Stopped without error, nothing changes.
+	progress = &reconcileProgress{}
+	progress.enter(NamespaceCreated)
+	require.NoError(t, r.updateReadinessConditions(context.TODO(), found, progress, nil))
+	assert.Equal(t, "True", conditionStatuses(found)[PluginError])
+
+	progress.enter(IstioPolicyReady)
+	progress.enter(RBACReady)
+	progress.enter(PluginError)
+	progress.finish()
+	require.NoError(t, r.updateReadinessConditions(context.TODO(), found, progress, nil))
+	assert.Equal(t, map[string]string{
+		NamespaceCreated: "True",
+		IstioPolicyReady: "True",
+		RBACReady:        "True",
+		PluginError:      "False",
+		ProfileReady:     "True",
+	}, conditionStatuses(found))
+	assert.Equal(t, int64(2), found.Status.ObservedGeneration)
+}
+
+func TestReconcileReadinessConditions(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Generation = 1
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getProfile := func() *profilev1.Profile {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		return found
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	found := getProfile()
+	assert.Equal(t, map[string]string{
+		NamespaceCreated: "True",
+		IstioPolicyReady: "True",
+		RBACReady:        "True",
+		PluginError:      "False",
+		ProfileReady:     "True",
+	}, conditionStatuses(found))
+	assert.Equal(t, int64(1), found.Status.ObservedGeneration)
+
+	// Failing to resolve the members fails the RBAC stage, the later stages keep their conditions.
+	r.MembershipResolver = failingMembershipResolver{}
+	_, err = r.Reconcile(request)
+	require.Error(t, err)
+	found = getProfile()
+	assert.Equal(t, map[string]string{
+		NamespaceCreated: "True",
+		IstioPolicyReady: "True",
+		RBACReady:        "False",
+		PluginError:      "False",
+		ProfileReady:     "False",
+	}, conditionStatuses(found))
+	for _, condition := range found.Status.Conditions {
+		if condition.Type == RBACReady {
+			assert.Equal(t, "membership service unavailable", condition.Message)
+		}
+	}
+
+	r.MembershipResolver = nil
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.Equal(t, "True", conditionStatuses(getProfile())[ProfileReady])
+}
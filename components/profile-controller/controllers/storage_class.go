@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Path the webhook setting the default storage class of profiles on PersistentVolumeClaims is served at.
+const STORAGECLASSDEFAULTINGPATH = "/mutate--v1-persistentvolumeclaim"
+
+// Annotation selecting the storage class of a PersistentVolumeClaim before spec.storageClassName.
+const BETASTORAGECLASSANNOTATION = "volume.beta.kubernetes.io/storage-class"
+
+// +kubebuilder:webhook:path=/mutate--v1-persistentvolumeclaim,mutating=true,failurePolicy=fail,groups="",resources=persistentvolumeclaims,verbs=create,versions=v1,name=mpvc.profile.kubeflow.org,sideEffects=None
+
+// storageClassDefaulter sets spec.defaultStorageClass of the profile on the PersistentVolumeClaims created in its
+// namespace without a storage class.
+type storageClassDefaulter struct {
+	r       *ProfileReconciler
+	decoder *admission.Decoder
+}
+
+// SetupStorageClassWebhookWithManager serves the storage class defaulting webhook of PersistentVolumeClaims at
+// STORAGECLASSDEFAULTINGPATH.
+func (r *ProfileReconciler) SetupStorageClassWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(STORAGECLASSDEFAULTINGPATH,
+		&webhook.Admission{Handler: &storageClassDefaulter{r: r}})
+	return nil
+}
+
+func (d *storageClassDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle sets the default storage class of the profile owning the namespace on created PersistentVolumeClaims
+// without one. Claims selecting a storage class, even the empty one, are admitted unchanged.
+func (d *storageClassDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create {
+		return admission.Allowed("")
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := d.decoder.Decode(req, pvc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if _, ok := pvc.Annotations[BETASTORAGECLASSANNOTATION]; ok || pvc.Spec.StorageClassName != nil {
+		return admission.Allowed("")
+	}
+	storageClass, err := d.r.defaultStorageClass(ctx, req.Namespace)
+	if err != nil {
+		d.r.Log.Error(err, "error reading default storage class", "namespace", req.Namespace)
+		IncRequestErrorCounter("error reading default storage class", SEVERITY_MAJOR)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if storageClass == "" {
+		return admission.Allowed("")
+	}
+	pvc.Spec.StorageClassName = &storageClass
+	defaulted, err := json.Marshal(pvc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	IncRequestCounter("default storage class of PersistentVolumeClaim")
+	return admission.PatchResponseFromRaw(req.Object.Raw, defaulted)
+}
+
+// defaultStorageClass returns spec.defaultStorageClass of the profile managed by the controller owning
+// namespace, empty if there is none.
+func (r *ProfileReconciler) defaultStorageClass(ctx context.Context, namespace string) (string, error) {
+	profileIns := &profilev1.Profile{}
+	if err := r.Get(ctx, types.NamespacedName{Name: r.profileOfNamespace(namespace)}, profileIns); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if !r.managesProfile(profileIns.Labels) || profileNamespace(profileIns) != namespace {
+		return "", nil
+	}
+	return profileIns.Spec.DefaultStorageClass, nil
+}
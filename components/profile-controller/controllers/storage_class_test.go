@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newPVCAdmissionRequest(t *testing.T, pvc *corev1.PersistentVolumeClaim) admission.Request {
+	raw, err := json.Marshal(pvc)
+	require.NoError(t, err)
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Namespace: pvc.Namespace,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func TestStorageClassDefaulter(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.DefaultStorageClass = "team-ml-ssd"
+	controller := true
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: profile.Name, OwnerReferences: []metav1.OwnerReference{{
+		APIVersion: "kubeflow.org/v1", Kind: "Profile", Name: profile.Name, Controller: &controller,
+	}}}}
+	r := newFakeReconciler(profile, ns)
+	decoder, err := admission.NewDecoder(r.Scheme)
+	require.NoError(t, err)
+	d := &storageClassDefaulter{r: r}
+	require.NoError(t, d.InjectDecoder(decoder))
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: profile.Name}}
+
+	resp := d.Handle(context.TODO(), newPVCAdmissionRequest(t, pvc))
+	assert.True(t, resp.Allowed)
+	require.Len(t, resp.Patches, 1)
+	assert.Equal(t, "/spec/storageClassName", resp.Patches[0].Path)
+	assert.Equal(t, "team-ml-ssd", resp.Patches[0].Value)
+
+	// Claims selecting a storage class keep it.
+	empty := ""
+	pvc.Spec.StorageClassName = &empty
+	resp = d.Handle(context.TODO(), newPVCAdmissionRequest(t, pvc))
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+	pvc.Spec.StorageClassName = nil
+	pvc.Annotations = map[string]string{BETASTORAGECLASSANNOTATION: "standard"}
+	resp = d.Handle(context.TODO(), newPVCAdmissionRequest(t, pvc))
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+
+	// Claims outside profile namespaces are not defaulted.
+	other := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "kubeflow"}}
+	resp = d.Handle(context.TODO(), newPVCAdmissionRequest(t, other))
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	istioSecurity "istio.io/api/security/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// UserIdHeader is a request header containing the user id, with the prefix of the user ids it holds.
+type UserIdHeader struct {
+	Header string
+	Prefix string
+}
+
+// SplitUserIdList splits a comma separated list of userid headers or prefixes. Empty entries are kept, an
+// empty prefix is valid for providers sending plain user ids.
+func SplitUserIdList(value string) []string {
+	list := strings.Split(value, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list
+}
+
+// headerPrefix returns the prefix aligned with the userid header at index. A single prefix applies to all
+// headers, headers without aligned prefix have none.
+func headerPrefix(prefixes []string, index int) string {
+	if len(prefixes) == 1 {
+		return prefixes[0]
+	}
+	if index < len(prefixes) {
+		return prefixes[index]
+	}
+	return ""
+}
+
+// userIdHeaders returns the headers of the comma separated UserIdHeader, each with its prefix of the comma
+// separated UserIdPrefix, so several identity providers can be accepted.
+func (r *ProfileReconciler) userIdHeaders() []UserIdHeader {
+	prefixes := SplitUserIdList(r.UserIdPrefix)
+	var headers []UserIdHeader
+	for i, header := range SplitUserIdList(r.UserIdHeader) {
+		headers = append(headers, UserIdHeader{Header: header, Prefix: headerPrefix(prefixes, i)})
+	}
+	return headers
+}
+
+// subjectConditions returns the AuthorizationPolicy conditions matching the requests of subject in the mesh, any
+// of which is enough: users by each user id header, groups by GroupClaim of the validated JWT, e.g. an AAD group
+// object id. It returns none for subjects that cannot be matched, ServiceAccounts and groups if no GroupClaim is
+// configured.
+func (r *ProfileReconciler) subjectConditions(subject rbacv1.Subject) []*istioSecurity.Condition {
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		var conditions []*istioSecurity.Condition
+		for _, header := range r.userIdHeaders() {
+			conditions = append(conditions, &istioSecurity.Condition{
+				Key:    fmt.Sprintf("request.headers[%v]", header.Header),
+				Values: []string{header.Prefix + subject.Name},
+			})
+		}
+		return conditions
+	case rbacv1.GroupKind:
+		if r.GroupClaim == "" {
+			return nil
+		}
+		return []*istioSecurity.Condition{{
+			Key:    fmt.Sprintf("request.auth.claims[%v]", r.GroupClaim),
+			Values: []string{subject.Name},
+		}}
+	}
+	return nil
+}
+
+// subjectRules returns one rule per condition of subject, see subjectConditions, each allowed to.
+func (r *ProfileReconciler) subjectRules(subject rbacv1.Subject, to []*istioSecurity.Rule_To) []*istioSecurity.Rule {
+	var rules []*istioSecurity.Rule
+	for _, condition := range r.subjectConditions(subject) {
+		rules = append(rules, &istioSecurity.Rule{To: to, When: []*istioSecurity.Condition{condition}})
+	}
+	return rules
+}
+
+// ownerRules returns the rules of the built-in AuthorizationPolicy letting the profile owner access all workloads
+// in the namespace, none if the owner cannot be matched in the mesh.
+func (r *ProfileReconciler) ownerRules(profileIns *profilev1.Profile) []*istioSecurity.Rule {
+	return r.subjectRules(profileIns.Spec.Owner, nil)
+}
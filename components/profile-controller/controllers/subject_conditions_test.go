@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	istioSecurity "istio.io/api/security/v1beta1"
+	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestSubjectConditions(t *testing.T) {
+	r := newFakeReconciler()
+	user := rbacv1.Subject{Kind: "User", Name: "user1@abcd.com"}
+	assert.Equal(t, []*istioSecurity.Condition{{
+		Key:    "request.headers[x-goog-authenticated-user-email]",
+		Values: []string{"accounts.google.com:user1@abcd.com"},
+	}}, r.subjectConditions(user))
+	group := rbacv1.Subject{Kind: "Group", Name: "3f2504e0-4f89-11d3-9a0c-0305e82c3301"}
+	assert.Nil(t, r.subjectConditions(group))
+	assert.Nil(t, r.subjectConditions(rbacv1.Subject{Kind: "ServiceAccount", Name: "default", Namespace: "ns"}))
+
+	r.GroupClaim = "groups"
+	assert.Equal(t, []*istioSecurity.Condition{{
+		Key:    "request.auth.claims[groups]",
+		Values: []string{group.Name},
+	}}, r.subjectConditions(group))
+
+	// Users are matched by any of the headers, with the aligned prefix.
+	r.UserIdHeader = "x-goog-authenticated-user-email, x-ms-client-principal-name"
+	r.UserIdPrefix = "accounts.google.com:,"
+	assert.Equal(t, []*istioSecurity.Condition{
+		{Key: "request.headers[x-goog-authenticated-user-email]", Values: []string{"accounts.google.com:user1@abcd.com"}},
+		{Key: "request.headers[x-ms-client-principal-name]", Values: []string{"user1@abcd.com"}},
+	}, r.subjectConditions(user))
+	r.UserIdPrefix = "accounts.google.com:"
+	assert.Equal(t, "accounts.google.com:user1@abcd.com", r.subjectConditions(user)[1].Values[0])
+}
+
+func TestReconcileUserIdHeaders(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.UserIdHeader = "x-goog-authenticated-user-email,x-ms-client-principal-name"
+	r.UserIdPrefix = "accounts.google.com:,"
+	r.AuditorAccess = &AuditorAccess{ClusterRole: "kubeflow-auditor", Users: []string{"auditor@abcd.com"}}
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	// The owner and the auditors get one rule per header.
+	policy := &istioSecurityClient.AuthorizationPolicy{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: AUTHZPOLICYISTIO, Namespace: profile.Name},
+		policy))
+	assert.Equal(t, []*istioSecurity.Condition{{
+		Key: "request.headers[x-goog-authenticated-user-email]", Values: []string{"accounts.google.com:user1@abcd.com"},
+	}}, policy.Spec.Rules[0].When)
+	assert.Equal(t, []*istioSecurity.Condition{{
+		Key: "request.headers[x-ms-client-principal-name]", Values: []string{"user1@abcd.com"},
+	}}, policy.Spec.Rules[1].When)
+	auditor := &istioSecurityClient.AuthorizationPolicy{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: AUDITORAUTHZPOLICY, Namespace: profile.Name},
+		auditor))
+	require.Len(t, auditor.Spec.Rules, 2)
+	assert.Equal(t, []*istioSecurity.Condition{{
+		Key: "request.headers[x-goog-authenticated-user-email]", Values: []string{"accounts.google.com:auditor@abcd.com"},
+	}}, auditor.Spec.Rules[0].When)
+	assert.Equal(t, []*istioSecurity.Condition{{
+		Key: "request.headers[x-ms-client-principal-name]", Values: []string{"auditor@abcd.com"},
+	}}, auditor.Spec.Rules[1].When)
+	assert.Equal(t, auditorMethods, auditor.Spec.Rules[1].To[0].Operation.Methods)
+}
+
+func TestReconcileGroupSubjects(t *testing.T) {
+	profile := newTestProfile("kubeflow-team1", "")
+	profile.Spec.Owner = rbacv1.Subject{APIGroup: "rbac.authorization.k8s.io", Kind: "Group", Name: "team1-admins"}
+	contributor := profilev1.Contributor{
+		Subject: rbacv1.Subject{APIGroup: "rbac.authorization.k8s.io", Kind: "Group", Name: "team1-members"},
+		Role:    VIEW,
+	}
+	profile.Spec.Contributors = []profilev1.Contributor{contributor}
+	r := newFakeReconciler(profile)
+	r.GroupClaim = "groups"
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}})
+	require.NoError(t, err)
+
+	owner := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: OWNERBINDING, Namespace: profile.Name}, owner))
+	assert.Equal(t, []rbacv1.Subject{profile.Spec.Owner}, owner.Subjects)
+	binding := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: getContributorBindingName(contributor),
+		Namespace: profile.Name}, binding))
+	assert.Equal(t, []rbacv1.Subject{contributor.Subject}, binding.Subjects)
+	assert.Equal(t, kubeflowView, binding.RoleRef.Name)
+
+	// The owner group is matched by the group claim of the built-in policy.
+	policy := &istioSecurityClient.AuthorizationPolicy{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: AUTHZPOLICYISTIO, Namespace: profile.Name},
+		policy))
+	assert.Equal(t, []*istioSecurity.Condition{
+		{Key: "request.auth.claims[groups]", Values: []string{"team1-admins"}},
+	}, policy.Spec.Rules[0].When)
+
+	policy = &istioSecurityClient.AuthorizationPolicy{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: getContributorBindingName(contributor),
+		Namespace: profile.Name}, policy))
+	require.Len(t, policy.Spec.Rules, 1)
+	assert.Equal(t, []*istioSecurity.Condition{
+		{Key: "request.auth.claims[groups]", Values: []string{"team1-members"}},
+	}, policy.Spec.Rules[0].When)
+	assert.Equal(t, auditorMethods, policy.Spec.Rules[0].To[0].Operation.Methods)
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Condition type set while the workloads of the profile namespace are suspended with spec.suspend or by the
+// expiry of the profile.
+const ProfileSuspended = "Suspended"
+
+// Name of the ResourceQuota admitting no pods in the namespace of suspended profiles. It applies next to KFQUOTA.
+const SUSPENDQUOTA = "kf-suspend-quota"
+
+// Annotation of the Deployments and StatefulSets scaled to zero by the suspension, holding the replicas
+// restored on resume.
+const SUSPENDEDREPLICAS = "profile.kubeflow.org/suspended-replicas"
+
+// Annotation of the notebook controller stopping a Notebook, set to the time it was stopped at.
+const NOTEBOOKSTOPPED = "kubeflow-resource-stopped"
+
+// Annotation of the Notebooks stopped by the suspension. Notebooks stopped before stay stopped on resume.
+const SUSPENDEDNOTEBOOK = "profile.kubeflow.org/suspended"
+
+// Notebooks are served by the notebook-controller component, handled as unstructured objects here.
+var notebookGVK = schema.GroupVersionKind{Group: "kubeflow.org", Version: "v1", Kind: "Notebook"}
+
+// updateSuspended suspends or resumes the workloads of the profile namespace from suspendMessage and sets the
+// Suspended condition. Suspended namespaces admit no pods with the SUSPENDQUOTA ResourceQuota, their
+// Deployments and StatefulSets are scaled to zero and their Notebooks stopped. Volumes, ConfigMaps and other
+// resources are kept. Profiles which were never suspended get no condition.
+func (r *ProfileReconciler) updateSuspended(ctx context.Context, profileIns *profilev1.Profile) error {
+	suspended := false
+	for _, condition := range profileIns.Status.Conditions {
+		if condition.Type == ProfileSuspended {
+			suspended = condition.Status == "True"
+		}
+	}
+	message := r.suspendMessage(profileIns)
+	if message == "" {
+		if !suspended {
+			return nil
+		}
+		if err := r.updateWorkloads(ctx, profileIns, false); err != nil {
+			return err
+		}
+		if err := r.deleteOwnedResourceQuota(ctx, profileIns, SUSPENDQUOTA); err != nil {
+			return err
+		}
+		return r.writeSuspended(ctx, profileIns, "False", "workloads resumed")
+	}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: SUSPENDQUOTA, Namespace: profileNamespace(profileIns)},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("0")},
+		},
+	}
+	if err := r.updateResourceQuota(ctx, profileIns, quota); err != nil {
+		return err
+	}
+	// Workloads scaled up while suspended are scaled down again.
+	if err := r.updateWorkloads(ctx, profileIns, true); err != nil {
+		return err
+	}
+	if suspended {
+		return nil
+	}
+	return r.writeSuspended(ctx, profileIns, "True", message)
+}
+
+// suspendMessage returns why the workloads of the profile are suspended, empty if they are not: spec.suspend, or
+// the Expired condition with the PROFILEEXPIRYSUSPEND action.
+func (r *ProfileReconciler) suspendMessage(profileIns *profilev1.Profile) string {
+	if profileIns.Spec.Suspend {
+		return "workloads suspended with spec.suspend, no pods are admitted in the namespace"
+	}
+	if r.ProfileExpiry == nil || r.ProfileExpiry.Action != PROFILEEXPIRYSUSPEND {
+		return ""
+	}
+	for _, condition := range profileIns.Status.Conditions {
+		if condition.Type == ProfileExpired && condition.Status == "True" {
+			return "workloads suspended since the profile expired, no pods are admitted in the namespace"
+		}
+	}
+	return ""
+}
+
+func (r *ProfileReconciler) writeSuspended(ctx context.Context, profileIns *profilev1.Profile, status string,
+	message string) error {
+	r.Log.Info("Updating suspended condition", "profile", profileIns.Name, "suspended", status)
+	r.setProfileCondition(profileIns, ProfileSuspended, status, message)
+	return r.Status().Update(ctx, profileIns)
+}
+
+// updateWorkloads scales the Deployments and StatefulSets of the profile namespace to zero and stops its
+// Notebooks if suspend is set, otherwise restores the ones suspended. StatefulSets of Notebooks are left to the
+// notebook controller.
+func (r *ProfileReconciler) updateWorkloads(ctx context.Context, profileIns *profilev1.Profile, suspend bool) error {
+	logger := r.Log.WithValues("profile", profileIns.Name)
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(profileNamespace(profileIns))); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if suspendReplicas(deployment, &deployment.Spec.Replicas, suspend) {
+			logger.Info("Scaling Deployment", "namespace", deployment.Namespace, "name", deployment.Name,
+				"suspend", suspend)
+			if err := r.Update(ctx, deployment); err != nil {
+				return err
+			}
+		}
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(profileNamespace(profileIns))); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		if ownedByNotebook(statefulSet) {
+			continue
+		}
+		if suspendReplicas(statefulSet, &statefulSet.Spec.Replicas, suspend) {
+			logger.Info("Scaling StatefulSet", "namespace", statefulSet.Namespace, "name", statefulSet.Name,
+				"suspend", suspend)
+			if err := r.Update(ctx, statefulSet); err != nil {
+				return err
+			}
+		}
+	}
+	notebooks := &unstructured.UnstructuredList{}
+	notebooks.SetGroupVersionKind(notebookGVK.GroupVersion().WithKind(notebookGVK.Kind + "List"))
+	if err := r.List(ctx, notebooks, client.InNamespace(profileNamespace(profileIns))); err != nil {
+		if meta.IsNoMatchError(err) {
+			// The notebook controller is not installed.
+			return nil
+		}
+		return err
+	}
+	for i := range notebooks.Items {
+		notebook := &notebooks.Items[i]
+		if suspendNotebook(notebook, suspend) {
+			logger.Info("Stopping Notebook", "namespace", notebook.GetNamespace(), "name", notebook.GetName(),
+				"suspend", suspend)
+			if err := r.Update(ctx, notebook); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// suspendReplicas scales the workload obj with replicas to zero, keeping its replicas in SUSPENDEDREPLICAS, if
+// suspend is set, otherwise restores them. Returns whether obj was changed.
+func suspendReplicas(obj metav1.Object, replicas **int32, suspend bool) bool {
+	annotations := obj.GetAnnotations()
+	saved, ok := annotations[SUSPENDEDREPLICAS]
+	if !suspend {
+		if !ok {
+			return false
+		}
+		restored, err := strconv.ParseInt(saved, 10, 32)
+		if err != nil || restored < 0 {
+			// Edited annotation, the default of a workload applies.
+			restored = 1
+		}
+		n := int32(restored)
+		*replicas = &n
+		delete(annotations, SUSPENDEDREPLICAS)
+		obj.SetAnnotations(annotations)
+		return true
+	}
+	if *replicas != nil && **replicas == 0 {
+		return false
+	}
+	if !ok {
+		n := int32(1)
+		if *replicas != nil {
+			n = **replicas
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[SUSPENDEDREPLICAS] = strconv.Itoa(int(n))
+		obj.SetAnnotations(annotations)
+	}
+	zero := int32(0)
+	*replicas = &zero
+	return true
+}
+
+// suspendNotebook stops the notebook with NOTEBOOKSTOPPED if suspend is set, otherwise starts it again if it was
+// stopped by the suspension. Returns whether notebook was changed.
+func suspendNotebook(notebook *unstructured.Unstructured, suspend bool) bool {
+	annotations := notebook.GetAnnotations()
+	_, stopped := annotations[NOTEBOOKSTOPPED]
+	_, suspended := annotations[SUSPENDEDNOTEBOOK]
+	if !suspend {
+		if !suspended {
+			return false
+		}
+		delete(annotations, NOTEBOOKSTOPPED)
+		delete(annotations, SUSPENDEDNOTEBOOK)
+		notebook.SetAnnotations(annotations)
+		return true
+	}
+	if stopped {
+		return false
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[NOTEBOOKSTOPPED] = time.Now().UTC().Format(time.RFC3339)
+	annotations[SUSPENDEDNOTEBOOK] = "true"
+	notebook.SetAnnotations(annotations)
+	return true
+}
+
+// ownedByNotebook tells if obj is controlled by a Notebook.
+func ownedByNotebook(obj metav1.Object) bool {
+	owner := metav1.GetControllerOf(obj)
+	return owner != nil && owner.Kind == notebookGVK.Kind && owner.APIVersion == notebookGVK.GroupVersion().String()
+}
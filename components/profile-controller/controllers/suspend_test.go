@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func int32Ptr(n int32) *int32 {
+	return &n
+}
+
+func TestSuspendReplicas(t *testing.T) {
+	deployment := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(3)}}
+	assert.True(t, suspendReplicas(deployment, &deployment.Spec.Replicas, true))
+	assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+	assert.Equal(t, "3", deployment.Annotations[SUSPENDEDREPLICAS])
+	assert.False(t, suspendReplicas(deployment, &deployment.Spec.Replicas, true))
+
+	// Scaled up while suspended, the replicas to restore are kept.
+	deployment.Spec.Replicas = int32Ptr(5)
+	assert.True(t, suspendReplicas(deployment, &deployment.Spec.Replicas, true))
+	assert.Equal(t, "3", deployment.Annotations[SUSPENDEDREPLICAS])
+
+	assert.True(t, suspendReplicas(deployment, &deployment.Spec.Replicas, false))
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+	assert.NotContains(t, deployment.Annotations, SUSPENDEDREPLICAS)
+	assert.False(t, suspendReplicas(deployment, &deployment.Spec.Replicas, false))
+
+	// Workloads scaled to zero before are kept at zero.
+	deployment.Spec.Replicas = int32Ptr(0)
+	assert.False(t, suspendReplicas(deployment, &deployment.Spec.Replicas, true))
+	assert.False(t, suspendReplicas(deployment, &deployment.Spec.Replicas, false))
+	assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+}
+
+func TestSuspendNotebook(t *testing.T) {
+	notebook := &unstructured.Unstructured{}
+	notebook.SetGroupVersionKind(notebookGVK)
+	assert.True(t, suspendNotebook(notebook, true))
+	assert.Contains(t, notebook.GetAnnotations(), NOTEBOOKSTOPPED)
+	assert.False(t, suspendNotebook(notebook, true))
+	assert.True(t, suspendNotebook(notebook, false))
+	assert.Empty(t, notebook.GetAnnotations())
+
+	// Notebooks stopped by their users stay stopped.
+	notebook.SetAnnotations(map[string]string{NOTEBOOKSTOPPED: "2021-06-01T00:00:00Z"})
+	assert.False(t, suspendNotebook(notebook, true))
+	assert.False(t, suspendNotebook(notebook, false))
+	assert.Equal(t, map[string]string{NOTEBOOKSTOPPED: "2021-06-01T00:00:00Z"}, notebook.GetAnnotations())
+}
+
+func TestReconcileSuspend(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	profile.Spec.Suspend = true
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "serving", Namespace: profile.Name},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+	}
+	notebookSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: profile.Name},
+		Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(1)},
+	}
+	controller := true
+	notebookSet.OwnerReferences = []metav1.OwnerReference{{APIVersion: "kubeflow.org/v1", Kind: "Notebook",
+		Name: "notebook", Controller: &controller}}
+	notebook := &unstructured.Unstructured{}
+	notebook.SetGroupVersionKind(notebookGVK)
+	notebook.SetName("notebook")
+	notebook.SetNamespace(profile.Name)
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "workspace", Namespace: profile.Name}}
+	r := newFakeReconciler(profile, deployment, notebookSet, notebook, pvc)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	quotaKey := types.NamespacedName{Name: SUSPENDQUOTA, Namespace: profile.Name}
+	getReplicas := func() (int32, int32) {
+		d := &appsv1.Deployment{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "serving", Namespace: profile.Name}, d))
+		s := &appsv1.StatefulSet{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "notebook", Namespace: profile.Name}, s))
+		return *d.Spec.Replicas, *s.Spec.Replicas
+	}
+	getNotebookAnnotations := func() map[string]string {
+		nb := &unstructured.Unstructured{}
+		nb.SetGroupVersionKind(notebookGVK)
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "notebook", Namespace: profile.Name}, nb))
+		return nb.GetAnnotations()
+	}
+	getCondition := func() string {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		for _, c := range found.Status.Conditions {
+			if c.Type == ProfileSuspended {
+				return c.Status
+			}
+		}
+		return ""
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	deploymentReplicas, notebookReplicas := getReplicas()
+	assert.Equal(t, int32(0), deploymentReplicas)
+	// The StatefulSet of the Notebook is left to the notebook controller.
+	assert.Equal(t, int32(1), notebookReplicas)
+	assert.Contains(t, getNotebookAnnotations(), NOTEBOOKSTOPPED)
+	quota := &corev1.ResourceQuota{}
+	require.NoError(t, r.Get(context.TODO(), quotaKey, quota))
+	assert.True(t, quota.Spec.Hard.Pods().IsZero())
+	assert.Equal(t, "True", getCondition())
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "workspace", Namespace: profile.Name},
+		&corev1.PersistentVolumeClaim{}))
+
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Spec.Suspend = false
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	deploymentReplicas, _ = getReplicas()
+	assert.Equal(t, int32(2), deploymentReplicas)
+	assert.NotContains(t, getNotebookAnnotations(), NOTEBOOKSTOPPED)
+	err = r.Get(context.TODO(), quotaKey, &corev1.ResourceQuota{})
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, "False", getCondition())
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Name of the Role and RoleBinding granting the default-editor service account the creation of its own tokens.
+const DEFAULTEDITORTOKENREQUEST = "default-editor-token-request"
+
+// getTokenRequestRole returns the Role to create TokenRequests for the default-editor service account only.
+func getTokenRequestRole(namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DEFAULTEDITORTOKENREQUEST,
+			Namespace: namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"serviceaccounts/token"},
+				ResourceNames: []string{DEFAULT_EDITOR},
+				Verbs:         []string{"create"},
+			},
+		},
+	}
+}
+
+// updateDefaultEditorTokenRequest grants the default-editor service account the creation of TokenRequests for
+// itself, e.g. for token exchange, if DefaultEditorTokenRequest is enabled, and removes it otherwise.
+func (r *ProfileReconciler) updateDefaultEditorTokenRequest(ctx context.Context,
+	profileIns *profilev1.Profile) error {
+	if !r.DefaultEditorTokenRequest {
+		if err := r.deleteOwnedRoleBinding(ctx, profileIns, DEFAULTEDITORTOKENREQUEST); err != nil {
+			return err
+		}
+		return r.deleteOwnedRole(ctx, profileIns, DEFAULTEDITORTOKENREQUEST)
+	}
+	if err := r.updateRole(ctx, profileIns, getTokenRequestRole(profileNamespace(profileIns))); err != nil {
+		return err
+	}
+	return r.updateRoleBinding(ctx, profileIns, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DEFAULTEDITORTOKENREQUEST,
+			Namespace: profileNamespace(profileIns),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     DEFAULTEDITORTOKENREQUEST,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      DEFAULT_EDITOR,
+				Namespace: profileNamespace(profileIns),
+			},
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileDefaultEditorTokenRequest(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: DEFAULTEDITORTOKENREQUEST, Namespace: profile.Name}
+
+	// Disabled by default.
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.Role{})))
+
+	r.DefaultEditorTokenRequest = true
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	role := &rbacv1.Role{}
+	require.NoError(t, r.Get(context.TODO(), key, role))
+	assert.Equal(t, []rbacv1.PolicyRule{{
+		APIGroups:     []string{""},
+		Resources:     []string{"serviceaccounts/token"},
+		ResourceNames: []string{DEFAULT_EDITOR},
+		Verbs:         []string{"create"},
+	}}, role.Rules)
+	binding := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Get(context.TODO(), key, binding))
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: DEFAULTEDITORTOKENREQUEST},
+		binding.RoleRef)
+	assert.Equal(t, []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: DEFAULT_EDITOR,
+		Namespace: profile.Name}}, binding.Subjects)
+
+	// Disabling it removes the Role and RoleBinding.
+	r.DefaultEditorTokenRequest = false
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.Role{})))
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &rbacv1.RoleBinding{})))
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+)
+
+// Profile annotation overriding the default trace sampling rate of the profile namespace.
+const TRACINGSAMPLINGRATE = "profile.kubeflow.org/tracing-sampling-rate"
+
+// TracingSampling configures the namespace annotation the tracing agent reads the sampling rate from.
+type TracingSampling struct {
+	// Annotation is the namespace annotation key holding the sampling rate.
+	Annotation string
+	// DefaultRate applies to profiles without TRACINGSAMPLINGRATE annotation, no annotation is set if empty.
+	DefaultRate string
+}
+
+// ValidateTracingSamplingRate checks rate is a percentage between 0 and 100.
+func ValidateTracingSamplingRate(rate string) error {
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r < 0 || r > 100 {
+		return fmt.Errorf("%q is not a percentage between 0 and 100", rate)
+	}
+	return nil
+}
+
+// rate returns the trace sampling rate of the profile: the TRACINGSAMPLINGRATE annotation if set, else
+// DefaultRate.
+func (s *TracingSampling) rate(profileIns *profilev1.Profile) (string, error) {
+	if rate, ok := profileIns.Annotations[TRACINGSAMPLINGRATE]; ok {
+		if err := ValidateTracingSamplingRate(rate); err != nil {
+			return "", fmt.Errorf("invalid %v annotation: %v", TRACINGSAMPLINGRATE, err)
+		}
+		return rate, nil
+	}
+	return s.DefaultRate, nil
+}
+
+// annotations returns the namespace annotation of the trace sampling rate, with an empty value if the
+// profile has no rate so that a previous one is removed.
+func (s *TracingSampling) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if s == nil || s.Annotation == "" {
+		return nil, nil
+	}
+	rate, err := s.rate(profileIns)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{s.Annotation: rate}, nil
+}
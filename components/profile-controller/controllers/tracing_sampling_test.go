@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestValidateTracingSamplingRate(t *testing.T) {
+	for _, rate := range []string{"0", "1.5", "100"} {
+		assert.NoError(t, ValidateTracingSamplingRate(rate), rate)
+	}
+	for _, rate := range []string{"", "often", "-1", "100.1"} {
+		assert.Error(t, ValidateTracingSamplingRate(rate), rate)
+	}
+}
+
+func TestTracingSamplingRate(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	for _, tc := range []struct {
+		name        string
+		defaultRate string
+		annotations map[string]string
+		expected    string
+		err         bool
+	}{
+		{name: "default", defaultRate: "1", expected: "1"},
+		{name: "no default", expected: ""},
+		{name: "annotation overrides default", defaultRate: "1",
+			annotations: map[string]string{TRACINGSAMPLINGRATE: "25"}, expected: "25"},
+		{name: "annotation without default", annotations: map[string]string{TRACINGSAMPLINGRATE: "0"}, expected: "0"},
+		{name: "invalid annotation", defaultRate: "1", annotations: map[string]string{TRACINGSAMPLINGRATE: "all"},
+			err: true},
+	} {
+		s := &TracingSampling{Annotation: "tracing.example.com/sampling", DefaultRate: tc.defaultRate}
+		profile.Annotations = tc.annotations
+		rate, err := s.rate(profile)
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, rate, tc.name)
+	}
+}
+
+func TestReconcileTracingSampling(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.TracingSampling = &TracingSampling{Annotation: "tracing.example.com/sampling", DefaultRate: "1"}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getRate := func() (string, bool) {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		rate, ok := ns.Annotations["tracing.example.com/sampling"]
+		return rate, ok
+	}
+	updateProfile := func(update func(*profilev1.Profile)) {
+		found := &profilev1.Profile{}
+		require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+		update(found)
+		require.NoError(t, r.Update(context.TODO(), found))
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	rate, _ := getRate()
+	assert.Equal(t, "1", rate)
+
+	updateProfile(func(p *profilev1.Profile) { p.Annotations = map[string]string{TRACINGSAMPLINGRATE: "50"} })
+	rate, _ = getRate()
+	assert.Equal(t, "50", rate)
+
+	// Without annotation and default rate the namespace annotation is removed.
+	r.TracingSampling.DefaultRate = ""
+	updateProfile(func(p *profilev1.Profile) { p.Annotations = nil })
+	_, ok := getRate()
+	assert.False(t, ok)
+}
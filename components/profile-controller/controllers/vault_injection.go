@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+)
+
+// Prefix of the annotations read by the Vault Agent Injector.
+const VAULTANNOTATIONPREFIX = "vault.hashicorp.com/"
+
+// Profile annotation opting the profile namespace and its default ServiceAccounts out of the Vault injection
+// annotations when set to "false".
+const VAULTINJECTION = "profile.kubeflow.org/vault-injection"
+
+// VaultInjection configures the Vault Agent Injector annotations set on profile namespaces and on their
+// default-editor and default-viewer ServiceAccounts.
+type VaultInjection struct {
+	// Annotations are rendered with ProfileTemplateData, e.g. `vault.hashicorp.com/role={{ .Name }}`.
+	Annotations AnnotationTemplates
+}
+
+// ParseVaultAnnotations parses the -vault-annotations value, comma separated key=template pairs with keys under
+// VAULTANNOTATIONPREFIX, so they never replace other annotations. Returns nil if value is empty.
+func ParseVaultAnnotations(value string) (*VaultInjection, error) {
+	templates, err := ParseAnnotationTemplates(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	for key := range templates {
+		if !strings.HasPrefix(key, VAULTANNOTATIONPREFIX) || key == VAULTANNOTATIONPREFIX {
+			return nil, fmt.Errorf("invalid Vault annotation %v, expected a key under %v", key, VAULTANNOTATIONPREFIX)
+		}
+	}
+	return &VaultInjection{Annotations: templates}, nil
+}
+
+// annotations returns the Vault annotations of the profile, with empty values if the profile opted out so that
+// previous ones are removed.
+func (v *VaultInjection) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if profileIns.Annotations[VAULTINJECTION] == "false" {
+		annotations := make(map[string]string, len(v.Annotations))
+		for key := range v.Annotations {
+			annotations[key] = ""
+		}
+		return annotations, nil
+	}
+	return v.Annotations.Render(profileIns)
+}
+
+// serviceAccountAnnotations returns the annotations of the default ServiceAccount saName of the profile. The Vault
+// annotations take precedence over the default-editor annotations.
+func (r *ProfileReconciler) serviceAccountAnnotations(profileIns *profilev1.Profile,
+	saName string) (map[string]string, error) {
+	annotations := map[string]string{}
+	if saName == DEFAULT_EDITOR {
+		editor, err := r.DefaultEditorAnnotations.Render(profileIns)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range editor {
+			annotations[k] = v
+		}
+	}
+	vault, err := r.VaultInjection.annotations(profileIns)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range vault {
+		annotations[k] = v
+	}
+	return annotations, nil
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseVaultAnnotations(t *testing.T) {
+	v, err := ParseVaultAnnotations("vault.hashicorp.com/agent-inject=true,vault.hashicorp.com/role={{ .Name }}")
+	require.NoError(t, err)
+	assert.Len(t, v.Annotations, 2)
+
+	v, err = ParseVaultAnnotations("")
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	for _, value := range []string{"owner=user1", "vault.hashicorp.com/=true", "vault.hashicorp.com/role={{ .Name"} {
+		_, err = ParseVaultAnnotations(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestVaultAnnotations(t *testing.T) {
+	v, err := ParseVaultAnnotations("vault.hashicorp.com/agent-inject=true,vault.hashicorp.com/role={{ .Name }}")
+	require.NoError(t, err)
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	annotations, err := v.annotations(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+		"vault.hashicorp.com/role":         "kubeflow-user1",
+	}, annotations)
+
+	profile.Annotations = map[string]string{VAULTINJECTION: "false"}
+	annotations, err = v.annotations(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"vault.hashicorp.com/agent-inject": "", "vault.hashicorp.com/role": ""},
+		annotations)
+
+	annotations, err = (*VaultInjection)(nil).annotations(profile)
+	require.NoError(t, err)
+	assert.Nil(t, annotations)
+}
+
+func TestReconcileVaultAnnotations(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        profile.Name,
+		Annotations: map[string]string{"owner": "user1@abcd.com", "team.example.com/contact": "ml"},
+	}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:        DEFAULT_EDITOR,
+		Namespace:   profile.Name,
+		Annotations: map[string]string{GCP_ANNOTATION_KEY: "user1@project.iam.gserviceaccount.com"},
+	}}
+	r := newFakeReconciler(profile, ns, sa)
+	templates, err := ParseAnnotationTemplates("eventing.knative.dev/broker=default")
+	require.NoError(t, err)
+	r.DefaultEditorAnnotations = templates
+	r.VaultInjection, err = ParseVaultAnnotations(
+		"vault.hashicorp.com/agent-inject=true,vault.hashicorp.com/role={{ .Name }}")
+	require.NoError(t, err)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getAnnotations := func(obj metav1.Object, key types.NamespacedName) map[string]string {
+		require.NoError(t, r.Get(context.TODO(), key, obj.(runtime.Object)))
+		return obj.GetAnnotations()
+	}
+	nsKey := types.NamespacedName{Name: profile.Name}
+	editorKey := types.NamespacedName{Name: DEFAULT_EDITOR, Namespace: profile.Name}
+	viewerKey := types.NamespacedName{Name: DEFAULT_VIEWER, Namespace: profile.Name}
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	annotations := getAnnotations(&corev1.Namespace{}, nsKey)
+	assert.Equal(t, "true", annotations["vault.hashicorp.com/agent-inject"])
+	assert.Equal(t, profile.Name, annotations["vault.hashicorp.com/role"])
+	assert.Equal(t, "ml", annotations["team.example.com/contact"], "other annotations coexist")
+	annotations = getAnnotations(&corev1.ServiceAccount{}, editorKey)
+	assert.Equal(t, profile.Name, annotations["vault.hashicorp.com/role"])
+	assert.Equal(t, "default", annotations["eventing.knative.dev/broker"])
+	assert.Equal(t, "user1@project.iam.gserviceaccount.com", annotations[GCP_ANNOTATION_KEY])
+	assert.Equal(t, profile.Name, getAnnotations(&corev1.ServiceAccount{}, viewerKey)["vault.hashicorp.com/role"])
+
+	// Opting the profile out removes the Vault annotations only.
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Annotations = map[string]string{VAULTINJECTION: "false"}
+	require.NoError(t, r.Update(context.TODO(), found))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	for _, obj := range []struct {
+		obj metav1.Object
+		key types.NamespacedName
+	}{{&corev1.Namespace{}, nsKey}, {&corev1.ServiceAccount{}, editorKey}, {&corev1.ServiceAccount{}, viewerKey}} {
+		annotations = getAnnotations(obj.obj, obj.key)
+		assert.NotContains(t, annotations, "vault.hashicorp.com/agent-inject", obj.key)
+		assert.NotContains(t, annotations, "vault.hashicorp.com/role", obj.key)
+	}
+	assert.Equal(t, "ml", getAnnotations(&corev1.Namespace{}, nsKey)["team.example.com/contact"])
+	annotations = getAnnotations(&corev1.ServiceAccount{}, editorKey)
+	assert.Equal(t, "default", annotations["eventing.knative.dev/broker"])
+	assert.Equal(t, "user1@project.iam.gserviceaccount.com", annotations[GCP_ANNOTATION_KEY])
+}
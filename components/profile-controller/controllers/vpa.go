@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Profile annotation excluding the profile namespace from VPA recommendations when set to "false".
+const VPARECOMMENDATIONS = "profile.kubeflow.org/vpa-recommendations"
+
+// MANAGEDLABELS lists the namespace label keys set by the controller for VPA, so keys dropped from the
+// configuration or of opted out profiles are pruned.
+const MANAGEDLABELS = "profile.kubeflow.org/managed-labels"
+
+// VPAInclusion configures the namespace labels and annotations including profile namespaces in the
+// recommendations of a VerticalPodAutoscaler running in recommendation mode.
+type VPAInclusion struct {
+	Labels      map[string]string
+	Annotations AnnotationTemplates
+}
+
+// ParseVPALabels parses the -vpa-labels value, comma separated key=value namespace labels.
+func ParseVPALabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid VPA label %q, expected key=value", pair)
+		}
+		if key == istioInjectionLabel {
+			return nil, fmt.Errorf("VPA label %v is set by the controller", key)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid VPA label key %q: %v", key, strings.Join(errs, ", "))
+		}
+		labelValue := strings.TrimSpace(kv[1])
+		if errs := validation.IsValidLabelValue(labelValue); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value of VPA label %v: %v", key, strings.Join(errs, ", "))
+		}
+		labels[key] = labelValue
+	}
+	return labels, nil
+}
+
+// included tells if the profile namespace is included in VPA recommendations.
+func (v *VPAInclusion) included(profileIns *profilev1.Profile) bool {
+	return v != nil && profileIns.Annotations[VPARECOMMENDATIONS] != "false"
+}
+
+// labels returns the VPA labels of the profile namespace, none if it is excluded.
+func (v *VPAInclusion) labels(profileIns *profilev1.Profile) map[string]string {
+	if !v.included(profileIns) {
+		return nil
+	}
+	return v.Labels
+}
+
+// annotations returns the VPA annotations of the profile namespace, with empty values if it is excluded so that
+// previous ones are removed.
+func (v *VPAInclusion) annotations(profileIns *profilev1.Profile) (map[string]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if !v.included(profileIns) {
+		annotations := make(map[string]string, len(v.Annotations))
+		for key := range v.Annotations {
+			annotations[key] = ""
+		}
+		return annotations, nil
+	}
+	return v.Annotations.Render(profileIns)
+}
+
+// updateManagedLabels sets the desired labels on ns and removes the ones previously set by the controller but no
+// longer desired, as listed in the MANAGEDLABELS annotation. Returns true if ns was changed.
+func updateManagedLabels(ns *corev1.Namespace, desired map[string]string) bool {
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string)
+	}
+	updated := false
+	managed := make([]string, 0, len(desired))
+	for k, v := range desired {
+		managed = append(managed, k)
+		if current, ok := ns.Labels[k]; !ok || current != v {
+			ns.Labels[k] = v
+			updated = true
+		}
+	}
+	for _, k := range strings.Split(ns.Annotations[MANAGEDLABELS], ",") {
+		if _, ok := desired[k]; ok || k == "" {
+			continue
+		}
+		if _, ok := ns.Labels[k]; ok {
+			delete(ns.Labels, k)
+			updated = true
+		}
+	}
+	sort.Strings(managed)
+	marker := strings.Join(managed, ",")
+	if current, ok := ns.Annotations[MANAGEDLABELS]; marker == "" {
+		if ok {
+			delete(ns.Annotations, MANAGEDLABELS)
+			updated = true
+		}
+	} else if current != marker {
+		if ns.Annotations == nil {
+			ns.Annotations = make(map[string]string)
+		}
+		ns.Annotations[MANAGEDLABELS] = marker
+		updated = true
+	}
+	return updated
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseVPALabels(t *testing.T) {
+	labels, err := ParseVPALabels("vpa.example.com/recommend=true, vpa.example.com/mode=Off")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"vpa.example.com/recommend": "true", "vpa.example.com/mode": "Off"}, labels)
+
+	for _, value := range []string{"recommend", "=true", "istio-injection=disabled", "vpa.example.com/mode=not valid"} {
+		_, err := ParseVPALabels(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestReconcileVPAInclusion(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	labels, err := ParseVPALabels("vpa.example.com/recommend=true,vpa.example.com/mode=Off")
+	require.NoError(t, err)
+	annotations, err := ParseAnnotationTemplates("vpa.example.com/owner={{ .Owner }}")
+	require.NoError(t, err)
+	r.VPAInclusion = &VPAInclusion{Labels: labels, Annotations: annotations}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	getNamespace := func() *corev1.Namespace {
+		ns := &corev1.Namespace{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+		return ns
+	}
+	reconcileNamespace := func() {
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+	}
+
+	reconcileNamespace()
+	ns := getNamespace()
+	assert.Equal(t, "true", ns.Labels["vpa.example.com/recommend"])
+	assert.Equal(t, "Off", ns.Labels["vpa.example.com/mode"])
+	assert.Equal(t, "user1@abcd.com", ns.Annotations["vpa.example.com/owner"])
+	assert.Equal(t, "enabled", ns.Labels[istioInjectionLabel])
+
+	// Drift is corrected, labels set by users are kept.
+	ns.Labels["vpa.example.com/mode"] = "Auto"
+	ns.Labels["team"] = "ml"
+	require.NoError(t, r.Update(context.TODO(), ns))
+	reconcileNamespace()
+	ns = getNamespace()
+	assert.Equal(t, "Off", ns.Labels["vpa.example.com/mode"])
+	assert.Equal(t, "ml", ns.Labels["team"])
+
+	// Labels dropped from the configuration are pruned.
+	r.VPAInclusion.Labels = map[string]string{"vpa.example.com/recommend": "true"}
+	reconcileNamespace()
+	ns = getNamespace()
+	assert.NotContains(t, ns.Labels, "vpa.example.com/mode")
+	assert.Equal(t, "true", ns.Labels["vpa.example.com/recommend"])
+
+	// Opted out profiles lose the VPA labels and annotations.
+	found := &profilev1.Profile{}
+	require.NoError(t, r.Get(context.TODO(), request.NamespacedName, found))
+	found.Annotations = map[string]string{VPARECOMMENDATIONS: "false"}
+	require.NoError(t, r.Update(context.TODO(), found))
+	reconcileNamespace()
+	ns = getNamespace()
+	assert.NotContains(t, ns.Labels, "vpa.example.com/recommend")
+	assert.NotContains(t, ns.Annotations, "vpa.example.com/owner")
+	assert.NotContains(t, ns.Annotations, MANAGEDLABELS)
+	assert.Equal(t, "ml", ns.Labels["team"])
+}
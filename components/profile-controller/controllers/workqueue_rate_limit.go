@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Defaults of the workqueue rate limiter, the ones of controller-runtime.
+const (
+	DEFAULTWORKQUEUEBASEDELAY = 5 * time.Millisecond
+	DEFAULTWORKQUEUEMAXDELAY  = 1000 * time.Second
+	DEFAULTWORKQUEUEQPS       = 10
+	DEFAULTWORKQUEUEBURST     = 100
+)
+
+// WorkqueueRateLimit configures the rate limiter of the Profile workqueue: the requeues of a profile back off
+// exponentially from BaseDelay to MaxDelay, and the requeues of all profiles share a token bucket refilled at QPS
+// and holding Burst tokens.
+type WorkqueueRateLimit struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	QPS       float64
+	Burst     int
+}
+
+// Validate returns an error if the delays or the token bucket are not positive, or MaxDelay is less than
+// BaseDelay.
+func (l WorkqueueRateLimit) Validate() error {
+	if l.BaseDelay <= 0 || l.MaxDelay <= 0 {
+		return fmt.Errorf("workqueue delays must be positive")
+	}
+	if l.MaxDelay < l.BaseDelay {
+		return fmt.Errorf("workqueue max delay %v must not be less than the base delay %v", l.MaxDelay, l.BaseDelay)
+	}
+	if l.QPS <= 0 || l.Burst < 1 {
+		return fmt.Errorf("workqueue qps and burst must be positive")
+	}
+	return nil
+}
+
+// rateLimiter returns the workqueue rate limiter of l.
+func (l *WorkqueueRateLimit) rateLimiter() workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(l.BaseDelay, l.MaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(l.QPS), l.Burst)},
+	)
+}
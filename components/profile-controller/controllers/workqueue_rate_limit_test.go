@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkqueueRateLimitValidate(t *testing.T) {
+	defaults := WorkqueueRateLimit{
+		BaseDelay: DEFAULTWORKQUEUEBASEDELAY,
+		MaxDelay:  DEFAULTWORKQUEUEMAXDELAY,
+		QPS:       DEFAULTWORKQUEUEQPS,
+		Burst:     DEFAULTWORKQUEUEBURST,
+	}
+	assert.NoError(t, defaults.Validate())
+	for _, l := range []WorkqueueRateLimit{
+		{BaseDelay: 0, MaxDelay: time.Minute, QPS: 10, Burst: 100},
+		{BaseDelay: time.Minute, MaxDelay: time.Second, QPS: 10, Burst: 100},
+		{BaseDelay: time.Second, MaxDelay: time.Minute, QPS: 0, Burst: 100},
+		{BaseDelay: time.Second, MaxDelay: time.Minute, QPS: 10, Burst: 0},
+	} {
+		assert.Error(t, l.Validate(), l)
+	}
+}
+
+func TestWorkqueueRateLimiter(t *testing.T) {
+	l := &WorkqueueRateLimit{BaseDelay: time.Second, MaxDelay: 3 * time.Second, QPS: 1000, Burst: 1000}
+	limiter := l.rateLimiter()
+	assert.Equal(t, time.Second, limiter.When("user1"))
+	assert.Equal(t, 2*time.Second, limiter.When("user1"))
+	assert.Equal(t, 3*time.Second, limiter.When("user1"))
+	assert.Equal(t, time.Second, limiter.When("user2"), "profiles back off independently")
+	limiter.Forget("user1")
+	assert.Equal(t, time.Second, limiter.When("user1"))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Kubeflow Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Name of the workspace PVC provisioned in target namespace.
+const WORKSPACEPVC = "workspace"
+
+// Namespace annotation marking the workspace PVC as provisioned, so a PVC deleted by its users is not created
+// again.
+const WORKSPACEPROVISIONED = "profile.kubeflow.org/workspace-provisioned"
+
+// WorkspaceDefaults configures the workspace PVC provisioned in the namespace of every profile. Profiles with
+// spec.workspace override them.
+type WorkspaceDefaults struct {
+	Size             resource.Quantity
+	StorageClassName string
+}
+
+// ParseWorkspaceDefaults parses the -workspace-size and -workspace-storage-class values. An empty size returns
+// nil.
+func ParseWorkspaceDefaults(size string, storageClassName string) (*WorkspaceDefaults, error) {
+	if size == "" {
+		if storageClassName != "" {
+			return nil, fmt.Errorf("workspace storage class %v set without a workspace size", storageClassName)
+		}
+		return nil, nil
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace size %q: %v", size, err)
+	}
+	if err := validateWorkspaceSize(quantity); err != nil {
+		return nil, err
+	}
+	return &WorkspaceDefaults{Size: quantity, StorageClassName: storageClassName}, nil
+}
+
+func validateWorkspaceSize(size resource.Quantity) error {
+	if size.Sign() <= 0 {
+		return fmt.Errorf("invalid workspace size %v: must be positive", size.String())
+	}
+	return nil
+}
+
+// getWorkspacePVC returns the workspace PVC of the profile, nil if the profile gets none: it has no
+// spec.workspace and no WorkspaceDefaults are configured.
+func (r *ProfileReconciler) getWorkspacePVC(profileIns *profilev1.Profile) (*corev1.PersistentVolumeClaim, error) {
+	workspace := profileIns.Spec.Workspace
+	if workspace == nil && r.WorkspaceDefaults == nil {
+		return nil, nil
+	}
+	var size *resource.Quantity
+	storageClassName := ""
+	if r.WorkspaceDefaults != nil {
+		size = &r.WorkspaceDefaults.Size
+		storageClassName = r.WorkspaceDefaults.StorageClassName
+	}
+	// The default storage class of the profile takes precedence over the one of the controller.
+	if profileIns.Spec.DefaultStorageClass != "" {
+		storageClassName = profileIns.Spec.DefaultStorageClass
+	}
+	if workspace != nil {
+		if workspace.Size != nil {
+			size = workspace.Size
+		}
+		if workspace.StorageClassName != "" {
+			storageClassName = workspace.StorageClassName
+		}
+	}
+	if size == nil {
+		return nil, fmt.Errorf("workspace has no size and no default size is configured")
+	}
+	if err := validateWorkspaceSize(*size); err != nil {
+		return nil, err
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      WORKSPACEPVC,
+			Namespace: profileNamespace(profileIns),
+			Labels:    map[string]string{MANAGEDBYLABEL: MANAGEDBYVALUE, PROFILELABEL: profileIns.Name},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: *size},
+			},
+		},
+	}
+	if storageClassName != "" {
+		pvc.Spec.StorageClassName = &storageClassName
+	}
+	return pvc, nil
+}
+
+// updateWorkspace provisions the workspace PVC of the profile once. The PVC holds user data: it has no controller
+// reference, so it is kept like other user volumes, and it is never updated or created again.
+func (r *ProfileReconciler) updateWorkspace(ctx context.Context, profileIns *profilev1.Profile) error {
+	pvc, err := r.getWorkspacePVC(profileIns)
+	if err != nil || pvc == nil {
+		return err
+	}
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: pvc.Namespace}, ns); err != nil {
+		return err
+	}
+	if _, ok := ns.Annotations[WORKSPACEPROVISIONED]; ok {
+		return nil
+	}
+	found := &corev1.PersistentVolumeClaim{}
+	err = r.Get(ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, found)
+	if errors.IsNotFound(err) {
+		r.Log.Info("Creating workspace PVC", "namespace", pvc.Namespace, "name", pvc.Name,
+			"size", pvc.Spec.Resources.Requests.Storage().String())
+		err = r.Create(ctx, pvc)
+	}
+	if err != nil {
+		return err
+	}
+	return r.applyAnnotations(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns.Name}},
+		WORKSPACEFIELDMANAGER, map[string]string{WORKSPACEPROVISIONED: "true"}, nil)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestParseWorkspaceDefaults(t *testing.T) {
+	defaults, err := ParseWorkspaceDefaults("10Gi", "standard")
+	require.NoError(t, err)
+	assert.Equal(t, resource.MustParse("10Gi"), defaults.Size)
+	assert.Equal(t, "standard", defaults.StorageClassName)
+
+	defaults, err = ParseWorkspaceDefaults("", "")
+	require.NoError(t, err)
+	assert.Nil(t, defaults)
+
+	for _, tc := range [][2]string{{"ten", ""}, {"0", ""}, {"-1Gi", ""}, {"", "standard"}} {
+		_, err = ParseWorkspaceDefaults(tc[0], tc[1])
+		assert.Error(t, err, tc)
+	}
+}
+
+func TestGetWorkspacePVC(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler()
+	pvc, err := r.getWorkspacePVC(profile)
+	require.NoError(t, err)
+	assert.Nil(t, pvc)
+
+	r.WorkspaceDefaults = &WorkspaceDefaults{Size: resource.MustParse("10Gi"), StorageClassName: "standard"}
+	pvc, err = r.getWorkspacePVC(profile)
+	require.NoError(t, err)
+	assert.Equal(t, resource.MustParse("10Gi"), pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+	assert.Equal(t, "standard", *pvc.Spec.StorageClassName)
+	profile.Spec.DefaultStorageClass = "team-ml-ssd"
+	pvc, err = r.getWorkspacePVC(profile)
+	require.NoError(t, err)
+	assert.Equal(t, "team-ml-ssd", *pvc.Spec.StorageClassName)
+
+	// The profile overrides the defaults.
+	size := resource.MustParse("50Gi")
+	profile.Spec.Workspace = &profilev1.Workspace{Size: &size, StorageClassName: "fast"}
+	pvc, err = r.getWorkspacePVC(profile)
+	require.NoError(t, err)
+	assert.Equal(t, size, pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+	assert.Equal(t, "fast", *pvc.Spec.StorageClassName)
+
+	r.WorkspaceDefaults = nil
+	profile.Spec.Workspace = &profilev1.Workspace{}
+	_, err = r.getWorkspacePVC(profile)
+	assert.Error(t, err, "no size")
+}
+
+func TestReconcileWorkspace(t *testing.T) {
+	profile := newTestProfile("kubeflow-user1", "user1@abcd.com")
+	r := newFakeReconciler(profile)
+	r.WorkspaceDefaults = &WorkspaceDefaults{Size: resource.MustParse("10Gi")}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: profile.Name}}
+	key := types.NamespacedName{Name: WORKSPACEPVC, Namespace: profile.Name}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	pvc := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, r.Get(context.TODO(), key, pvc))
+	assert.Equal(t, resource.MustParse("10Gi"), pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+	assert.Nil(t, pvc.Spec.StorageClassName)
+	assert.Nil(t, metav1.GetControllerOf(pvc), "kept with the user data")
+	ns := &corev1.Namespace{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: profile.Name}, ns))
+	assert.Equal(t, "true", ns.Annotations[WORKSPACEPROVISIONED])
+
+	// A workspace deleted by its users is not provisioned again.
+	require.NoError(t, r.Delete(context.TODO(), pvc))
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(context.TODO(), key, &corev1.PersistentVolumeClaim{})))
+}
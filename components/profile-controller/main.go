@@ -16,17 +16,35 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/ghodss/yaml"
 	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	profilev1beta1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1beta1"
+	profilev2 "github.com/kubeflow/kubeflow/components/profile-controller/api/v2"
 	"github.com/kubeflow/kubeflow/components/profile-controller/controllers"
+	istioNetworkingClient "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioSecurityClient "istio.io/client-go/pkg/apis/security/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -34,66 +52,1118 @@ const USERIDHEADER = "userid-header"
 const USERIDPREFIX = "userid-prefix"
 const WORKLOADIDENTITY = "workload-identity"
 
+// VALIDATEPD is the subcommand validating a -pd value without starting the controller.
+const VALIDATEPD = "validate-pd"
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
+	// version is set at build time with -ldflags "-X main.version=<version>".
+	version = "dev"
 )
 
 func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = profilev1.AddToScheme(scheme)
+	_ = profilev1beta1.AddToScheme(scheme)
+	_ = profilev2.AddToScheme(scheme)
 	_ = istioSecurityClient.AddToScheme(scheme)
+	_ = istioNetworkingClient.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
-	var metricsAddr, leaderElectionNamespace string
+	if len(os.Args) > 1 && os.Args[1] == VALIDATEPD {
+		os.Exit(runValidatePodDefaults(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	var metricsAddr, healthProbeAddr, leaderElectionNamespace string
 	var enableLeaderElection bool
 	var userIdHeader string
 	var userIdPrefix string
+	var groupClaim string
 	var workloadIdentity string
-	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	var verifyWorkloadIdentity bool
+	var logRoutingAnnotations string
+	var catalogAnnotations string
+	var externalDNSAnnotations string
+	var certReminderAnnotations string
+	var documentationAnnotations string
+	var gatewayTLSDomain, gatewayTLSAnnotations string
+	var gpuReservationAnnotations string
+	var cleanupPolicyAnnotations string
+	var cleanupPolicies string
+	var profileExpiryAction string
+	var profileExpiryWarning time.Duration
+	var defaultCleanupPolicy string
+	var registryMirrors string
+	var registryMirrorAnnotations string
+	var vpaLabels, vpaAnnotations string
+	var defaultEditorAnnotations string
+	var vaultAnnotations string
+	var defaultImagePullSecrets string
+	var finalizerTimeout time.Duration
+	var finalizerTimeoutForce bool
+	var maxConcurrentReconciles int
+	var workqueueRateLimit controllers.WorkqueueRateLimit
+	var platform string
+	var globalReconcileRPS float64
+	var quotaTemplatesFile string
+	var baselineQuotaConfig string
+	var gpuResources string
+	var maxGPUQuota string
+	var pvcStorageMin, pvcStorageMax string
+	var workspaceSize, workspaceStorageClass string
+	var containerDefaultRequest, containerDefaultLimit string
+	var editorClusterRole, viewerClusterRole string
+	var authorizationPolicyTemplateFile string
+	var networkPolicies bool
+	var networkPolicyTemplateFile, networkPolicyGatewayNamespace, networkPolicyEgressAllowlist string
+	var profileLabelSelector string
+	var watchLabelSelector string
+	var ignoreNamespaces string
+	var notebookControllerSA, notebookControllerRole string
+	var chaosSA, chaosRole string
+	var backupSA, backupRole string
+	var auditorUsers, auditorGroups, auditorRole string
+	var podDefaultsConfig string
+	var podDefaultsSkipInvalid bool
+	var podDefaultsStrict bool
+	var podDefaultsConfigMap string
+	var namespaceMetadataConfigMap string
+	var podDefaultLabelsConfig string
+	var podAntiAffinityConfig string
+	var cleanupOrderConfig string
+	var onDeleteConfig string
+	var quotaSummaryConfigMap string
+	var adoptLegacyLabels bool
+	var adoptNamespaces bool
+	var namespacePrefix, namespaceSuffix string
+	var versionAnnotation string
+	var ownerScaleAccess bool
+	var ownerPortForwardAccess bool
+	var ownerNetworkPolicyAccess bool
+	var ownerPodRestartAccess bool
+	var defaultEditorTokenRequest bool
+	var ownerApprovalAnnotation string
+	var dataClassificationLevels string
+	var requireDataClassification bool
+	var admissionWebhookURL, admissionFailPolicy string
+	var admissionWebhookTimeout time.Duration
+	var namespaceQuotaRetryBaseDelay, namespaceQuotaRetryMaxDelay time.Duration
+	var maxManagedNamespaces int
+	var reconcileTimeout time.Duration
+	var resyncPeriod time.Duration
+	var leaseDuration, renewDeadline, retryPeriod time.Duration
+	var observeOnlyConfigMap string
+	var dryRun bool
+	var conversionWebhook bool
+	var validatingWebhook bool
+	var defaultingWebhook bool
+	var storageClassWebhook bool
+	var profileDefaults string
+	var gpuFairShareAnnotation, gpuFairShareTierLabel, gpuFairShareWeights, gpuFairShareDefaultWeight string
+	var tracingSamplingAnnotation, tracingSamplingDefaultRate string
+	var rateLimitMaxTokens, rateLimitTokensPerFill uint
+	var rateLimitFillInterval time.Duration
+	flag.StringVar(&metricsAddr, "metrics-addr", ":8080",
+		"The address the metric endpoint binds to, [host]:port or a port. Disabled if 0.")
+	flag.StringVar(&healthProbeAddr, "health-probe-addr", ":8081",
+		"The address the /healthz and /readyz probe endpoints bind to, [host]:port or a port. Disabled if 0.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
 		"Determines the namespace in which the leader election configmap will be created.")
-	flag.StringVar(&userIdHeader, USERIDHEADER, "x-goog-authenticated-user-email", "Key of request header containing user id")
-	flag.StringVar(&userIdPrefix, USERIDPREFIX, "accounts.google.com:", "Request header user id common prefix")
-	flag.StringVar(&workloadIdentity, WORKLOADIDENTITY, "", "Default identity (GCP service account) for workload_identity plugin")
+	flag.DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second,
+		"Duration non-leader candidates wait before trying to acquire leadership.")
+	flag.DurationVar(&renewDeadline, "leader-election-renew-deadline", 10*time.Second,
+		"Duration the leader retries refreshing leadership before giving it up, less than the lease duration.")
+	flag.DurationVar(&retryPeriod, "leader-election-retry-period", 2*time.Second,
+		"Duration candidates wait between tries of leader election actions.")
+	flag.StringVar(&userIdHeader, USERIDHEADER, "x-goog-authenticated-user-email", "Key of request header containing user id, comma separated to accept several providers")
+	flag.StringVar(&userIdPrefix, USERIDPREFIX, "accounts.google.com:", "Request header user id common prefix, comma separated list aligned with the userid headers")
+	flag.StringVar(&groupClaim, "group-claim", "", "JWT claim listing the groups of the user, e.g. groups, matched "+
+		"by the AuthorizationPolicies of Group owners and contributors. Requires an Istio RequestAuthentication "+
+		"validating the token. Group subjects get no AuthorizationPolicy if empty.")
+	flag.StringVar(&workloadIdentity, WORKLOADIDENTITY, "", "Default identity (GCP service account) for workload_identity plugin, "+
+		"overridden by the spec.gcpServiceAccount of a Profile")
+	flag.BoolVar(&verifyWorkloadIdentity, "verify-workload-identity", false,
+		"Verify the GCP IAM binding of the workload_identity plugin and report it in the profile status. "+
+			"Requires permission to read IAM policies of the GCP service accounts.")
+	flag.StringVar(&logRoutingAnnotations, "log-routing-annotations", "",
+		"Comma separated key=template namespace annotations consumed by the logging agent, "+
+			"e.g. 'logging.example.com/index={{ .Labels.team }}-{{ .Labels.environment }}'")
+	flag.DurationVar(&finalizerTimeout, "finalizer-timeout", 0,
+		"Maximum time plugin cleanup may block profile deletion, 0 means no limit.")
+	flag.BoolVar(&finalizerTimeoutForce, "finalizer-timeout-force", true,
+		"Remove the profile finalizer once finalizer-timeout expired. If false, deletion stays blocked until cleanup succeeds.")
 
+	flag.StringVar(&platform, "platform", controllers.PLATFORMKUBERNETES,
+		"Platform profile namespaces are created on: "+controllers.PLATFORMKUBERNETES+" creates Namespaces, "+
+			controllers.PLATFORMOPENSHIFT+" requests Projects.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"Maximum number of Profiles reconciled concurrently, defaults to 1 for compatibility.")
+	flag.DurationVar(&workqueueRateLimit.BaseDelay, "workqueue-base-delay", controllers.DEFAULTWORKQUEUEBASEDELAY,
+		"Delay of the first requeue of a Profile, doubled on every following failed reconcile.")
+	flag.DurationVar(&workqueueRateLimit.MaxDelay, "workqueue-max-delay", controllers.DEFAULTWORKQUEUEMAXDELAY,
+		"Maximum delay of the requeues of a Profile.")
+	flag.Float64Var(&workqueueRateLimit.QPS, "workqueue-qps", controllers.DEFAULTWORKQUEUEQPS,
+		"Requeues per second across all Profiles once -workqueue-burst is used up.")
+	flag.IntVar(&workqueueRateLimit.Burst, "workqueue-burst", controllers.DEFAULTWORKQUEUEBURST,
+		"Requeues across all Profiles admitted at once before -workqueue-qps applies.")
+	flag.Float64Var(&globalReconcileRPS, "global-reconcile-rps", 0,
+		"Maximum reconciles per second across all Profiles, to protect a shared API server. Disabled if 0.")
+	flag.StringVar(&quotaTemplatesFile, "quota-templates", "",
+		"Path to a yaml file of named ResourceQuota specs profiles can select with spec.quotaTemplate.")
+	flag.StringVar(&editorClusterRole, "editor-clusterrole", "kubeflow-edit",
+		"ClusterRole bound to default-editor, the contributors and the members of profile namespaces, "+
+			"e.g. an aggregated ClusterRole.")
+	flag.StringVar(&viewerClusterRole, "viewer-clusterrole", "kubeflow-view",
+		"ClusterRole bound to default-viewer in profile namespaces, e.g. an aggregated ClusterRole.")
+	flag.StringVar(&baselineQuotaConfig, "baseline-quota", "",
+		"Comma separated <resource>=<quantity> added to the ResourceQuota of every profile namespace for the "+
+			"resources the profile does not set, e.g. 'services.loadbalancers=0' to forbid LoadBalancer services. "+
+			controllers.COUNTLOADBALANCERS+" is accepted for services.loadbalancers.")
+	flag.StringVar(&gpuResources, "gpu-resources", controllers.DEFAULTGPURESOURCES,
+		"Comma separated extended resources handled as GPUs by profile quotas, which enforce their quota as "+
+			"requests.<resource>. GPU quotas are not handled if empty.")
+	flag.StringVar(&maxGPUQuota, "max-gpu-quota", "",
+		"Maximum quota of each -gpu-resources resource per profile namespace, e.g. '8'. Namespaces whose quota "+
+			"sets none are capped at it. No cap if empty.")
+	flag.StringVar(&pvcStorageMin, "pvc-storage-min", "",
+		"Minimum storage request of PersistentVolumeClaims in profile namespaces whose limitRangeSpec sets no "+
+			"PersistentVolumeClaim limit, e.g. '1Gi'. No minimum if empty.")
+	flag.StringVar(&pvcStorageMax, "pvc-storage-max", "",
+		"Maximum storage request of PersistentVolumeClaims in profile namespaces whose limitRangeSpec sets no "+
+			"PersistentVolumeClaim limit, e.g. '100Gi'. No maximum if empty.")
+	flag.StringVar(&containerDefaultRequest, "container-default-request", "",
+		"Comma separated <resource>=<quantity> default requests of containers without requests in profile "+
+			"namespaces whose limitRangeSpec sets no Container limit, e.g. 'cpu=100m,memory=256Mi'.")
+	flag.StringVar(&containerDefaultLimit, "container-default-limit", "",
+		"Comma separated <resource>=<quantity> default limits of containers without limits in profile "+
+			"namespaces whose limitRangeSpec sets no Container limit, e.g. 'cpu=1,memory=1Gi'.")
+	flag.StringVar(&catalogAnnotations, "catalog-annotations", "",
+		"Comma separated key=template namespace annotations registering namespaces with the service catalog, "+
+			"e.g. 'catalog.example.com/owner={{ .Owner }},catalog.example.com/team={{ .Labels.team }}'")
+	flag.StringVar(&externalDNSAnnotations, "external-dns-annotations", "",
+		"Comma separated key=template namespace annotations consumed by external-dns, "+
+			"e.g. 'external-dns.alpha.kubernetes.io/hostname={{ .Name }}.kubeflow.example.com'")
+	flag.StringVar(&certReminderAnnotations, "cert-reminder-annotations", "",
+		"Comma separated key=template namespace annotations consumed by the certificate rotation reminder job, "+
+			"e.g. 'certs.example.com/remind-before=720h,certs.example.com/remind={{ .Owner }}'")
+	flag.StringVar(&documentationAnnotations, "documentation-annotations", "",
+		"Comma separated key=template namespace annotations read by the namespace documentation generator, "+
+			"e.g. 'docs.example.com/owner={{ .Owner }},docs.example.com/slack-channel={{ .Labels.slack }}'")
+	flag.StringVar(&gatewayTLSDomain, "gateway-tls-domain", "",
+		"Domain of the hosts of profile namespaces behind the shared gateway, the host of a namespace is "+
+			"<namespace>.<domain>, e.g. 'apps.example.com'.")
+	flag.StringVar(&gatewayTLSAnnotations, "gateway-tls-annotations", "",
+		"Comma separated key=template namespace annotations configuring the shared gateway and its TLS "+
+			"termination, rendered with the namespace host as .Host, "+
+			"e.g. 'gateway.example.com/hosts={{ .Host }},gateway.example.com/tls-secret={{ .Name }}-tls'")
+	flag.StringVar(&defaultEditorAnnotations, "default-editor-annotations", "",
+		"Comma separated key=template annotations of the default-editor service account, "+
+			"e.g. for Knative eventing integration.")
+	flag.StringVar(&vaultAnnotations, "vault-annotations", "",
+		"Comma separated key=template Vault Agent Injector annotations of the profile namespaces and their "+
+			"default-editor and default-viewer service accounts, e.g. 'vault.hashicorp.com/role={{ .Name }}'. "+
+			"Keys must start with "+controllers.VAULTANNOTATIONPREFIX+". Profiles annotated with "+
+			controllers.VAULTINJECTION+"=false are excluded.")
+	flag.StringVar(&defaultImagePullSecrets, "default-image-pull-secrets", "",
+		"Comma separated imagePullSecrets of the default-editor and default-viewer service accounts, as templates "+
+			"rendered against the Profile, e.g. 'registry-credentials,registry-{{ .Labels.team }}'. The secrets "+
+			"must exist in the profile namespaces, imagePullSecrets added by users are kept.")
+	flag.StringVar(&authorizationPolicyTemplateFile, "authorization-policy-template", "",
+		"Path to a go template of the owner AuthorizationPolicy spec in yaml, with .Namespace, .Owner, "+
+			".OwnerKind, .UserIdHeader, .UserIdPrefix, .UserIdHeaders and .GroupClaim placeholders. Defaults to the "+
+			"built-in policy.")
+	flag.BoolVar(&networkPolicies, "network-policies", false,
+		"Create baseline NetworkPolicies in every profile namespace: ingress only from the namespace itself and "+
+			"the gateway namespace, egress restricted to the egress allowlist if set. Namespaces are open otherwise.")
+	flag.StringVar(&networkPolicyTemplateFile, "network-policy-template", "",
+		"Path to a go template of the baseline NetworkPolicies in yaml, a map of names to NetworkPolicy specs, "+
+			"with .Namespace, .Owner, .GatewayNamespace and .EgressAllowlist placeholders. Defaults to the "+
+			"built-in policies.")
+	flag.StringVar(&networkPolicyGatewayNamespace, "network-policy-gateway-namespace", "istio-system",
+		"Namespace of the Istio ingress gateway, allowed ingress by the baseline NetworkPolicies.")
+	flag.StringVar(&networkPolicyEgressAllowlist, "network-policy-egress-allowlist", "",
+		"Comma separated CIDRs pods of profile namespaces may connect to, e.g. '10.0.0.0/8'. DNS, the namespace "+
+			"itself and the gateway namespace stay reachable. Egress is not restricted if empty.")
+	flag.StringVar(&profileLabelSelector, "profile-label-selector", "",
+		"Label selector of the Profiles managed by this controller, e.g. 'team in (ml,data)'. Defaults to all Profiles.")
+	flag.StringVar(&watchLabelSelector, "watch-label-selector", "",
+		"Label selector matched against the labels of every Profile merged with the labels of its namespace, only "+
+			"matching Profiles are reconciled, e.g. 'canary=true' and '!canary' for two controller versions. "+
+			"Defaults to all Profiles.")
+	flag.StringVar(&ignoreNamespaces, "ignore-namespaces", "",
+		"Comma separated namespaces whose Profiles are not reconciled.")
+	flag.StringVar(&notebookControllerSA, "notebook-controller-sa", "",
+		"Service account (namespace/name) of the notebook controller bound in every profile namespace. Disabled if empty.")
+	flag.StringVar(&notebookControllerRole, "notebook-controller-role", "kubeflow-edit",
+		"ClusterRole bound to the notebook controller service account in profile namespaces.")
+	flag.StringVar(&chaosSA, "chaos-sa", "",
+		"Service account (namespace/name) of the chaos-engineering tool bound in the namespaces of profiles annotated "+
+			"with "+controllers.CHAOSOPTIN+"=true. Disabled if empty.")
+	flag.StringVar(&chaosRole, "chaos-role", "kubeflow-edit",
+		"ClusterRole bound to the chaos-engineering tool service account in opted-in profile namespaces.")
+	flag.StringVar(&backupSA, "backup-sa", "",
+		"Service account (namespace/name) of the backup operator bound in every profile namespace. Disabled if empty.")
+	flag.StringVar(&backupRole, "backup-role", "",
+		"ClusterRole bound to the backup operator service account in profile namespaces, e.g. granting read access "+
+			"to PVCs and VolumeSnapshots. Required with -backup-sa.")
+	flag.StringVar(&auditorUsers, "auditor-users", "",
+		"Comma separated users granted read access to every profile namespace, and read-only (GET, HEAD, OPTIONS) "+
+			"access to its services through the mesh.")
+	flag.StringVar(&auditorGroups, "auditor-groups", "",
+		"Comma separated groups granted read access to every profile namespace. Groups get no mesh access, the "+
+			"mesh only identifies users.")
+	flag.StringVar(&auditorRole, "auditor-role", "kubeflow-view",
+		"ClusterRole bound to the auditors in profile namespaces.")
+	flag.StringVar(&podDefaultsConfig, "pd", "",
+		"PodDefaults created in every profile namespace, separated by ';', each '<name>:<field>=<value>,...', "+
+			"e.g. 'add-gcp-secret:env.GOOGLE_APPLICATION_CREDENTIALS=/secret/gcp/user-gcp-sa.json'. "+
+			"Run the "+VALIDATEPD+" subcommand with the value to check it without starting the controller.")
+	flag.BoolVar(&podDefaultsSkipInvalid, "pd-skip-invalid", false,
+		"Skip invalid -pd entries instead of failing to start, they are counted in poddefaults_parse_errors_total.")
+	flag.BoolVar(&podDefaultsStrict, "pd-strict", true,
+		"Fail to start if -pd sets fields unknown to the PodDefault spec, e.g. 'lables.team'. Only warn if false.")
+	flag.StringVar(&podDefaultsConfigMap, "pd-configmap", "",
+		"ConfigMap (namespace/name) whose values hold the PodDefaults in the -pd format, joined in key order. "+
+			"Changes of the ConfigMap are applied to every profile namespace. Mutually exclusive with -pd.")
+	flag.StringVar(&namespaceMetadataConfigMap, "namespace-metadata-configmap", "",
+		"ConfigMap (namespace/name) whose '"+controllers.NAMESPACEMETADATALABELS+"' and '"+
+			controllers.NAMESPACEMETADATAANNOTATIONS+"' values hold key=template pairs, one per line, of labels and "+
+			"annotations set on every profile namespace, e.g. 'cost-center={{ index .Labels \"cost-center\" }}'. "+
+			"Changes of the ConfigMap are applied to every profile namespace.")
+	flag.StringVar(&podDefaultLabelsConfig, "poddefault-labels", "",
+		"Comma separated key=value labels set on every PodDefault the controller creates, next to "+
+			controllers.MANAGEDBYLABEL+"="+controllers.MANAGEDBYVALUE+" and "+controllers.PROFILELABEL+
+			". Values can be double quoted.")
+	flag.StringVar(&podAntiAffinityConfig, "pod-anti-affinity", "",
+		"Comma separated '<mode>:<topologyKey>' terms, mode 'required' or 'preferred', of the pod anti-affinity "+
+			"added by the "+controllers.ANTIAFFINITYPODDEFAULT+" PodDefault of every profile namespace to the pods "+
+			"labeled "+controllers.ANTIAFFINITYPODDEFAULT+"=true, e.g. 'preferred:kubernetes.io/hostname'. "+
+			"Disabled if empty.")
+	flag.UintVar(&rateLimitMaxTokens, "rate-limit-max-tokens", 0,
+		"Maximum burst of requests to each profile namespace, rate limiting is disabled if 0.")
+	flag.UintVar(&rateLimitTokensPerFill, "rate-limit-tokens-per-fill", 0,
+		"Requests allowed per rate-limit-fill-interval, defaults to rate-limit-max-tokens.")
+	flag.DurationVar(&rateLimitFillInterval, "rate-limit-fill-interval", time.Minute,
+		"Interval at which rate-limit-tokens-per-fill requests are allowed again.")
+	flag.StringVar(&cleanupOrderConfig, "cleanup-order", "",
+		"Comma separated kinds of profile resources deleted one after the other on profile deletion, "+
+			"e.g. 'PeerAuthentication,AuthorizationPolicy,Namespace'. Resources not listed are garbage collected.")
+	flag.StringVar(&onDeleteConfig, "on-delete", controllers.ONDELETEDELETE,
+		"Policy applied to the user data of deleted profiles: 'delete' deletes the namespace, 'retain-namespace' "+
+			"keeps the namespace and only deletes the profile resources in it, 'retain-pvcs' deletes the "+
+			"namespace but sets the reclaim policy of the PersistentVolumes bound to its claims to Retain.")
+	flag.StringVar(&quotaSummaryConfigMap, "quota-summary-configmap", "",
+		"Name of a ConfigMap summarizing the ResourceQuotas and LimitRanges of every profile namespace. Disabled if empty.")
+	flag.BoolVar(&adoptNamespaces, "adopt-namespaces", false,
+		"Let profiles take over the existing namespace of their name, which is not controlled by anything else, "+
+			"instead of failing. Otherwise only profiles annotated with "+controllers.ADOPTNAMESPACE+"=true do.")
+	flag.StringVar(&namespacePrefix, "namespace-prefix", "",
+		"Prefix of the namespace names of profiles without spec.namespaceName, e.g. 'kf-'.")
+	flag.StringVar(&namespaceSuffix, "namespace-suffix", "",
+		"Suffix of the namespace names of profiles without spec.namespaceName.")
+	flag.BoolVar(&adoptLegacyLabels, "adopt-legacy-labels", false,
+		"Adopt RoleBindings labeled by older profile controllers instead of creating duplicates. One-time migration aid.")
+	flag.StringVar(&gpuFairShareAnnotation, "gpu-fair-share-annotation", "",
+		"Namespace annotation holding the GPU fair-share weight read by the GPU scheduler. Disabled if empty.")
+	flag.StringVar(&gpuFairShareTierLabel, "gpu-fair-share-tier-label", "profile.kubeflow.org/tier",
+		"Profile label holding the owner tier the GPU fair-share weight is derived from.")
+	flag.StringVar(&gpuFairShareWeights, "gpu-fair-share-weights", "",
+		"Comma separated tier=weight GPU fair-share weights, e.g. 'gold=4,silver=2'. "+
+			"The profile annotation "+controllers.GPUFAIRSHAREWEIGHT+" overrides them.")
+	flag.StringVar(&gpuFairShareDefaultWeight, "gpu-fair-share-default-weight", "",
+		"GPU fair-share weight of profiles of an unknown tier. No annotation is set if empty.")
+	flag.StringVar(&gpuReservationAnnotations, "gpu-reservation-annotations", "",
+		"Comma separated key=template namespace annotations keying GPU reservations, set on the namespaces of "+
+			"profiles annotated with "+controllers.GPURESERVATION+", e.g. 'gpu.example.com/reservation={{ .Reservation }}'")
+	flag.StringVar(&cleanupPolicyAnnotations, "cleanup-policy-annotations", "",
+		"Comma separated key=template namespace annotations selecting the namespaces of a cleanup policy, set on "+
+			"the namespaces of profiles with a policy, e.g. 'cleanup.example.com/policy={{ .Policy }}'")
+	flag.StringVar(&cleanupPolicies, "cleanup-policies", "",
+		"Comma separated cleanup policies profiles can select with the "+controllers.CLEANUPPOLICY+
+			" annotation, e.g. 'delete-idle-pvcs,keep'. Any policy if empty.")
+	flag.StringVar(&defaultCleanupPolicy, "default-cleanup-policy", "",
+		"Cleanup policy of profiles without the "+controllers.CLEANUPPOLICY+" annotation. None if empty.")
+	flag.StringVar(&profileExpiryAction, "profile-expiry-action", "",
+		"Action on profiles expired with spec.ttl or spec.expiresAt: '"+controllers.PROFILEEXPIRYDELETE+
+			"' deletes the profile and its namespace, '"+controllers.PROFILEEXPIRYSUSPEND+"' suspends its workloads "+
+			"like spec.suspend, keeping its data. Profiles do not expire if empty.")
+	flag.DurationVar(&profileExpiryWarning, "profile-expiry-warning", 72*time.Hour,
+		"Period before the expiry of a profile during which warning events are emitted on it, daily.")
+	flag.StringVar(&workspaceSize, "workspace-size", "",
+		"Size of the "+controllers.WORKSPACEPVC+" PVC provisioned once in the namespace of every profile, e.g. '10Gi'. "+
+			"Only profiles with spec.workspace get one if empty.")
+	flag.StringVar(&workspaceStorageClass, "workspace-storage-class", "",
+		"Storage class of the workspace PVCs, the default storage class of the cluster if empty.")
+	flag.StringVar(&registryMirrors, "registry-mirrors", "",
+		"Comma separated registry=mirror pull-through caches exposed to -registry-mirror-annotations, e.g. "+
+			"'docker.io=mirror.example.com/dockerhub'.")
+	flag.StringVar(&registryMirrorAnnotations, "registry-mirror-annotations", "",
+		"Comma separated key=template namespace annotations configuring the registry mirrors, e.g. "+
+			"'registry.example.com/mirrors={{ .MirrorList }}'. Profiles annotated with "+controllers.REGISTRYMIRRORS+
+			"=false are excluded.")
+	flag.StringVar(&vpaLabels, "vpa-labels", "",
+		"Comma separated key=value labels including profile namespaces in VPA recommendations, e.g. "+
+			"'vpa.example.com/recommend=true'. Profiles annotated with "+controllers.VPARECOMMENDATIONS+
+			"=false are excluded.")
+	flag.StringVar(&vpaAnnotations, "vpa-annotations", "",
+		"Comma separated key=template namespace annotations set next to -vpa-labels, e.g. "+
+			"'vpa.example.com/owner={{ .Owner }}'")
+	flag.StringVar(&tracingSamplingAnnotation, "tracing-sampling-annotation", "",
+		"Namespace annotation holding the trace sampling rate read by the tracing agent. Disabled if empty.")
+	flag.StringVar(&tracingSamplingDefaultRate, "tracing-sampling-default-rate", "",
+		"Trace sampling rate in percent of profile namespaces, e.g. '1.5'. The profile annotation "+
+			controllers.TRACINGSAMPLINGRATE+" overrides it. No annotation is set if empty.")
+	flag.StringVar(&versionAnnotation, "version-annotation", controllers.CONTROLLERVERSION,
+		"Namespace annotation holding the version of the controller which last reconciled the namespace. Disabled if empty.")
+	flag.BoolVar(&ownerScaleAccess, "owner-scale-access", false,
+		"Grant profile owners scale access to deployments and statefulsets in their namespace through a Role.")
+	flag.BoolVar(&ownerPortForwardAccess, "owner-port-forward-access", false,
+		"Grant profile owners port-forward access to pods in their namespace through a Role.")
+	flag.BoolVar(&ownerNetworkPolicyAccess, "owner-network-policy-access", false,
+		"Grant profile owners the creation and management of NetworkPolicies in their namespace through a Role. "+
+			"Not allowed with -network-policies, the owners could delete or override the baseline NetworkPolicies.")
+	flag.BoolVar(&ownerPodRestartAccess, "owner-pod-restart-access", false,
+		"Grant profile owners get, list and delete on pods in their namespace through a Role, to restart pods "+
+			"without edit access to workloads.")
+	flag.BoolVar(&defaultEditorTokenRequest, "default-editor-token-request", false,
+		"Grant the default-editor service account the creation of TokenRequests for itself through a Role, "+
+			"e.g. for token exchange.")
+	flag.StringVar(&ownerApprovalAnnotation, "owner-approval-annotation", "",
+		"Profile annotation which must be \"true\" before the profile owner is granted access to the namespace, "+
+			"e.g. 'profile.kubeflow.org/approved'. Approval is not required if empty.")
+	flag.StringVar(&dataClassificationLevels, "data-classification-levels", "public,internal,confidential,restricted",
+		"Comma separated levels allowed in the "+controllers.DATACLASSIFICATION+" annotation of profiles, "+
+			"propagated to the profile namespace. Profiles with another level are rejected.")
+	flag.BoolVar(&requireDataClassification, "require-data-classification", false,
+		"Reject profiles without "+controllers.DATACLASSIFICATION+" annotation.")
+	flag.StringVar(&admissionWebhookURL, "admission-webhook-url", "",
+		"URL of a policy service receiving a POST of the name and owner of every profile before its namespace is "+
+			"created. Any answer but 200, or allowed false, denies the profile. Disabled if empty.")
+	flag.StringVar(&admissionFailPolicy, "admission-fail-policy", controllers.ADMISSIONFAILCLOSED,
+		"Namespace creation when the admission webhook times out or fails: "+controllers.ADMISSIONFAILCLOSED+
+			" waits for it, "+controllers.ADMISSIONFAILOPEN+" creates the namespace.")
+	flag.DurationVar(&admissionWebhookTimeout, "admission-webhook-timeout", 5*time.Second,
+		"Timeout of the admission webhook requests.")
+	flag.DurationVar(&namespaceQuotaRetryBaseDelay, "namespace-quota-retry-base-delay", 5*time.Second,
+		"Initial delay of namespace creation retries when a cluster quota rejects the namespace, doubled on every retry.")
+	flag.DurationVar(&namespaceQuotaRetryMaxDelay, "namespace-quota-retry-max-delay", 5*time.Minute,
+		"Maximum delay of namespace creation retries when a cluster quota rejects the namespace.")
+	flag.IntVar(&maxManagedNamespaces, "max-managed-namespaces", 0,
+		"Maximum number of profile namespaces, profiles beyond it wait for their namespace with the "+
+			controllers.MaxManagedNamespacesExceeded+" condition. Unlimited if 0.")
+	flag.DurationVar(&reconcileTimeout, "reconcile-timeout", 0,
+		"Deadline of the reconcile of one Profile, after which its API calls are cancelled and the Profile is "+
+			"requeued with backoff. Disabled if 0.")
+	flag.DurationVar(&resyncPeriod, "resync-period", 0,
+		"Period after which every reconciled Profile is reconciled again, so manual edits or deletions of its "+
+			"RoleBindings, AuthorizationPolicies and other resources are corrected without a watch event, e.g. "+
+			"'30m'. Disabled if 0.")
+	flag.StringVar(&observeOnlyConfigMap, "observe-only-configmap", "",
+		"ConfigMap (namespace/name) recording the changes the controller would make per profile, without applying them. "+
+			"Observe-only mode is disabled if empty.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Log the creates, updates and deletes the controller would perform and serve them on "+
+			controllers.DRYRUNPATH+" of the metrics address, without writing to the cluster. Disables leader "+
+			"election so the controller can run next to the active one.")
+	flag.BoolVar(&conversionWebhook, "conversion-webhook", false,
+		"Serve the webhook converting profiles between the v1beta1, v1 and v2 APIs on port 443, with the serving "+
+			"certificate read from /tmp/k8s-webhook-server/serving-certs.")
+	flag.BoolVar(&validatingWebhook, "validating-webhook", false,
+		"Serve the webhook rejecting invalid profiles at admission, e.g. with an owner which is not an email address "+
+			"or an unknown plugin kind, on port 443 with the certificate of -conversion-webhook.")
+	flag.BoolVar(&defaultingWebhook, "defaulting-webhook", false,
+		"Serve the webhook applying the -profile-defaults ProfileDefault to created profiles, on port 443 with the "+
+			"certificate of -conversion-webhook.")
+	flag.BoolVar(&storageClassWebhook, "storage-class-webhook", false,
+		"Serve the webhook setting spec.defaultStorageClass of profiles on the PersistentVolumeClaims created "+
+			"without a storage class in their namespace, on port 443 with the certificate of -conversion-webhook.")
+	flag.StringVar(&profileDefaults, "profile-defaults", "default",
+		"Name of the cluster-scoped ProfileDefault (settings v1alpha1 API) applied by -defaulting-webhook.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.Logger(true))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	logRoutingTemplates, err := controllers.ParseAnnotationTemplates(logRoutingAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse log routing annotations")
+		os.Exit(1)
+	}
+	catalogTemplates, err := controllers.ParseAnnotationTemplates(catalogAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse catalog annotations")
+		os.Exit(1)
+	}
+	externalDNSTemplates, err := controllers.ParseAnnotationTemplates(externalDNSAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse external-dns annotations")
+		os.Exit(1)
+	}
+	certReminderTemplates, err := controllers.ParseAnnotationTemplates(certReminderAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse certificate reminder annotations")
+		os.Exit(1)
+	}
+	documentationTemplates, err := controllers.ParseAnnotationTemplates(documentationAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse documentation annotations")
+		os.Exit(1)
+	}
+	defaultEditorTemplates, err := controllers.ParseAnnotationTemplates(defaultEditorAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse default-editor annotations")
+		os.Exit(1)
+	}
+	vaultInjection, err := controllers.ParseVaultAnnotations(vaultAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse Vault annotations")
+		os.Exit(1)
+	}
+	imagePullSecretTemplates, err := controllers.ParseImagePullSecrets(defaultImagePullSecrets)
+	if err != nil {
+		setupLog.Error(err, "unable to parse default imagePullSecrets")
+		os.Exit(1)
+	}
+	if err := controllers.ValidatePlatform(platform); err != nil {
+		setupLog.Error(err, "invalid platform")
+		os.Exit(1)
+	}
+	if err := workqueueRateLimit.Validate(); err != nil {
+		setupLog.Error(err, "invalid workqueue rate limit")
+		os.Exit(1)
+	}
+	if err := validateClusterRoles(editorClusterRole, viewerClusterRole); err != nil {
+		setupLog.Error(err, "invalid ClusterRoles")
+		os.Exit(1)
+	}
+	if err := validateNetworkPolicyAccess(networkPolicies, ownerNetworkPolicyAccess); err != nil {
+		setupLog.Error(err, "invalid NetworkPolicy flags")
+		os.Exit(1)
+	}
+	baselineQuota, err := controllers.ParseBaselineQuota(baselineQuotaConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to parse baseline quota")
+		os.Exit(1)
+	}
+	gpuQuota, err := controllers.ParseGPUQuota(gpuResources, maxGPUQuota)
+	if err != nil {
+		setupLog.Error(err, "invalid GPU quota")
+		os.Exit(1)
+	}
+	if err := gpuQuota.ValidateBaselineQuota(baselineQuota); err != nil {
+		setupLog.Error(err, "invalid baseline quota")
+		os.Exit(1)
+	}
+	pvcStorageLimit, err := controllers.ParsePVCStorageLimit(pvcStorageMin, pvcStorageMax)
+	if err != nil {
+		setupLog.Error(err, "invalid PVC storage limit")
+		os.Exit(1)
+	}
+	workspaceDefaults, err := controllers.ParseWorkspaceDefaults(workspaceSize, workspaceStorageClass)
+	if err != nil {
+		setupLog.Error(err, "invalid workspace defaults")
+		os.Exit(1)
+	}
+	containerDefaults, err := controllers.ParseContainerDefaults(containerDefaultRequest, containerDefaultLimit)
+	if err != nil {
+		setupLog.Error(err, "invalid container defaults")
+		os.Exit(1)
+	}
+	var quotaTemplates controllers.QuotaTemplates
+	if quotaTemplatesFile != "" {
+		if quotaTemplates, err = controllers.LoadQuotaTemplates(quotaTemplatesFile); err != nil {
+			setupLog.Error(err, "unable to load quota templates")
+			os.Exit(1)
+		}
+	}
+
+	var authorizationPolicyTemplate *template.Template
+	if authorizationPolicyTemplateFile != "" {
+		if authorizationPolicyTemplate, err = controllers.LoadAuthorizationPolicyTemplate(authorizationPolicyTemplateFile); err != nil {
+			setupLog.Error(err, "unable to load AuthorizationPolicy template")
+			os.Exit(1)
+		}
+	}
+
+	var baselineNetworkPolicies *controllers.NetworkPolicies
+	if networkPolicies {
+		baselineNetworkPolicies = &controllers.NetworkPolicies{GatewayNamespace: networkPolicyGatewayNamespace}
+		if baselineNetworkPolicies.EgressAllowlist, err = controllers.ParseNetworkPolicyEgressAllowlist(
+			networkPolicyEgressAllowlist); err != nil {
+			setupLog.Error(err, "unable to parse NetworkPolicy egress allowlist")
+			os.Exit(1)
+		}
+		if networkPolicyTemplateFile != "" {
+			if baselineNetworkPolicies.Template, err = controllers.LoadNetworkPolicyTemplate(
+				networkPolicyTemplateFile); err != nil {
+				setupLog.Error(err, "unable to load NetworkPolicy template")
+				os.Exit(1)
+			}
+		}
+	}
+
+	var profileSelector labels.Selector
+	if profileLabelSelector != "" {
+		if profileSelector, err = labels.Parse(profileLabelSelector); err != nil {
+			setupLog.Error(err, "unable to parse profile label selector")
+			os.Exit(1)
+		}
+	}
+	var watchSelector labels.Selector
+	if watchLabelSelector != "" {
+		if watchSelector, err = labels.Parse(watchLabelSelector); err != nil {
+			setupLog.Error(err, "unable to parse watch label selector")
+			os.Exit(1)
+		}
+	}
+	ignoredNamespaces, err := controllers.ParseIgnoredNamespaces(ignoreNamespaces)
+	if err != nil {
+		setupLog.Error(err, "unable to parse ignored namespaces")
+		os.Exit(1)
+	}
+
+	var notebookControllerBinding *controllers.PlatformBinding
+	if notebookControllerSA != "" {
+		if notebookControllerBinding, err = controllers.ParsePlatformBinding(notebookControllerSA, notebookControllerRole); err != nil {
+			setupLog.Error(err, "unable to parse notebook controller service account")
+			os.Exit(1)
+		}
+	}
+	var chaosBinding *controllers.PlatformBinding
+	if chaosSA != "" {
+		if chaosBinding, err = controllers.ParsePlatformBinding(chaosSA, chaosRole); err != nil {
+			setupLog.Error(err, "unable to parse chaos-engineering service account")
+			os.Exit(1)
+		}
+	}
+	var backupBinding *controllers.PlatformBinding
+	if backupSA != "" {
+		if backupBinding, err = controllers.ParsePlatformBinding(backupSA, backupRole); err != nil {
+			setupLog.Error(err, "unable to parse backup operator service account")
+			os.Exit(1)
+		}
+	}
+	auditorAccess, err := controllers.ParseAuditorAccess(auditorUsers, auditorGroups, auditorRole)
+	if err != nil {
+		setupLog.Error(err, "invalid auditor access")
+		os.Exit(1)
+	}
+
+	var rateLimit *controllers.RateLimit
+	if rateLimitMaxTokens > 0 {
+		if rateLimitTokensPerFill == 0 {
+			rateLimitTokensPerFill = rateLimitMaxTokens
+		}
+		rateLimit = &controllers.RateLimit{
+			MaxTokens:     uint32(rateLimitMaxTokens),
+			TokensPerFill: uint32(rateLimitTokensPerFill),
+			FillInterval:  rateLimitFillInterval,
+		}
+	}
+	cleanupOrder, err := controllers.ParseCleanupOrder(cleanupOrderConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to parse cleanup order")
+		os.Exit(1)
+	}
+	onDelete, err := controllers.ParseOnDeletePolicy(onDeleteConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to parse on-delete policy")
+		os.Exit(1)
+	}
+	podDefaultsConfigMapKey, err := parsePodDefaultsConfigMap(podDefaultsConfig, podDefaultsConfigMap)
+	if err != nil {
+		setupLog.Error(err, "invalid PodDefaults ConfigMap")
+		os.Exit(1)
+	}
+	var namespaceMetadataConfigMapKey types.NamespacedName
+	if namespaceMetadataConfigMap != "" {
+		namespaceMetadataConfigMapKey, err = parseNamespacedName(namespaceMetadataConfigMap)
+		if err != nil {
+			setupLog.Error(err, "invalid namespace metadata ConfigMap")
+			os.Exit(1)
+		}
+	}
+	podDefaults, podDefaultErrs := controllers.ParsePodDefaultsSkipInvalid(podDefaultsConfig)
+	for _, err := range podDefaultErrs {
+		setupLog.Error(err, "unable to parse PodDefaults")
+	}
+	if len(podDefaultErrs) > 0 && !podDefaultsSkipInvalid {
+		os.Exit(1)
+	}
+	if fieldErrs := podDefaults.ValidateFields(); len(fieldErrs) > 0 {
+		for _, err := range fieldErrs {
+			if podDefaultsStrict {
+				setupLog.Error(err, "invalid PodDefault field")
+			} else {
+				setupLog.Info("invalid PodDefault field has no effect, -pd-strict=false", "error", err.Error())
+			}
+		}
+		if podDefaultsStrict {
+			os.Exit(1)
+		}
+	}
+	podDefaultLabels, err := controllers.ParsePodDefaultLabels(podDefaultLabelsConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to parse PodDefault labels")
+		os.Exit(1)
+	}
+	podAntiAffinity, err := controllers.ParsePodAntiAffinity(podAntiAffinityConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to parse pod anti-affinity")
+		os.Exit(1)
+	}
+
+	levels, err := controllers.ParseDataClassificationLevels(dataClassificationLevels)
+	if err != nil {
+		setupLog.Error(err, "unable to parse data classification levels")
+		os.Exit(1)
+	}
+	dataClassification := &controllers.DataClassification{Levels: levels, Required: requireDataClassification}
+	var admissionWebhook *controllers.AdmissionWebhook
+	if admissionWebhookURL != "" {
+		if err := controllers.ValidateAdmissionFailPolicy(admissionFailPolicy); err != nil {
+			setupLog.Error(err, "invalid admission webhook")
+			os.Exit(1)
+		}
+		if _, err := url.ParseRequestURI(admissionWebhookURL); err != nil {
+			setupLog.Error(err, "invalid admission webhook")
+			os.Exit(1)
+		}
+		admissionWebhook = &controllers.AdmissionWebhook{
+			URL:        admissionWebhookURL,
+			FailPolicy: admissionFailPolicy,
+			Client:     &http.Client{Timeout: admissionWebhookTimeout},
+		}
+	}
+
+	gatewayTLSTemplates, err := controllers.ParseAnnotationTemplates(gatewayTLSAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse gateway TLS annotations")
+		os.Exit(1)
+	}
+	var gatewayTLS *controllers.GatewayTLS
+	if len(gatewayTLSTemplates) > 0 {
+		if err := controllers.ValidateGatewayDomain(gatewayTLSDomain); err != nil {
+			setupLog.Error(err, "invalid gateway TLS domain")
+			os.Exit(1)
+		}
+		gatewayTLS = &controllers.GatewayTLS{Domain: gatewayTLSDomain, Annotations: gatewayTLSTemplates}
+	}
+
+	var gpuFairShare *controllers.GPUFairShare
+	if gpuFairShareAnnotation != "" {
+		weights, err := controllers.ParseGPUFairShareWeights(gpuFairShareWeights)
+		if err != nil {
+			setupLog.Error(err, "unable to parse GPU fair-share weights")
+			os.Exit(1)
+		}
+		gpuFairShare = &controllers.GPUFairShare{
+			Annotation:    gpuFairShareAnnotation,
+			TierLabel:     gpuFairShareTierLabel,
+			TierWeights:   weights,
+			DefaultWeight: gpuFairShareDefaultWeight,
+		}
+	}
+
+	gpuReservationTemplates, err := controllers.ParseAnnotationTemplates(gpuReservationAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse GPU reservation annotations")
+		os.Exit(1)
+	}
+	var gpuReservation *controllers.GPUReservation
+	if len(gpuReservationTemplates) > 0 {
+		gpuReservation = &controllers.GPUReservation{Annotations: gpuReservationTemplates}
+	}
+
+	cleanupPolicyTemplates, err := controllers.ParseAnnotationTemplates(cleanupPolicyAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse cleanup policy annotations")
+		os.Exit(1)
+	}
+	var cleanupPolicy *controllers.CleanupPolicy
+	if len(cleanupPolicyTemplates) > 0 {
+		policies, err := controllers.ParseCleanupPolicies(cleanupPolicies)
+		if err != nil {
+			setupLog.Error(err, "unable to parse cleanup policies")
+			os.Exit(1)
+		}
+		cleanupPolicy = &controllers.CleanupPolicy{
+			Policies:    policies,
+			Default:     defaultCleanupPolicy,
+			Annotations: cleanupPolicyTemplates,
+		}
+		if err := cleanupPolicy.Validate(); err != nil {
+			setupLog.Error(err, "invalid default cleanup policy")
+			os.Exit(1)
+		}
+	}
+
+	var profileExpiry *controllers.ProfileExpiry
+	if profileExpiryAction != "" {
+		profileExpiry = &controllers.ProfileExpiry{Action: profileExpiryAction, WarningPeriod: profileExpiryWarning}
+		if err := profileExpiry.Validate(); err != nil {
+			setupLog.Error(err, "invalid profile expiry")
+			os.Exit(1)
+		}
+	}
+
+	namespaceNaming := controllers.NamespaceNaming{Prefix: namespacePrefix, Suffix: namespaceSuffix}
+	if err := namespaceNaming.Validate(); err != nil {
+		setupLog.Error(err, "invalid namespace naming")
+		os.Exit(1)
+	}
+
+	mirrors, err := controllers.ParseRegistryMirrors(registryMirrors)
+	if err != nil {
+		setupLog.Error(err, "unable to parse registry mirrors")
+		os.Exit(1)
+	}
+	registryMirrorTemplates, err := controllers.ParseAnnotationTemplates(registryMirrorAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse registry mirror annotations")
+		os.Exit(1)
+	}
+	var registryMirror *controllers.RegistryMirror
+	if len(registryMirrorTemplates) > 0 {
+		registryMirror = &controllers.RegistryMirror{Mirrors: mirrors, Annotations: registryMirrorTemplates}
+	}
+
+	vpaLabelSet, err := controllers.ParseVPALabels(vpaLabels)
+	if err != nil {
+		setupLog.Error(err, "unable to parse VPA labels")
+		os.Exit(1)
+	}
+	vpaAnnotationTemplates, err := controllers.ParseAnnotationTemplates(vpaAnnotations)
+	if err != nil {
+		setupLog.Error(err, "unable to parse VPA annotations")
+		os.Exit(1)
+	}
+	var vpaInclusion *controllers.VPAInclusion
+	if len(vpaLabelSet) > 0 || len(vpaAnnotationTemplates) > 0 {
+		vpaInclusion = &controllers.VPAInclusion{Labels: vpaLabelSet, Annotations: vpaAnnotationTemplates}
+	}
+
+	var tracingSampling *controllers.TracingSampling
+	if tracingSamplingAnnotation != "" {
+		if tracingSamplingDefaultRate != "" {
+			if err := controllers.ValidateTracingSamplingRate(tracingSamplingDefaultRate); err != nil {
+				setupLog.Error(err, "invalid default trace sampling rate")
+				os.Exit(1)
+			}
+		}
+		tracingSampling = &controllers.TracingSampling{
+			Annotation:  tracingSamplingAnnotation,
+			DefaultRate: tracingSamplingDefaultRate,
+		}
+	}
+
+	if metricsAddr, err = normalizeBindAddress("metrics-addr", metricsAddr); err != nil {
+		setupLog.Error(err, "invalid metrics address")
+		os.Exit(1)
+	}
+	if healthProbeAddr, err = normalizeBindAddress("health-probe-addr", healthProbeAddr); err != nil {
+		setupLog.Error(err, "invalid health probe address")
+		os.Exit(1)
+	}
+	if dryRun {
+		if observeOnlyConfigMap != "" {
+			setupLog.Error(fmt.Errorf("-dry-run and -observe-only-configmap are mutually exclusive, set only one of them"),
+				"invalid dry-run settings")
+			os.Exit(1)
+		}
+		enableLeaderElection = false
+	}
+	options := ctrl.Options{
 		Scheme:                  scheme,
 		MetricsBindAddress:      metricsAddr,
+		HealthProbeBindAddress:  healthProbeAddr,
 		LeaderElection:          enableLeaderElection,
 		LeaderElectionNamespace: leaderElectionNamespace,
 		LeaderElectionID:        "kubeflow-profile-controller",
-	})
+	}
+	if err = setLeaderElectionTimings(&options, leaseDuration, renewDeadline, retryPeriod); err != nil {
+		setupLog.Error(err, "invalid leader election settings")
+		os.Exit(1)
+	}
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.ProfileReconciler{
-		Client:           mgr.GetClient(),
-		Scheme:           mgr.GetScheme(),
-		Log:              ctrl.Log.WithName("controllers").WithName("Profile"),
-		UserIdHeader:     userIdHeader,
-		UserIdPrefix:     userIdPrefix,
-		WorkloadIdentity: workloadIdentity,
-	}).SetupWithManager(mgr); err != nil {
+	profileReconciler := &controllers.ProfileReconciler{
+		Client:                       mgr.GetClient(),
+		Scheme:                       mgr.GetScheme(),
+		Log:                          ctrl.Log.WithName("controllers").WithName("Profile"),
+		UserIdHeader:                 userIdHeader,
+		UserIdPrefix:                 userIdPrefix,
+		GroupClaim:                   groupClaim,
+		WorkloadIdentity:             workloadIdentity,
+		VerifyWorkloadIdentity:       verifyWorkloadIdentity,
+		FinalizerTimeout:             finalizerTimeout,
+		FinalizerTimeoutForce:        finalizerTimeoutForce,
+		LogRoutingAnnotations:        logRoutingTemplates,
+		CatalogAnnotations:           catalogTemplates,
+		ExternalDNSAnnotations:       externalDNSTemplates,
+		CertReminderAnnotations:      certReminderTemplates,
+		DocumentationAnnotations:     documentationTemplates,
+		GatewayTLS:                   gatewayTLS,
+		DefaultEditorAnnotations:     defaultEditorTemplates,
+		VaultInjection:               vaultInjection,
+		DefaultImagePullSecrets:      imagePullSecretTemplates,
+		AuthorizationPolicyTemplate:  authorizationPolicyTemplate,
+		ProfileSelector:              profileSelector,
+		WatchSelector:                watchSelector,
+		IgnoredNamespaces:            ignoredNamespaces,
+		NotebookControllerBinding:    notebookControllerBinding,
+		ChaosBinding:                 chaosBinding,
+		BackupBinding:                backupBinding,
+		AuditorAccess:                auditorAccess,
+		MaxConcurrentReconciles:      maxConcurrentReconciles,
+		WorkqueueRateLimit:           &workqueueRateLimit,
+		GlobalReconcileRPS:           globalReconcileRPS,
+		Platform:                     platform,
+		QuotaTemplates:               quotaTemplates,
+		BaselineQuota:                baselineQuota,
+		GPUQuota:                     gpuQuota,
+		PVCStorageLimit:              pvcStorageLimit,
+		ContainerDefaults:            containerDefaults,
+		EditorClusterRole:            editorClusterRole,
+		ViewerClusterRole:            viewerClusterRole,
+		PodDefaults:                  podDefaults,
+		PodDefaultsConfigMap:         podDefaultsConfigMapKey,
+		NamespaceMetadataConfigMap:   namespaceMetadataConfigMapKey,
+		ProfileDefaults:              profileDefaults,
+		PodDefaultLabels:             podDefaultLabels,
+		PodAntiAffinity:              podAntiAffinity,
+		RateLimit:                    rateLimit,
+		CleanupOrder:                 cleanupOrder,
+		OnDelete:                     onDelete,
+		QuotaSummaryConfigMap:        quotaSummaryConfigMap,
+		AdoptLegacyLabels:            adoptLegacyLabels,
+		AdoptNamespaces:              adoptNamespaces,
+		NamespaceNaming:              namespaceNaming,
+		WorkspaceDefaults:            workspaceDefaults,
+		GPUFairShare:                 gpuFairShare,
+		GPUReservation:               gpuReservation,
+		CleanupPolicy:                cleanupPolicy,
+		ProfileExpiry:                profileExpiry,
+		RegistryMirror:               registryMirror,
+		VPAInclusion:                 vpaInclusion,
+		TracingSampling:              tracingSampling,
+		Version:                      version,
+		VersionAnnotation:            versionAnnotation,
+		OwnerScaleAccess:             ownerScaleAccess,
+		OwnerPortForwardAccess:       ownerPortForwardAccess,
+		OwnerNetworkPolicyAccess:     ownerNetworkPolicyAccess,
+		NetworkPolicies:              baselineNetworkPolicies,
+		OwnerPodRestartAccess:        ownerPodRestartAccess,
+		DefaultEditorTokenRequest:    defaultEditorTokenRequest,
+		OwnerApprovalAnnotation:      ownerApprovalAnnotation,
+		DataClassification:           dataClassification,
+		AdmissionWebhook:             admissionWebhook,
+		NamespaceQuotaRetryBaseDelay: namespaceQuotaRetryBaseDelay,
+		NamespaceQuotaRetryMaxDelay:  namespaceQuotaRetryMaxDelay,
+		MaxManagedNamespaces:         maxManagedNamespaces,
+		ReconcileTimeout:             reconcileTimeout,
+		ResyncPeriod:                 resyncPeriod,
+		Recorder:                     mgr.GetEventRecorderFor("profile-controller"),
+	}
+	if observeOnlyConfigMap != "" {
+		key, err := parseNamespacedName(observeOnlyConfigMap)
+		if err != nil {
+			setupLog.Error(err, "invalid observe-only ConfigMap")
+			os.Exit(1)
+		}
+		setupLog.Info("observe-only mode, changes are recorded but not applied", "configmap", observeOnlyConfigMap)
+		profileReconciler.ObserveOnly(key)
+	}
+	if dryRun {
+		setupLog.Info("dry-run mode, changes are logged but not applied", "path", controllers.DRYRUNPATH)
+		profileReconciler.DryRun()
+		if err = mgr.AddMetricsExtraHandler(controllers.DRYRUNPATH, profileReconciler.DryRunHandler()); err != nil {
+			setupLog.Error(err, "unable to add dry-run handler")
+			os.Exit(1)
+		}
+	}
+	if err = profileReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Profile")
 		os.Exit(1)
 	}
+	if err = profileReconciler.SetupExpiryWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ProfileExpiry")
+		os.Exit(1)
+	}
+	// Reassert the configured PodDefaults in existing namespaces once the cache is synced. The resync is skipped
+	// when changes are only recorded: its writes belong to no reconcile of a profile and would not be reported,
+	// while the recorded PodDefaults would hide their changes from the reconciles of the profiles.
+	if dryRun || observeOnlyConfigMap != "" {
+		setupLog.Info("PodDefaults resync skipped, changes are not applied")
+	} else if err = mgr.Add(manager.RunnableFunc(func(<-chan struct{}) error {
+		if err := profileReconciler.ResyncPodDefaults(context.Background()); err != nil {
+			setupLog.Error(err, "unable to resync PodDefaults")
+		}
+		return nil
+	})); err != nil {
+		setupLog.Error(err, "unable to add PodDefaults resync")
+		os.Exit(1)
+	}
+	if err = mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to add health check")
+		os.Exit(1)
+	}
+	if err = mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to add ready check")
+		os.Exit(1)
+	}
+	if conversionWebhook {
+		if err = ctrl.NewWebhookManagedBy(mgr).For(&profilev1.Profile{}).Complete(); err != nil {
+			setupLog.Error(err, "unable to create conversion webhook", "webhook", "Profile")
+			os.Exit(1)
+		}
+	}
+	if validatingWebhook {
+		if err = profileReconciler.SetupValidatingWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create validating webhook", "webhook", "Profile")
+			os.Exit(1)
+		}
+	}
+	if defaultingWebhook {
+		if err = profileReconciler.SetupDefaultingWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create defaulting webhook", "webhook", "Profile")
+			os.Exit(1)
+		}
+	}
+	if storageClassWebhook {
+		if err = profileReconciler.SetupStorageClassWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create storage class webhook", "webhook", "PersistentVolumeClaim")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
-	setupLog.Info("starting manager")
+	setupLog.Info("starting manager", "version", version)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// Exit codes of the validate-pd subcommand.
+const (
+	validateOK    = 0
+	validateError = 1
+	validateUsage = 2
+)
+
+// setLeaderElectionTimings sets the leader election lease duration, renew deadline and retry period of the
+// manager options. The leader must renew its lease before it expires, so the renew deadline has to be less
+// than the lease duration.
+func setLeaderElectionTimings(options *ctrl.Options, leaseDuration, renewDeadline, retryPeriod time.Duration) error {
+	if leaseDuration <= 0 || renewDeadline <= 0 || retryPeriod <= 0 {
+		return fmt.Errorf("leader election durations must be positive")
+	}
+	if renewDeadline >= leaseDuration {
+		return fmt.Errorf("leader election renew deadline %v must be less than the lease duration %v",
+			renewDeadline, leaseDuration)
+	}
+	options.LeaseDuration = &leaseDuration
+	options.RenewDeadline = &renewDeadline
+	options.RetryPeriod = &retryPeriod
+	return nil
+}
+
+// normalizeBindAddress checks addr of flag name is a [host]:port bind address and prepends ":" to a bare port,
+// e.g. "8080" becomes ":8080". "0" disables the endpoint and is returned as is.
+func normalizeBindAddress(name string, addr string) (string, error) {
+	if addr == "0" {
+		return addr, nil
+	}
+	normalized := addr
+	if !strings.Contains(addr, ":") {
+		normalized = ":" + addr
+	}
+	_, port, err := net.SplitHostPort(normalized)
+	if err == nil {
+		if p, convErr := strconv.ParseUint(port, 10, 16); convErr != nil || p == 0 {
+			err = fmt.Errorf("port %q must be a number between 1 and 65535", port)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid -%v %q, expected [host]:port or a port, e.g. :8080: %v", name, addr, err)
+	}
+	return normalized, nil
+}
+
+// validateClusterRoles checks the -editor-clusterrole and -viewer-clusterrole values are set.
+func validateClusterRoles(editor string, viewer string) error {
+	if strings.TrimSpace(editor) == "" {
+		return fmt.Errorf("-editor-clusterrole must not be empty")
+	}
+	if strings.TrimSpace(viewer) == "" {
+		return fmt.Errorf("-viewer-clusterrole must not be empty")
+	}
+	return nil
+}
+
+// validateNetworkPolicyAccess checks -owner-network-policy-access is not set with -network-policies: the Role covers
+// all NetworkPolicies of the namespace, the baseline ones included.
+func validateNetworkPolicyAccess(networkPolicies bool, ownerNetworkPolicyAccess bool) error {
+	if networkPolicies && ownerNetworkPolicyAccess {
+		return fmt.Errorf("-owner-network-policy-access must not be set with -network-policies")
+	}
+	return nil
+}
+
+// parseNamespacedName parses a "namespace/name" flag value.
+func parseNamespacedName(value string) (types.NamespacedName, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("expected namespace/name, got %q", value)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// parsePodDefaultsConfigMap parses the -pd-configmap value, which is mutually exclusive with -pd. The returned
+// key is empty if no ConfigMap is configured.
+func parsePodDefaultsConfigMap(podDefaults string, configMap string) (types.NamespacedName, error) {
+	if configMap == "" {
+		return types.NamespacedName{}, nil
+	}
+	if podDefaults != "" {
+		return types.NamespacedName{}, fmt.Errorf("-pd and -pd-configmap are mutually exclusive, set only one of them")
+	}
+	return parseNamespacedName(configMap)
+}
+
+// runValidatePodDefaults parses the -pd value in args and prints the resulting PodDefault specs, including
+// their selectors, in yaml. It never connects to a cluster and returns the process exit code.
+func runValidatePodDefaults(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintf(stderr, "usage: %v '<name>:<field>=<value>,...;...'\n", VALIDATEPD)
+		return validateUsage
+	}
+	podDefaults, err := controllers.ParsePodDefaults(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid PodDefaults: %v\n", err)
+		return validateError
+	}
+	if errs := podDefaults.ValidateFields(); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(stderr, "invalid PodDefaults: %v\n", err)
+		}
+		return validateError
+	}
+	out, err := yaml.Marshal(podDefaults.Specs())
+	if err != nil {
+		fmt.Fprintf(stderr, "error printing PodDefaults: %v\n", err)
+		return validateError
+	}
+	fmt.Fprintf(stdout, "%d PodDefaults\n%s", len(podDefaults), out)
+	return validateOK
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	profilev1 "github.com/kubeflow/kubeflow/components/profile-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+func TestRunValidatePodDefaults(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runValidatePodDefaults([]string{`add-gcp-secret:desc="GCP, credentials",env.GOOGLE_APPLICATION_CREDENTIALS=/secret/gcp/user-gcp-sa.json`},
+		&stdout, &stderr)
+	assert.Equal(t, validateOK, code)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, `1 PodDefaults
+add-gcp-secret:
+  desc: GCP, credentials
+  env:
+  - name: GOOGLE_APPLICATION_CREDENTIALS
+    value: /secret/gcp/user-gcp-sa.json
+  selector:
+    matchLabels:
+      add-gcp-secret: "true"
+`, stdout.String())
+}
+
+func TestRunValidatePodDefaultsInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{name: "parse error", args: []string{`pd:desc="unterminated`}, code: validateError,
+			stderr: "invalid PodDefaults: unterminated quote"},
+		{name: "invalid name", args: []string{"Not_A_Name:desc=x"}, code: validateError,
+			stderr: "invalid PodDefaults: invalid PodDefault name"},
+		{name: "unknown field", args: []string{"pd:lables.team=ml"}, code: validateError,
+			stderr: `invalid PodDefaults: unknown field "lables.team" of PodDefault pd`},
+		{name: "no value", code: validateUsage, stderr: "usage: validate-pd"},
+		{name: "extra argument", args: []string{"a:desc=x", "b"}, code: validateUsage, stderr: "usage: validate-pd"},
+	} {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, tc.code, runValidatePodDefaults(tc.args, &stdout, &stderr), tc.name)
+		assert.Contains(t, stderr.String(), tc.stderr, tc.name)
+		assert.Empty(t, stdout.String(), tc.name)
+	}
+}
+
+func TestSetLeaderElectionTimings(t *testing.T) {
+	options := ctrl.Options{}
+	require.NoError(t, setLeaderElectionTimings(&options, time.Minute, 40*time.Second, 5*time.Second))
+	assert.Equal(t, time.Minute, *options.LeaseDuration)
+	assert.Equal(t, 40*time.Second, *options.RenewDeadline)
+	assert.Equal(t, 5*time.Second, *options.RetryPeriod)
+
+	for _, tc := range []struct {
+		name                                      string
+		leaseDuration, renewDeadline, retryPeriod time.Duration
+	}{
+		{name: "renew deadline equals lease duration", leaseDuration: time.Minute, renewDeadline: time.Minute,
+			retryPeriod: time.Second},
+		{name: "renew deadline exceeds lease duration", leaseDuration: 10 * time.Second,
+			renewDeadline: 15 * time.Second, retryPeriod: time.Second},
+		{name: "zero retry period", leaseDuration: time.Minute, renewDeadline: 10 * time.Second},
+	} {
+		options := ctrl.Options{}
+		assert.Error(t, setLeaderElectionTimings(&options, tc.leaseDuration, tc.renewDeadline, tc.retryPeriod),
+			tc.name)
+		assert.Nil(t, options.LeaseDuration, tc.name)
+	}
+}
+
+func TestParsePodDefaultsConfigMap(t *testing.T) {
+	key, err := parsePodDefaultsConfigMap("", "kubeflow/profile-poddefaults")
+	require.NoError(t, err)
+	assert.Equal(t, types.NamespacedName{Namespace: "kubeflow", Name: "profile-poddefaults"}, key)
+
+	key, err = parsePodDefaultsConfigMap("add-proxy:env.HTTP_PROXY=http://proxy:3128", "")
+	require.NoError(t, err)
+	assert.Empty(t, key.Name)
+
+	_, err = parsePodDefaultsConfigMap("add-proxy:env.HTTP_PROXY=http://proxy:3128", "kubeflow/profile-poddefaults")
+	assert.EqualError(t, err, "-pd and -pd-configmap are mutually exclusive, set only one of them")
+	_, err = parsePodDefaultsConfigMap("", "profile-poddefaults")
+	assert.Error(t, err)
+}
+
+func TestValidateClusterRoles(t *testing.T) {
+	assert.NoError(t, validateClusterRoles("kubeflow-edit", "kubeflow-view"))
+	assert.Error(t, validateClusterRoles("", "kubeflow-view"))
+	assert.Error(t, validateClusterRoles("kubeflow-edit", " "))
+}
+
+func TestValidateNetworkPolicyAccess(t *testing.T) {
+	assert.NoError(t, validateNetworkPolicyAccess(true, false))
+	assert.NoError(t, validateNetworkPolicyAccess(false, true))
+	assert.Error(t, validateNetworkPolicyAccess(true, true))
+}
+
+func TestNormalizeBindAddress(t *testing.T) {
+	for _, tc := range []struct {
+		addr     string
+		expected string
+	}{
+		{addr: ":8080", expected: ":8080"},
+		{addr: "8080", expected: ":8080"},
+		{addr: "0.0.0.0:8080", expected: "0.0.0.0:8080"},
+		{addr: "localhost:8081", expected: "localhost:8081"},
+		{addr: "[::1]:8080", expected: "[::1]:8080"},
+		{addr: "0", expected: "0"},
+	} {
+		addr, err := normalizeBindAddress("metrics-addr", tc.addr)
+		require.NoError(t, err, tc.addr)
+		assert.Equal(t, tc.expected, addr, tc.addr)
+	}
+
+	for _, addr := range []string{"garbage", "", ":", "host:port", "0.0.0.0:99999", ":0", "1.2.3.4:80:80"} {
+		_, err := normalizeBindAddress("metrics-addr", addr)
+		require.Error(t, err, addr)
+		assert.Contains(t, err.Error(), "-metrics-addr", addr)
+	}
+}
+
+func TestProfileConvertible(t *testing.T) {
+	// The conversion webhook is only registered if every profile version in the scheme converts through the hub.
+	convertible, err := conversion.IsConvertible(scheme, &profilev1.Profile{})
+	require.NoError(t, err)
+	assert.True(t, convertible)
+}